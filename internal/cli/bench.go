@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime/pprof"
+	"strings"
+
+	"github.com/mark-chris/tmkb/internal/knowledge/bench"
+	"github.com/spf13/cobra"
+)
+
+var (
+	benchFixtures string
+	benchProfile  string
+)
+
+var benchCmd = &cobra.Command{
+	Use:   "bench",
+	Short: "Run the query pipeline against labeled fixtures and report latency/quality",
+	Long: `Drive Loader.LoadAll + Index.Build + a scripted Query workload defined by
+YAML fixtures, reporting per-query latency percentiles, index build time,
+memory footprint, and precision@k/recall@k against the fixtures' expected
+pattern IDs.
+
+Examples:
+  # Run against a fixture directory
+  tmkb bench --fixtures=./bench/*.yaml --output=json
+
+  # Capture CPU and memory profiles alongside the report
+  tmkb bench --fixtures=./bench/*.yaml --profile=cpu,mem`,
+	RunE: runBench,
+}
+
+func init() {
+	benchCmd.Flags().StringVar(&benchFixtures, "fixtures", "bench/*.yaml",
+		"Glob of YAML fixture files defining the query workload")
+	benchCmd.Flags().StringVar(&benchProfile, "profile", "",
+		"Comma-separated pprof profiles to write: cpu,mem")
+}
+
+func runBench(cmd *cobra.Command, args []string) error {
+	profiles := map[string]bool{}
+	for _, p := range strings.Split(benchProfile, ",") {
+		p = strings.TrimSpace(p)
+		if p != "" {
+			profiles[p] = true
+		}
+	}
+
+	if profiles["cpu"] {
+		f, err := os.Create("cpu.pprof")
+		if err != nil {
+			return fmt.Errorf("failed to create cpu.pprof: %w", err)
+		}
+		defer f.Close()
+		if err := pprof.StartCPUProfile(f); err != nil {
+			return fmt.Errorf("failed to start CPU profile: %w", err)
+		}
+		defer pprof.StopCPUProfile()
+	}
+
+	cases, err := bench.LoadFixtures(benchFixtures)
+	if err != nil {
+		return fmt.Errorf("failed to load fixtures: %w", err)
+	}
+	if len(cases) == 0 {
+		return fmt.Errorf("no fixture cases found matching %q", benchFixtures)
+	}
+
+	idx, buildTime, err := bench.BuildIndex(loader)
+	if err != nil {
+		return fmt.Errorf("failed to build index: %w", err)
+	}
+
+	report, err := bench.Run(idx, cases, buildTime)
+	if err != nil {
+		return fmt.Errorf("failed to run benchmark: %w", err)
+	}
+
+	if profiles["mem"] {
+		f, err := os.Create("mem.pprof")
+		if err != nil {
+			return fmt.Errorf("failed to create mem.pprof: %w", err)
+		}
+		defer f.Close()
+		if err := pprof.WriteHeapProfile(f); err != nil {
+			return fmt.Errorf("failed to write heap profile: %w", err)
+		}
+	}
+
+	if outputMode == "human" {
+		printBenchText(report)
+		return nil
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal report: %w", err)
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+func printBenchText(report *bench.Report) {
+	fmt.Printf("Indexed %d pattern(s) in %s\n", report.PatternCount, report.IndexBuildTime)
+	fmt.Printf("Ran %d case(s): p50=%s p95=%s p99=%s\n",
+		len(report.Cases), report.P50, report.P95, report.P99)
+	fmt.Printf("Mean precision@k: %.2f | Mean recall@k: %.2f\n", report.MeanPrecision, report.MeanRecall)
+	fmt.Printf("Memory allocated during run: %d bytes\n", report.MemAllocBytes)
+}
@@ -197,9 +197,6 @@ func BenchmarkValidate_All(b *testing.B) {
 	}
 	index.Build(patterns)
 
-	// Set validate all flag
-	validateAll = true
-
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		err = runValidate(validateCmd, []string{})
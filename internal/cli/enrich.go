@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mark-chris/tmkb/internal/enrich"
+	"github.com/spf13/cobra"
+)
+
+var (
+	enrichCacheDir  string
+	enrichNVDURL    string
+	enrichCWEURL    string
+	enrichRateLimit time.Duration
+	enrichOffline   bool
+	enrichInPlace   bool
+)
+
+var enrichCmd = &cobra.Command{
+	Use:   "enrich",
+	Short: "Attach canonical CVE/CWE/OWASP metadata to patterns",
+	Long: `Resolve each loaded pattern's CWE, OWASP Top 10, and CVE references
+against NVD and MITRE's public feeds, and record the result (titles, CVSS
+scores, publish dates, related CWEs) for patterns-* to surface.
+
+By default the result is written as a patterns/<id>.enriched.yaml sidecar
+inside the patterns directory, which Loader picks up automatically on
+the next load. With --in-place, the pattern's own YAML file is edited
+instead (preserving its existing formatting and comments).
+
+Fetched responses are cached to --cache-dir so a repeat run only
+re-fetches what changed; --offline replays that cache without touching
+the network at all, for reproducible CI runs against a pre-downloaded
+feed dump.
+
+Examples:
+  # Enrich every loaded pattern, writing sidecars
+  tmkb enrich
+
+  # Replay a pre-downloaded cache with no network access
+  tmkb enrich --offline --cache-dir ./testdata/enrich-cache
+
+  # Merge metadata into the pattern files themselves
+  tmkb enrich --in-place`,
+	RunE: runEnrich,
+}
+
+func init() {
+	enrichCmd.Flags().StringVar(&enrichCacheDir, "cache-dir", ".tmkb-enrich-cache",
+		"Directory to cache fetched CVE/CWE responses in")
+	enrichCmd.Flags().StringVar(&enrichNVDURL, "nvd-url", "",
+		"Override the NVD CVE API 2.0 endpoint")
+	enrichCmd.Flags().StringVar(&enrichCWEURL, "cwe-url", "",
+		"Override the MITRE CWE XML catalog URL")
+	enrichCmd.Flags().DurationVar(&enrichRateLimit, "rate-limit", time.Second,
+		"Minimum interval between outbound requests to a single source, e.g. 500ms")
+	enrichCmd.Flags().BoolVar(&enrichOffline, "offline", false,
+		"Replay --cache-dir only; fail rather than fetch on a cache miss")
+	enrichCmd.Flags().BoolVar(&enrichInPlace, "in-place", false,
+		"Merge enrichment into each pattern's own YAML file instead of writing sidecars")
+}
+
+func runEnrich(cmd *cobra.Command, args []string) error {
+	results, err := enrich.Enrich(context.Background(), index.GetAll(), patternsDir, enrich.Options{
+		FS:         patternsFS,
+		CacheDir:   enrichCacheDir,
+		NVDBaseURL: enrichNVDURL,
+		CWEBaseURL: enrichCWEURL,
+		RateLimit:  enrichRateLimit,
+		Offline:    enrichOffline,
+		InPlace:    enrichInPlace,
+	})
+	if err != nil {
+		return fmt.Errorf("enrich failed: %w", err)
+	}
+
+	var enriched, failed int
+	for _, r := range results {
+		if len(r.ExternalRefs) > 0 {
+			enriched++
+		}
+		for _, e := range r.Errors {
+			failed++
+			if outputMode == "human" {
+				fmt.Printf("%s: %v\n", r.PatternID, e)
+			}
+		}
+	}
+
+	fmt.Printf("Enriched %d of %d pattern(s); %d reference(s) failed to resolve\n",
+		enriched, len(results), failed)
+	return nil
+}
@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/mark-chris/tmkb/internal/knowledge"
+	"github.com/spf13/afero"
+)
+
+func resetEnrichFlags() {
+	enrichCacheDir = ".tmkb-enrich-cache"
+	enrichNVDURL = ""
+	enrichCWEURL = ""
+	enrichOffline = false
+	enrichInPlace = false
+}
+
+// TestEnrichCommand_OfflineWithEmptyCacheReportsUnresolved verifies
+// --offline against an empty cache resolves nothing but still succeeds,
+// reporting every reference as unresolved rather than failing the run.
+func TestEnrichCommand_OfflineWithEmptyCacheReportsUnresolved(t *testing.T) {
+	resetEnrichFlags()
+	defer resetEnrichFlags()
+
+	patternsDir = "/patterns"
+	patternsFS = afero.NewMemMapFs()
+	enrichCacheDir = t.TempDir()
+	enrichOffline = true
+
+	idx := knowledge.NewIndex()
+	idx.Build([]knowledge.ThreatPattern{
+		{
+			ID:       "TMKB-AUTHZ-001",
+			Name:     "Missing Authorization Check",
+			Severity: "critical",
+			Provenance: knowledge.Provenance{
+				PublicReferences: []knowledge.PublicReference{{CWE: "CWE-862"}},
+			},
+		},
+	})
+	index = idx
+
+	output := captureOutput(func() {
+		if err := runEnrich(enrichCmd, []string{}); err != nil {
+			t.Fatalf("enrich command failed: %v", err)
+		}
+	})
+
+	if !contains(output, "Enriched 0 of 1 pattern(s); 1 reference(s) failed to resolve") {
+		t.Errorf("expected an all-unresolved summary, got: %s", output)
+	}
+}
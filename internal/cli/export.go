@@ -0,0 +1,280 @@
+package cli
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mark-chris/tmkb/internal/knowledge"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	exportContext   string
+	exportLanguage  string
+	exportFramework string
+	exportFilter    string
+	exportFormat    string
+	exportOut       string
+	exportBundle    string
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Bulk-export patterns to disk as a pattern pack",
+	Long: `Write the patterns matching a query (or the whole corpus) to disk, one
+file per pattern, for teams that curate a subset of TMKB for downstream
+tools or air-gapped environments.
+
+Filters reuse the query engine's --context/--filter/--language/--framework
+predicates (the same ones 'tmkb query' accepts), so --filter 'severity ==
+"critical"' works here too. With no filters at all, every loaded pattern is
+exported. Output is always sorted by ID, so two exports of the same
+patterns produce byte-identical files.
+
+--out is a directory for --format json/yaml (one <id>.json or <id>.yaml per
+pattern) or a single file for --format ndjson (one compact JSON object per
+line). Pass --bundle to additionally package the result as a gzip-
+compressed tarball, ready to ship as a pattern pack.
+
+Examples:
+  # Export every pattern as individual YAML files
+  tmkb export --format yaml --out ./pack
+
+  # Export only critical Python patterns
+  tmkb export --filter 'severity == "critical" and language == "python"' --out ./pack
+
+  # Export as a single newline-delimited JSON file
+  tmkb export --format ndjson --out patterns.ndjson
+
+  # Export and package as a tarball pattern pack
+  tmkb export --format yaml --out ./pack --bundle patterns.tar.gz`,
+	RunE: runExport,
+}
+
+func init() {
+	exportCmd.Flags().StringVarP(&exportContext, "context", "c", "",
+		"Relevance context narrowing which patterns to export, e.g. 'background job'")
+	exportCmd.Flags().StringVarP(&exportLanguage, "language", "l", "",
+		"Programming language filter (e.g., python)")
+	exportCmd.Flags().StringVar(&exportFramework, "framework", "",
+		"Framework filter (e.g., flask)")
+	exportCmd.Flags().StringVar(&exportFilter, "filter", "",
+		`Boolean expression to filter patterns, e.g. 'severity == "critical" and language == "python"'`)
+	exportCmd.Flags().StringVar(&exportFormat, "format", "json",
+		"Output format: json, yaml, or ndjson")
+	exportCmd.Flags().StringVar(&exportOut, "out", "",
+		"Directory (json/yaml) or file (ndjson) to write exported patterns to")
+	exportCmd.Flags().StringVar(&exportBundle, "bundle", "",
+		"Also package the exported output as a gzip tarball at this path")
+	exportCmd.MarkFlagRequired("out")
+}
+
+func runExport(cmd *cobra.Command, args []string) error {
+	if exportFormat != "json" && exportFormat != "yaml" && exportFormat != "ndjson" {
+		return fmt.Errorf("invalid --format %q: must be json, yaml, or ndjson", exportFormat)
+	}
+
+	patterns, err := matchingExportPatterns()
+	if err != nil {
+		return err
+	}
+	if len(patterns) == 0 {
+		fmt.Println("No patterns matched; nothing exported")
+		return nil
+	}
+
+	if exportFormat == "ndjson" {
+		if err := writeNDJSON(patternsFS, exportOut, patterns); err != nil {
+			return err
+		}
+	} else if err := writePatternFiles(patternsFS, exportOut, exportFormat, patterns); err != nil {
+		return err
+	}
+
+	if exportBundle != "" {
+		if err := bundleExport(patternsFS, exportOut, exportBundle); err != nil {
+			return fmt.Errorf("failed to bundle export: %w", err)
+		}
+	}
+
+	fmt.Printf("Exported %d pattern(s) to %s\n", len(patterns), exportOut)
+	if exportBundle != "" {
+		fmt.Printf("Bundled as %s\n", exportBundle)
+	}
+	return nil
+}
+
+// matchingExportPatterns runs the query engine's context/filter/language/
+// framework predicates (see knowledge.Query) over the loaded index and
+// returns the matching full ThreatPattern documents, sorted by ID. Query
+// only ever returns a PatternOutput summary, so each match is looked back
+// up in the index by ID for its full record - the same approach query.go's
+// filterQueryResult uses for --select.
+func matchingExportPatterns() ([]knowledge.ThreatPattern, error) {
+	result, err := knowledge.Query(index, knowledge.QueryOptions{
+		Context:   exportContext,
+		Language:  exportLanguage,
+		Framework: exportFramework,
+		Filter:    exportFilter,
+		// Only p.ID below is used (the full record is looked back up in the
+		// index), so the compact agent-mode PatternOutput is all we need -
+		// no need to pay for Verbosity: "human"'s full per-pattern detail.
+		// Query defaults to a relevance-ranked top few matches; export wants
+		// every match, so ask for effectively no limit.
+		Limit: math.MaxInt32,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid --filter: %w", err)
+	}
+
+	patterns := make([]knowledge.ThreatPattern, 0, len(result.Patterns))
+	for _, p := range result.Patterns {
+		if full := index.GetByID(p.ID); full != nil {
+			patterns = append(patterns, *full)
+		}
+	}
+	sort.Slice(patterns, func(i, j int) bool { return patterns[i].ID < patterns[j].ID })
+	return patterns, nil
+}
+
+// writePatternFiles writes one <id>.json or <id>.yaml file per pattern into
+// dir, replacing any previous contents so a re-export with a narrower
+// filter doesn't leave stale files behind from an earlier run. The yaml
+// form wraps each pattern in a threat_pattern key, matching the shape
+// Loader reads back in.
+func writePatternFiles(fs afero.Fs, dir, format string, patterns []knowledge.ThreatPattern) error {
+	if err := fs.RemoveAll(dir); err != nil {
+		return fmt.Errorf("failed to clear %s: %w", dir, err)
+	}
+	if err := fs.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+
+	for _, p := range patterns {
+		var data []byte
+		var err error
+		var name string
+		switch format {
+		case "yaml":
+			data, err = yaml.Marshal(knowledge.PatternWrapper{ThreatPattern: p})
+			name = p.ID + ".yaml"
+		default:
+			data, err = json.MarshalIndent(p, "", "  ")
+			name = p.ID + ".json"
+		}
+		if err != nil {
+			return fmt.Errorf("failed to marshal %s: %w", p.ID, err)
+		}
+
+		// p.ID names the output file directly, so a pattern loaded from an
+		// untrusted source (e.g. HTTPSource/GitSource - see source.go) with
+		// a crafted ID like "../../etc/cron.d/evil" must not be allowed to
+		// escape dir, mirroring extractTar's equivalent guard on tar entry
+		// names.
+		path := filepath.Join(dir, name)
+		if rel, err := filepath.Rel(dir, path); err != nil || strings.HasPrefix(rel, "..") {
+			return fmt.Errorf("pattern id %q escapes output directory %s", p.ID, dir)
+		}
+		if err := afero.WriteFile(fs, path, data, 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// writeNDJSON writes patterns to path as one compact JSON object per line.
+func writeNDJSON(fs afero.Fs, path string, patterns []knowledge.ThreatPattern) error {
+	if dir := filepath.Dir(path); dir != "." {
+		if err := fs.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create %s: %w", dir, err)
+		}
+	}
+
+	f, err := fs.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	defer f.Close()
+
+	enc := json.NewEncoder(f)
+	for _, p := range patterns {
+		if err := enc.Encode(p); err != nil {
+			return fmt.Errorf("failed to write %s: %w", p.ID, err)
+		}
+	}
+	return nil
+}
+
+// bundleExport packages out (the directory or single file writePatternFiles/
+// writeNDJSON just wrote) as a gzip-compressed tarball at bundlePath, the
+// inverse of source.go's extractTar.
+func bundleExport(fs afero.Fs, out, bundlePath string) error {
+	f, err := fs.Create(bundlePath)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", bundlePath, err)
+	}
+	defer f.Close()
+
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+
+	info, err := fs.Stat(out)
+	if err != nil {
+		return err
+	}
+
+	if !info.IsDir() {
+		if err := addFileToTar(fs, tw, out, filepath.Base(out), info); err != nil {
+			return err
+		}
+	} else {
+		err := afero.Walk(fs, out, func(path string, fi os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if fi.IsDir() {
+				return nil
+			}
+			rel, err := filepath.Rel(out, path)
+			if err != nil {
+				return err
+			}
+			return addFileToTar(fs, tw, path, rel, fi)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("failed to finalize tar: %w", err)
+	}
+	return gz.Close()
+}
+
+// addFileToTar writes fullPath's content into tw as a single entry named
+// tarName.
+func addFileToTar(fs afero.Fs, tw *tar.Writer, fullPath, tarName string, info os.FileInfo) error {
+	data, err := afero.ReadFile(fs, fullPath)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", fullPath, err)
+	}
+
+	hdr := &tar.Header{Name: tarName, Mode: int64(info.Mode()), Size: int64(len(data))}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return fmt.Errorf("failed to write tar header for %s: %w", tarName, err)
+	}
+	if _, err := tw.Write(data); err != nil {
+		return fmt.Errorf("failed to write tar content for %s: %w", tarName, err)
+	}
+	return nil
+}
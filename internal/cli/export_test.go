@@ -0,0 +1,262 @@
+package cli
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/mark-chris/tmkb/internal/knowledge"
+	"github.com/spf13/afero"
+)
+
+func resetExportFlags() {
+	exportContext = ""
+	exportLanguage = ""
+	exportFramework = ""
+	exportFilter = ""
+	exportFormat = "json"
+	exportOut = ""
+	exportBundle = ""
+}
+
+func buildExportIndex() *knowledge.Index {
+	idx := knowledge.NewIndex()
+	idx.Build([]knowledge.ThreatPattern{
+		{ID: "TMKB-EXPORT-002", Name: "Second", Severity: "medium", Language: "python"},
+		{ID: "TMKB-EXPORT-001", Name: "First", Severity: "critical", Language: "go"},
+	})
+	return idx
+}
+
+// TestExportCommand_WritesOneFilePerPatternSortedByID verifies the default
+// (json) export writes one file per pattern, named after its ID, regardless
+// of load order.
+func TestExportCommand_WritesOneFilePerPatternSortedByID(t *testing.T) {
+	resetExportFlags()
+	defer resetExportFlags()
+
+	patternsFS = afero.NewMemMapFs()
+	index = buildExportIndex()
+	exportOut = "/out"
+
+	if err := runExport(exportCmd, []string{}); err != nil {
+		t.Fatalf("export command failed: %v", err)
+	}
+
+	for _, id := range []string{"TMKB-EXPORT-001", "TMKB-EXPORT-002"} {
+		data, err := afero.ReadFile(patternsFS, "/out/"+id+".json")
+		if err != nil {
+			t.Fatalf("expected %s.json to be written: %v", id, err)
+		}
+		var p knowledge.ThreatPattern
+		if err := json.Unmarshal(data, &p); err != nil {
+			t.Fatalf("failed to parse %s.json: %v", id, err)
+		}
+		if p.ID != id {
+			t.Errorf("%s.json: ID = %q, want %q", id, p.ID, id)
+		}
+	}
+}
+
+// TestExportCommand_YAMLWrapsThreatPatternKey verifies --format yaml writes
+// the same threat_pattern-wrapped shape Loader reads back in.
+func TestExportCommand_YAMLWrapsThreatPatternKey(t *testing.T) {
+	resetExportFlags()
+	defer resetExportFlags()
+
+	patternsFS = afero.NewMemMapFs()
+	index = buildExportIndex()
+	exportFormat = "yaml"
+	exportOut = "/out"
+
+	if err := runExport(exportCmd, []string{}); err != nil {
+		t.Fatalf("export command failed: %v", err)
+	}
+
+	data, err := afero.ReadFile(patternsFS, "/out/TMKB-EXPORT-001.yaml")
+	if err != nil {
+		t.Fatalf("expected TMKB-EXPORT-001.yaml to be written: %v", err)
+	}
+	if !strings.HasPrefix(string(data), "threat_pattern:") {
+		t.Errorf("expected a threat_pattern-wrapped document, got:\n%s", data)
+	}
+}
+
+// TestExportCommand_NDJSONWritesOneLinePerPattern verifies --format ndjson
+// writes patterns.ndjson as one JSON object per line, sorted by ID.
+func TestExportCommand_NDJSONWritesOneLinePerPattern(t *testing.T) {
+	resetExportFlags()
+	defer resetExportFlags()
+
+	patternsFS = afero.NewMemMapFs()
+	index = buildExportIndex()
+	exportFormat = "ndjson"
+	exportOut = "/out/patterns.ndjson"
+
+	if err := runExport(exportCmd, []string{}); err != nil {
+		t.Fatalf("export command failed: %v", err)
+	}
+
+	data, err := afero.ReadFile(patternsFS, "/out/patterns.ndjson")
+	if err != nil {
+		t.Fatalf("expected patterns.ndjson to be written: %v", err)
+	}
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d: %q", len(lines), data)
+	}
+	var first, second struct{ ID string `json:"id"` }
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil {
+		t.Fatalf("failed to parse first line: %v", err)
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &second); err != nil {
+		t.Fatalf("failed to parse second line: %v", err)
+	}
+	if first.ID != "TMKB-EXPORT-001" || second.ID != "TMKB-EXPORT-002" {
+		t.Errorf("expected patterns sorted by ID, got %s then %s", first.ID, second.ID)
+	}
+}
+
+// TestExportCommand_FilterNarrowsPatterns verifies --filter reuses the
+// query engine's expr filter, the same mechanism 'tmkb query' uses.
+func TestExportCommand_FilterNarrowsPatterns(t *testing.T) {
+	resetExportFlags()
+	defer resetExportFlags()
+
+	patternsFS = afero.NewMemMapFs()
+	index = buildExportIndex()
+	exportFilter = `severity == "critical"`
+	exportOut = "/out"
+
+	if err := runExport(exportCmd, []string{}); err != nil {
+		t.Fatalf("export command failed: %v", err)
+	}
+
+	if _, err := afero.ReadFile(patternsFS, "/out/TMKB-EXPORT-001.json"); err != nil {
+		t.Errorf("expected the critical pattern to be exported: %v", err)
+	}
+	if exists, _ := afero.Exists(patternsFS, "/out/TMKB-EXPORT-002.json"); exists {
+		t.Errorf("expected the medium-severity pattern to be filtered out")
+	}
+}
+
+// TestExportCommand_NoMatchesReportsWithoutWriting verifies a filter
+// matching nothing exits cleanly without creating --out.
+func TestExportCommand_NoMatchesReportsWithoutWriting(t *testing.T) {
+	resetExportFlags()
+	defer resetExportFlags()
+
+	patternsFS = afero.NewMemMapFs()
+	index = buildExportIndex()
+	exportFilter = `severity == "low"`
+	exportOut = "/out"
+
+	output := captureOutput(func() {
+		if err := runExport(exportCmd, []string{}); err != nil {
+			t.Fatalf("export command failed: %v", err)
+		}
+	})
+
+	if !contains(output, "No patterns matched") {
+		t.Errorf("expected a no-match message, got: %s", output)
+	}
+	if exists, _ := afero.DirExists(patternsFS, "/out"); exists {
+		t.Errorf("expected --out not to be created when nothing matched")
+	}
+}
+
+// TestExportCommand_BundleCreatesTarball verifies --bundle packages the
+// exported directory as a gzip tarball alongside the plain output.
+func TestExportCommand_BundleCreatesTarball(t *testing.T) {
+	resetExportFlags()
+	defer resetExportFlags()
+
+	patternsFS = afero.NewMemMapFs()
+	index = buildExportIndex()
+	exportOut = "/out"
+	exportBundle = "/pack.tar.gz"
+
+	if err := runExport(exportCmd, []string{}); err != nil {
+		t.Fatalf("export command failed: %v", err)
+	}
+
+	info, err := patternsFS.Stat("/pack.tar.gz")
+	if err != nil {
+		t.Fatalf("expected /pack.tar.gz to be written: %v", err)
+	}
+	if info.Size() == 0 {
+		t.Errorf("expected a non-empty tarball")
+	}
+}
+
+// TestExportCommand_RejectsPatternIDEscapingOutDir verifies a pattern
+// whose ID resolves outside --out (e.g. loaded from an untrusted
+// HTTPSource/GitSource pattern pack) is rejected rather than written
+// outside the requested directory.
+func TestExportCommand_RejectsPatternIDEscapingOutDir(t *testing.T) {
+	resetExportFlags()
+	defer resetExportFlags()
+
+	patternsFS = afero.NewMemMapFs()
+	idx := knowledge.NewIndex()
+	idx.Build([]knowledge.ThreatPattern{
+		{ID: "../../etc/cron.d/evil", Name: "Malicious", Severity: "critical"},
+	})
+	index = idx
+	exportOut = "/out"
+
+	err := runExport(exportCmd, []string{})
+	if err == nil {
+		t.Fatal("expected an error for a pattern ID escaping --out")
+	}
+	if exists, _ := afero.Exists(patternsFS, "/etc/cron.d/evil"); exists {
+		t.Fatal("pattern escaped --out and was written outside it")
+	}
+}
+
+// TestExportCommand_ClearsStaleFilesFromPreviousRun verifies re-exporting
+// with a narrower filter doesn't leave a previous run's now-unmatched
+// pattern files behind in --out.
+func TestExportCommand_ClearsStaleFilesFromPreviousRun(t *testing.T) {
+	resetExportFlags()
+	defer resetExportFlags()
+
+	patternsFS = afero.NewMemMapFs()
+	index = buildExportIndex()
+	exportOut = "/out"
+	if err := runExport(exportCmd, []string{}); err != nil {
+		t.Fatalf("first export failed: %v", err)
+	}
+
+	exportFilter = `severity == "critical"`
+	if err := runExport(exportCmd, []string{}); err != nil {
+		t.Fatalf("second export failed: %v", err)
+	}
+
+	if exists, _ := afero.Exists(patternsFS, "/out/TMKB-EXPORT-002.json"); exists {
+		t.Error("expected the previous run's now-unmatched pattern file to be removed")
+	}
+	if exists, _ := afero.Exists(patternsFS, "/out/TMKB-EXPORT-001.json"); !exists {
+		t.Error("expected the still-matching pattern file to remain")
+	}
+}
+
+// TestExportCommand_RejectsUnknownFormat verifies an unsupported --format
+// value fails fast with a clear error rather than writing anything.
+func TestExportCommand_RejectsUnknownFormat(t *testing.T) {
+	resetExportFlags()
+	defer resetExportFlags()
+
+	patternsFS = afero.NewMemMapFs()
+	index = buildExportIndex()
+	exportFormat = "xml"
+	exportOut = "/out"
+
+	err := runExport(exportCmd, []string{})
+	if err == nil {
+		t.Fatal("expected an error for an unsupported --format")
+	}
+	if !contains(err.Error(), "xml") {
+		t.Errorf("expected the error to name the bad format, got: %v", err)
+	}
+}
@@ -2,41 +2,113 @@ package cli
 
 import (
 	"fmt"
+	"strings"
 
+	"github.com/mark-chris/tmkb/internal/cli/views"
 	"github.com/mark-chris/tmkb/internal/knowledge"
+	"github.com/mark-chris/tmkb/internal/knowledge/selector"
 	"github.com/spf13/cobra"
 )
 
+var (
+	getFields   []string
+	getJSONPath string
+	getSelect   string
+)
+
 var getCmd = &cobra.Command{
-	Use:   "get <pattern-id>",
-	Short: "Get a specific pattern by ID",
-	Long: `Retrieve detailed information about a specific threat pattern.
+	Use:   "get [pattern-id...]",
+	Short: "Get one or more patterns by ID, or by selector",
+	Long: `Retrieve detailed information about one or more threat patterns, either
+by ID (one or more, space-separated) or with a --select expression.
 
 Examples:
   # Get pattern details (JSON)
   tmkb get TMKB-AUTHZ-001
 
+  # Get several patterns at once
+  tmkb get TMKB-AUTHZ-001 TMKB-AUTHZ-002
+
+  # Get every pattern under a prefix, excluding one
+  tmkb get TMKB-AUTHZ-... -TMKB-AUTHZ-003
+
+  # Get every pattern matching a selector
+  tmkb get --select 'lang:python && tier<2'
+
   # Get pattern details (human-readable)
-  tmkb get TMKB-AUTHZ-001 --verbose`,
-	Args: cobra.ExactArgs(1),
+  tmkb get TMKB-AUTHZ-001 --output=human
+
+  # Narrow to specific fields
+  tmkb get TMKB-AUTHZ-001 --fields=id,severity,mitigations[*].id
+
+  # Or narrow with a single JSONPath-style selector
+  tmkb get TMKB-AUTHZ-001 --jsonpath 'provenance.public_references[?(@.cwe)].url'`,
 	RunE: runGet,
 }
 
+func init() {
+	getCmd.Flags().StringSliceVar(&getFields, "fields", nil,
+		"Comma-separated, repeatable field selectors to narrow the output (see --jsonpath for the full grammar)")
+	getCmd.Flags().StringVar(&getJSONPath, "jsonpath", "",
+		"A single JSONPath-style selector, e.g. 'mitigations[*].id'")
+	getCmd.Flags().StringVar(&getSelect, "select", "",
+		"Selector expression choosing patterns instead of positional IDs, e.g. 'lang:python && tier<2'")
+}
+
 func runGet(cmd *cobra.Command, args []string) error {
-	patternID := args[0]
+	if getSelect != "" && len(args) > 0 {
+		return fmt.Errorf("use either positional pattern IDs or --select, not both")
+	}
 
-	// Look up pattern
-	pattern := index.GetByID(patternID)
-	if pattern == nil {
-		return fmt.Errorf("pattern not found: %s", patternID)
+	var patterns []*knowledge.ThreatPattern
+	switch {
+	case getSelect != "":
+		sel, err := selector.Compile(getSelect)
+		if err != nil {
+			return fmt.Errorf("invalid --select expression: %w", err)
+		}
+		for _, p := range index.GetAll() {
+			p := p
+			if sel.Match(&p) {
+				patterns = append(patterns, &p)
+			}
+		}
+	case hasSelectorSyntax(args):
+		for _, p := range knowledge.ExpandSelectors(index.GetAll(), args) {
+			p := p
+			patterns = append(patterns, &p)
+		}
+		if len(patterns) == 0 {
+			return fmt.Errorf("no patterns matched: %s", strings.Join(args, " "))
+		}
+	case len(args) > 0:
+		for _, id := range args {
+			pattern := index.GetByID(id)
+			if pattern == nil {
+				return fmt.Errorf("pattern not found: %s", id)
+			}
+			patterns = append(patterns, pattern)
+		}
+	default:
+		return fmt.Errorf("get requires a pattern ID or --select expression")
 	}
 
-	// Format output
-	output, err := knowledge.FormatPatternDetail(pattern, getFormat())
-	if err != nil {
-		return fmt.Errorf("failed to format output: %w", err)
+	fields := getFields
+	if getJSONPath != "" {
+		fields = append(fields, getJSONPath)
 	}
 
-	fmt.Println(output)
-	return nil
+	return views.NewGetView(outputMode, patterns, fields).Render(cmd.OutOrStdout())
+}
+
+// hasSelectorSyntax reports whether any positional arg uses
+// knowledge.ExpandSelectors syntax ("..." or a leading "-") rather than
+// being a plain exact pattern ID.
+func hasSelectorSyntax(args []string) bool {
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") || strings.Contains(arg, "...") {
+			return true
+		}
+	}
+	return false
 }
@@ -16,8 +16,10 @@ func contains(s, substr string) bool {
 
 // resetGetFlags resets get command flags and global variables
 func resetGetFlags() {
-	verbose = false
-	outputFormat = "json"
+	outputMode = "json"
+	getSelect = ""
+	getFields = nil
+	getJSONPath = ""
 }
 
 // TestGetCommand_ValidID tests retrieving a pattern by valid ID
@@ -47,10 +49,17 @@ func TestGetCommand_ValidID(t *testing.T) {
 	}
 
 	// Validate JSON output contains the pattern
-	var pattern knowledge.ThreatPattern
-	if err := json.Unmarshal([]byte(output), &pattern); err != nil {
+	var doc struct {
+		FormatVersion int                        `json:"format_version"`
+		Patterns      []knowledge.ThreatPattern `json:"patterns"`
+	}
+	if err := json.Unmarshal([]byte(output), &doc); err != nil {
 		t.Fatalf("Failed to parse JSON output: %v", err)
 	}
+	if len(doc.Patterns) != 1 {
+		t.Fatalf("Expected 1 pattern, got %d", len(doc.Patterns))
+	}
+	pattern := doc.Patterns[0]
 
 	if pattern.ID != "TMKB-TEST-001" {
 		t.Errorf("Expected pattern ID TMKB-TEST-001, got %s", pattern.ID)
@@ -94,7 +103,146 @@ func TestGetCommand_InvalidID(t *testing.T) {
 	}
 }
 
-// TestGetCommand_VerboseOutput tests verbose mode produces human-readable output
+// TestGetCommand_MultipleIDs tests retrieving several patterns by ID at once
+func TestGetCommand_MultipleIDs(t *testing.T) {
+	fixture := testutil.SetupTestPatterns(t)
+	defer fixture.Cleanup()
+
+	resetGetFlags()
+	patternsDir = fixture.Dir
+	loader = knowledge.NewLoader(patternsDir)
+	index = knowledge.NewIndex()
+	patterns, err := loader.LoadAll()
+	if err != nil {
+		t.Fatalf("Failed to load patterns: %v", err)
+	}
+	index.Build(patterns)
+
+	output := captureOutput(func() {
+		err = runGet(getCmd, []string{"TMKB-TEST-001", "TMKB-TEST-002"})
+	})
+	if err != nil {
+		t.Fatalf("Get command failed: %v", err)
+	}
+
+	var doc struct {
+		FormatVersion int                        `json:"format_version"`
+		Patterns      []knowledge.ThreatPattern `json:"patterns"`
+	}
+	if jsonErr := json.Unmarshal([]byte(output), &doc); jsonErr != nil {
+		t.Fatalf("Failed to parse JSON output: %v", jsonErr)
+	}
+	results := doc.Patterns
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 patterns, got %d", len(results))
+	}
+	if results[0].ID != "TMKB-TEST-001" || results[1].ID != "TMKB-TEST-002" {
+		t.Errorf("Expected TMKB-TEST-001 then TMKB-TEST-002, got %s then %s", results[0].ID, results[1].ID)
+	}
+}
+
+// TestGetCommand_PrefixExclude tests the "prefix... -exact" positional
+// expansion form.
+func TestGetCommand_PrefixExclude(t *testing.T) {
+	fixture := testutil.SetupTestPatterns(t)
+	defer fixture.Cleanup()
+
+	resetGetFlags()
+	patternsDir = fixture.Dir
+	loader = knowledge.NewLoader(patternsDir)
+	index = knowledge.NewIndex()
+	patterns, err := loader.LoadAll()
+	if err != nil {
+		t.Fatalf("Failed to load patterns: %v", err)
+	}
+	index.Build(patterns)
+
+	output := captureOutput(func() {
+		err = runGet(getCmd, []string{"TMKB-TEST-...", "-TMKB-TEST-003"})
+	})
+	if err != nil {
+		t.Fatalf("Get command with prefix/exclude selectors failed: %v", err)
+	}
+
+	var doc struct {
+		FormatVersion int                        `json:"format_version"`
+		Patterns      []knowledge.ThreatPattern `json:"patterns"`
+	}
+	if jsonErr := json.Unmarshal([]byte(output), &doc); jsonErr != nil {
+		t.Fatalf("Failed to parse JSON output: %v", jsonErr)
+	}
+	results := doc.Patterns
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 patterns, got %d", len(results))
+	}
+	for _, r := range results {
+		if r.ID == "TMKB-TEST-003" {
+			t.Error("Expected TMKB-TEST-003 to be excluded")
+		}
+	}
+}
+
+// TestGetCommand_Select tests choosing patterns via --select instead of
+// positional IDs.
+func TestGetCommand_Select(t *testing.T) {
+	fixture := testutil.SetupTestPatterns(t)
+	defer fixture.Cleanup()
+
+	resetGetFlags()
+	getSelect = "id:TMKB-TEST-00*"
+	patternsDir = fixture.Dir
+	loader = knowledge.NewLoader(patternsDir)
+	index = knowledge.NewIndex()
+	patterns, err := loader.LoadAll()
+	if err != nil {
+		t.Fatalf("Failed to load patterns: %v", err)
+	}
+	index.Build(patterns)
+
+	output := captureOutput(func() {
+		err = runGet(getCmd, []string{})
+	})
+	if err != nil {
+		t.Fatalf("Get command failed: %v", err)
+	}
+
+	var doc struct {
+		FormatVersion int                        `json:"format_version"`
+		Patterns      []knowledge.ThreatPattern `json:"patterns"`
+	}
+	if jsonErr := json.Unmarshal([]byte(output), &doc); jsonErr != nil {
+		t.Fatalf("Failed to parse JSON output: %v", jsonErr)
+	}
+	results := doc.Patterns
+	if len(results) != 3 {
+		t.Errorf("Expected 3 patterns matched by --select, got %d", len(results))
+	}
+}
+
+// TestGetCommand_SelectAndIDs tests that combining --select with positional
+// IDs is rejected.
+func TestGetCommand_SelectAndIDs(t *testing.T) {
+	fixture := testutil.SetupTestPatterns(t)
+	defer fixture.Cleanup()
+
+	resetGetFlags()
+	getSelect = "id:TMKB-TEST-001"
+	patternsDir = fixture.Dir
+	loader = knowledge.NewLoader(patternsDir)
+	index = knowledge.NewIndex()
+	patterns, err := loader.LoadAll()
+	if err != nil {
+		t.Fatalf("Failed to load patterns: %v", err)
+	}
+	index.Build(patterns)
+
+	err = runGet(getCmd, []string{"TMKB-TEST-002"})
+	if err == nil {
+		t.Fatal("Expected error when combining --select with positional IDs, got none")
+	}
+}
+
+// TestGetCommand_VerboseOutput tests --output=human produces human-readable output
 func TestGetCommand_VerboseOutput(t *testing.T) {
 	// Setup test fixtures
 	fixture := testutil.SetupTestPatterns(t)
@@ -103,7 +251,7 @@ func TestGetCommand_VerboseOutput(t *testing.T) {
 	// Configure for test
 	resetGetFlags()
 	patternsDir = fixture.Dir
-	verbose = true
+	outputMode = "human"
 	loader = knowledge.NewLoader(patternsDir)
 	index = knowledge.NewIndex()
 	patterns, err := loader.LoadAll()
@@ -154,8 +302,7 @@ func TestGetCommand_JSONOutput(t *testing.T) {
 	// Configure for test
 	resetGetFlags()
 	patternsDir = fixture.Dir
-	outputFormat = "json"
-	verbose = false
+	outputMode = "json"
 	loader = knowledge.NewLoader(patternsDir)
 	index = knowledge.NewIndex()
 	patterns, err := loader.LoadAll()
@@ -174,10 +321,20 @@ func TestGetCommand_JSONOutput(t *testing.T) {
 	}
 
 	// Validate JSON structure
-	var pattern knowledge.ThreatPattern
-	if err := json.Unmarshal([]byte(output), &pattern); err != nil {
+	var doc struct {
+		FormatVersion int                       `json:"format_version"`
+		Patterns      []knowledge.ThreatPattern `json:"patterns"`
+	}
+	if err := json.Unmarshal([]byte(output), &doc); err != nil {
 		t.Fatalf("Failed to parse JSON output: %v", err)
 	}
+	if doc.FormatVersion == 0 {
+		t.Error("Expected format_version to be set")
+	}
+	if len(doc.Patterns) != 1 {
+		t.Fatalf("Expected 1 pattern, got %d", len(doc.Patterns))
+	}
+	pattern := doc.Patterns[0]
 
 	// Verify all required fields are present
 	if pattern.ID != "TMKB-TEST-003" {
@@ -58,10 +58,16 @@ func TestWorkflow_QueryThenGet(t *testing.T) {
 	}
 
 	// Parse get results
-	var pattern knowledge.ThreatPattern
-	if err := json.Unmarshal([]byte(getOutput), &pattern); err != nil {
+	var doc struct {
+		Patterns []knowledge.ThreatPattern `json:"patterns"`
+	}
+	if err := json.Unmarshal([]byte(getOutput), &doc); err != nil {
 		t.Fatalf("Failed to parse get output: %v", err)
 	}
+	if len(doc.Patterns) != 1 {
+		t.Fatalf("Expected 1 pattern, got %d", len(doc.Patterns))
+	}
+	pattern := doc.Patterns[0]
 
 	// Verify we got the same pattern
 	if pattern.ID != firstPatternID {
@@ -119,9 +125,10 @@ func TestWorkflow_ListThenValidate(t *testing.T) {
 
 	// Step 2: Validate one of the patterns from the list
 	resetValidateFlags()
+	validateSelect = "id:TMKB-TEST-001"
 
 	validateOutput := captureOutput(func() {
-		err = runValidate(validateCmd, []string{"TMKB-TEST-001"})
+		err = runValidate(validateCmd, []string{})
 	})
 
 	if err != nil {
@@ -139,7 +146,6 @@ func TestWorkflow_ListThenValidate(t *testing.T) {
 
 	// Step 3: Validate all patterns
 	resetValidateFlags()
-	validateAll = true
 
 	validateAllOutput := captureOutput(func() {
 		err = runValidate(validateCmd, []string{})
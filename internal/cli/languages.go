@@ -0,0 +1,38 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/mark-chris/tmkb/internal/langs"
+	"github.com/spf13/cobra"
+)
+
+var languagesCmd = &cobra.Command{
+	Use:   "languages",
+	Short: "List supported languages and their frameworks",
+	Long: `List the language plugins TMKB validates query/code-example parameters
+against, and the frameworks recognized for each.
+
+This doesn't require a patterns directory - it reflects the registry built
+into this build of tmkb, not the loaded knowledge base.`,
+	RunE: runLanguages,
+}
+
+func runLanguages(cmd *cobra.Command, args []string) error {
+	plugins := langs.Default().Plugins()
+
+	fmt.Printf("Supported language(s): %d\n\n", len(plugins))
+
+	for _, p := range plugins {
+		if outputMode == "human" {
+			fmt.Printf("%s\n", p.ID)
+			fmt.Printf("  Frameworks:    %v\n", p.Frameworks)
+			fmt.Printf("  File patterns: %v\n", p.FilePatterns)
+			fmt.Println()
+		} else {
+			fmt.Printf("%-12s  %v\n", p.ID, p.Frameworks)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,42 @@
+package cli
+
+import "testing"
+
+// TestLanguagesCommand_ListsPlugins verifies the non-verbose listing
+// includes every registered language and at least one of its frameworks.
+func TestLanguagesCommand_ListsPlugins(t *testing.T) {
+	outputMode = "json"
+
+	var err error
+	output := captureOutput(func() {
+		err = runLanguages(languagesCmd, []string{})
+	})
+	if err != nil {
+		t.Fatalf("Languages command failed: %v", err)
+	}
+
+	expected := []string{"python", "javascript", "typescript", "go", "java", "flask", "spring"}
+	for _, s := range expected {
+		if !contains(output, s) {
+			t.Errorf("Expected output to contain %q, got: %s", s, output)
+		}
+	}
+}
+
+// TestLanguagesCommand_Verbose verifies verbose mode includes file patterns.
+func TestLanguagesCommand_Verbose(t *testing.T) {
+	outputMode = "human"
+	defer func() { outputMode = "json" }()
+
+	var err error
+	output := captureOutput(func() {
+		err = runLanguages(languagesCmd, []string{})
+	})
+	if err != nil {
+		t.Fatalf("Languages command failed: %v", err)
+	}
+
+	if !contains(output, "File patterns") {
+		t.Error("Expected verbose output to include file patterns")
+	}
+}
@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/mark-chris/tmkb/internal/knowledge"
+	"github.com/spf13/cobra"
+)
+
+var lintCmd = &cobra.Command{
+	Use:   "lint",
+	Short: "Compile every pattern's triggers.expr and report errors",
+	Long: `Compile every loaded pattern's triggers.expr (see knowledge.Triggers) and
+report any that fail to compile or don't evaluate to a boolean.
+
+This is a focused slice of what "tmkb validate" already checks (diagnostic
+code TMKB017), run on its own so a pattern author - or a pre-commit hook -
+can catch a bad expression without running the full validation suite.
+
+Examples:
+  # Lint every loaded pattern's triggers.expr
+  tmkb lint`,
+	RunE: runLint,
+}
+
+func runLint(cmd *cobra.Command, args []string) error {
+	patterns := index.GetAll()
+
+	var checked, failed int
+	for _, p := range patterns {
+		if p.Triggers.Expr == "" {
+			continue
+		}
+		checked++
+		if err := knowledge.ValidatePatternExpr(p.Triggers.Expr); err != nil {
+			failed++
+			fmt.Printf("%s: triggers.expr %q: %v\n", p.ID, p.Triggers.Expr, err)
+		}
+	}
+
+	if failed > 0 {
+		fmt.Printf("\n%d of %d triggers.expr failed to lint\n", failed, checked)
+		os.Exit(1)
+	}
+
+	if checked == 0 {
+		fmt.Println("No patterns define triggers.expr")
+		return nil
+	}
+	fmt.Printf("%d triggers.expr OK\n", checked)
+	return nil
+}
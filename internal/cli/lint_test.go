@@ -0,0 +1,46 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/mark-chris/tmkb/internal/knowledge"
+)
+
+// TestLintCommand_NoExprs verifies lint reports nothing to check when no
+// pattern defines triggers.expr.
+func TestLintCommand_NoExprs(t *testing.T) {
+	index = knowledge.NewIndex()
+	index.Build([]knowledge.ThreatPattern{
+		{ID: "TMKB-TEST-001", Triggers: knowledge.Triggers{Keywords: []string{"auth"}}},
+	})
+
+	output := captureOutput(func() {
+		if err := runLint(lintCmd, []string{}); err != nil {
+			t.Fatalf("lint command failed: %v", err)
+		}
+	})
+
+	if !contains(output, "No patterns define triggers.expr") {
+		t.Errorf("expected a no-exprs message, got: %s", output)
+	}
+}
+
+// TestLintCommand_ValidExprsPass verifies lint reports success for patterns
+// whose triggers.expr compiles and evaluates to a boolean.
+func TestLintCommand_ValidExprsPass(t *testing.T) {
+	index = knowledge.NewIndex()
+	index.Build([]knowledge.ThreatPattern{
+		{ID: "TMKB-TEST-001", Triggers: knowledge.Triggers{Expr: `language == "go"`}},
+		{ID: "TMKB-TEST-002", Triggers: knowledge.Triggers{Expr: `has_import("crypto/md5")`}},
+	})
+
+	output := captureOutput(func() {
+		if err := runLint(lintCmd, []string{}); err != nil {
+			t.Fatalf("lint command failed: %v", err)
+		}
+	})
+
+	if !contains(output, "2 triggers.expr OK") {
+		t.Errorf("expected both exprs to pass lint, got: %s", output)
+	}
+}
@@ -3,26 +3,75 @@ package cli
 import (
 	"fmt"
 
+	"github.com/mark-chris/tmkb/internal/knowledge"
 	"github.com/spf13/cobra"
 )
 
+var (
+	listRun  string
+	listSkip string
+)
+
 var listCmd = &cobra.Command{
-	Use:   "list",
+	Use:   "list [selector...]",
 	Short: "List all available patterns",
-	Long: `List all threat patterns in the knowledge base.
+	Long: `List threat patterns in the knowledge base.
+
+With no arguments, lists every pattern. Positional arguments narrow this
+down to one or more selectors: an exact pattern ID, a "prefix..." term
+matching any ID/language/framework with that prefix ("..." alone matches
+everything), or a "-"-prefixed term excluding matches from what came before
+it.
+
+--run/--skip apply a Go-test-style regex instead: a slash-separated
+expression matches component-wise against id/language/framework/category.
 
 Examples:
   # List all patterns
   tmkb list
 
+  # List every pattern under a prefix, excluding one
+  tmkb list TMKB-AUTHZ-... -TMKB-AUTHZ-003
+
+  # List only Python/Flask patterns under the AUTHZ family
+  tmkb list --run 'AUTHZ/Python/Flask'
+
+  # List everything except crypto patterns
+  tmkb list --skip 'CRYPTO'
+
   # List with verbose output
-  tmkb list --verbose`,
+  tmkb list --output=human`,
 	RunE: runList,
 }
 
+func init() {
+	listCmd.Flags().StringVar(&listRun, "run", "",
+		"Regex (Go-test style, e.g. 'AUTHZ/Python/Flask') narrowing patterns by id/language/framework/category")
+	listCmd.Flags().StringVar(&listSkip, "skip", "",
+		"Regex (same syntax as --run) excluding matching patterns")
+}
+
 func runList(cmd *cobra.Command, args []string) error {
 	patterns := index.GetAll()
 
+	if len(args) > 0 {
+		patterns = knowledge.ExpandSelectors(patterns, args)
+	}
+
+	if listRun != "" || listSkip != "" {
+		matcher, err := knowledge.NewPatternMatcher(listRun, listSkip)
+		if err != nil {
+			return err
+		}
+		var filtered []knowledge.ThreatPattern
+		for _, p := range patterns {
+			if matcher.Matches(p) {
+				filtered = append(filtered, p)
+			}
+		}
+		patterns = filtered
+	}
+
 	if len(patterns) == 0 {
 		fmt.Println("No patterns found")
 		return nil
@@ -31,7 +80,7 @@ func runList(cmd *cobra.Command, args []string) error {
 	fmt.Printf("Found %d pattern(s):\n\n", len(patterns))
 
 	for _, p := range patterns {
-		if verbose {
+		if outputMode == "human" {
 			fmt.Printf("[%s] %s\n", p.Tier, p.ID)
 			fmt.Printf("  Name:     %s\n", p.Name)
 			fmt.Printf("  Category: %s > %s\n", p.Category, p.Subcategory)
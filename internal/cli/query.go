@@ -3,16 +3,35 @@ package cli
 import (
 	"fmt"
 
+	"github.com/mark-chris/tmkb/internal/cli/views"
 	"github.com/mark-chris/tmkb/internal/knowledge"
+	"github.com/mark-chris/tmkb/internal/knowledge/selector"
 	"github.com/spf13/cobra"
 )
 
 var (
-	queryContext   string
-	queryLanguage  string
-	queryFramework string
-	queryCategory  string
-	queryLimit     int
+	queryContext          string
+	queryLanguage         string
+	queryFramework        string
+	queryCategory         string
+	queryLimit            int
+	queryFilter           string
+	querySelect           string
+	queryFields           []string
+	queryJSONPath         string
+	queryBudget           int
+	queryModel            string
+	queryFilePath         string
+	queryEnforcementScope string
+	queryRanker           string
+	queryCWE              string
+	queryOWASP            string
+	queryMaxTokens        int
+	queryCursor           string
+	queryK1               float64
+	queryB                float64
+	queryMinScore         float64
+	queryMatchMode        string
 )
 
 var queryCmd = &cobra.Command{
@@ -21,7 +40,7 @@ var queryCmd = &cobra.Command{
 	Long: `Query the threat model knowledge base for patterns relevant to your implementation.
 
 Returns structured, actionable security context optimized for AI agent consumption.
-Use --verbose for human-readable detailed output.
+Use --output=human for pretty-printed detailed output.
 
 Examples:
   # Query by context
@@ -30,11 +49,47 @@ Examples:
   # Query with language/framework filter
   tmkb query --context "background job" --language python --framework flask
 
-  # Get verbose human-readable output
-  tmkb query --context "file upload processing" --verbose
+  # Get pretty-printed human-readable output
+  tmkb query --context "file upload processing" --output=human
 
   # Limit results
-  tmkb query --context "authorization" --limit 5`,
+  tmkb query --context "authorization" --limit 5
+
+  # Filter with an expr-style boolean expression
+  tmkb query --context "file upload" --filter 'severity in ["critical","high"] and language == "python"'
+
+  # Further narrow ranked results with a selector expression
+  tmkb query --context "authorization" --select 'tier:A'
+
+  # Pack as many patterns as fit in a hard token budget
+  tmkb query --context "authorization" --budget 1000 --model gpt-4o
+
+  # Match patterns whose triggers.any/all references the file being edited
+  tmkb query --context "background job processing" --file tasks/celery/worker.py
+
+  # Resolve each pattern's enforcement action for a CI caller
+  tmkb query --context "admin dashboard" --enforcement-scope ci
+
+  # Rank with Okapi BM25 instead of the default hybrid formula
+  tmkb query --context "background job processing" --ranker bm25
+
+  # Rank with field-boosted BM25, weighting keywords/agent summary/name above description
+  tmkb query --context "authorization" --ranker bm25f --k1 1.5 --b 0.8
+
+  # Find patterns addressing a specific CWE or OWASP Top 10 category
+  tmkb query --context "admin dashboard" --cwe CWE-352
+  tmkb query --context "authorization" --owasp A01:2021
+
+  # Knapsack-pack matches into a token budget, paginating across calls
+  tmkb query --context "authorization" --max-tokens 1000
+  tmkb query --context "authorization" --max-tokens 1000 --cursor 20
+
+  # Prune low-relevance matches below a BM25 score threshold
+  tmkb query --context "authorization" --min-score 2.5
+
+  # Match morphological variants ("uploading" -> "upload") or typos
+  tmkb query --context "uploading files from users" --match-mode stemmed
+  tmkb query --context "autorization check" --match-mode fuzzy`,
 	RunE: runQuery,
 }
 
@@ -49,33 +104,121 @@ func init() {
 		"Category filter (e.g., authorization)")
 	queryCmd.Flags().IntVar(&queryLimit, "limit", 0,
 		"Maximum number of patterns to return (default: 3 for agent, 10 for verbose)")
+	queryCmd.Flags().StringVar(&queryFilter, "filter", "",
+		`Boolean expression to filter patterns, e.g. 'severity == "critical" and "CWE-285" in cwe_references'`)
+	queryCmd.Flags().StringVar(&querySelect, "select", "",
+		"Selector expression further narrowing the query results, e.g. 'tier:A && framework:flask'")
+	queryCmd.Flags().StringSliceVar(&queryFields, "fields", nil,
+		"Comma-separated, repeatable field selectors to narrow the output (see --jsonpath for the full grammar)")
+	queryCmd.Flags().StringVar(&queryJSONPath, "jsonpath", "",
+		"A single JSONPath-style selector, e.g. 'patterns[*].id'")
+	queryCmd.Flags().IntVar(&queryBudget, "budget", 0,
+		"Pack matches into the response greedily by relevance until this many tokens would be exceeded (0: disabled, use --limit instead)")
+	queryCmd.Flags().StringVar(&queryModel, "model", "",
+		"Model name used to pick a token encoding for --budget, e.g. gpt-4o, gpt-4, claude-3-opus (default: cl100k_base)")
+	queryCmd.Flags().StringVar(&queryFilePath, "file", "",
+		`File path to match against a pattern's triggers.any/all "file"-field leaves`)
+	queryCmd.Flags().StringVar(&queryEnforcementScope, "enforcement-scope", "",
+		"Caller type (agent, human, ci, ide) used to resolve each pattern's effective enforcement action")
+	queryCmd.Flags().StringVar(&queryRanker, "ranker", "",
+		"Relevance ranking formula: hybrid (default), bm25 (Okapi BM25 over corpus keyword statistics), or bm25f (field-boosted BM25)")
+	queryCmd.Flags().Float64Var(&queryK1, "k1", 0,
+		"BM25 term-frequency saturation parameter, only used by --ranker bm25f (default: 1.2)")
+	queryCmd.Flags().Float64Var(&queryB, "b", 0,
+		"BM25 length-normalization parameter, only used by --ranker bm25f (default: 0.75)")
+	queryCmd.Flags().StringVar(&queryCWE, "cwe", "",
+		"CWE identifier filter, e.g. CWE-352")
+	queryCmd.Flags().StringVar(&queryOWASP, "owasp", "",
+		"OWASP Top 10 identifier filter, e.g. A01:2021")
+	queryCmd.Flags().IntVar(&queryMaxTokens, "max-tokens", 0,
+		"Token budget for knapsack-optimized pattern selection, maximizing relevance within budget (0: disabled, use --budget or --limit instead)")
+	queryCmd.Flags().StringVar(&queryCursor, "cursor", "",
+		"Pagination cursor from a prior response's next_cursor; only meaningful together with --max-tokens")
+	queryCmd.Flags().Float64Var(&queryMinScore, "min-score", 0,
+		"Drop patterns whose relevance score falls below this threshold (0: disabled); only meaningful with --context")
+	queryCmd.Flags().StringVar(&queryMatchMode, "match-mode", "",
+		"Context-matching mode: stemmed (default, also matches morphological variants), exact (literal terms only), or fuzzy (stemmed plus edit-distance typo tolerance)")
 }
 
 func runQuery(cmd *cobra.Command, args []string) error {
 	// Build query options
 	verbosity := "agent"
-	if verbose {
+	if outputMode == "human" {
 		verbosity = "human"
 	}
 
 	opts := knowledge.QueryOptions{
-		Context:   queryContext,
-		Language:  queryLanguage,
-		Framework: queryFramework,
-		Category:  queryCategory,
-		Limit:     queryLimit,
-		Verbosity: verbosity,
+		Context:          queryContext,
+		Language:         queryLanguage,
+		Framework:        queryFramework,
+		Category:         queryCategory,
+		Filter:           queryFilter,
+		FilePath:         queryFilePath,
+		Limit:            queryLimit,
+		Verbosity:        verbosity,
+		Budget:           queryBudget,
+		Model:            queryModel,
+		EnforcementScope: queryEnforcementScope,
+		Ranker:           queryRanker,
+		K1:               queryK1,
+		B:                queryB,
+		CWE:              queryCWE,
+		OWASP:            queryOWASP,
+		MaxTokens:        queryMaxTokens,
+		Cursor:           queryCursor,
+		MinScore:         queryMinScore,
+		MatchMode:        queryMatchMode,
 	}
 
 	// Execute query
-	result := knowledge.Query(index, opts)
-
-	// Format output
-	output, err := knowledge.FormatOutput(result, getFormat(), verbose)
+	result, err := knowledge.Query(index, opts)
 	if err != nil {
-		return fmt.Errorf("failed to format output: %w", err)
+		return fmt.Errorf("invalid --filter: %w", err)
+	}
+
+	if querySelect != "" {
+		sel, err := selector.Compile(querySelect)
+		if err != nil {
+			return fmt.Errorf("invalid --select expression: %w", err)
+		}
+		result = filterQueryResult(result, sel)
+	}
+
+	fields := queryFields
+	if queryJSONPath != "" {
+		fields = append(fields, queryJSONPath)
+	}
+
+	return views.NewQueryView(outputMode, result, fields).Render(cmd.OutOrStdout())
+}
+
+// filterQueryResult narrows a query's patterns/verbose_patterns down to
+// those whose full ThreatPattern (looked up by ID in the index) matches sel,
+// keeping --select consistent with its meaning in `validate` and `get`
+// without requiring the ranking/token-budget logic in Query itself to know
+// about selector expressions.
+func filterQueryResult(result knowledge.QueryResult, sel *selector.Selector) knowledge.QueryResult {
+	if len(result.Patterns) > 0 {
+		filtered := make([]knowledge.PatternOutput, 0, len(result.Patterns))
+		for _, p := range result.Patterns {
+			if full := index.GetByID(p.ID); full != nil && sel.Match(full) {
+				filtered = append(filtered, p)
+			}
+		}
+		result.Patterns = filtered
+		result.PatternsIncluded = len(filtered)
+	}
+
+	if len(result.VerbosePatterns) > 0 {
+		filtered := make([]knowledge.PatternOutputVerbose, 0, len(result.VerbosePatterns))
+		for _, p := range result.VerbosePatterns {
+			if full := index.GetByID(p.ID); full != nil && sel.Match(full) {
+				filtered = append(filtered, p)
+			}
+		}
+		result.VerbosePatterns = filtered
+		result.PatternsIncluded = len(filtered)
 	}
 
-	fmt.Println(output)
-	return nil
+	return result
 }
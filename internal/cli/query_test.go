@@ -19,8 +19,17 @@ func resetQueryFlags() {
 	queryFramework = ""
 	queryCategory = ""
 	queryLimit = 0
-	verbose = false
-	outputFormat = "json"
+	querySelect = ""
+	queryBudget = 0
+	queryModel = ""
+	queryFilePath = ""
+	queryEnforcementScope = ""
+	queryRanker = ""
+	queryCWE = ""
+	queryOWASP = ""
+	queryMaxTokens = 0
+	queryCursor = ""
+	outputMode = "json"
 }
 
 // captureOutput captures stdout for testing
@@ -200,6 +209,51 @@ func TestQueryCommand_CombinedFilters(t *testing.T) {
 	// Output validation can be added later
 }
 
+// TestQueryCommand_SelectFlag tests that --select narrows ranked results
+// down to patterns matching the selector expression
+func TestQueryCommand_SelectFlag(t *testing.T) {
+	// Setup test fixtures
+	fixture := testutil.SetupTestPatterns(t)
+	defer fixture.Cleanup()
+
+	// Configure for test
+	resetQueryFlags()
+	patternsDir = fixture.Dir
+	loader = knowledge.NewLoader(patternsDir)
+	index = knowledge.NewIndex()
+	patterns, err := loader.LoadAll()
+	if err != nil {
+		t.Fatalf("Failed to load patterns: %v", err)
+	}
+	index.Build(patterns)
+
+	// A context matching keywords from both TMKB-TEST-002 (session, token)
+	// and TMKB-TEST-003 (tenant, isolation), narrowed to only TMKB-TEST-003
+	// via --select.
+	queryContext = "session token tenant isolation"
+	querySelect = "lang:javascript"
+
+	output := captureOutput(func() {
+		err = runQuery(queryCmd, []string{})
+	})
+	if err != nil {
+		t.Fatalf("Query command with --select failed: %v", err)
+	}
+
+	var result knowledge.QueryResult
+	if err := json.Unmarshal([]byte(output), &result); err != nil {
+		t.Fatalf("Failed to parse JSON output: %v", err)
+	}
+
+	if len(result.Patterns) != 1 || result.Patterns[0].ID != "TMKB-TEST-003" {
+		t.Errorf("Expected only TMKB-TEST-003 after --select, got %v", result.Patterns)
+	}
+	if result.PatternsIncluded != len(result.Patterns) {
+		t.Errorf("Expected PatternsIncluded to match filtered count, got %d for %d patterns",
+			result.PatternsIncluded, len(result.Patterns))
+	}
+}
+
 // TestQueryCommand_LimitFlag tests the --limit flag caps results
 func TestQueryCommand_LimitFlag(t *testing.T) {
 	// Setup test fixtures
@@ -246,6 +300,52 @@ func TestQueryCommand_LimitFlag(t *testing.T) {
 	}
 }
 
+// TestQueryCommand_BudgetFlag tests that --budget/--model are threaded into
+// the query and cap the response by token count rather than pattern count.
+func TestQueryCommand_BudgetFlag(t *testing.T) {
+	// Setup test fixtures
+	fixture := testutil.SetupTestPatterns(t)
+	defer fixture.Cleanup()
+
+	// Configure for test
+	resetQueryFlags()
+	patternsDir = fixture.Dir
+	loader = knowledge.NewLoader(patternsDir)
+	index = knowledge.NewIndex()
+	patterns, err := loader.LoadAll()
+	if err != nil {
+		t.Fatalf("Failed to load patterns: %v", err)
+	}
+	index.Build(patterns)
+
+	// A context touching all three fixtures' keywords matches all of them;
+	// a tiny budget should still only pack one.
+	queryContext = "background job authorization session token tenant isolation"
+	queryLimit = 3
+	queryBudget = 1
+	queryModel = "gpt-4o"
+
+	output := captureOutput(func() {
+		err = runQuery(queryCmd, []string{})
+	})
+
+	if err != nil {
+		t.Errorf("Query command with --budget failed: %v", err)
+	}
+
+	var result knowledge.QueryResult
+	if err := json.Unmarshal([]byte(output), &result); err != nil {
+		t.Fatalf("Failed to parse JSON output: %v", err)
+	}
+
+	if len(result.Patterns) != 1 {
+		t.Errorf("Expected exactly 1 pattern packed under a 1-token budget, got %d", len(result.Patterns))
+	}
+	if !result.TokenLimitReached {
+		t.Error("Expected token_limit_reached to be true with a 1-token budget")
+	}
+}
+
 // TestQueryCommand_VerboseMode tests --verbose produces human-readable output
 func TestQueryCommand_VerboseMode(t *testing.T) {
 	// Setup test fixtures
@@ -265,7 +365,7 @@ func TestQueryCommand_VerboseMode(t *testing.T) {
 
 	// Set query parameters with verbose mode
 	queryContext = "background job"
-	verbose = true
+	outputMode = "human"
 
 	// Capture output
 	output := captureOutput(func() {
@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/mark-chris/tmkb/internal/knowledge"
+	"github.com/spf13/cobra"
+)
+
+var refsTargets []string
+
+var refsCmd = &cobra.Command{
+	Use:   "refs",
+	Short: "Show CWE/OWASP cross-reference coverage",
+	Long: `Show how the loaded knowledge base cross-references CWE and OWASP Top 10
+identifiers.
+
+With no flags, lists every CWE/OWASP identifier referenced by at least one
+pattern, and how many patterns reference it.
+
+With --targets, instead reports coverage gaps: the identifiers in the target
+list referenced by zero patterns in this knowledge base.
+
+Examples:
+  # List CWE/OWASP coverage across the loaded patterns
+  tmkb refs
+
+  # Check a target CWE list for coverage gaps
+  tmkb refs --targets CWE-79,CWE-89,CWE-352`,
+	RunE: runRefs,
+}
+
+func init() {
+	refsCmd.Flags().StringSliceVar(&refsTargets, "targets", nil,
+		"Comma-separated, repeatable list of CWE identifiers to check for coverage gaps, e.g. CWE-79,CWE-89")
+}
+
+func runRefs(cmd *cobra.Command, args []string) error {
+	if len(refsTargets) > 0 {
+		gaps := knowledge.CoverageGaps(index, refsTargets)
+		if len(gaps) == 0 {
+			fmt.Printf("No coverage gaps among %d target(s)\n", len(refsTargets))
+			return nil
+		}
+		fmt.Printf("%d of %d target(s) have no covering pattern:\n\n", len(gaps), len(refsTargets))
+		for _, cwe := range gaps {
+			fmt.Printf("  %s\n", cwe)
+		}
+		return nil
+	}
+
+	counts := make(map[string]int)
+	for _, p := range index.GetAll() {
+		for _, cwe := range p.CWEs() {
+			counts[cwe]++
+		}
+		for _, owasp := range p.OWASPs() {
+			counts[owasp]++
+		}
+	}
+
+	if len(counts) == 0 {
+		fmt.Println("No CWE/OWASP references found in the loaded patterns")
+		return nil
+	}
+
+	ids := make([]string, 0, len(counts))
+	for id := range counts {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	fmt.Printf("Found %d referenced identifier(s):\n\n", len(ids))
+	for _, id := range ids {
+		fmt.Printf("%-12s  %d pattern(s)\n", id, counts[id])
+	}
+
+	return nil
+}
@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/mark-chris/tmkb/internal/knowledge"
+)
+
+func resetRefsFlags() {
+	refsTargets = nil
+}
+
+func buildRefsTestIndex() *knowledge.Index {
+	patterns := []knowledge.ThreatPattern{
+		{
+			ID:       "TMKB-AUTHZ-001",
+			Name:     "Missing Authorization Check",
+			Severity: "critical",
+			Provenance: knowledge.Provenance{
+				PublicReferences: []knowledge.PublicReference{
+					{CWE: "CWE-862", Name: "Missing Authorization"},
+				},
+			},
+		},
+		{
+			ID:       "TMKB-AUTHZ-002",
+			Name:     "Cross-Site Request Forgery",
+			Severity: "high",
+			Provenance: knowledge.Provenance{
+				PublicReferences: []knowledge.PublicReference{
+					{CWE: "CWE-352", OWASP: "A01:2021", Name: "CSRF"},
+				},
+			},
+		},
+	}
+
+	idx := knowledge.NewIndex()
+	idx.Build(patterns)
+	return idx
+}
+
+// TestRefsCommand_ListsReferencedIdentifiers verifies the default (no
+// --targets) mode lists every CWE/OWASP identifier referenced in the index.
+func TestRefsCommand_ListsReferencedIdentifiers(t *testing.T) {
+	resetRefsFlags()
+	index = buildRefsTestIndex()
+
+	output := captureOutput(func() {
+		if err := runRefs(refsCmd, []string{}); err != nil {
+			t.Fatalf("refs command failed: %v", err)
+		}
+	})
+
+	for _, id := range []string{"CWE-862", "CWE-352", "A01:2021"} {
+		if !contains(output, id) {
+			t.Errorf("Expected output to contain %q, got: %s", id, output)
+		}
+	}
+}
+
+// TestRefsCommand_TargetsReportsGaps verifies --targets reports only the
+// identifiers with zero covering patterns.
+func TestRefsCommand_TargetsReportsGaps(t *testing.T) {
+	resetRefsFlags()
+	index = buildRefsTestIndex()
+	refsTargets = []string{"CWE-862", "CWE-79"}
+	defer resetRefsFlags()
+
+	output := captureOutput(func() {
+		if err := runRefs(refsCmd, []string{}); err != nil {
+			t.Fatalf("refs command failed: %v", err)
+		}
+	})
+
+	if contains(output, "CWE-862") {
+		t.Errorf("Expected covered CWE-862 to be absent from gap report, got: %s", output)
+	}
+	if !contains(output, "CWE-79") {
+		t.Errorf("Expected uncovered CWE-79 in gap report, got: %s", output)
+	}
+}
+
+// TestRefsCommand_NoGaps verifies an all-covered target list is reported as
+// having no gaps.
+func TestRefsCommand_NoGaps(t *testing.T) {
+	resetRefsFlags()
+	index = buildRefsTestIndex()
+	refsTargets = []string{"CWE-862", "CWE-352"}
+	defer resetRefsFlags()
+
+	output := captureOutput(func() {
+		if err := runRefs(refsCmd, []string{}); err != nil {
+			t.Fatalf("refs command failed: %v", err)
+		}
+	})
+
+	if !contains(output, "No coverage gaps") {
+		t.Errorf("Expected no-gaps message, got: %s", output)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
 	"github.com/mark-chris/tmkb/internal/knowledge"
 )
@@ -12,8 +13,16 @@ import (
 var (
 	// Global flags
 	patternsDir string
-	outputFormat string
-	verbose bool
+	// outputMode is the --output value: "human" (pretty-printed for a
+	// person), "json" (default; a single aggregated document), or "raw"
+	// (newline-delimited JSON, one record per line, for jq/pipelines). See
+	// internal/cli/views for the renderers this selects between.
+	outputMode string
+
+	// patternsFS is the filesystem patterns are loaded from. It defaults to
+	// the real OS filesystem but can be swapped (e.g. by `serve`, or by
+	// tests) to load patterns from anywhere afero.Fs can reach.
+	patternsFS afero.Fs = afero.NewOsFs()
 
 	// Shared resources
 	loader *knowledge.Loader
@@ -39,26 +48,54 @@ Examples:
   # Validate all patterns
   tmkb validate --all
 
+  # Check CWE cross-reference coverage
+  tmkb refs --targets CWE-79,CWE-89
+
   # Start MCP server
   tmkb serve --port 3000`,
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-		// Skip initialization for help commands
-		if cmd.Name() == "help" || cmd.Name() == "version" {
+		// Skip initialization for commands that don't touch the patterns
+		// directory/index at all.
+		if cmd.Name() == "help" || cmd.Name() == "version" || cmd.Name() == "languages" {
 			return nil
 		}
 
 		// Initialize loader and index
-		loader = knowledge.NewLoader(patternsDir)
+		loader = knowledge.NewLoaderWithFS(patternsFS, patternsDir)
 		index = knowledge.NewIndex()
 
-		// Load patterns
-		patterns, err := loader.LoadAll()
+		// Load patterns from --patterns, layered under any additional
+		// sources TMKB_PATTERNS_PATH lists (highest-precedence first) -
+		// see knowledge.ParsePatternsPath. --patterns is always the last,
+		// lowest-precedence source, so an org-local or per-project pack
+		// can override a built-in pattern by ID without replacing it.
+		sources := append(knowledge.ParsePatternsPath(os.Getenv(knowledge.PatternsPathEnvVar)), knowledge.NewDirSource(loader))
+		patterns, warnings, err := knowledge.NewMultiLoader(sources...).LoadAll()
 		if err != nil {
 			return fmt.Errorf("failed to load patterns: %w", err)
 		}
-
-		// Build index
+		for _, w := range warnings {
+			fmt.Fprintf(os.Stderr, "tmkb: warning: %s\n", w)
+		}
 		index.Build(patterns)
+		index.SetLoadWarnings(warnings)
+
+		// Load the KB's shared named trigger definitions, if any, so
+		// Triggers.Any/All can reference them by pattern_ref.
+		registry, err := loader.LoadTriggerRegistry()
+		if err != nil {
+			return fmt.Errorf("failed to load trigger registry: %w", err)
+		}
+		index.SetTriggerRegistry(registry)
+
+		// Load the KB's shared synonym groups, if any, so
+		// MatchContextWithOptions can treat declared variants (e.g. "auth"/
+		// "authn"/"authentication") as interchangeable.
+		synonyms, err := loader.LoadSynonyms()
+		if err != nil {
+			return fmt.Errorf("failed to load synonym map: %w", err)
+		}
+		index.SetSynonyms(synonyms)
 
 		return nil
 	},
@@ -76,10 +113,8 @@ func init() {
 	// Global flags
 	rootCmd.PersistentFlags().StringVarP(&patternsDir, "patterns", "p", defaultPatternsDir,
 		"Path to patterns directory")
-	rootCmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", "json",
-		"Output format: json or text")
-	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false,
-		"Human-readable verbose output")
+	rootCmd.PersistentFlags().StringVar(&outputMode, "output", "json",
+		"Output mode: human (pretty-printed for a person), json (a single aggregated document), or raw (newline-delimited JSON, one record per line, for jq/pipelines)")
 
 	// Add subcommands
 	rootCmd.AddCommand(queryCmd)
@@ -88,6 +123,13 @@ func init() {
 	rootCmd.AddCommand(listCmd)
 	rootCmd.AddCommand(serveCmd)
 	rootCmd.AddCommand(versionCmd)
+	rootCmd.AddCommand(languagesCmd)
+	rootCmd.AddCommand(benchCmd)
+	rootCmd.AddCommand(testCmd)
+	rootCmd.AddCommand(refsCmd)
+	rootCmd.AddCommand(lintCmd)
+	rootCmd.AddCommand(enrichCmd)
+	rootCmd.AddCommand(exportCmd)
 }
 
 // findPatternsDir locates the patterns directory
@@ -109,10 +151,3 @@ func findPatternsDir() string {
 	return "patterns"
 }
 
-// getFormat returns the output format based on flags
-func getFormat() knowledge.OutputFormat {
-	if outputFormat == "text" || verbose {
-		return knowledge.FormatText
-	}
-	return knowledge.FormatJSON
-}
@@ -4,15 +4,20 @@ import (
 	"testing"
 
 	"github.com/mark-chris/tmkb/internal/cli/testutil"
+	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
 )
 
-// resetRootFlags resets root command flags and global variables
+// resetRootFlags resets root command flags and global variables. It also
+// restores patternsFS to the real OS filesystem, since other tests in this
+// package (e.g. export_test.go, enrich_test.go) swap it for an in-memory
+// afero.Fs and never restore it, which would otherwise leak into this
+// test's use of a real on-disk fixture directory.
 func resetRootFlags() {
-	verbose = false
-	outputFormat = "json"
+	outputMode = "json"
 	loader = nil
 	index = nil
+	patternsFS = afero.NewOsFs()
 }
 
 // TestRootCommand_InitializesIndex tests that root command triggers index initialization
@@ -25,9 +30,11 @@ func TestRootCommand_InitializesIndex(t *testing.T) {
 	resetRootFlags()
 	patternsDir = fixture.Dir
 
-	// Create a test command that will trigger PersistentPreRunE
-	testCmd := &cobra.Command{
-		Use:   "test",
+	// Create a dummy command that will trigger PersistentPreRunE. Named
+	// distinctly from the real "test" subcommand (internal/cli/test.go) so
+	// rootCmd dispatches to this fixture instead of the real one.
+	dummyCmd := &cobra.Command{
+		Use:   "init-probe",
 		Short: "Test command",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return nil
@@ -35,14 +42,14 @@ func TestRootCommand_InitializesIndex(t *testing.T) {
 	}
 
 	// Set up the command hierarchy
-	rootCmd.AddCommand(testCmd)
+	rootCmd.AddCommand(dummyCmd)
 	defer func() {
 		// Clean up after test
-		rootCmd.RemoveCommand(testCmd)
+		rootCmd.RemoveCommand(dummyCmd)
 	}()
 
 	// Execute the command - this should trigger PersistentPreRunE
-	rootCmd.SetArgs([]string{"test", "-p", fixture.Dir})
+	rootCmd.SetArgs([]string{"init-probe", "-p", fixture.Dir})
 	err := rootCmd.Execute()
 
 	if err != nil {
@@ -1,35 +1,70 @@
 package cli
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
 
+	"github.com/mark-chris/tmkb/internal/knowledge"
 	"github.com/mark-chris/tmkb/internal/mcp"
 	"github.com/spf13/cobra"
 )
 
+var (
+	serveHTTPAddr string
+	serveStrict   bool
+)
+
 var serveCmd = &cobra.Command{
 	Use:   "serve",
 	Short: "Start MCP server for AI agent integration",
 	Long: `Start a Model Context Protocol (MCP) server that AI agents can query.
 
-The MCP server communicates via stdin/stdout using the JSON-RPC 2.0 protocol.
-It is designed to be invoked by MCP clients like Claude Code.
+By default the MCP server communicates via stdin/stdout using the
+JSON-RPC 2.0 protocol, designed to be invoked by MCP clients like Claude
+Code. With --http, it instead serves the MCP Streamable HTTP transport
+(POST /mcp for requests, GET /mcp for the server-sent-events notification
+stream), letting several agents share one running tmkb process.
 
 Examples:
   # Start MCP server (typically invoked by Claude Code)
-  tmkb serve`,
+  tmkb serve
+
+  # Serve the Streamable HTTP transport instead, for multiple agents
+  tmkb serve --http :8080
+
+  # Refuse to finish the handshake if the loaded patterns have conflicts
+  tmkb serve --strict`,
 	RunE: runServe,
 }
 
+func init() {
+	serveCmd.Flags().StringVar(&serveHTTPAddr, "http", "",
+		"Serve the MCP Streamable HTTP transport on this address instead of stdio, e.g. :8080")
+	serveCmd.Flags().BoolVar(&serveStrict, "strict", false,
+		"Refuse to complete a client's initialize handshake if the loaded patterns fail cross-pattern validation (see 'tmkb validate')")
+}
+
 func runServe(cmd *cobra.Command, args []string) error {
 	// Create MCP server with the loaded pattern index
 	server := mcp.NewServer(index)
+	server.StrictValidation = serveStrict
 
 	// Log to stderr (stdout is reserved for protocol communication)
 	log.SetOutput(os.Stderr)
 	log.Printf("Starting MCP server with %d patterns loaded", index.Count())
+
+	go watchPatterns(server)
+
+	if serveHTTPAddr != "" {
+		log.Printf("Server ready for MCP protocol communication via HTTP+SSE on %s", serveHTTPAddr)
+		if err := server.ServeHTTP(serveHTTPAddr); err != nil {
+			return fmt.Errorf("MCP server error: %w", err)
+		}
+		return nil
+	}
+
 	log.Println("Server ready for MCP protocol communication via stdio")
 
 	// Run server - blocks until stdin closes (EOF)
@@ -40,3 +75,24 @@ func runServe(cmd *cobra.Command, args []string) error {
 	log.Println("MCP server shutdown")
 	return nil
 }
+
+// watchPatterns runs loader.Watch for the lifetime of the process, so
+// editing a pattern file while `tmkb serve` is running is picked up
+// without restarting the server or its MCP connection. A failed reload
+// (e.g. a pattern file with invalid YAML) keeps server on whatever Index
+// it already has and broadcasts the failure to every connected client
+// instead of only logging it server-side.
+func watchPatterns(server *mcp.Server) {
+	err := loader.Watch(context.Background(), func(idx *knowledge.Index, err error) {
+		if err != nil {
+			log.Printf("[WARN] pattern reload failed: %v", err)
+			server.BroadcastMessage("warning", fmt.Sprintf("pattern reload failed: %v", err))
+			return
+		}
+		server.SetIndex(idx)
+		log.Printf("Reloaded patterns: %d patterns now loaded", idx.Count())
+	})
+	if err != nil {
+		log.Printf("[WARN] pattern hot-reload disabled: %v", err)
+	}
+}
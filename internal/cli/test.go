@@ -0,0 +1,158 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/mark-chris/tmkb/internal/knowledge/bench"
+	"github.com/mark-chris/tmkb/internal/knowledge/regression"
+	"github.com/mark-chris/tmkb/internal/patterntest"
+	"github.com/spf13/cobra"
+)
+
+var (
+	testFixtures string
+	testRun      string
+	testUpdate   bool
+	testJUnit    string
+)
+
+var testCmd = &cobra.Command{
+	Use:   "test",
+	Short: "Run query behavior against YAML regression fixtures",
+	Long: `Run the centralized "tmkb bench"-style fixtures (--fixtures), asserting
+that each case's query returns exactly its expected ranked pattern IDs,
+then also discover and run any per-pattern fixtures living in a sibling
+"<pattern>.tests/" directory next to a pattern's YAML file (see
+internal/patterntest) - each asserting must_match/must_not_match/top/
+min_score against that one pattern's own queries. Unlike "bench", which
+scores partial matches via precision/recall, a single mismatch in either
+fixture format fails the run - use this in CI to catch scoring
+regressions, and "bench" to track relevance/latency trends over time.
+
+Examples:
+  # Run fixtures and fail CI on any mismatch
+  tmkb test --fixtures=./bench/*.yaml
+
+  # Only run per-pattern fixtures whose name matches a regexp
+  tmkb test --run=TMKB-AUTHZ-001
+
+  # Regenerate per-pattern fixtures' expected must_match/top from current results
+  tmkb test --update
+
+  # Write a JUnit XML report of the per-pattern fixtures for CI
+  tmkb test --junit=patterntest.xml`,
+	RunE: runTest,
+}
+
+func init() {
+	testCmd.Flags().StringVar(&testFixtures, "fixtures", "bench/*.yaml",
+		"Glob of YAML fixture files defining the expected query behavior")
+	testCmd.Flags().StringVar(&testRun, "run", "",
+		"Only run per-pattern fixtures (see internal/patterntest) whose name matches this regexp")
+	testCmd.Flags().BoolVar(&testUpdate, "update", false,
+		"Rewrite per-pattern fixtures' must_match/top to the current query results instead of checking them")
+	testCmd.Flags().StringVar(&testJUnit, "junit", "",
+		"Path to write a JUnit XML report of the per-pattern fixtures to")
+}
+
+func runTest(cmd *cobra.Command, args []string) error {
+	if err := runCentralizedFixtures(); err != nil {
+		return err
+	}
+	return runPatternFixtures()
+}
+
+func runCentralizedFixtures() error {
+	cases, err := bench.LoadFixtures(testFixtures)
+	if err != nil {
+		return fmt.Errorf("failed to load fixtures: %w", err)
+	}
+	if len(cases) == 0 {
+		return fmt.Errorf("no fixture cases found matching %q", testFixtures)
+	}
+
+	report, err := regression.Run(index, cases)
+	if err != nil {
+		return fmt.Errorf("failed to run regression tests: %w", err)
+	}
+
+	for _, cr := range report.Cases {
+		status := "✓"
+		if !cr.Passed {
+			status = "✗"
+		}
+		fmt.Printf("%s %s\n", status, cr.Name)
+		if !cr.Passed {
+			fmt.Printf("  expected: %v\n", cr.Expected)
+			fmt.Printf("  got:      %v\n", cr.Got)
+		}
+	}
+
+	fmt.Printf("\nRan %d case(s): %d passed, %d failed\n", len(report.Cases), report.Passed, report.Failed)
+
+	if report.Failed > 0 {
+		os.Exit(1)
+	}
+
+	return nil
+}
+
+func runPatternFixtures() error {
+	fixtures, err := patterntest.Discover(patternsFS, index.GetAll())
+	if err != nil {
+		return fmt.Errorf("failed to discover per-pattern fixtures: %w", err)
+	}
+
+	fixtures, err = patterntest.Filter(fixtures, testRun)
+	if err != nil {
+		return err
+	}
+
+	if len(fixtures) == 0 {
+		return nil
+	}
+
+	if testUpdate {
+		if err := patterntest.Update(patternsFS, index, fixtures); err != nil {
+			return fmt.Errorf("failed to update per-pattern fixtures: %w", err)
+		}
+		fmt.Printf("Updated %d per-pattern fixture(s)\n", len(fixtures))
+		return nil
+	}
+
+	report, err := patterntest.Run(index, fixtures)
+	if err != nil {
+		return fmt.Errorf("failed to run per-pattern fixtures: %w", err)
+	}
+
+	for _, r := range report.Results {
+		status := "✓"
+		if !r.Passed {
+			status = "✗"
+		}
+		fmt.Printf("%s %s\n", status, r.Fixture.Name)
+		for _, f := range r.Failures {
+			fmt.Printf("  %s\n", f)
+		}
+	}
+	fmt.Printf("\nRan %d per-pattern fixture(s): %d passed, %d failed\n",
+		len(report.Results), report.Passed, report.Failed)
+
+	if testJUnit != "" {
+		f, err := os.Create(testJUnit)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", testJUnit, err)
+		}
+		defer f.Close()
+		if err := patterntest.WriteJUnit(f, report); err != nil {
+			return fmt.Errorf("failed to write JUnit report: %w", err)
+		}
+	}
+
+	if report.Failed > 0 {
+		os.Exit(1)
+	}
+
+	return nil
+}
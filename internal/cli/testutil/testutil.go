@@ -1,19 +1,20 @@
 package testutil
 
 import (
-	"os"
 	"path/filepath"
 	"testing"
 
 	"github.com/mark-chris/tmkb/internal/knowledge"
+	"github.com/spf13/afero"
 	"gopkg.in/yaml.v3"
 )
 
 // TestFixture holds test resources and provides cleanup
 type TestFixture struct {
-	Dir      string                     // Temporary directory containing test patterns
-	Patterns []knowledge.ThreatPattern  // Loaded test patterns
-	Cleanup  func()                      // Cleanup function to remove temporary resources
+	Dir      string                    // Directory (real or in-memory) containing test patterns
+	FS       afero.Fs                  // Filesystem backing Dir; pass to knowledge.NewLoaderWithFS
+	Patterns []knowledge.ThreatPattern // Loaded test patterns
+	Cleanup  func()                    // Cleanup function to remove temporary resources
 }
 
 // PatternWrapper handles the top-level threat_pattern key in YAML files
@@ -42,20 +43,57 @@ func SetupTestPatterns(t *testing.T) *TestFixture {
 	patterns[1].Triggers.Keywords = []string{"session", "token"}
 	patterns[2].Triggers.Keywords = []string{"tenant", "isolation"}
 
+	fs := afero.NewOsFs()
+
 	// Write patterns to disk
 	for _, pattern := range patterns {
-		if err := writePatternFile(tmpDir, pattern); err != nil {
+		if err := writePatternFile(fs, tmpDir, pattern); err != nil {
 			t.Fatalf("Failed to write pattern file: %v", err)
 		}
 	}
 
 	return &TestFixture{
 		Dir:      tmpDir,
+		FS:       fs,
 		Patterns: patterns,
 		Cleanup:  func() {}, // t.TempDir() handles cleanup automatically
 	}
 }
 
+// SetupTestPatternsFS is the hermetic counterpart to SetupTestPatterns: it
+// writes the same 3 test patterns to an in-memory afero.MemMapFs instead of
+// real temp-directory files, for tests that want no filesystem I/O at all.
+// Pair it with knowledge.NewLoaderWithFS(fixture.FS, fixture.Dir).
+func SetupTestPatternsFS(t *testing.T) *TestFixture {
+	t.Helper()
+
+	const dir = "/patterns"
+	fs := afero.NewMemMapFs()
+
+	patterns := []knowledge.ThreatPattern{
+		CreateTestPattern("TMKB-TEST-001", "Test Pattern 001", "Python", "Flask"),
+		CreateTestPattern("TMKB-TEST-002", "Test Pattern 002", "Go", "any"),
+		CreateTestPattern("TMKB-TEST-003", "Test Pattern 003", "JavaScript", "Express"),
+	}
+
+	patterns[0].Triggers.Keywords = []string{"background", "job", "authorization"}
+	patterns[1].Triggers.Keywords = []string{"session", "token"}
+	patterns[2].Triggers.Keywords = []string{"tenant", "isolation"}
+
+	for _, pattern := range patterns {
+		if err := writePatternFile(fs, dir, pattern); err != nil {
+			t.Fatalf("Failed to write pattern file: %v", err)
+		}
+	}
+
+	return &TestFixture{
+		Dir:      dir,
+		FS:       fs,
+		Patterns: patterns,
+		Cleanup:  func() {},
+	}
+}
+
 // CreateTestPattern generates a minimal valid threat pattern for testing
 func CreateTestPattern(id, name, language, framework string) knowledge.ThreatPattern {
 	return knowledge.ThreatPattern{
@@ -103,8 +141,9 @@ func CreateTestPattern(id, name, language, framework string) knowledge.ThreatPat
 	}
 }
 
-// writePatternFile writes a threat pattern to a YAML file in the specified directory
-func writePatternFile(dir string, pattern knowledge.ThreatPattern) error {
+// writePatternFile writes a threat pattern to a YAML file in the specified
+// directory on fs.
+func writePatternFile(fs afero.Fs, dir string, pattern knowledge.ThreatPattern) error {
 	// Wrap pattern in threat_pattern key
 	wrapper := PatternWrapper{ThreatPattern: pattern}
 
@@ -117,5 +156,5 @@ func writePatternFile(dir string, pattern knowledge.ThreatPattern) error {
 	// Write to file
 	filename := filepath.Join(dir, pattern.ID+".yaml")
 	// #nosec G306 -- Test files don't need restrictive permissions
-	return os.WriteFile(filename, data, 0644)
+	return afero.WriteFile(fs, filename, data, 0644)
 }
@@ -110,6 +110,26 @@ func TestSetupTestPatterns_CreatesPatterns(t *testing.T) {
 	}
 }
 
+// TestSetupTestPatternsFS_InMemory verifies that SetupTestPatternsFS creates
+// the same 3 patterns without touching the real filesystem
+func TestSetupTestPatternsFS_InMemory(t *testing.T) {
+	fixture := SetupTestPatternsFS(t)
+	defer fixture.Cleanup()
+
+	if len(fixture.Patterns) != 3 {
+		t.Errorf("SetupTestPatternsFS created %d patterns, want 3", len(fixture.Patterns))
+	}
+
+	if _, err := fixture.FS.Stat(fixture.Dir); err != nil {
+		t.Errorf("fixture.Dir %s not present on fixture.FS: %v", fixture.Dir, err)
+	}
+
+	// The real filesystem must be untouched
+	if _, err := os.Stat(fixture.Dir); err == nil {
+		t.Errorf("SetupTestPatternsFS should not create %s on the real filesystem", fixture.Dir)
+	}
+}
+
 // TestCreateTestPattern_ValidStructure verifies that CreateTestPattern generates a valid pattern
 func TestCreateTestPattern_ValidStructure(t *testing.T) {
 	pattern := CreateTestPattern("TMKB-TEST-999", "Test Pattern", "Python", "Django")
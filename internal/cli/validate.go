@@ -4,96 +4,150 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/mark-chris/tmkb/internal/cli/views"
 	"github.com/mark-chris/tmkb/internal/knowledge"
+	"github.com/mark-chris/tmkb/internal/knowledge/selector"
 	"github.com/spf13/cobra"
 )
 
 var (
-	validateAll bool
+	validateSelect string
+	validateRun    string
+	validateSkip   string
+	failOn         string
+	// validateFormat is validate's own --format flag, kept separate from
+	// the shared --output flag because "sarif" isn't one of
+	// human/json/raw - it's a distinct upload format for GitHub code
+	// scanning, not a general-purpose rendering mode.
+	validateFormat string
 )
 
 var validateCmd = &cobra.Command{
-	Use:   "validate [pattern-id]",
+	Use:   "validate [selector...]",
 	Short: "Validate threat patterns",
 	Long: `Validate threat patterns against the schema requirements.
 
-Checks for required fields, proper formatting, and Tier A/B specific requirements.
+Checks for required fields, proper formatting, and Tier A/B specific
+requirements, plus conflicts across the selected set: duplicate ids,
+patterns with identical category/framework/language/keywords (which would
+always match the same queries), and keywords shared by so many patterns
+they no longer discriminate between them.
+
+With no arguments, validates every pattern. Positional arguments narrow this
+down to one or more selectors: an exact pattern ID, a "prefix..." term
+matching any ID/language/framework with that prefix ("..." alone matches
+everything), or a "-"-prefixed term excluding matches from what came before
+it. See --select for the richer field-expression form.
+
+With --output=json (the default), prints a structured ValidationReport:
+per-pattern diagnostics plus totals by severity and by diagnostic code,
+suitable for CI pipelines or other tooling to consume. --format=sarif prints
+a SARIF 2.1.0 log instead, for uploading straight to GitHub code scanning.
 
 Examples:
   # Validate all patterns
-  tmkb validate --all
+  tmkb validate
 
   # Validate a specific pattern
-  tmkb validate TMKB-AUTHZ-001`,
+  tmkb validate TMKB-AUTHZ-001
+
+  # Validate every pattern under a prefix, excluding one
+  tmkb validate TMKB-AUTHZ-... -TMKB-AUTHZ-003
+
+  # Validate every pattern
+  tmkb validate ...
+
+  # Validate a subset with a selector expression
+  tmkb validate --select 'lang:python && tier<2'
+
+  # Validate only Python/Flask patterns under the AUTHZ family, Go-test style
+  tmkb validate --run 'AUTHZ/Python/Flask'
+
+  # Validate everything except crypto patterns
+  tmkb validate --skip 'CRYPTO'
+
+  # Fail the build on warnings too, not just errors
+  tmkb validate --fail-on=warning
+
+  # Export diagnostics as SARIF for GitHub code scanning
+  tmkb validate --format sarif > tmkb.sarif`,
 	RunE: runValidate,
 }
 
 func init() {
-	validateCmd.Flags().BoolVar(&validateAll, "all", false,
-		"Validate all patterns in the patterns directory")
+	validateCmd.Flags().StringVar(&validateSelect, "select", "",
+		"Selector expression narrowing which patterns to validate, e.g. 'lang:python && tier<2' (default: all patterns)")
+	validateCmd.Flags().StringVar(&failOn, "fail-on", "error",
+		"Minimum diagnostic severity that causes a non-zero exit: error or warning")
+	validateCmd.Flags().StringVar(&validateRun, "run", "",
+		"Regex (Go-test style, e.g. 'AUTHZ/Python/Flask') narrowing patterns by id/language/framework/category")
+	validateCmd.Flags().StringVar(&validateSkip, "skip", "",
+		"Regex (same syntax as --run) excluding matching patterns")
+	validateCmd.Flags().StringVar(&validateFormat, "format", "",
+		"Set to sarif to emit a SARIF 2.1.0 log instead of --output's format, for uploading to GitHub code scanning")
 }
 
 func runValidate(cmd *cobra.Command, args []string) error {
+	if len(args) > 0 && validateSelect != "" {
+		return fmt.Errorf("use either positional pattern selectors or --select, not both")
+	}
+
 	patterns := index.GetAll()
 
+	switch {
+	case len(args) > 0:
+		patterns = knowledge.ExpandSelectors(patterns, args)
+	case validateSelect != "":
+		sel, err := selector.Compile(validateSelect)
+		if err != nil {
+			return fmt.Errorf("invalid --select expression: %w", err)
+		}
+		patterns = selector.Filter(patterns, sel)
+	}
+
+	if validateRun != "" || validateSkip != "" {
+		matcher, err := knowledge.NewPatternMatcher(validateRun, validateSkip)
+		if err != nil {
+			return err
+		}
+		var filtered []knowledge.ThreatPattern
+		for _, p := range patterns {
+			if matcher.Matches(p) {
+				filtered = append(filtered, p)
+			}
+		}
+		patterns = filtered
+	}
+
 	if len(patterns) == 0 {
 		fmt.Println("No patterns found to validate")
 		return nil
 	}
 
-	// Filter to specific pattern if provided
-	if len(args) > 0 && !validateAll {
-		patternID := args[0]
-		pattern := index.GetByID(patternID)
-		if pattern == nil {
-			return fmt.Errorf("pattern not found: %s", patternID)
+	// Validate each pattern individually, then append cross-pattern
+	// conflicts (duplicate ids, overlapping category/framework/language/
+	// keyword scopes, overused keywords) that only show up looking at the
+	// whole selected set at once.
+	results := append(knowledge.ValidateAll(patterns), knowledge.ValidateSetResults(patterns)...)
+
+	if validateFormat == "sarif" {
+		output, err := knowledge.FormatValidation(patterns, results, knowledge.FormatSARIF, false)
+		if err != nil {
+			return fmt.Errorf("failed to format output: %w", err)
 		}
-		patterns = []knowledge.ThreatPattern{*pattern}
+		fmt.Fprintln(cmd.OutOrStdout(), output)
+	} else if err := views.NewValidateView(outputMode, patterns, results).Render(cmd.OutOrStdout()); err != nil {
+		return fmt.Errorf("failed to format output: %w", err)
 	}
 
-	// Validate
-	results := knowledge.ValidateAll(patterns)
-
-	// Output results
-	hasErrors := false
-	totalErrors := 0
-	totalWarnings := 0
-
 	for _, result := range results {
-		totalErrors += len(result.Errors)
-		totalWarnings += len(result.Warnings)
-
 		if !result.IsValid {
-			hasErrors = true
+			os.Exit(1)
 		}
-
-		// Print results for each pattern
-		if len(result.Errors) > 0 || len(result.Warnings) > 0 || verbose {
-			status := "✓"
-			if !result.IsValid {
-				status = "✗"
-			}
-			fmt.Printf("%s %s\n", status, result.PatternID)
-
-			for _, err := range result.Errors {
-				fmt.Printf("  ERROR: %s - %s\n", err.Field, err.Message)
-			}
-			for _, warn := range result.Warnings {
-				fmt.Printf("  WARN:  %s - %s\n", warn.Field, warn.Message)
-			}
-			if len(result.Errors) > 0 || len(result.Warnings) > 0 {
-				fmt.Println()
-			}
+		if failOn == "warning" && len(result.Warnings) > 0 {
+			os.Exit(1)
 		}
 	}
 
-	// Summary
-	fmt.Printf("\nValidated %d pattern(s): %d error(s), %d warning(s)\n",
-		len(results), totalErrors, totalWarnings)
-
-	if hasErrors {
-		os.Exit(1)
-	}
-
 	return nil
 }
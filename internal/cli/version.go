@@ -20,7 +20,7 @@ var versionCmd = &cobra.Command{
 	Short: "Print version information",
 	Run: func(cmd *cobra.Command, args []string) {
 		fmt.Printf("tmkb version %s\n", Version)
-		if verbose {
+		if outputMode == "human" {
 			fmt.Printf("  Git commit: %s\n", GitCommit)
 			fmt.Printf("  Build date: %s\n", BuildDate)
 		}
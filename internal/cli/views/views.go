@@ -0,0 +1,207 @@
+// Package views renders a command's result to an io.Writer in one of
+// three output modes, selected by the CLI's --output flag:
+//
+//   - human: pretty-printed prose for a person to read
+//   - json:  a single aggregated document, stamped with format_version
+//     so a consumer can detect a future breaking change to its shape
+//   - raw:   newline-delimited JSON, one record per line, for jq/pipelines
+//
+// Each command gets its own View implementation (QueryView, GetView,
+// ValidateView) rather than branching on output mode inline, so
+// runQuery/runGet/runValidate stay focused on building their result and
+// handing it off to a renderer.
+package views
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/mark-chris/tmkb/internal/knowledge"
+)
+
+// FormatVersion is the value stamped as format_version on every full
+// --output=json document this package renders. Output narrowed with
+// --fields or --jsonpath is written without it.
+const FormatVersion = 1
+
+// View renders a command's result to w.
+type View interface {
+	Render(w io.Writer) error
+}
+
+// renderJSON marshals data, stamps a top-level format_version field onto
+// it, and writes the indented result to w.
+func renderJSON(w io.Writer, data interface{}) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	doc["format_version"] = FormatVersion
+
+	out, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	_, err = fmt.Fprintln(w, string(out))
+	return err
+}
+
+// renderNDJSON writes one compact JSON object per item to w.
+func renderNDJSON(w io.Writer, items ...interface{}) error {
+	enc := json.NewEncoder(w)
+	for _, item := range items {
+		if err := enc.Encode(item); err != nil {
+			return fmt.Errorf("failed to encode record: %w", err)
+		}
+	}
+	return nil
+}
+
+// QueryView renders `query`'s result.
+type QueryView struct {
+	Mode   string
+	Result knowledge.QueryResult
+	Fields []string
+}
+
+// NewQueryView builds the View for `query`'s result in the given output
+// mode ("human", "json", or "raw"; anything else is treated as "json").
+func NewQueryView(mode string, result knowledge.QueryResult, fields []string) View {
+	return QueryView{Mode: mode, Result: result, Fields: fields}
+}
+
+func (v QueryView) Render(w io.Writer) error {
+	switch v.Mode {
+	case "human":
+		out, err := knowledge.FormatOutput(v.Result, knowledge.FormatText, true, v.Fields)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, out)
+		return err
+	case "raw":
+		// --fields/--jsonpath narrows each match down to a selector value,
+		// which no longer has a whole pattern to NDJSON-encode - fall back
+		// to one flattened value per line, as before.
+		if len(v.Fields) > 0 {
+			out, err := knowledge.FormatOutput(v.Result, knowledge.FormatRaw, false, v.Fields)
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintln(w, out)
+			return err
+		}
+		if len(v.Result.VerbosePatterns) > 0 {
+			items := make([]interface{}, len(v.Result.VerbosePatterns))
+			for i, p := range v.Result.VerbosePatterns {
+				items[i] = p
+			}
+			return renderNDJSON(w, items...)
+		}
+		items := make([]interface{}, len(v.Result.Patterns))
+		for i, p := range v.Result.Patterns {
+			items[i] = p
+		}
+		return renderNDJSON(w, items...)
+	default:
+		if len(v.Fields) > 0 {
+			out, err := knowledge.FormatOutput(v.Result, knowledge.FormatJSON, false, v.Fields)
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintln(w, out)
+			return err
+		}
+		return renderJSON(w, v.Result)
+	}
+}
+
+// GetView renders `get`'s result: one or more full pattern records.
+type GetView struct {
+	Mode     string
+	Patterns []*knowledge.ThreatPattern
+	Fields   []string
+}
+
+// NewGetView builds the View for `get`'s result in the given output mode.
+func NewGetView(mode string, patterns []*knowledge.ThreatPattern, fields []string) View {
+	return GetView{Mode: mode, Patterns: patterns, Fields: fields}
+}
+
+func (v GetView) Render(w io.Writer) error {
+	switch v.Mode {
+	case "human":
+		return v.renderJoined(w, knowledge.FormatText, "\n\n")
+	case "raw":
+		if len(v.Fields) > 0 {
+			return v.renderJoined(w, knowledge.FormatRaw, "\n")
+		}
+		items := make([]interface{}, len(v.Patterns))
+		for i, p := range v.Patterns {
+			items[i] = p
+		}
+		return renderNDJSON(w, items...)
+	default:
+		if len(v.Fields) > 0 {
+			return v.renderJoined(w, knowledge.FormatJSON, "")
+		}
+		return renderJSON(w, struct {
+			Patterns []*knowledge.ThreatPattern `json:"patterns"`
+		}{v.Patterns})
+	}
+}
+
+// renderJoined formats each pattern individually (the shape --fields/
+// --jsonpath narrowing already requires, see knowledge.FormatPatternDetail)
+// and joins them with sep.
+func (v GetView) renderJoined(w io.Writer, format knowledge.OutputFormat, sep string) error {
+	outputs := make([]string, len(v.Patterns))
+	for i, p := range v.Patterns {
+		out, err := knowledge.FormatPatternDetail(p, format, v.Fields)
+		if err != nil {
+			return fmt.Errorf("failed to format output: %w", err)
+		}
+		outputs[i] = out
+	}
+	_, err := fmt.Fprintln(w, strings.Join(outputs, sep))
+	return err
+}
+
+// ValidateView renders `validate`'s result.
+type ValidateView struct {
+	Mode     string
+	Patterns []knowledge.ThreatPattern
+	Results  []knowledge.ValidationResult
+}
+
+// NewValidateView builds the View for `validate`'s result in the given
+// output mode.
+func NewValidateView(mode string, patterns []knowledge.ThreatPattern, results []knowledge.ValidationResult) View {
+	return ValidateView{Mode: mode, Patterns: patterns, Results: results}
+}
+
+func (v ValidateView) Render(w io.Writer) error {
+	switch v.Mode {
+	case "human":
+		out, err := knowledge.FormatValidation(v.Patterns, v.Results, knowledge.FormatText, true)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, out)
+		return err
+	case "raw":
+		items := make([]interface{}, len(v.Results))
+		for i, r := range v.Results {
+			items[i] = r
+		}
+		return renderNDJSON(w, items...)
+	default:
+		return renderJSON(w, knowledge.BuildReport(v.Patterns, v.Results))
+	}
+}
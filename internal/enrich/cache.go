@@ -0,0 +1,65 @@
+package enrich
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// cacheEntry is what diskCache persists per URL: the response body plus
+// the validators (ETag/Last-Modified) a conditional GET needs to avoid
+// re-downloading a feed entry that hasn't changed.
+type cacheEntry struct {
+	Body         []byte `json:"body"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+// diskCache persists one cacheEntry per URL under dir, named by the
+// URL's SHA-256 so a populated cache directory can be checked into CI and
+// replayed with Options.Offline, without the network ever being touched.
+type diskCache struct {
+	dir string
+}
+
+func newDiskCache(dir string) *diskCache {
+	return &diskCache{dir: dir}
+}
+
+func (c *diskCache) path(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// load returns the cached entry for url, and whether one was found. A
+// missing or corrupt cache file is treated the same as a miss - enrich
+// falls back to a live fetch rather than failing the whole run over a
+// damaged cache entry.
+func (c *diskCache) load(url string) (*cacheEntry, bool) {
+	data, err := os.ReadFile(c.path(url))
+	if err != nil {
+		return nil, false
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, false
+	}
+	return &entry, true
+}
+
+func (c *diskCache) store(url string, entry cacheEntry) error {
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory %s: %w", c.dir, err)
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal cache entry: %w", err)
+	}
+	if err := os.WriteFile(c.path(url), data, 0644); err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	return nil
+}
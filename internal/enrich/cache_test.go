@@ -0,0 +1,28 @@
+package enrich
+
+import "testing"
+
+func TestDiskCache_StoreThenLoadRoundTrips(t *testing.T) {
+	cache := newDiskCache(t.TempDir())
+
+	entry := cacheEntry{Body: []byte("hello"), ETag: `"abc"`, LastModified: "Mon, 01 Jan 2024 00:00:00 GMT"}
+	if err := cache.store("https://example.com/feed", entry); err != nil {
+		t.Fatalf("store returned error: %v", err)
+	}
+
+	got, ok := cache.load("https://example.com/feed")
+	if !ok {
+		t.Fatal("expected a cache hit after store")
+	}
+	if string(got.Body) != "hello" || got.ETag != entry.ETag || got.LastModified != entry.LastModified {
+		t.Errorf("got %+v, want %+v", got, entry)
+	}
+}
+
+func TestDiskCache_MissOnUnknownURL(t *testing.T) {
+	cache := newDiskCache(t.TempDir())
+
+	if _, ok := cache.load("https://example.com/never-stored"); ok {
+		t.Error("expected a miss for a URL never stored")
+	}
+}
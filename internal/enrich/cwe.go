@@ -0,0 +1,103 @@
+package enrich
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"strings"
+
+	"github.com/mark-chris/tmkb/internal/knowledge"
+)
+
+// defaultCWEBaseURL is the MITRE CWE XML catalog export CWESource fetches
+// when no override is configured.
+const defaultCWEBaseURL = "https://cwe.mitre.org/data/xml/cwec_latest.xml"
+
+// CWESource resolves a CWE identifier's canonical name and related
+// weaknesses from MITRE's CWE XML catalog export (the full catalog, not
+// a per-ID endpoint - see BaseURL), fetched and parsed once per CWESource
+// instance and memoized for every subsequent Fetch call.
+type CWESource struct {
+	// BaseURL is the CWE XML catalog to fetch; overridable so tests and
+	// offline CI runs can point at a local fixture instead.
+	BaseURL string
+	fetch   *fetcher
+
+	weaknesses map[string]cweWeakness
+}
+
+// NewCWESource creates a CWESource backed by fetch for caching, rate
+// limiting, and offline replay (see newFetcher).
+func NewCWESource(baseURL string, fetch *fetcher) *CWESource {
+	return &CWESource{BaseURL: baseURL, fetch: fetch}
+}
+
+// cweCatalog is the subset of MITRE's CWE XML schema this package reads:
+// each weakness's ID, name, and ChildOf/ParentOf relationships.
+type cweCatalog struct {
+	Weaknesses struct {
+		Weakness []struct {
+			ID                string `xml:"ID,attr"`
+			Name              string `xml:"Name,attr"`
+			RelatedWeaknesses struct {
+				Related []struct {
+					CweID string `xml:"CWE_ID,attr"`
+				} `xml:"Related_Weakness"`
+			} `xml:"Related_Weaknesses"`
+		} `xml:"Weakness"`
+	} `xml:"Weaknesses"`
+}
+
+type cweWeakness struct {
+	Name    string
+	Related []string
+}
+
+// load fetches and parses the CWE catalog once, memoizing the result for
+// every subsequent Fetch call on this source.
+func (s *CWESource) load(ctx context.Context) error {
+	if s.weaknesses != nil {
+		return nil
+	}
+
+	body, err := s.fetch.get(ctx, s.BaseURL)
+	if err != nil {
+		return fmt.Errorf("failed to fetch CWE catalog: %w", err)
+	}
+
+	var catalog cweCatalog
+	if err := xml.Unmarshal(body, &catalog); err != nil {
+		return fmt.Errorf("failed to parse CWE catalog: %w", err)
+	}
+
+	weaknesses := make(map[string]cweWeakness, len(catalog.Weaknesses.Weakness))
+	for _, w := range catalog.Weaknesses.Weakness {
+		related := make([]string, 0, len(w.RelatedWeaknesses.Related))
+		for _, r := range w.RelatedWeaknesses.Related {
+			related = append(related, "CWE-"+r.CweID)
+		}
+		weaknesses["CWE-"+w.ID] = cweWeakness{Name: w.Name, Related: related}
+	}
+	s.weaknesses = weaknesses
+	return nil
+}
+
+// Fetch resolves cweID (e.g. "CWE-862", already normalized by
+// ParseCWE/ThreatPattern.CWEs) into an ExternalRef.
+func (s *CWESource) Fetch(ctx context.Context, cweID string) (*knowledge.ExternalRef, error) {
+	if err := s.load(ctx); err != nil {
+		return nil, err
+	}
+
+	w, ok := s.weaknesses[strings.ToUpper(cweID)]
+	if !ok {
+		return nil, fmt.Errorf("no CWE catalog entry for %s", cweID)
+	}
+
+	return &knowledge.ExternalRef{
+		ID:          cweID,
+		Title:       w.Name,
+		URL:         fmt.Sprintf("https://cwe.mitre.org/data/definitions/%s.html", strings.TrimPrefix(cweID, "CWE-")),
+		RelatedCWEs: w.Related,
+	}, nil
+}
@@ -0,0 +1,244 @@
+// Package enrich attaches canonical CVE/CWE/OWASP metadata to threat
+// patterns: it resolves each pattern's CWEReferences/OWASPReferences/CVE
+// mentions against their authoritative sources and writes the result as a
+// patterns/<id>.enriched.yaml sidecar (or, with Options.InPlace, merges it
+// into the pattern's own YAML), which knowledge.Loader.mergeSidecar reads
+// back in at load time. See the `tmkb enrich` command.
+package enrich
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/mark-chris/tmkb/internal/knowledge"
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v3"
+)
+
+// sidecarSuffix must match knowledge's enrichedSidecarSuffix - duplicated
+// here since that constant is unexported across the package boundary.
+const sidecarSuffix = ".enriched.yaml"
+
+// Options configures an Enrich run: which sources to query, where to
+// cache responses, how hard to rate-limit, and whether to write sidecar
+// files or rewrite patterns in place.
+type Options struct {
+	// FS is the filesystem sidecars/in-place rewrites are written to.
+	// Defaults to the real OS filesystem when nil.
+	FS afero.Fs
+
+	// CacheDir persists fetched responses (ETag/Last-Modified aware) so a
+	// repeat run only re-fetches what changed. Always a real OS path,
+	// even when FS isn't, since the cache is an implementation detail
+	// rather than part of the knowledge base.
+	CacheDir string
+	// CacheClient is the HTTP client used for live fetches. Defaults to
+	// http.DefaultClient when nil.
+	CacheClient *http.Client
+
+	// NVDBaseURL and CWEBaseURL override the default public feed
+	// endpoints (see defaultNVDBaseURL/defaultCWEBaseURL), for tests and
+	// mirrors.
+	NVDBaseURL string
+	CWEBaseURL string
+
+	// RateLimit is the minimum interval between outbound requests; 0
+	// disables throttling.
+	RateLimit time.Duration
+	// Offline replays CacheDir without ever touching the network; a cache
+	// miss is an error rather than a fetch, for reproducible CI runs
+	// against a pre-downloaded feed dump.
+	Offline bool
+
+	// InPlace rewrites each pattern's own YAML file instead of writing a
+	// patterns/<id>.enriched.yaml sidecar.
+	InPlace bool
+}
+
+// Result reports what Enrich resolved for a single pattern. A reference
+// that failed to resolve doesn't fail the whole pattern: its error is
+// recorded here and every reference that did resolve is still written.
+type Result struct {
+	PatternID    string
+	ExternalRefs []knowledge.ExternalRef
+	Errors       []error
+}
+
+// cveIDFormat finds CVE identifiers embedded in free text. TMKB's
+// PublicReference schema has no dedicated CVE field, so a CVE is recorded
+// as text in a reference's Name or URL (e.g. a URL linking directly to
+// NVD's page for it).
+var cveIDFormat = regexp.MustCompile(`CVE-\d{4}-\d{4,}`)
+
+// Enrich resolves CWE, OWASP, and CVE references for each of patterns and
+// writes the result under basePath (the patterns directory), returning one
+// Result per pattern in the same order. A write failure aborts the run
+// entirely (the caller's sidecars/in-place rewrites would otherwise be
+// silently partial); a single reference failing to resolve does not.
+func Enrich(ctx context.Context, patterns []knowledge.ThreatPattern, basePath string, opts Options) ([]Result, error) {
+	fs := opts.FS
+	if fs == nil {
+		fs = afero.NewOsFs()
+	}
+
+	fetch := newFetcher(opts.CacheClient, newDiskCache(opts.CacheDir), newRateLimiter(opts.RateLimit), opts.Offline)
+	nvd := NewNVDSource(orDefault(opts.NVDBaseURL, defaultNVDBaseURL), fetch)
+	cwe := NewCWESource(orDefault(opts.CWEBaseURL, defaultCWEBaseURL), fetch)
+	var owasp OWASPSource
+
+	results := make([]Result, 0, len(patterns))
+	for _, p := range patterns {
+		result := Result{PatternID: p.ID}
+
+		for _, id := range p.CWEs() {
+			if ref, err := cwe.Fetch(ctx, id); err != nil {
+				result.Errors = append(result.Errors, err)
+			} else {
+				result.ExternalRefs = append(result.ExternalRefs, *ref)
+			}
+		}
+
+		for _, id := range p.OWASPs() {
+			if ref, err := owasp.Fetch(ctx, id); err != nil {
+				result.Errors = append(result.Errors, err)
+			} else {
+				result.ExternalRefs = append(result.ExternalRefs, *ref)
+			}
+		}
+
+		for _, id := range extractCVEIDs(p) {
+			if ref, err := nvd.Fetch(ctx, id); err != nil {
+				result.Errors = append(result.Errors, err)
+			} else {
+				result.ExternalRefs = append(result.ExternalRefs, *ref)
+			}
+		}
+
+		if len(result.ExternalRefs) > 0 {
+			if opts.InPlace {
+				if err := writeInPlace(fs, p, result.ExternalRefs); err != nil {
+					return results, fmt.Errorf("failed to enrich %s: %w", p.ID, err)
+				}
+			} else if err := writeSidecar(fs, basePath, p.ID, result.ExternalRefs); err != nil {
+				return results, fmt.Errorf("failed to enrich %s: %w", p.ID, err)
+			}
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// extractCVEIDs returns the distinct CVE identifiers mentioned in p's
+// public references, in first-seen order.
+func extractCVEIDs(p knowledge.ThreatPattern) []string {
+	seen := make(map[string]bool)
+	var ids []string
+	for _, ref := range p.Provenance.PublicReferences {
+		for _, field := range [...]string{ref.Name, ref.URL} {
+			for _, id := range cveIDFormat.FindAllString(field, -1) {
+				if !seen[id] {
+					seen[id] = true
+					ids = append(ids, id)
+				}
+			}
+		}
+	}
+	return ids
+}
+
+// orDefault returns v, or def when v is empty.
+func orDefault(v, def string) string {
+	if v == "" {
+		return def
+	}
+	return v
+}
+
+// sidecarDoc is the top-level shape of a <id>.enriched.yaml sidecar,
+// mirroring knowledge's unexported enrichedSidecar.
+type sidecarDoc struct {
+	ExternalRefs []knowledge.ExternalRef `yaml:"external_refs"`
+}
+
+// writeSidecar writes refs as patterns/<id>.enriched.yaml, the default
+// (non---in-place) enrichment output.
+func writeSidecar(fs afero.Fs, basePath, id string, refs []knowledge.ExternalRef) error {
+	data, err := yaml.Marshal(sidecarDoc{ExternalRefs: refs})
+	if err != nil {
+		return fmt.Errorf("failed to marshal sidecar: %w", err)
+	}
+	path := filepath.Join(basePath, id+sidecarSuffix)
+	if err := afero.WriteFile(fs, path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// writeInPlace merges refs into p's own source YAML file as its
+// threat_pattern.external_refs key, editing the parsed yaml.Node tree
+// rather than round-tripping the whole document through ThreatPattern, so
+// an author's field ordering and comments elsewhere in the file survive.
+func writeInPlace(fs afero.Fs, p knowledge.ThreatPattern, refs []knowledge.ExternalRef) error {
+	data, err := afero.ReadFile(fs, p.SourceFile)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", p.SourceFile, err)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", p.SourceFile, err)
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return fmt.Errorf("%s: expected a mapping document", p.SourceFile)
+	}
+	root := doc.Content[0]
+
+	var patternNode *yaml.Node
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "threat_pattern" {
+			patternNode = root.Content[i+1]
+			break
+		}
+	}
+	if patternNode == nil || patternNode.Kind != yaml.MappingNode {
+		return fmt.Errorf("%s: missing threat_pattern mapping", p.SourceFile)
+	}
+
+	var refsNode yaml.Node
+	if err := refsNode.Encode(refs); err != nil {
+		return fmt.Errorf("failed to encode external refs: %w", err)
+	}
+
+	for i := 0; i+1 < len(patternNode.Content); i += 2 {
+		if patternNode.Content[i].Value == "external_refs" {
+			patternNode.Content[i+1] = &refsNode
+			data, err := yaml.Marshal(&doc)
+			if err != nil {
+				return fmt.Errorf("failed to marshal %s: %w", p.SourceFile, err)
+			}
+			return writeFile(fs, p.SourceFile, data)
+		}
+	}
+
+	patternNode.Content = append(patternNode.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Value: "external_refs"}, &refsNode)
+
+	out, err := yaml.Marshal(&doc)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", p.SourceFile, err)
+	}
+	return writeFile(fs, p.SourceFile, out)
+}
+
+func writeFile(fs afero.Fs, path string, data []byte) error {
+	if err := afero.WriteFile(fs, path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
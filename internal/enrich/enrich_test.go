@@ -0,0 +1,180 @@
+package enrich
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mark-chris/tmkb/internal/knowledge"
+	"github.com/spf13/afero"
+)
+
+func testPattern(id string, refs []knowledge.PublicReference) knowledge.ThreatPattern {
+	return knowledge.ThreatPattern{
+		ID:         id,
+		SourceFile: "/patterns/" + id + ".yaml",
+		Provenance: knowledge.Provenance{PublicReferences: refs},
+	}
+}
+
+const nvdFixture = `{
+  "vulnerabilities": [
+    {
+      "cve": {
+        "id": "CVE-2021-44228",
+        "published": "2021-12-10T10:15Z",
+        "descriptions": [{"lang": "en", "value": "Log4Shell remote code execution"}],
+        "metrics": {
+          "cvssMetricV31": [{"cvssData": {"baseScore": 10.0}}]
+        }
+      }
+    }
+  ]
+}`
+
+const cweFixture = `<?xml version="1.0"?>
+<Weakness_Catalog>
+  <Weaknesses>
+    <Weakness ID="862" Name="Missing Authorization">
+      <Related_Weaknesses>
+        <Related_Weakness CWE_ID="285"/>
+      </Related_Weaknesses>
+    </Weakness>
+  </Weaknesses>
+</Weakness_Catalog>`
+
+func TestEnrich_ResolvesCWEOWASPAndCVE(t *testing.T) {
+	nvdServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(nvdFixture))
+	}))
+	defer nvdServer.Close()
+
+	cweServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(cweFixture))
+	}))
+	defer cweServer.Close()
+
+	p := testPattern("TMKB-ENRICH-001", []knowledge.PublicReference{
+		{CWE: "CWE-862", OWASP: "A01:2021", URL: "https://nvd.nist.gov/vuln/detail/CVE-2021-44228"},
+	})
+
+	fs := afero.NewMemMapFs()
+	results, err := Enrich(context.Background(), []knowledge.ThreatPattern{p}, "/patterns", Options{
+		FS:         fs,
+		CacheDir:   t.TempDir(),
+		NVDBaseURL: nvdServer.URL,
+		CWEBaseURL: cweServer.URL,
+	})
+	if err != nil {
+		t.Fatalf("Enrich returned error: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(results))
+	}
+	result := results[0]
+	if len(result.Errors) != 0 {
+		t.Fatalf("expected no resolution errors, got %v", result.Errors)
+	}
+
+	byID := make(map[string]knowledge.ExternalRef)
+	for _, ref := range result.ExternalRefs {
+		byID[ref.ID] = ref
+	}
+	if byID["CWE-862"].Title != "Missing Authorization" {
+		t.Errorf("expected CWE-862 title resolved, got %+v", byID["CWE-862"])
+	}
+	if byID["A01:2021"].Title != "Broken Access Control" {
+		t.Errorf("expected A01:2021 title resolved, got %+v", byID["A01:2021"])
+	}
+	if byID["CVE-2021-44228"].CVSS != 10.0 {
+		t.Errorf("expected CVE-2021-44228 CVSS 10.0, got %+v", byID["CVE-2021-44228"])
+	}
+
+	exists, err := afero.Exists(fs, "/patterns/TMKB-ENRICH-001.enriched.yaml")
+	if err != nil || !exists {
+		t.Errorf("expected sidecar written to fs, exists=%v err=%v", exists, err)
+	}
+}
+
+func TestEnrich_UnresolvedReferenceDoesNotFailPattern(t *testing.T) {
+	cweServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(cweFixture))
+	}))
+	defer cweServer.Close()
+
+	p := testPattern("TMKB-ENRICH-002", []knowledge.PublicReference{{CWE: "CWE-9999"}})
+
+	fs := afero.NewMemMapFs()
+	results, err := Enrich(context.Background(), []knowledge.ThreatPattern{p}, "/patterns", Options{
+		FS:         fs,
+		CacheDir:   t.TempDir(),
+		CWEBaseURL: cweServer.URL,
+	})
+	if err != nil {
+		t.Fatalf("Enrich returned error: %v", err)
+	}
+	if len(results[0].Errors) != 1 {
+		t.Fatalf("expected one unresolved-reference error, got %v", results[0].Errors)
+	}
+	if len(results[0].ExternalRefs) != 0 {
+		t.Errorf("expected no ExternalRefs for an unresolvable CWE, got %v", results[0].ExternalRefs)
+	}
+}
+
+func TestEnrich_InPlaceWritesIntoPatternYAML(t *testing.T) {
+	cweServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(cweFixture))
+	}))
+	defer cweServer.Close()
+
+	fs := afero.NewMemMapFs()
+	source := `threat_pattern:
+  id: TMKB-ENRICH-003
+  name: In Place Pattern
+`
+	if err := afero.WriteFile(fs, "/patterns/TMKB-ENRICH-003.yaml", []byte(source), 0644); err != nil {
+		t.Fatalf("failed to seed pattern file: %v", err)
+	}
+
+	p := testPattern("TMKB-ENRICH-003", []knowledge.PublicReference{{CWE: "CWE-862"}})
+
+	_, err := Enrich(context.Background(), []knowledge.ThreatPattern{p}, "/patterns", Options{
+		FS:         fs,
+		CacheDir:   t.TempDir(),
+		CWEBaseURL: cweServer.URL,
+		InPlace:    true,
+	})
+	if err != nil {
+		t.Fatalf("Enrich returned error: %v", err)
+	}
+
+	data, err := afero.ReadFile(fs, "/patterns/TMKB-ENRICH-003.yaml")
+	if err != nil {
+		t.Fatalf("failed to read rewritten pattern: %v", err)
+	}
+	if !strings.Contains(string(data), "external_refs") || !strings.Contains(string(data), "Missing Authorization") {
+		t.Errorf("expected in-place rewrite to add external_refs, got:\n%s", data)
+	}
+
+	exists, _ := afero.Exists(fs, "/patterns/TMKB-ENRICH-003.enriched.yaml")
+	if exists {
+		t.Error("expected no sidecar written in --in-place mode")
+	}
+}
+
+func TestOffline_FailsOnCacheMiss(t *testing.T) {
+	p := testPattern("TMKB-ENRICH-004", []knowledge.PublicReference{{CWE: "CWE-862"}})
+
+	_, err := Enrich(context.Background(), []knowledge.ThreatPattern{p}, "/patterns", Options{
+		FS:       afero.NewMemMapFs(),
+		CacheDir: t.TempDir(),
+		Offline:  true,
+	})
+	// An offline cache miss surfaces as a per-reference resolution error,
+	// not a failed Enrich call - the pattern simply ends up unenriched.
+	if err != nil {
+		t.Fatalf("Enrich returned error: %v, want nil (miss recorded per-reference)", err)
+	}
+}
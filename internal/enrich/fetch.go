@@ -0,0 +1,89 @@
+package enrich
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// fetcher performs a conditional, rate-limited, disk-cached GET: a fresh
+// cache entry's ETag/Last-Modified is sent back as If-None-Match/
+// If-Modified-Since, and a 304 (or any fetch error with a cached fallback
+// available) reuses the cached body instead of failing the run. With
+// offline set, fetcher never touches the network at all - a cache miss is
+// an error rather than a fetch, the mode `tmkb enrich --offline` uses
+// against a pre-downloaded feed dump so CI gets reproducible results.
+type fetcher struct {
+	client  *http.Client
+	cache   *diskCache
+	limiter *rateLimiter
+	offline bool
+}
+
+func newFetcher(client *http.Client, cache *diskCache, limiter *rateLimiter, offline bool) *fetcher {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &fetcher{client: client, cache: cache, limiter: limiter, offline: offline}
+}
+
+func (f *fetcher) get(ctx context.Context, url string) ([]byte, error) {
+	cached, hasCached := f.cache.load(url)
+
+	if f.offline {
+		if !hasCached {
+			return nil, fmt.Errorf("offline mode: no cached response for %s", url)
+		}
+		return cached.Body, nil
+	}
+
+	if err := f.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request for %s: %w", url, err)
+	}
+	if hasCached {
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		if hasCached {
+			return cached.Body, nil
+		}
+		return nil, fmt.Errorf("failed to fetch %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && hasCached {
+		return cached.Body, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		if hasCached {
+			return cached.Body, nil
+		}
+		return nil, fmt.Errorf("fetch %s: unexpected status %s", url, resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body for %s: %w", url, err)
+	}
+
+	_ = f.cache.store(url, cacheEntry{
+		Body:         body,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	})
+
+	return body, nil
+}
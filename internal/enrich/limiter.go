@@ -0,0 +1,52 @@
+package enrich
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// rateLimiter enforces a minimum interval between successive Wait calls -
+// a single-slot token bucket, enough to keep `tmkb enrich` from bursting
+// requests at NVD/MITRE across a large pattern corpus. A nil *rateLimiter
+// or a non-positive interval never throttles.
+type rateLimiter struct {
+	interval time.Duration
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+func newRateLimiter(interval time.Duration) *rateLimiter {
+	return &rateLimiter{interval: interval}
+}
+
+// Wait blocks until the next request is allowed to proceed, or ctx is
+// cancelled first.
+func (r *rateLimiter) Wait(ctx context.Context) error {
+	if r == nil || r.interval <= 0 {
+		return nil
+	}
+
+	r.mu.Lock()
+	now := time.Now()
+	wait := r.next.Sub(now)
+	if wait < 0 {
+		wait = 0
+	}
+	r.next = now.Add(wait + r.interval)
+	r.mu.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
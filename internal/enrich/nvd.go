@@ -0,0 +1,100 @@
+package enrich
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/mark-chris/tmkb/internal/knowledge"
+)
+
+// defaultNVDBaseURL is the NIST NVD CVE API 2.0 endpoint NVDSource queries
+// when no override is configured.
+const defaultNVDBaseURL = "https://services.nvd.nist.gov/rest/json/cves/2.0"
+
+// NVDSource resolves a CVE identifier against the NVD CVE API (or a
+// compatible mirror/pre-downloaded dump set via BaseURL), extracting just
+// the fields TMKB surfaces: title, CVSS base score, and publish date.
+type NVDSource struct {
+	// BaseURL is the NVD CVE API endpoint to query; overridable so tests
+	// and offline CI runs can point at a local fixture instead.
+	BaseURL string
+	fetch   *fetcher
+}
+
+// NewNVDSource creates an NVDSource backed by fetch for caching, rate
+// limiting, and offline replay (see newFetcher).
+func NewNVDSource(baseURL string, fetch *fetcher) *NVDSource {
+	return &NVDSource{BaseURL: baseURL, fetch: fetch}
+}
+
+// nvdResponse is the subset of the NVD CVE API 2.0 response shape this
+// package reads; the real response carries many more fields TMKB doesn't
+// need.
+type nvdResponse struct {
+	Vulnerabilities []struct {
+		CVE struct {
+			ID           string `json:"id"`
+			Published    string `json:"published"`
+			Descriptions []struct {
+				Lang  string `json:"lang"`
+				Value string `json:"value"`
+			} `json:"descriptions"`
+			Metrics struct {
+				CvssMetricV31 []struct {
+					CvssData struct {
+						BaseScore float64 `json:"baseScore"`
+					} `json:"cvssData"`
+				} `json:"cvssMetricV31"`
+				CvssMetricV30 []struct {
+					CvssData struct {
+						BaseScore float64 `json:"baseScore"`
+					} `json:"cvssData"`
+				} `json:"cvssMetricV30"`
+			} `json:"metrics"`
+		} `json:"cve"`
+	} `json:"vulnerabilities"`
+}
+
+// Fetch resolves cveID (e.g. "CVE-2021-44228") into an ExternalRef,
+// preferring a CVSS v3.1 score over v3.0 when both are present.
+func (s *NVDSource) Fetch(ctx context.Context, cveID string) (*knowledge.ExternalRef, error) {
+	url := fmt.Sprintf("%s?cveId=%s", s.BaseURL, cveID)
+	body, err := s.fetch.get(ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", cveID, err)
+	}
+
+	var parsed nvdResponse
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse NVD response for %s: %w", cveID, err)
+	}
+	if len(parsed.Vulnerabilities) == 0 {
+		return nil, fmt.Errorf("no NVD record found for %s", cveID)
+	}
+	cve := parsed.Vulnerabilities[0].CVE
+
+	title := cveID
+	for _, d := range cve.Descriptions {
+		if d.Lang == "en" {
+			title = d.Value
+			break
+		}
+	}
+
+	var score float64
+	switch {
+	case len(cve.Metrics.CvssMetricV31) > 0:
+		score = cve.Metrics.CvssMetricV31[0].CvssData.BaseScore
+	case len(cve.Metrics.CvssMetricV30) > 0:
+		score = cve.Metrics.CvssMetricV30[0].CvssData.BaseScore
+	}
+
+	return &knowledge.ExternalRef{
+		ID:            cveID,
+		Title:         title,
+		URL:           fmt.Sprintf("https://nvd.nist.gov/vuln/detail/%s", cveID),
+		CVSS:          score,
+		PublishedDate: cve.Published,
+	}, nil
+}
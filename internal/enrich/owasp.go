@@ -0,0 +1,48 @@
+package enrich
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark-chris/tmkb/internal/knowledge"
+)
+
+// owaspTop10Titles maps OWASP Top 10 2021 identifiers to their official
+// category names. Unlike CVE/CWE, the Top 10 is a small, infrequently
+// revised list rather than a feed worth fetching over the network, so
+// OWASPSource resolves it from this table instead of an HTTP source.
+var owaspTop10Titles = map[string]string{
+	"A01:2021": "Broken Access Control",
+	"A02:2021": "Cryptographic Failures",
+	"A03:2021": "Injection",
+	"A04:2021": "Insecure Design",
+	"A05:2021": "Security Misconfiguration",
+	"A06:2021": "Vulnerable and Outdated Components",
+	"A07:2021": "Identification and Authentication Failures",
+	"A08:2021": "Software and Data Integrity Failures",
+	"A09:2021": "Security Logging and Monitoring Failures",
+	"A10:2021": "Server-Side Request Forgery (SSRF)",
+}
+
+// owaspTop10URL is the OWASP Top 10 project's landing page; individual
+// category pages aren't linked directly since their URL slugs vary by
+// edition.
+const owaspTop10URL = "https://owasp.org/Top10/"
+
+// OWASPSource resolves an OWASP Top 10 identifier into its official
+// category title.
+type OWASPSource struct{}
+
+// Fetch resolves owaspID (e.g. "A01:2021", already normalized by
+// ParseOWASP/ThreatPattern.OWASPs) into an ExternalRef.
+func (OWASPSource) Fetch(_ context.Context, owaspID string) (*knowledge.ExternalRef, error) {
+	title, ok := owaspTop10Titles[owaspID]
+	if !ok {
+		return nil, fmt.Errorf("unrecognized OWASP Top 10 identifier: %s", owaspID)
+	}
+	return &knowledge.ExternalRef{
+		ID:    owaspID,
+		Title: title,
+		URL:   owaspTop10URL,
+	}, nil
+}
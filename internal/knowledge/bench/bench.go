@@ -0,0 +1,201 @@
+// Package bench drives the query pipeline (Loader.LoadAll, Index.Build,
+// and a scripted workload of Query calls) against a labeled corpus of
+// YAML fixtures, the same way parser benchmarks in log-analysis tools
+// drive a parser against labeled event fixtures. It reports latency
+// percentiles alongside ranking-quality scores so both speed and
+// relevance regressions show up in CI.
+package bench
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"time"
+
+	"github.com/mark-chris/tmkb/internal/knowledge"
+	"gopkg.in/yaml.v3"
+)
+
+// Case is a single scripted query and the pattern IDs it should surface,
+// in the order `tmkb query` is expected to rank them.
+type Case struct {
+	Name        string   `yaml:"name"`
+	Context     string   `yaml:"context"`
+	Language    string   `yaml:"language"`
+	Framework   string   `yaml:"framework"`
+	ExpectedIDs []string `yaml:"expected_ids"`
+}
+
+type fixtureFile struct {
+	Cases []Case `yaml:"cases"`
+}
+
+// LoadFixtures reads every YAML file matching glob and concatenates their
+// cases into a single workload.
+func LoadFixtures(glob string) ([]Case, error) {
+	paths, err := filepath.Glob(glob)
+	if err != nil {
+		return nil, fmt.Errorf("failed to expand fixture glob %q: %w", glob, err)
+	}
+
+	var cases []Case
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read fixture %s: %w", path, err)
+		}
+		var ff fixtureFile
+		if err := yaml.Unmarshal(data, &ff); err != nil {
+			return nil, fmt.Errorf("failed to parse fixture %s: %w", path, err)
+		}
+		cases = append(cases, ff.Cases...)
+	}
+	return cases, nil
+}
+
+// CaseResult captures the outcome of running a single Case.
+type CaseResult struct {
+	Name      string
+	Latency   time.Duration
+	Precision float64
+	Recall    float64
+}
+
+// Report summarizes a benchmark run across all cases.
+type Report struct {
+	IndexBuildTime time.Duration
+	PatternCount   int
+	MemAllocBytes  uint64
+	Cases          []CaseResult
+	P50            time.Duration
+	P95            time.Duration
+	P99            time.Duration
+	MeanPrecision  float64
+	MeanRecall     float64
+}
+
+// BuildIndex times Loader.LoadAll + Index.Build, the setup cost every
+// query workload pays once.
+func BuildIndex(l *knowledge.Loader) (*knowledge.Index, time.Duration, error) {
+	start := time.Now()
+	patterns, err := l.LoadAll()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to load patterns: %w", err)
+	}
+	idx := knowledge.NewIndex()
+	idx.Build(patterns)
+	return idx, time.Since(start), nil
+}
+
+// Run executes every case against idx and aggregates latency and
+// ranking-quality metrics into a Report.
+func Run(idx *knowledge.Index, cases []Case, buildTime time.Duration) (*Report, error) {
+	report := &Report{
+		IndexBuildTime: buildTime,
+		PatternCount:   idx.Count(),
+		Cases:          make([]CaseResult, 0, len(cases)),
+	}
+
+	var memBefore runtime.MemStats
+	runtime.ReadMemStats(&memBefore)
+
+	var latencies []time.Duration
+	var precisionSum, recallSum float64
+
+	for _, c := range cases {
+		opts := knowledge.QueryOptions{
+			Context:   c.Context,
+			Language:  c.Language,
+			Framework: c.Framework,
+			Limit:     len(c.ExpectedIDs),
+			Verbosity: "human",
+		}
+
+		start := time.Now()
+		result, err := knowledge.Query(idx, opts)
+		elapsed := time.Since(start)
+		if err != nil {
+			return nil, fmt.Errorf("case %q: %w", c.Name, err)
+		}
+
+		got := make([]string, 0, len(result.VerbosePatterns))
+		for _, p := range result.VerbosePatterns {
+			got = append(got, p.ID)
+		}
+
+		precision, recall := precisionRecall(got, c.ExpectedIDs)
+		latencies = append(latencies, elapsed)
+		precisionSum += precision
+		recallSum += recall
+
+		report.Cases = append(report.Cases, CaseResult{
+			Name:      c.Name,
+			Latency:   elapsed,
+			Precision: precision,
+			Recall:    recall,
+		})
+	}
+
+	var memAfter runtime.MemStats
+	runtime.ReadMemStats(&memAfter)
+	if memAfter.TotalAlloc >= memBefore.TotalAlloc {
+		report.MemAllocBytes = memAfter.TotalAlloc - memBefore.TotalAlloc
+	}
+
+	if n := len(cases); n > 0 {
+		report.MeanPrecision = precisionSum / float64(n)
+		report.MeanRecall = recallSum / float64(n)
+	}
+	report.P50 = percentile(latencies, 0.50)
+	report.P95 = percentile(latencies, 0.95)
+	report.P99 = percentile(latencies, 0.99)
+
+	return report, nil
+}
+
+// precisionRecall scores got (the ranked IDs a query actually returned)
+// against expected (the labeled ground truth), both precision@k and
+// recall@k where k = len(got).
+func precisionRecall(got, expected []string) (precision, recall float64) {
+	if len(expected) == 0 {
+		return 1, 1
+	}
+	expectedSet := make(map[string]bool, len(expected))
+	for _, id := range expected {
+		expectedSet[id] = true
+	}
+
+	hits := 0
+	for _, id := range got {
+		if expectedSet[id] {
+			hits++
+		}
+	}
+
+	if len(got) > 0 {
+		precision = float64(hits) / float64(len(got))
+	}
+	recall = float64(hits) / float64(len(expected))
+	return precision, recall
+}
+
+// percentile returns the p-th percentile (0 < p <= 1) of latencies using
+// nearest-rank interpolation.
+func percentile(latencies []time.Duration, p float64) time.Duration {
+	if len(latencies) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration(nil), latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	idx := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	if idx >= len(sorted) {
+		idx = len(sorted) - 1
+	}
+	return sorted[idx]
+}
@@ -0,0 +1,77 @@
+package bench
+
+import (
+	"testing"
+
+	"github.com/mark-chris/tmkb/internal/cli/testutil"
+	"github.com/mark-chris/tmkb/internal/knowledge"
+)
+
+func TestPrecisionRecall(t *testing.T) {
+	tests := []struct {
+		name          string
+		got, expected []string
+		wantPrecision float64
+		wantRecall    float64
+	}{
+		{"perfect match", []string{"A", "B"}, []string{"A", "B"}, 1.0, 1.0},
+		{"partial match", []string{"A", "C"}, []string{"A", "B"}, 0.5, 0.5},
+		{"no match", []string{"C"}, []string{"A", "B"}, 0.0, 0.0},
+		{"empty expected", []string{"A"}, nil, 1.0, 1.0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, r := precisionRecall(tt.got, tt.expected)
+			if p != tt.wantPrecision || r != tt.wantRecall {
+				t.Errorf("precisionRecall(%v, %v) = (%v, %v), want (%v, %v)",
+					tt.got, tt.expected, p, r, tt.wantPrecision, tt.wantRecall)
+			}
+		})
+	}
+}
+
+func TestRun_ReportsLatencyAndQuality(t *testing.T) {
+	fixture := testutil.SetupTestPatterns(t)
+	defer fixture.Cleanup()
+
+	idx := knowledge.NewIndex()
+	idx.Build(fixture.Patterns)
+
+	cases := []Case{
+		{Name: "background job", Context: "background job authorization", ExpectedIDs: []string{"TMKB-TEST-001"}},
+	}
+
+	report, err := Run(idx, cases, 0)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if len(report.Cases) != 1 {
+		t.Fatalf("expected 1 case result, got %d", len(report.Cases))
+	}
+	if report.MeanPrecision != 1.0 || report.MeanRecall != 1.0 {
+		t.Errorf("expected perfect precision/recall, got precision=%v recall=%v",
+			report.MeanPrecision, report.MeanRecall)
+	}
+}
+
+// BenchmarkRun_WarmIndex measures the query workload cost with a
+// pre-built index, the steady-state case CI regression-tests against.
+func BenchmarkRun_WarmIndex(b *testing.B) {
+	fixture := testutil.SetupTestPatterns(&testing.T{})
+	defer fixture.Cleanup()
+
+	idx := knowledge.NewIndex()
+	idx.Build(fixture.Patterns)
+
+	cases := []Case{
+		{Name: "background job", Context: "background job authorization", ExpectedIDs: []string{"TMKB-TEST-001"}},
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := Run(idx, cases, 0); err != nil {
+			b.Fatalf("Run failed: %v", err)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package knowledge
+
+import "testing"
+
+// BenchmarkQuery_WarmIndex measures Query's per-call cost against an
+// already-built in-memory index, independent of the cli-layer process
+// startup BenchmarkQuery_WarmIndex in internal/cli covers.
+func BenchmarkQuery_WarmIndex(b *testing.B) {
+	idx := createTestIndex()
+	opts := QueryOptions{Context: "background job authorization", Verbosity: "agent"}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := Query(idx, opts); err != nil {
+			b.Fatalf("Query failed: %v", err)
+		}
+	}
+}
+
+// BenchmarkIndex_Build measures the one-time cost of building an Index
+// from a loaded pattern set.
+func BenchmarkIndex_Build(b *testing.B) {
+	patterns := createTestIndex().GetAll()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		idx := NewIndex()
+		idx.Build(patterns)
+	}
+}
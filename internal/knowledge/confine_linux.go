@@ -0,0 +1,84 @@
+package knowledge
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/afero"
+	"golang.org/x/sys/unix"
+)
+
+// readFileConfined reads path for an on-disk loader by resolving it
+// relative to l.basePath via openat2(RESOLVE_BENEATH), so the kernel - not
+// a string comparison - refuses any resolution that would leave the base
+// directory, including a symlink swapped into place between validatePath's
+// check and this read (a TOCTOU window a lexical check alone can't close).
+// Non-OS filesystems (MemMapFs in tests, or any other afero.Fs) have no
+// real file descriptors to confine, so they fall back to a plain
+// afero.ReadFile; validatePath's lexical check is their only protection.
+func (l *Loader) readFileConfined(path string) ([]byte, error) {
+	if _, ok := l.fs.(*afero.OsFs); !ok {
+		return afero.ReadFile(l.fs, path)
+	}
+
+	rel, err := filepath.Rel(l.basePath, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute relative path: %w", err)
+	}
+	if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil, fmt.Errorf("path traversal detected: %s is outside base path %s", path, l.basePath)
+	}
+
+	f, err := openBeneath(l.basePath, rel)
+	if err != nil {
+		if errors.Is(err, unix.ENOSYS) || errors.Is(err, unix.EPERM) {
+			// openat2 itself is unavailable - an older kernel (pre-5.6)
+			// or a seccomp/gVisor profile blocking the syscall - rather
+			// than a rejected resolution. Fall back to the lexical
+			// validatePath check non-Linux platforms already rely on
+			// instead of failing every load on such a host.
+			return afero.ReadFile(l.fs, path)
+		}
+		return nil, fmt.Errorf("path escapes base directory: %w", err)
+	}
+	defer f.Close()
+
+	return io.ReadAll(f)
+}
+
+// openBeneath opens rel - a path relative to base - with RESOLVE_BENEATH|
+// RESOLVE_NO_MAGICLINKS, so the open fails at the syscall level if
+// resolution would leave base (including via a symlink swapped into
+// place after validatePath's check, closing that TOCTOU window) or
+// passes through a procfs magic link. It deliberately omits
+// RESOLVE_NO_SYMLINKS: that would also reject symlinks that never leave
+// base, breaking common deployment shapes like a Kubernetes ConfigMap/
+// Secret volume mount, where every file is a chain of *relative*
+// symlinks that still resolves inside the mounted directory (an
+// absolute-target symlink is rejected regardless of where it points,
+// since RESOLVE_BENEATH treats leaving the relative walk as an escape on
+// its own - which is exactly how ConfigMap/Secret mounts are built).
+// This is the kernel-enforced confinement os.Root gives on Go 1.24+;
+// Openat2 gets the same guarantee on older toolchains without requiring
+// one.
+func openBeneath(base, rel string) (*os.File, error) {
+	baseFd, err := unix.Open(base, unix.O_DIRECTORY|unix.O_RDONLY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open base directory %s: %w", base, err)
+	}
+	defer unix.Close(baseFd)
+
+	how := unix.OpenHow{
+		Flags:   unix.O_RDONLY | unix.O_CLOEXEC,
+		Resolve: unix.RESOLVE_BENEATH | unix.RESOLVE_NO_MAGICLINKS,
+	}
+	fd, err := unix.Openat2(baseFd, rel, &how)
+	if err != nil {
+		return nil, err
+	}
+	return os.NewFile(uintptr(fd), filepath.Join(base, rel)), nil
+}
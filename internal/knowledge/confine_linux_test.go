@@ -0,0 +1,127 @@
+//go:build linux
+
+package knowledge
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestOpenBeneath_ConcurrentSymlinkSwap hammers a single path with
+// concurrent "replace the regular file with a symlink to an outside
+// secret, then swap it back" races while readFileConfined repeatedly
+// reads it. This is the TOCTOU window a validatePath-then-afero.ReadFile
+// sequence is vulnerable to: a symlink planted between the check and the
+// read would previously let the read follow it. RESOLVE_BENEATH makes the
+// open itself fail whenever the path (even momentarily) resolves outside
+// the base directory, so every read must either return the legitimate
+// content or an error - never the outside secret's content.
+func TestOpenBeneath_ConcurrentSymlinkSwap(t *testing.T) {
+	tmpDir := t.TempDir()
+	baseDir := filepath.Join(tmpDir, "patterns")
+	outsideDir := filepath.Join(tmpDir, "outside")
+	if err := os.MkdirAll(baseDir, 0755); err != nil {
+		t.Fatalf("failed to create base dir: %v", err)
+	}
+	if err := os.MkdirAll(outsideDir, 0755); err != nil {
+		t.Fatalf("failed to create outside dir: %v", err)
+	}
+
+	secretPath := filepath.Join(outsideDir, "secret.yaml")
+	if err := os.WriteFile(secretPath, []byte("SECRET"), 0644); err != nil {
+		t.Fatalf("failed to write secret file: %v", err)
+	}
+
+	targetPath := filepath.Join(baseDir, "target.yaml")
+	if err := os.WriteFile(targetPath, []byte("LEGIT"), 0644); err != nil {
+		t.Fatalf("failed to write target file: %v", err)
+	}
+
+	loader := NewLoader(baseDir)
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			os.Remove(targetPath)
+			if i%2 == 0 {
+				os.Symlink(secretPath, targetPath)
+			} else {
+				os.WriteFile(targetPath, []byte("LEGIT"), 0644)
+			}
+		}
+	}()
+
+	leaked := false
+	deadline := time.Now().Add(200 * time.Millisecond)
+	for time.Now().Before(deadline) {
+		data, err := loader.readFileConfined(targetPath)
+		if err == nil && strings.Contains(string(data), "SECRET") {
+			leaked = true
+			break
+		}
+	}
+	close(stop)
+	wg.Wait()
+
+	if leaked {
+		t.Fatal("readFileConfined returned the outside secret's content across a concurrent symlink swap")
+	}
+}
+
+// TestReadFileConfined_AllowsInBoundsSymlinkChain confirms openBeneath's
+// RESOLVE_BENEATH (without RESOLVE_NO_SYMLINKS) still loads a pattern
+// reached through a chain of symlinks that never leaves basePath - the
+// shape a Kubernetes ConfigMap/Secret volume mount uses for every file
+// it exposes (file -> ..data/file -> ..<timestamp>/file).
+func TestReadFileConfined_AllowsInBoundsSymlinkChain(t *testing.T) {
+	tmpDir := t.TempDir()
+	baseDir := filepath.Join(tmpDir, "patterns")
+	if err := os.MkdirAll(baseDir, 0755); err != nil {
+		t.Fatalf("failed to create base dir: %v", err)
+	}
+
+	realDir := filepath.Join(baseDir, "..data-real")
+	if err := os.MkdirAll(realDir, 0755); err != nil {
+		t.Fatalf("failed to create real data dir: %v", err)
+	}
+	realFile := filepath.Join(realDir, "pattern.yaml")
+	if err := os.WriteFile(realFile, []byte("LEGIT"), 0644); err != nil {
+		t.Fatalf("failed to write real file: %v", err)
+	}
+
+	// Kubernetes's ConfigMap/Secret atomic writer links with relative
+	// targets ("..data" -> "..<timestamp>", "file" -> "..data/file"), not
+	// absolute ones - precisely because RESOLVE_BENEATH treats an
+	// absolute-target symlink as always escaping its dirfd, even when the
+	// absolute path it names happens to resolve back inside base.
+	dataLink := filepath.Join(baseDir, "..data")
+	if err := os.Symlink("..data-real", dataLink); err != nil {
+		t.Skipf("cannot create symlinks (permission denied): %v", err)
+	}
+	fileLink := filepath.Join(baseDir, "pattern.yaml")
+	if err := os.Symlink(filepath.Join("..data", "pattern.yaml"), fileLink); err != nil {
+		t.Fatalf("failed to create file symlink: %v", err)
+	}
+
+	loader := NewLoader(baseDir)
+	data, err := loader.readFileConfined(fileLink)
+	if err != nil {
+		t.Fatalf("expected an in-bounds symlink chain to load, got error: %v", err)
+	}
+	if string(data) != "LEGIT" {
+		t.Errorf("readFileConfined = %q, want %q", data, "LEGIT")
+	}
+}
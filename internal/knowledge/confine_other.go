@@ -0,0 +1,13 @@
+//go:build !linux
+
+package knowledge
+
+import "github.com/spf13/afero"
+
+// readFileConfined reads path via the loader's afero.Fs directly.
+// Openat2(RESOLVE_BENEATH) is Linux-only, so non-Linux platforms rely on
+// validatePath's lexical-plus-EvalSymlinks check alone; there's no
+// equivalent syscall-level confinement wired up here yet.
+func (l *Loader) readFileConfined(path string) ([]byte, error) {
+	return afero.ReadFile(l.fs, path)
+}
@@ -0,0 +1,107 @@
+package knowledge
+
+import "strings"
+
+// Action is an enforcement action a caller should take when a pattern
+// matches its query.
+type Action string
+
+const (
+	ActionWarn   Action = "warn"
+	ActionDeny   Action = "deny"
+	ActionAudit  Action = "audit"
+	ActionDryRun Action = "dryrun"
+)
+
+// ScopedAction scopes an enforcement Action to a caller type (Scope) and,
+// optionally, to patterns meeting Conditions - so a pattern can e.g. "deny"
+// for a CI runner but only "warn" for an IDE assistant. A ScopedAction with
+// no Scope applies regardless of caller type, as a default.
+type ScopedAction struct {
+	Action     Action                 `yaml:"action" json:"action"`
+	Scope      string                 `yaml:"scope,omitempty" json:"scope,omitempty"`
+	Conditions *EnforcementConditions `yaml:"conditions,omitempty" json:"conditions,omitempty"`
+}
+
+// EnforcementConditions narrows when a ScopedAction applies: only at or
+// above MinSeverity, and/or only for a specific Language/Framework. An
+// empty field is not checked.
+type EnforcementConditions struct {
+	MinSeverity string `yaml:"min_severity,omitempty" json:"min_severity,omitempty"`
+	Language    string `yaml:"language,omitempty" json:"language,omitempty"`
+	Framework   string `yaml:"framework,omitempty" json:"framework,omitempty"`
+}
+
+// EnforcementContext carries the query-time language/framework
+// ResolveEnforcement checks a ScopedAction's Conditions against.
+type EnforcementContext struct {
+	Language  string
+	Framework string
+}
+
+var enforcementSeverityRank = map[string]int{
+	"low":      0,
+	"medium":   1,
+	"high":     2,
+	"critical": 3,
+}
+
+// tierDefaultAction is the fallback action for a pattern with no applicable
+// EnforcementActions entry, keyed by Tier.
+var tierDefaultAction = map[string]Action{
+	"A": ActionDeny,
+	"B": ActionWarn,
+	"C": ActionAudit,
+}
+
+// ResolveEnforcement returns the effective Action for p at scope, given
+// query context ctx. Precedence: a ScopedAction whose Scope matches scope
+// (and whose Conditions, if any, match) beats an unscoped ScopedAction
+// (Scope == "") that matches, which in turn beats p's tier default.
+// Returns "" if none of these apply (e.g. an unrecognized tier with no
+// EnforcementActions at all).
+func ResolveEnforcement(p *ThreatPattern, scope string, ctx EnforcementContext) Action {
+	var scoped, unscoped *ScopedAction
+	for i := range p.EnforcementActions {
+		sa := &p.EnforcementActions[i]
+		if !enforcementConditionsMatch(sa.Conditions, p, ctx) {
+			continue
+		}
+		switch {
+		case sa.Scope != "" && strings.EqualFold(sa.Scope, scope):
+			scoped = sa
+		case sa.Scope == "" && unscoped == nil:
+			unscoped = sa
+		}
+	}
+
+	if scoped != nil {
+		return scoped.Action
+	}
+	if unscoped != nil {
+		return unscoped.Action
+	}
+	return tierDefaultAction[strings.ToUpper(p.Tier)]
+}
+
+// enforcementConditionsMatch reports whether p/ctx satisfy c. A nil c
+// always matches.
+func enforcementConditionsMatch(c *EnforcementConditions, p *ThreatPattern, ctx EnforcementContext) bool {
+	if c == nil {
+		return true
+	}
+	if c.MinSeverity != "" {
+		want, wantOK := enforcementSeverityRank[strings.ToLower(c.MinSeverity)]
+		got, gotOK := enforcementSeverityRank[strings.ToLower(p.Severity)]
+		if wantOK && gotOK && got < want {
+			return false
+		}
+	}
+	if c.Language != "" && !strings.EqualFold(c.Language, ctx.Language) {
+		return false
+	}
+	if c.Framework != "" && !strings.EqualFold(c.Framework, ctx.Framework) {
+		return false
+	}
+	return true
+}
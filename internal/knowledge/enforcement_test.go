@@ -0,0 +1,74 @@
+package knowledge
+
+import "testing"
+
+func TestResolveEnforcement_ScopedBeatsUnscoped(t *testing.T) {
+	p := &ThreatPattern{
+		Tier:     "B",
+		Severity: "high",
+		EnforcementActions: []ScopedAction{
+			{Action: ActionWarn},
+			{Action: ActionDeny, Scope: "ci"},
+		},
+	}
+
+	if got := ResolveEnforcement(p, "ci", EnforcementContext{}); got != ActionDeny {
+		t.Errorf("expected scoped action to win for ci, got %q", got)
+	}
+	if got := ResolveEnforcement(p, "ide", EnforcementContext{}); got != ActionWarn {
+		t.Errorf("expected unscoped default for ide, got %q", got)
+	}
+}
+
+func TestResolveEnforcement_FallsBackToTierDefault(t *testing.T) {
+	p := &ThreatPattern{Tier: "A", Severity: "critical"}
+
+	if got := ResolveEnforcement(p, "ci", EnforcementContext{}); got != ActionDeny {
+		t.Errorf("expected tier A default of deny, got %q", got)
+	}
+}
+
+func TestResolveEnforcement_ConditionsGateMatch(t *testing.T) {
+	p := &ThreatPattern{
+		Tier:     "B",
+		Severity: "medium",
+		EnforcementActions: []ScopedAction{
+			{
+				Action: ActionDeny,
+				Scope:  "ci",
+				Conditions: &EnforcementConditions{
+					MinSeverity: "high",
+				},
+			},
+		},
+	}
+
+	if got := ResolveEnforcement(p, "ci", EnforcementContext{}); got != ActionWarn {
+		t.Errorf("expected the gated scoped action to be skipped (medium < high), got %q", got)
+	}
+
+	p.Severity = "critical"
+	if got := ResolveEnforcement(p, "ci", EnforcementContext{}); got != ActionDeny {
+		t.Errorf("expected the scoped action to apply once severity meets the threshold, got %q", got)
+	}
+}
+
+func TestResolveEnforcement_ConditionsGateLanguageFramework(t *testing.T) {
+	p := &ThreatPattern{
+		Tier:     "C",
+		Severity: "medium",
+		EnforcementActions: []ScopedAction{
+			{
+				Action:     ActionDeny,
+				Conditions: &EnforcementConditions{Language: "python", Framework: "flask"},
+			},
+		},
+	}
+
+	if got := ResolveEnforcement(p, "ci", EnforcementContext{Language: "go"}); got != ActionAudit {
+		t.Errorf("expected language mismatch to fall through to tier default, got %q", got)
+	}
+	if got := ResolveEnforcement(p, "ci", EnforcementContext{Language: "python", Framework: "flask"}); got != ActionDeny {
+		t.Errorf("expected matching language/framework to apply the scoped action, got %q", got)
+	}
+}
@@ -0,0 +1,83 @@
+package knowledge
+
+import "strings"
+
+// ExpandSelectors expands positional pattern selectors — as accepted by
+// `validate`, `get`, and `list` — against patterns. Each arg is either a
+// positive selector, added to the result, or, prefixed with '-', a negative
+// selector removed from it. Args are applied in order, so a later negative
+// selector can carve an exception out of an earlier positive one (e.g.
+// `TMKB-AUTHZ-... -TMKB-AUTHZ-003`).
+//
+// A selector is one of:
+//   - "..." on its own: matches every pattern.
+//   - a term ending in "...": matches any pattern whose ID, Language, or
+//     Framework has that prefix (case-insensitive for Language/Framework).
+//   - anything else: matches a pattern with that exact ID.
+//
+// The result preserves the order in which IDs were first included.
+func ExpandSelectors(patterns []ThreatPattern, args []string) []ThreatPattern {
+	byID := make(map[string]ThreatPattern, len(patterns))
+	for _, p := range patterns {
+		byID[p.ID] = p
+	}
+
+	var order []string
+	included := make(map[string]bool)
+
+	for _, arg := range args {
+		exclude := strings.HasPrefix(arg, "-")
+		term := strings.TrimPrefix(arg, "-")
+
+		for _, id := range matchSelectorTerm(patterns, term) {
+			if exclude {
+				delete(included, id)
+				continue
+			}
+			if !included[id] {
+				order = append(order, id)
+			}
+			included[id] = true
+		}
+	}
+
+	result := make([]ThreatPattern, 0, len(order))
+	for _, id := range order {
+		if included[id] {
+			result = append(result, byID[id])
+		}
+	}
+	return result
+}
+
+// matchSelectorTerm returns the IDs of patterns matching a single
+// ExpandSelectors term (with any leading "-" already stripped).
+func matchSelectorTerm(patterns []ThreatPattern, term string) []string {
+	if term == "..." {
+		ids := make([]string, len(patterns))
+		for i, p := range patterns {
+			ids[i] = p.ID
+		}
+		return ids
+	}
+
+	if strings.HasSuffix(term, "...") {
+		prefix := strings.TrimSuffix(term, "...")
+		var ids []string
+		for _, p := range patterns {
+			if strings.HasPrefix(p.ID, prefix) ||
+				strings.HasPrefix(strings.ToLower(p.Language), strings.ToLower(prefix)) ||
+				strings.HasPrefix(strings.ToLower(p.Framework), strings.ToLower(prefix)) {
+				ids = append(ids, p.ID)
+			}
+		}
+		return ids
+	}
+
+	for _, p := range patterns {
+		if p.ID == term {
+			return []string{p.ID}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package knowledge
+
+import (
+	"reflect"
+	"testing"
+)
+
+func expandTestPatterns() []ThreatPattern {
+	return []ThreatPattern{
+		{ID: "TMKB-AUTHZ-001", Language: "python", Framework: "flask"},
+		{ID: "TMKB-AUTHZ-002", Language: "go", Framework: "gin"},
+		{ID: "TMKB-AUTHZ-003", Language: "go", Framework: "gin"},
+		{ID: "TMKB-CRYPTO-001", Language: "java", Framework: "spring"},
+	}
+}
+
+func idsOf(patterns []ThreatPattern) []string {
+	ids := make([]string, len(patterns))
+	for i, p := range patterns {
+		ids[i] = p.ID
+	}
+	return ids
+}
+
+func TestExpandSelectors(t *testing.T) {
+	patterns := expandTestPatterns()
+
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{"exact id", []string{"TMKB-AUTHZ-001"}, []string{"TMKB-AUTHZ-001"}},
+		{"unknown exact id matches nothing", []string{"TMKB-NOPE-001"}, nil},
+		{"ellipsis matches everything", []string{"..."}, []string{
+			"TMKB-AUTHZ-001", "TMKB-AUTHZ-002", "TMKB-AUTHZ-003", "TMKB-CRYPTO-001",
+		}},
+		{"id prefix ellipsis", []string{"TMKB-AUTHZ-..."}, []string{
+			"TMKB-AUTHZ-001", "TMKB-AUTHZ-002", "TMKB-AUTHZ-003",
+		}},
+		{"prefix with exclusion", []string{"TMKB-AUTHZ-...", "-TMKB-AUTHZ-003"}, []string{
+			"TMKB-AUTHZ-001", "TMKB-AUTHZ-002",
+		}},
+		{"language namespace ellipsis", []string{"go..."}, []string{
+			"TMKB-AUTHZ-002", "TMKB-AUTHZ-003",
+		}},
+		{"multiple positive terms, preserves first-seen order", []string{"TMKB-CRYPTO-001", "TMKB-AUTHZ-001"}, []string{
+			"TMKB-CRYPTO-001", "TMKB-AUTHZ-001",
+		}},
+		{"exclude everything", []string{"...", "-..."}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := idsOf(ExpandSelectors(patterns, tt.args))
+			if len(got) == 0 && len(tt.want) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ExpandSelectors(%v) = %v, want %v", tt.args, got, tt.want)
+			}
+		})
+	}
+}
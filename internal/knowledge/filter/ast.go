@@ -0,0 +1,227 @@
+package filter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// node is an evaluable expression node.
+type node interface {
+	eval(env *env) (interface{}, error)
+}
+
+type literalNode struct{ val interface{} }
+
+func (n literalNode) eval(*env) (interface{}, error) { return n.val, nil }
+
+type listNode struct{ items []node }
+
+func (n listNode) eval(e *env) (interface{}, error) {
+	out := make([]interface{}, len(n.items))
+	for i, it := range n.items {
+		v, err := it.eval(e)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = v
+	}
+	return out, nil
+}
+
+// fieldNode resolves a dotted path (e.g. provenance.added) against the
+// pattern's JSON-shaped environment.
+type fieldNode struct{ path []string }
+
+func (n fieldNode) eval(e *env) (interface{}, error) {
+	v, ok := e.lookup(n.path)
+	if !ok {
+		return nil, fmt.Errorf("unknown field %q", strings.Join(n.path, "."))
+	}
+	return v, nil
+}
+
+type unaryNode struct {
+	op string
+	x  node
+}
+
+func (n unaryNode) eval(e *env) (interface{}, error) {
+	v, err := n.x.eval(e)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "not":
+		b, ok := v.(bool)
+		if !ok {
+			return nil, fmt.Errorf("not: operand is not a boolean")
+		}
+		return !b, nil
+	}
+	return nil, fmt.Errorf("unknown unary operator %q", n.op)
+}
+
+type binaryNode struct {
+	op   string
+	l, r node
+}
+
+func (n binaryNode) eval(e *env) (interface{}, error) {
+	// Short-circuit and/or before evaluating the right side.
+	if n.op == "and" || n.op == "or" {
+		lv, err := n.l.eval(e)
+		if err != nil {
+			return nil, err
+		}
+		lb, ok := lv.(bool)
+		if !ok {
+			return nil, fmt.Errorf("%s: left operand is not a boolean", n.op)
+		}
+		if n.op == "and" && !lb {
+			return false, nil
+		}
+		if n.op == "or" && lb {
+			return true, nil
+		}
+		rv, err := n.r.eval(e)
+		if err != nil {
+			return nil, err
+		}
+		rb, ok := rv.(bool)
+		if !ok {
+			return nil, fmt.Errorf("%s: right operand is not a boolean", n.op)
+		}
+		return rb, nil
+	}
+
+	lv, err := n.l.eval(e)
+	if err != nil {
+		return nil, err
+	}
+	rv, err := n.r.eval(e)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==":
+		return equalValues(lv, rv), nil
+	case "!=":
+		return !equalValues(lv, rv), nil
+	case "in":
+		return inValue(lv, rv), nil
+	case "<", "<=", ">", ">=":
+		lf, lok := toFloat(lv)
+		rf, rok := toFloat(rv)
+		if !lok || !rok {
+			return nil, fmt.Errorf("%s: operands must be numbers", n.op)
+		}
+		switch n.op {
+		case "<":
+			return lf < rf, nil
+		case "<=":
+			return lf <= rf, nil
+		case ">":
+			return lf > rf, nil
+		case ">=":
+			return lf >= rf, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown binary operator %q", n.op)
+}
+
+type callNode struct {
+	name string
+	args []node
+}
+
+func (n callNode) eval(e *env) (interface{}, error) {
+	// matches(field, regex) takes a bare field reference as its first
+	// argument, not the field's resolved value, so the dotted path can be
+	// looked up directly even when the field is absent or non-string.
+	if n.name == "matches" {
+		if len(n.args) != 2 {
+			return nil, fmt.Errorf("matches: expected 2 arguments, got %d", len(n.args))
+		}
+		fn, ok := n.args[0].(fieldNode)
+		if !ok {
+			return nil, fmt.Errorf("matches: first argument must be a field reference")
+		}
+		patternVal, err := n.args[1].eval(e)
+		if err != nil {
+			return nil, err
+		}
+		pattern, ok := patternVal.(string)
+		if !ok {
+			return nil, fmt.Errorf("matches: second argument must be a regex string")
+		}
+		v, ok := e.lookup(fn.path)
+		if !ok {
+			return false, nil
+		}
+		s, ok := v.(string)
+		if !ok {
+			return false, nil
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("matches: invalid regex %q: %w", pattern, err)
+		}
+		return re.MatchString(s), nil
+	}
+
+	args := make([]interface{}, len(n.args))
+	for i, a := range n.args {
+		v, err := a.eval(e)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = v
+	}
+
+	switch n.name {
+	case "has_mitigation":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("has_mitigation: expected 1 argument, got %d", len(args))
+		}
+		id, ok := args[0].(string)
+		if !ok {
+			return nil, fmt.Errorf("has_mitigation: argument must be a string")
+		}
+		for _, m := range e.mitigations() {
+			mm, ok := m.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if mid, _ := mm["id"].(string); mid == id {
+				return true, nil
+			}
+		}
+		return false, nil
+	case "age_days":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("age_days: expected 1 argument, got %d", len(args))
+		}
+		s, ok := args[0].(string)
+		if !ok {
+			return nil, fmt.Errorf("age_days: argument must be a date string")
+		}
+		t, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			return nil, fmt.Errorf("age_days: invalid date %q: %w", s, err)
+		}
+		return time.Since(t).Hours() / 24, nil
+	case "has_import":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("has_import: expected 1 argument, got %d", len(args))
+		}
+		path, ok := args[0].(string)
+		if !ok {
+			return nil, fmt.Errorf("has_import: argument must be a string")
+		}
+		return inValue(path, e.imports()), nil
+	}
+	return nil, fmt.Errorf("unknown function %q", n.name)
+}
@@ -0,0 +1,108 @@
+package filter
+
+import "fmt"
+
+// env exposes a marshaled pattern document to expressions using its JSON
+// field names, mirroring the shape agents see in `tmkb get`/`tmkb query`
+// output. Operating on the generic map (rather than knowledge.ThreatPattern
+// directly) keeps this package free of a dependency on internal/knowledge,
+// which in turn depends on filter to implement --filter.
+type env struct {
+	doc map[string]interface{}
+}
+
+func newEnv(doc map[string]interface{}) *env {
+	return &env{doc: doc}
+}
+
+// lookup resolves a dotted JSON path (e.g. ["provenance", "added"]) against
+// the pattern's marshaled form.
+func (e *env) lookup(path []string) (interface{}, bool) {
+	if path[0] == "cwe_references" && len(path) == 1 {
+		return e.cweReferences(), true
+	}
+
+	var cur interface{} = e.doc
+	for _, key := range path {
+		m, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		v, ok := m[key]
+		if !ok {
+			return nil, false
+		}
+		cur = v
+	}
+	return cur, true
+}
+
+// cweReferences flattens provenance.public_references[*].cwe into the
+// cwe_references helper field the expression grammar exposes.
+func (e *env) cweReferences() []interface{} {
+	var refs []interface{}
+	provenance, _ := e.doc["provenance"].(map[string]interface{})
+	if provenance == nil {
+		return refs
+	}
+	publicRefs, _ := provenance["public_references"].([]interface{})
+	for _, r := range publicRefs {
+		ref, ok := r.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if cwe, ok := ref["cwe"].(string); ok && cwe != "" {
+			refs = append(refs, cwe)
+		}
+	}
+	return refs
+}
+
+// mitigations returns the doc's mitigations list as generic maps.
+func (e *env) mitigations() []interface{} {
+	m, _ := e.doc["mitigations"].([]interface{})
+	return m
+}
+
+// imports returns the doc's "imports" list, the extracted-import-path
+// helper field a query-context document (see knowledge.exprContextDoc)
+// exposes for has_import; a pattern document without one (the --filter
+// use case) simply reports no imports.
+func (e *env) imports() []interface{} {
+	imports, _ := e.doc["imports"].([]interface{})
+	return imports
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+func equalValues(a, b interface{}) bool {
+	af, aok := toFloat(a)
+	bf, bok := toFloat(b)
+	if aok && bok {
+		return af == bf
+	}
+	return fmt.Sprint(a) == fmt.Sprint(b)
+}
+
+// inValue reports whether needle equals haystack, or (if haystack is a
+// slice) whether needle equals any element of it.
+func inValue(needle, haystack interface{}) bool {
+	items, ok := haystack.([]interface{})
+	if !ok {
+		return equalValues(needle, haystack)
+	}
+	for _, item := range items {
+		if equalValues(needle, item) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+// Package filter implements a small boolean expression engine used to
+// filter patterns ahead of scoring. The grammar and evaluation model
+// follow the antonmedv/expr style: a source string is Compile'd once into
+// a Program, then Run (or Match) cheaply per document.
+//
+// Programs evaluate against a generic map[string]interface{} rather than
+// a concrete pattern type, matching the shape patterns take once marshaled
+// to JSON (id, severity, cwe_references, mitigations[*].effectiveness,
+// etc). Callers typically produce that map with json.Marshal+Unmarshal
+// over a knowledge.ThreatPattern.
+package filter
+
+import (
+	"fmt"
+)
+
+// Program is a compiled filter expression ready to be evaluated against
+// individual pattern documents.
+type Program struct {
+	src  string
+	expr node
+}
+
+// Compile parses src into a Program. Compile errors carry a line/column
+// pointing into src so callers can surface actionable diagnostics.
+func Compile(src string) (*Program, error) {
+	toks, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: toks, src: src}
+	expr, err := p.parseExpr(0)
+	if err != nil {
+		return nil, err
+	}
+	if !p.atEnd() {
+		tok := p.peek()
+		return nil, &CompileError{Src: src, Line: tok.line, Col: tok.col, Msg: fmt.Sprintf("unexpected token %q", tok.lit)}
+	}
+	return &Program{src: src, expr: expr}, nil
+}
+
+// Match evaluates the Program against doc, a pattern marshaled to its JSON
+// shape, and reports whether it satisfies the expression.
+func (prog *Program) Match(doc map[string]interface{}) (bool, error) {
+	v, err := prog.expr.eval(newEnv(doc))
+	if err != nil {
+		return false, fmt.Errorf("filter %q: %w", prog.src, err)
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("filter %q: expression did not evaluate to a boolean", prog.src)
+	}
+	return b, nil
+}
+
+// CompileError reports a parse/lex failure with a position into the
+// original source, so CLI and MCP callers can point agents at the mistake.
+type CompileError struct {
+	Src  string
+	Line int
+	Col  int
+	Msg  string
+}
+
+func (e *CompileError) Error() string {
+	return fmt.Sprintf("%d:%d: %s", e.Line, e.Col, e.Msg)
+}
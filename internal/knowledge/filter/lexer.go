@@ -0,0 +1,168 @@
+package filter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokString
+	tokNumber
+	tokLParen
+	tokRParen
+	tokLBracket
+	tokRBracket
+	tokComma
+	tokDot
+	tokQuestion
+	tokOp // == != < <= > >=
+)
+
+type token struct {
+	kind tokenKind
+	lit  string
+	line int
+	col  int
+}
+
+// lex tokenizes src. Keywords (and, or, not, in) are returned as tokIdent
+// and disambiguated by the parser, matching how the reference grammar
+// treats them as identifiers that happen to be reserved.
+func lex(src string) ([]token, error) {
+	var toks []token
+	line, col := 1, 1
+	i := 0
+	advance := func(n int) {
+		for _, r := range src[i : i+n] {
+			if r == '\n' {
+				line++
+				col = 1
+			} else {
+				col++
+			}
+		}
+		i += n
+	}
+
+	for i < len(src) {
+		r, size := utf8.DecodeRuneInString(src[i:])
+
+		switch {
+		case unicode.IsSpace(r):
+			advance(size)
+		case r == '(':
+			toks = append(toks, token{tokLParen, "(", line, col})
+			advance(size)
+		case r == ')':
+			toks = append(toks, token{tokRParen, ")", line, col})
+			advance(size)
+		case r == '[':
+			toks = append(toks, token{tokLBracket, "[", line, col})
+			advance(size)
+		case r == ']':
+			toks = append(toks, token{tokRBracket, "]", line, col})
+			advance(size)
+		case r == ',':
+			toks = append(toks, token{tokComma, ",", line, col})
+			advance(size)
+		case r == '.':
+			toks = append(toks, token{tokDot, ".", line, col})
+			advance(size)
+		case r == '?':
+			toks = append(toks, token{tokQuestion, "?", line, col})
+			advance(size)
+		case r == '"' || r == '\'':
+			startLine, startCol := line, col
+			quote := r
+			advance(size)
+			var sb strings.Builder
+			closed := false
+			for i < len(src) {
+				c, sz := utf8.DecodeRuneInString(src[i:])
+				if c == quote {
+					advance(sz)
+					closed = true
+					break
+				}
+				sb.WriteRune(c)
+				advance(sz)
+			}
+			if !closed {
+				return nil, &CompileError{Src: src, Line: startLine, Col: startCol, Msg: "unterminated string literal"}
+			}
+			toks = append(toks, token{tokString, sb.String(), startLine, startCol})
+		case r == '=' && peekRune(src, i+size) == '=':
+			toks = append(toks, token{tokOp, "==", line, col})
+			advance(size + 1)
+		case r == '!' && peekRune(src, i+size) == '=':
+			toks = append(toks, token{tokOp, "!=", line, col})
+			advance(size + 1)
+		case r == '<' && peekRune(src, i+size) == '=':
+			toks = append(toks, token{tokOp, "<=", line, col})
+			advance(size + 1)
+		case r == '>' && peekRune(src, i+size) == '=':
+			toks = append(toks, token{tokOp, ">=", line, col})
+			advance(size + 1)
+		case r == '<':
+			toks = append(toks, token{tokOp, "<", line, col})
+			advance(size)
+		case r == '>':
+			toks = append(toks, token{tokOp, ">", line, col})
+			advance(size)
+		case unicode.IsDigit(r):
+			startLine, startCol := line, col
+			start := i
+			for i < len(src) {
+				c, sz := utf8.DecodeRuneInString(src[i:])
+				if !unicode.IsDigit(c) && c != '.' {
+					break
+				}
+				advance(sz)
+			}
+			lit := src[start:i]
+			if _, err := strconv.ParseFloat(lit, 64); err != nil {
+				return nil, &CompileError{Src: src, Line: startLine, Col: startCol, Msg: fmt.Sprintf("invalid number %q", lit)}
+			}
+			toks = append(toks, token{tokNumber, lit, startLine, startCol})
+		case isIdentStart(r):
+			startLine, startCol := line, col
+			start := i
+			for i < len(src) {
+				c, sz := utf8.DecodeRuneInString(src[i:])
+				if !isIdentPart(c) {
+					break
+				}
+				advance(sz)
+			}
+			toks = append(toks, token{tokIdent, src[start:i], startLine, startCol})
+		default:
+			return nil, &CompileError{Src: src, Line: line, Col: col, Msg: fmt.Sprintf("unexpected character %q", r)}
+		}
+	}
+
+	toks = append(toks, token{tokEOF, "", line, col})
+	return toks, nil
+}
+
+func peekRune(src string, i int) rune {
+	if i >= len(src) {
+		return 0
+	}
+	r, _ := utf8.DecodeRuneInString(src[i:])
+	return r
+}
+
+func isIdentStart(r rune) bool {
+	return unicode.IsLetter(r) || r == '_'
+}
+
+func isIdentPart(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
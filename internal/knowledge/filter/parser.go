@@ -0,0 +1,196 @@
+package filter
+
+import (
+	"fmt"
+	"strconv"
+)
+
+type parser struct {
+	tokens []token
+	pos    int
+	src    string
+}
+
+func (p *parser) peek() token { return p.tokens[p.pos] }
+func (p *parser) atEnd() bool { return p.peek().kind == tokEOF }
+func (p *parser) advance() token {
+	t := p.tokens[p.pos]
+	if p.pos < len(p.tokens)-1 {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) errorf(t token, format string, args ...interface{}) error {
+	return &CompileError{Src: p.src, Line: t.line, Col: t.col, Msg: fmt.Sprintf(format, args...)}
+}
+
+// precedence levels, lowest to highest
+const (
+	precOr = iota
+	precAnd
+	precNot
+	precCompare
+	precUnary
+)
+
+// parseExpr implements a small precedence-climbing parser for the
+// and/or/not/comparison grammar described in the package doc.
+func (p *parser) parseExpr(minPrec int) (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		tok := p.peek()
+		op, prec, ok := binOpAt(tok)
+		if !ok || prec < minPrec {
+			break
+		}
+		p.advance()
+		right, err := p.parseExprAbove(prec)
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{op: op, l: left, r: right}
+	}
+	return left, nil
+}
+
+// parseExprAbove parses an expression binding tighter than prec (for
+// left-associative operators, we recurse at prec+1).
+func (p *parser) parseExprAbove(prec int) (node, error) {
+	return p.parseExpr(prec + 1)
+}
+
+func binOpAt(t token) (op string, prec int, ok bool) {
+	if t.kind == tokIdent {
+		switch t.lit {
+		case "or":
+			return "or", precOr, true
+		case "and":
+			return "and", precAnd, true
+		case "in":
+			return "in", precCompare, true
+		}
+		return "", 0, false
+	}
+	if t.kind == tokOp {
+		return t.lit, precCompare, true
+	}
+	return "", 0, false
+}
+
+func (p *parser) parseUnary() (node, error) {
+	tok := p.peek()
+	if tok.kind == tokIdent && tok.lit == "not" {
+		p.advance()
+		x, err := p.parseExpr(precNot)
+		if err != nil {
+			return nil, err
+		}
+		return unaryNode{op: "not", x: x}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	tok := p.peek()
+	switch tok.kind {
+	case tokString:
+		p.advance()
+		return literalNode{val: tok.lit}, nil
+	case tokNumber:
+		p.advance()
+		f, _ := strconv.ParseFloat(tok.lit, 64)
+		return literalNode{val: f}, nil
+	case tokLParen:
+		p.advance()
+		inner, err := p.parseExpr(0)
+		if err != nil {
+			return nil, err
+		}
+		if p.peek().kind != tokRParen {
+			return nil, p.errorf(p.peek(), "expected ')'")
+		}
+		p.advance()
+		return inner, nil
+	case tokLBracket:
+		p.advance()
+		var items []node
+		if p.peek().kind != tokRBracket {
+			for {
+				item, err := p.parseExpr(0)
+				if err != nil {
+					return nil, err
+				}
+				items = append(items, item)
+				if p.peek().kind == tokComma {
+					p.advance()
+					continue
+				}
+				break
+			}
+		}
+		if p.peek().kind != tokRBracket {
+			return nil, p.errorf(p.peek(), "expected ']'")
+		}
+		p.advance()
+		return listNode{items: items}, nil
+	case tokIdent:
+		switch tok.lit {
+		case "true":
+			p.advance()
+			return literalNode{val: true}, nil
+		case "false":
+			p.advance()
+			return literalNode{val: false}, nil
+		case "null", "nil":
+			p.advance()
+			return literalNode{val: nil}, nil
+		}
+		return p.parseIdentExpr()
+	}
+	return nil, p.errorf(tok, "unexpected token %q", tok.lit)
+}
+
+// parseIdentExpr parses a dotted field path or a function call.
+func (p *parser) parseIdentExpr() (node, error) {
+	tok := p.advance() // first ident
+	name := tok.lit
+
+	if p.peek().kind == tokLParen {
+		p.advance()
+		var args []node
+		if p.peek().kind != tokRParen {
+			for {
+				arg, err := p.parseExpr(0)
+				if err != nil {
+					return nil, err
+				}
+				args = append(args, arg)
+				if p.peek().kind == tokComma {
+					p.advance()
+					continue
+				}
+				break
+			}
+		}
+		if p.peek().kind != tokRParen {
+			return nil, p.errorf(p.peek(), "expected ')' to close call to %s", name)
+		}
+		p.advance()
+		return callNode{name: name, args: args}, nil
+	}
+
+	path := []string{name}
+	for p.peek().kind == tokDot {
+		p.advance()
+		if p.peek().kind != tokIdent {
+			return nil, p.errorf(p.peek(), "expected field name after '.'")
+		}
+		path = append(path, p.advance().lit)
+	}
+	return fieldNode{path: path}, nil
+}
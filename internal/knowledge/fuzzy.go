@@ -0,0 +1,63 @@
+package knowledge
+
+// damerauLevenshtein returns the edit distance between a and b, counting
+// single-character insertions, deletions, substitutions, and adjacent
+// transpositions as one edit each - the transposition term is what makes
+// this Damerau- rather than plain Levenshtein, so a common typo like
+// "atuh"/"auth" costs 1 instead of 2.
+func damerauLevenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	la, lb := len(ra), len(rb)
+
+	d := make([][]int, la+1)
+	for i := range d {
+		d[i] = make([]int, lb+1)
+		d[i][0] = i
+	}
+	for j := 0; j <= lb; j++ {
+		d[0][j] = j
+	}
+
+	for i := 1; i <= la; i++ {
+		for j := 1; j <= lb; j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			d[i][j] = min3(
+				d[i-1][j]+1,      // deletion
+				d[i][j-1]+1,      // insertion
+				d[i-1][j-1]+cost, // substitution
+			)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				if t := d[i-2][j-2] + cost; t < d[i][j] {
+					d[i][j] = t
+				}
+			}
+		}
+	}
+	return d[la][lb]
+}
+
+func min3(a, b, c int) int {
+	m := a
+	if b < m {
+		m = b
+	}
+	if c < m {
+		m = c
+	}
+	return m
+}
+
+// fuzzyThreshold returns the maximum edit distance a token may be from a
+// vocabulary entry to still count as a fuzzy match: 1 for short tokens,
+// where even a single substitution can change the word's meaning, 2 for
+// longer ones, where the request's own examples (typos, plurals the
+// stemmer didn't catch) need more slack.
+func fuzzyThreshold(token string) int {
+	if len([]rune(token)) <= 4 {
+		return 1
+	}
+	return 2
+}
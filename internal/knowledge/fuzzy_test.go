@@ -0,0 +1,32 @@
+package knowledge
+
+import "testing"
+
+func TestDamerauLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"auth", "auth", 0},
+		{"auth", "atuh", 1},  // adjacent transposition
+		{"auth", "auths", 1}, // insertion
+		{"auth", "aut", 1},   // deletion
+		{"auth", "aith", 1},  // substitution
+		{"authorization", "authorisation", 1},
+	}
+
+	for _, tt := range tests {
+		if got := damerauLevenshtein(tt.a, tt.b); got != tt.want {
+			t.Errorf("damerauLevenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestFuzzyThreshold(t *testing.T) {
+	if got := fuzzyThreshold("auth"); got != 1 {
+		t.Errorf("fuzzyThreshold(%q) = %d, want 1", "auth", got)
+	}
+	if got := fuzzyThreshold("authorization"); got != 2 {
+		t.Errorf("fuzzyThreshold(%q) = %d, want 2", "authorization", got)
+	}
+}
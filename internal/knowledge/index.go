@@ -1,35 +1,99 @@
 package knowledge
 
 import (
+	"fmt"
+	"sort"
 	"strings"
 	"sync"
+	"time"
+
+	"github.com/mark-chris/tmkb/internal/knowledge/filter"
+	"github.com/mark-chris/tmkb/internal/knowledge/match"
 )
 
+// compiledTrigger pairs one Triggers.Keywords entry with its compiled
+// match.Pattern, built once per pattern in Index.Build so MatchContext and
+// calculateRelevancePatterns don't recompile a pattern on every query.
+type compiledTrigger struct {
+	raw     string
+	pattern *match.Pattern
+}
+
 // Index provides fast lookups for patterns
 type Index struct {
-	patterns    []ThreatPattern
-	byID        map[string]*ThreatPattern
-	byCategory  map[string][]*ThreatPattern
-	byKeyword   map[string][]*ThreatPattern
-	byFramework map[string][]*ThreatPattern
-	byLanguage  map[string][]*ThreatPattern
-	mu          sync.RWMutex
+	patterns         []ThreatPattern
+	byID             map[string]*ThreatPattern
+	byCategory       map[string][]*ThreatPattern
+	byKeyword        map[string][]*ThreatPattern
+	byFramework      map[string][]*ThreatPattern
+	byLanguage       map[string][]*ThreatPattern
+	byLangFramework  map[string][]*ThreatPattern
+	byCWE            map[string][]*ThreatPattern
+	byOWASP          map[string][]*ThreatPattern
+	keywordStats     KeywordStats
+	corpusStats      CorpusStats
+	triggerRegistry  TriggerRegistry
+	compiledTriggers map[string][]compiledTrigger
+	exprPrograms     map[string]*filter.Program
+	stemIndex        map[string][]string
+	synonyms         SynonymMap
+	synonymGroups    map[string][]string
+	buildDuration    time.Duration
+	loadWarnings     []string
+	mu               sync.RWMutex
 }
 
 // NewIndex creates a new empty index
 func NewIndex() *Index {
 	return &Index{
-		patterns:    make([]ThreatPattern, 0),
-		byID:        make(map[string]*ThreatPattern),
-		byCategory:  make(map[string][]*ThreatPattern),
-		byKeyword:   make(map[string][]*ThreatPattern),
-		byFramework: make(map[string][]*ThreatPattern),
-		byLanguage:  make(map[string][]*ThreatPattern),
+		patterns:         make([]ThreatPattern, 0),
+		byID:             make(map[string]*ThreatPattern),
+		byCategory:       make(map[string][]*ThreatPattern),
+		byKeyword:        make(map[string][]*ThreatPattern),
+		byFramework:      make(map[string][]*ThreatPattern),
+		byLanguage:       make(map[string][]*ThreatPattern),
+		byLangFramework:  make(map[string][]*ThreatPattern),
+		byCWE:            make(map[string][]*ThreatPattern),
+		byOWASP:          make(map[string][]*ThreatPattern),
+		triggerRegistry:  make(TriggerRegistry),
+		compiledTriggers: make(map[string][]compiledTrigger),
+		exprPrograms:     make(map[string]*filter.Program),
+		stemIndex:        make(map[string][]string),
+		synonyms:         make(SynonymMap),
+		synonymGroups:    make(map[string][]string),
+	}
+}
+
+// SetTriggerRegistry installs the KB's named trigger definitions (see
+// TriggerRegistry), resolved when a pattern's Triggers.Any/All references
+// one by PatternRef. Call it after Build; a nil/empty registry just means
+// no pattern_ref targets are defined, which is fine for patterns that
+// don't use Any/All at all.
+func (idx *Index) SetTriggerRegistry(reg TriggerRegistry) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	if reg == nil {
+		reg = make(TriggerRegistry)
 	}
+	idx.triggerRegistry = reg
+}
+
+// SetSynonyms installs the KB's synonym groups (see SynonymMap), consulted
+// by MatchContextWithOptions regardless of MatchMode. Call it after Build;
+// a nil/empty map just means no synonym expansion happens.
+func (idx *Index) SetSynonyms(syn SynonymMap) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	if syn == nil {
+		syn = make(SynonymMap)
+	}
+	idx.synonyms = syn
+	idx.synonymGroups = buildSynonymGroups(syn)
 }
 
 // Build creates the index from a slice of patterns
 func (idx *Index) Build(patterns []ThreatPattern) {
+	start := time.Now()
 	idx.mu.Lock()
 	defer idx.mu.Unlock()
 
@@ -42,6 +106,27 @@ func (idx *Index) Build(patterns []ThreatPattern) {
 	idx.byKeyword = make(map[string][]*ThreatPattern)
 	idx.byFramework = make(map[string][]*ThreatPattern)
 	idx.byLanguage = make(map[string][]*ThreatPattern)
+	idx.byLangFramework = make(map[string][]*ThreatPattern)
+	idx.byCWE = make(map[string][]*ThreatPattern)
+	idx.byOWASP = make(map[string][]*ThreatPattern)
+	idx.compiledTriggers = make(map[string][]compiledTrigger)
+	idx.exprPrograms = make(map[string]*filter.Program)
+
+	// Precompute BM25 corpus statistics for CalculateRelevanceBM25
+	idx.keywordStats = NewKeywordStats(patterns)
+
+	// Precompute BM25F corpus statistics for CalculateRelevanceBM25F
+	idx.corpusStats = NewCorpusStats(patterns)
+
+	// Precompute the stemmed-root -> vocabulary-terms index that
+	// MatchContextWithOptions's stemmed/fuzzy modes expand a query token
+	// against, so stemming happens once per Build rather than once per
+	// query term per query.
+	idx.stemIndex = make(map[string][]string)
+	for term := range idx.corpusStats.DF {
+		root := stemPhrase(term)
+		idx.stemIndex[root] = append(idx.stemIndex[root], term)
+	}
 
 	// Build indexes
 	for i := range idx.patterns {
@@ -62,12 +147,95 @@ func (idx *Index) Build(patterns []ThreatPattern) {
 		lang := strings.ToLower(p.Language)
 		idx.byLanguage[lang] = append(idx.byLanguage[lang], p)
 
-		// By keywords (from triggers)
+		// By combined (language, framework)
+		key := langFrameworkKey(p.Language, p.Framework)
+		idx.byLangFramework[key] = append(idx.byLangFramework[key], p)
+
+		// By keywords (from triggers). Keywords.Keywords entries may be
+		// plain literals or glob/regex trigger patterns (see the match
+		// package); literal ones are indexed by exact text exactly as
+		// before, while glob/regex ones are kept compiled for MatchContext
+		// and scoring to test directly, since they have no single exact
+		// key to index by.
+		compiled := make([]compiledTrigger, 0, len(p.Triggers.Keywords))
 		for _, kw := range p.Triggers.Keywords {
-			kwLower := strings.ToLower(kw)
-			idx.byKeyword[kwLower] = append(idx.byKeyword[kwLower], p)
+			pat, err := match.Compile(kw)
+			if err != nil {
+				// Validate is expected to have already caught an invalid
+				// trigger pattern; Build degrades gracefully rather than
+				// failing the whole index over one bad keyword.
+				continue
+			}
+			compiled = append(compiled, compiledTrigger{raw: kw, pattern: pat})
+			if pat.Literal() {
+				kwLower := strings.ToLower(kw)
+				idx.byKeyword[kwLower] = append(idx.byKeyword[kwLower], p)
+			}
+		}
+		idx.compiledTriggers[p.ID] = compiled
+
+		// Precompile Triggers.Expr, if set, so Query only ever evaluates an
+		// already-compiled program against the query context. As with the
+		// keyword loop above, a compile error here is expected to have
+		// already been caught by ValidatePatternExpr/tmkb lint; Build
+		// degrades gracefully by leaving the pattern with no compiled
+		// program (Query then treats it as having no Expr at all) rather
+		// than failing the whole index over one bad pattern.
+		if strings.TrimSpace(p.Triggers.Expr) != "" {
+			if prog, err := filter.Compile(p.Triggers.Expr); err == nil {
+				idx.exprPrograms[p.ID] = prog
+			}
+		}
+
+		// By CWE/OWASP reference
+		for _, cwe := range p.CWEs() {
+			idx.byCWE[cwe] = append(idx.byCWE[cwe], p)
+		}
+		for _, owasp := range p.OWASPs() {
+			idx.byOWASP[owasp] = append(idx.byOWASP[owasp], p)
 		}
 	}
+
+	idx.buildRelatedByReference()
+
+	idx.buildDuration = time.Since(start)
+}
+
+// buildRelatedByReference stamps each pattern's RelatedByReference with the
+// IDs of other patterns sharing at least one CWE or OWASP reference. It
+// runs after byCWE/byOWASP are fully populated, so a pattern surfaces as
+// related to every other match in the corpus, not just earlier ones.
+func (idx *Index) buildRelatedByReference() {
+	for i := range idx.patterns {
+		p := &idx.patterns[i]
+
+		related := make(map[string]bool)
+		for _, cwe := range p.CWEs() {
+			for _, other := range idx.byCWE[cwe] {
+				if other.ID != p.ID {
+					related[other.ID] = true
+				}
+			}
+		}
+		for _, owasp := range p.OWASPs() {
+			for _, other := range idx.byOWASP[owasp] {
+				if other.ID != p.ID {
+					related[other.ID] = true
+				}
+			}
+		}
+
+		if len(related) == 0 {
+			p.RelatedByReference = nil
+			continue
+		}
+		ids := make([]string, 0, len(related))
+		for id := range related {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		p.RelatedByReference = ids
+	}
 }
 
 // GetByID returns a pattern by its ID
@@ -105,6 +273,63 @@ func (idx *Index) GetByLanguage(language string) []*ThreatPattern {
 	return idx.byLanguage[strings.ToLower(language)]
 }
 
+// langFrameworkKey builds the combined lookup key used by byLangFramework.
+func langFrameworkKey(language, framework string) string {
+	return strings.ToLower(language) + "|" + strings.ToLower(framework)
+}
+
+// GetByLanguageFramework returns all patterns matching both language and
+// framework exactly (case-insensitively). Unlike GetByFramework/GetByLanguage,
+// this doesn't fall back to "any" on either side - callers that want that
+// fallback should query GetByFramework(framework) separately.
+func (idx *Index) GetByLanguageFramework(language, framework string) []*ThreatPattern {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return idx.byLangFramework[langFrameworkKey(language, framework)]
+}
+
+// GetByCWE returns all patterns referencing the given CWE identifier (e.g.
+// "CWE-79" or "cwe-79"). A malformed identifier returns nil, same as an
+// unknown one.
+func (idx *Index) GetByCWE(cwe string) []*ThreatPattern {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	id, err := ParseCWE(cwe)
+	if err != nil {
+		return nil
+	}
+	return idx.byCWE[id]
+}
+
+// GetByOWASP returns all patterns referencing the given OWASP Top 10
+// identifier (e.g. "A03:2021" or "a03:2021"). A malformed identifier
+// returns nil, same as an unknown one.
+func (idx *Index) GetByOWASP(owasp string) []*ThreatPattern {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	id, err := ParseOWASP(owasp)
+	if err != nil {
+		return nil
+	}
+	return idx.byOWASP[id]
+}
+
+// KeywordStats returns the corpus-wide BM25 statistics computed by the
+// last Build call, for use with CalculateRelevanceBM25.
+func (idx *Index) KeywordStats() KeywordStats {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return idx.keywordStats
+}
+
+// CorpusStats returns the corpus-wide BM25F statistics computed by the
+// last Build call, for use with CalculateRelevanceBM25F.
+func (idx *Index) CorpusStats() CorpusStats {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return idx.corpusStats
+}
+
 // GetAll returns all indexed patterns
 func (idx *Index) GetAll() []ThreatPattern {
 	idx.mu.RLock()
@@ -119,23 +344,88 @@ func (idx *Index) Count() int {
 	return len(idx.patterns)
 }
 
-// MatchContext finds patterns relevant to a given context string
-// Uses simple keyword matching; could be enhanced with fuzzy matching
+// BuildDuration returns how long the last Build call took to index the
+// corpus, for callers (e.g. tmkb_stats) that want to surface index health
+// without re-timing Build themselves.
+func (idx *Index) BuildDuration() time.Duration {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return idx.buildDuration
+}
+
+// SetLoadWarnings installs the non-fatal warnings produced while loading
+// the patterns this Index was Build from - currently just MultiLoader's
+// duplicate-ID messages - so a caller that only has access to the Index
+// (not the loader that built it) can still surface them.
+func (idx *Index) SetLoadWarnings(warnings []string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.loadWarnings = warnings
+}
+
+// LoadWarnings returns the warnings installed by SetLoadWarnings, if any.
+func (idx *Index) LoadWarnings() []string {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return idx.loadWarnings
+}
+
+// MatchContext finds patterns relevant to a given context string, scored
+// and ordered by BM25F relevance (see CalculateRelevanceBM25F) over each
+// pattern's Keywords/Actions/AgentSummary/Name/Description document rather
+// than arbitrary map-iteration order - so a context sharing no literal
+// substring with a pattern's keywords can still surface it on bag-of-words
+// term overlap, and --limit cuts off the weakest matches instead of a
+// random subset. A context with no scoreable tokens (e.g. punctuation
+// only) falls back to the original literal-keyword substring check, since
+// BM25 has nothing to score. Action triggers and compiled glob/regex
+// Triggers.Keywords entries are author-specified precise signals
+// independent of bag-of-words overlap, so they're checked unconditionally
+// on top of whichever path above ran.
 func (idx *Index) MatchContext(context string) []*ThreatPattern {
+	return idx.MatchContextWithOptions(context, MatchOptions{Mode: MatchModeExact})
+}
+
+// MatchContextWithOptions is MatchContext plus opts.Mode: MatchModeStemmed
+// and MatchModeFuzzy additionally expand each of the context's query terms
+// against the corpus vocabulary (see expandQueryTerms, idx.stemIndex) before
+// BM25F scoring, so a context like "uploading files" can retrieve a pattern
+// keyed on "file upload" even though no term matches literally. Synonym
+// expansion (idx.synonyms) applies regardless of opts.Mode, including
+// MatchModeExact, since it's an author-declared equivalence rather than a
+// fuzziness trade-off. An empty opts.Mode behaves like MatchModeStemmed,
+// the default a caller gets by only setting other MatchOptions fields.
+func (idx *Index) MatchContextWithOptions(context string, opts MatchOptions) []*ThreatPattern {
 	idx.mu.RLock()
 	defer idx.mu.RUnlock()
 
+	mode := opts.Mode
+	if mode == "" {
+		mode = MatchModeStemmed
+	}
+
 	contextLower := strings.ToLower(context)
 	seen := make(map[string]bool)
-	var matches []*ThreatPattern
+	var scored []patternWithScore
 
-	// Check each keyword against the context
-	for keyword, patterns := range idx.byKeyword {
-		if strings.Contains(contextLower, keyword) {
-			for _, p := range patterns {
-				if !seen[p.ID] {
-					seen[p.ID] = true
-					matches = append(matches, p)
+	if queryTerms := matchContextKeywords(context); len(queryTerms) > 0 {
+		queryTerms = expandQueryTerms(queryTerms, mode, idx.corpusStats.DF, idx.stemIndex, idx.synonymGroups)
+		for i := range idx.patterns {
+			p := &idx.patterns[i]
+			score := CalculateRelevanceBM25F(queryTerms, *p, idx.corpusStats, defaultRankerK1, bm25B, matchContextFieldBoosts)
+			if score > 0 {
+				seen[p.ID] = true
+				scored = append(scored, patternWithScore{pattern: p, score: score})
+			}
+		}
+	} else {
+		for keyword, patterns := range idx.byKeyword {
+			if strings.Contains(contextLower, keyword) {
+				for _, p := range patterns {
+					if !seen[p.ID] {
+						seen[p.ID] = true
+						scored = append(scored, patternWithScore{pattern: p})
+					}
 				}
 			}
 		}
@@ -150,11 +440,78 @@ func (idx *Index) MatchContext(context string) []*ThreatPattern {
 		for _, action := range p.Triggers.Actions {
 			if strings.Contains(contextLower, strings.ToLower(action)) {
 				seen[p.ID] = true
-				matches = append(matches, p)
+				scored = append(scored, patternWithScore{pattern: p})
+				break
+			}
+		}
+	}
+
+	// Also check glob/regex trigger patterns, which can't be looked up by
+	// exact key the way byKeyword's literal entries are.
+	for i := range idx.patterns {
+		p := &idx.patterns[i]
+		if seen[p.ID] {
+			continue
+		}
+		for _, ct := range idx.compiledTriggers[p.ID] {
+			if ct.pattern.Literal() {
+				continue
+			}
+			if ct.pattern.MatchString(context) {
+				seen[p.ID] = true
+				scored = append(scored, patternWithScore{pattern: p})
 				break
 			}
 		}
 	}
 
+	sortByRelevance(scored)
+
+	matches := make([]*ThreatPattern, len(scored))
+	for i, s := range scored {
+		matches[i] = s.pattern
+	}
 	return matches
 }
+
+// compiledTriggersFor returns the compiled trigger patterns for a pattern
+// ID, as built by the last Build call, for use by calculateRelevancePatterns.
+func (idx *Index) compiledTriggersFor(id string) []compiledTrigger {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return idx.compiledTriggers[id]
+}
+
+// exprProgramFor returns the compiled Triggers.Expr program for a pattern
+// ID, as built by the last Build call, and whether one exists at all - a
+// pattern with no Expr, or one that failed to compile, reports ok=false so
+// Query can skip it without needing its own presence check.
+func (idx *Index) exprProgramFor(id string) (*filter.Program, bool) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	prog, ok := idx.exprPrograms[id]
+	return prog, ok
+}
+
+// MatchTriggerExpr returns patterns whose boolean Any/All trigger
+// expression matches tc, evaluated independently of MatchContext's
+// keyword/action substring matching - so a pattern with a non-empty
+// Any/All is reachable even without bag-of-words overlap. Patterns with
+// neither Any nor All set are never returned here.
+func (idx *Index) MatchTriggerExpr(tc TriggerContext) ([]*ThreatPattern, error) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	var matches []*ThreatPattern
+	for i := range idx.patterns {
+		p := &idx.patterns[i]
+		ok, err := MatchesTriggers(p.Triggers, idx.triggerRegistry, tc)
+		if err != nil {
+			return nil, fmt.Errorf("pattern %s: %w", p.ID, err)
+		}
+		if ok {
+			matches = append(matches, p)
+		}
+	}
+	return matches, nil
+}
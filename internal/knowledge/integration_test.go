@@ -24,7 +24,10 @@ func TestIntegration_AgentMode_RealPatterns(t *testing.T) {
 		Limit:     3,
 	}
 
-	result := Query(idx, opts)
+	result, err := Query(idx, opts)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
 
 	// Validate structure
 	if result.PatternCount == 0 {
@@ -39,10 +42,12 @@ func TestIntegration_AgentMode_RealPatterns(t *testing.T) {
 		t.Error("Expected token count to be calculated")
 	}
 
-	if result.TokenCount > tokenLimit {
-		if !result.TokenLimitReached {
-			t.Error("Token count exceeds limit but token_limit_reached not set")
-		}
+	// Sanity bound: a 3-pattern agent response should stay well under a
+	// typical ~500-token budget even though this query doesn't set
+	// MaxTokens (that's ResponseBuilder's job; see response_builder_test.go).
+	const sanityTokenBound = 500
+	if result.TokenCount > sanityTokenBound && !result.TokenLimitReached {
+		t.Error("Token count exceeds a reasonable bound but token_limit_reached not set")
 	}
 
 	// Validate pattern structure
@@ -96,7 +101,10 @@ func TestIntegration_VerboseMode_RealPatterns(t *testing.T) {
 		Limit:     5,
 	}
 
-	result := Query(idx, opts)
+	result, err := Query(idx, opts)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
 
 	// Validate structure
 	if result.PatternCount == 0 {
@@ -191,7 +199,11 @@ func TestIntegration_DeterministicOrdering(t *testing.T) {
 	// Run query 3 times
 	results := make([]QueryResult, 3)
 	for i := 0; i < 3; i++ {
-		results[i] = Query(idx, opts)
+		result, err := Query(idx, opts)
+		if err != nil {
+			t.Fatalf("Query failed: %v", err)
+		}
+		results[i] = result
 	}
 
 	// Validate all results are identical
@@ -226,7 +238,10 @@ func TestIntegration_DeterministicOrdering(t *testing.T) {
 		Limit:     10,
 	}
 
-	result := Query(idx, optsNoContext)
+	result, err := Query(idx, optsNoContext)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
 
 	severityOrder := map[string]int{
 		"critical": 0,
@@ -1,11 +1,15 @@
 package knowledge
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/afero"
 	"gopkg.in/yaml.v3"
 )
 
@@ -14,21 +18,32 @@ type PatternWrapper struct {
 	ThreatPattern ThreatPattern `yaml:"threat_pattern"`
 }
 
-// Loader handles loading patterns from the filesystem
+// Loader handles loading patterns from a filesystem. It operates against an
+// afero.Fs rather than the os package directly, so callers can point it at
+// an on-disk directory, an embed.FS wrapped for afero, an in-memory
+// MemMapFs for tests, or any other afero-compatible backend.
 type Loader struct {
+	fs       afero.Fs
 	basePath string
 }
 
-// NewLoader creates a new pattern loader with the given base path
+// NewLoader creates a new pattern loader that reads from the real
+// filesystem at the given base path. This is the common case; use
+// NewLoaderWithFS directly when patterns live somewhere other than disk.
 func NewLoader(basePath string) *Loader {
-	return &Loader{basePath: basePath}
+	return NewLoaderWithFS(afero.NewOsFs(), basePath)
+}
+
+// NewLoaderWithFS creates a pattern loader backed by fs, rooted at basePath.
+func NewLoaderWithFS(fs afero.Fs, basePath string) *Loader {
+	return &Loader{fs: fs, basePath: basePath}
 }
 
 // LoadAll loads all patterns from the patterns directory
 func (l *Loader) LoadAll() ([]ThreatPattern, error) {
 	var patterns []ThreatPattern
 
-	err := filepath.Walk(l.basePath, func(path string, info os.FileInfo, err error) error {
+	err := afero.Walk(l.fs, l.basePath, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
@@ -43,6 +58,17 @@ func (l *Loader) LoadAll() ([]ThreatPattern, error) {
 			return nil
 		}
 
+		// _triggers.yaml holds the KB's shared trigger registry, not a
+		// pattern - see LoadTriggerRegistry. synonyms.yaml likewise holds
+		// the KB's shared synonym groups, not a pattern - see
+		// LoadSynonyms. A *.enriched.yaml sidecar holds external metadata
+		// merged into its pattern by LoadFile, not a pattern of its own -
+		// see mergeSidecar.
+		base := filepath.Base(path)
+		if base == "_triggers.yaml" || base == "_triggers.yml" || base == "synonyms.yaml" || base == "synonyms.yml" || strings.HasSuffix(base, enrichedSidecarSuffix) {
+			return nil
+		}
+
 		pattern, err := l.LoadFile(path)
 		if err != nil {
 			return fmt.Errorf("failed to load %s: %w", path, err)
@@ -66,7 +92,7 @@ func (l *Loader) LoadFile(path string) (ThreatPattern, error) {
 		return ThreatPattern{}, err
 	}
 
-	data, err := os.ReadFile(path)
+	data, err := l.readFileConfined(path)
 	if err != nil {
 		return ThreatPattern{}, fmt.Errorf("failed to read file: %w", err)
 	}
@@ -76,9 +102,133 @@ func (l *Loader) LoadFile(path string) (ThreatPattern, error) {
 		return ThreatPattern{}, fmt.Errorf("failed to parse YAML: %w", err)
 	}
 
+	wrapper.ThreatPattern.SourceFile = path
+
+	if err := l.mergeSidecar(&wrapper.ThreatPattern); err != nil {
+		return ThreatPattern{}, err
+	}
+
 	return wrapper.ThreatPattern, nil
 }
 
+// enrichedSidecarSuffix names the sidecar file an enrichment pass (see
+// internal/enrich and `tmkb enrich`) writes inside the patterns
+// directory: patterns/<id>.enriched.yaml, merged into the pattern with
+// that ID at load time rather than hand-copied into the pattern's own
+// YAML.
+const enrichedSidecarSuffix = ".enriched.yaml"
+
+// enrichedSidecar is the top-level shape of a <id>.enriched.yaml sidecar -
+// just the ExternalRefs an enrichment pass resolved for the pattern's
+// CWE/OWASP/CVE references.
+type enrichedSidecar struct {
+	ExternalRefs []ExternalRef `yaml:"external_refs"`
+}
+
+// mergeSidecar looks for l.basePath/<p.ID>.enriched.yaml and, if present,
+// merges its ExternalRefs into p. A missing sidecar is not an error - most
+// patterns won't have been enriched yet, and `tmkb enrich` is opt-in.
+func (l *Loader) mergeSidecar(p *ThreatPattern) error {
+	path := filepath.Join(l.basePath, p.ID+enrichedSidecarSuffix)
+
+	exists, err := afero.Exists(l.fs, path)
+	if err != nil {
+		return fmt.Errorf("failed to check enrichment sidecar for %s: %w", p.ID, err)
+	}
+	if !exists {
+		return nil
+	}
+
+	data, err := afero.ReadFile(l.fs, path)
+	if err != nil {
+		return fmt.Errorf("failed to read enrichment sidecar for %s: %w", p.ID, err)
+	}
+
+	var sidecar enrichedSidecar
+	if err := yaml.Unmarshal(data, &sidecar); err != nil {
+		return fmt.Errorf("failed to parse enrichment sidecar for %s: %w", p.ID, err)
+	}
+
+	p.ExternalRefs = sidecar.ExternalRefs
+	return nil
+}
+
+// triggerRegistryFile wraps the top-level `triggers:` key of the KB's
+// optional _triggers.yaml file: the registry of named TriggerExpr
+// definitions patterns reference via trigger.pattern_ref instead of
+// repeating the same expression.
+type triggerRegistryFile struct {
+	Triggers TriggerRegistry `yaml:"triggers"`
+}
+
+// LoadTriggerRegistry loads the KB's shared named trigger definitions
+// from _triggers.yaml at the root of the patterns directory. A missing
+// file is not an error - it just means no pattern_ref targets are
+// defined, which is fine for patterns that don't use Any/All at all.
+func (l *Loader) LoadTriggerRegistry() (TriggerRegistry, error) {
+	path := filepath.Join(l.basePath, "_triggers.yaml")
+
+	exists, err := afero.Exists(l.fs, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check trigger registry: %w", err)
+	}
+	if !exists {
+		return TriggerRegistry{}, nil
+	}
+
+	data, err := afero.ReadFile(l.fs, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read trigger registry: %w", err)
+	}
+
+	var wrapper triggerRegistryFile
+	if err := yaml.Unmarshal(data, &wrapper); err != nil {
+		return nil, fmt.Errorf("failed to parse trigger registry: %w", err)
+	}
+	if wrapper.Triggers == nil {
+		wrapper.Triggers = TriggerRegistry{}
+	}
+	return wrapper.Triggers, nil
+}
+
+// synonymMapFile wraps the top-level `synonyms:` key of the KB's optional
+// synonyms.yaml file: a set of canonical-term-to-variants groups that
+// Index.MatchContextWithOptions treats as interchangeable regardless of
+// match mode.
+type synonymMapFile struct {
+	Synonyms SynonymMap `yaml:"synonyms"`
+}
+
+// LoadSynonyms loads the KB's shared synonym groups from synonyms.yaml at
+// the root of the patterns directory. A missing file is not an error - it
+// just means no synonym expansion happens, which is fine for KBs that
+// don't need it.
+func (l *Loader) LoadSynonyms() (SynonymMap, error) {
+	path := filepath.Join(l.basePath, "synonyms.yaml")
+
+	exists, err := afero.Exists(l.fs, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check synonym map: %w", err)
+	}
+	if !exists {
+		return SynonymMap{}, nil
+	}
+
+	data, err := afero.ReadFile(l.fs, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read synonym map: %w", err)
+	}
+
+	var wrapper synonymMapFile
+	if err := yaml.Unmarshal(data, &wrapper); err != nil {
+		return nil, fmt.Errorf("failed to parse synonym map: %w", err)
+	}
+	if wrapper.Synonyms == nil {
+		wrapper.Synonyms = SynonymMap{}
+	}
+	return wrapper.Synonyms, nil
+}
+
 // LoadByID loads a specific pattern by its ID
 func (l *Loader) LoadByID(id string) (ThreatPattern, error) {
 	patterns, err := l.LoadAll()
@@ -112,8 +262,142 @@ func (l *Loader) LoadByCategory(category string) ([]ThreatPattern, error) {
 	return filtered, nil
 }
 
-// validatePath ensures the given path is within the loader's basePath
-// and prevents directory traversal attacks
+// watchDebounce bounces a burst of near-simultaneous fsnotify events - an
+// editor's save-as-temp-then-rename dance touches several paths in quick
+// succession - into a single reload, instead of reloading once per event.
+const watchDebounce = 200 * time.Millisecond
+
+// Watch observes l.basePath recursively for filesystem changes and reloads
+// on every settled burst (see watchDebounce), calling onChange with a
+// freshly built *Index on success or with a nil Index and the load error
+// on failure. It blocks until ctx is cancelled, returning ctx.Err().
+//
+// Watch only supports a real, on-disk l.fs: fsnotify watches OS paths, so
+// it can't observe an in-memory afero.MemMapFs the way LoadAll can. Use
+// NewLoader (not NewLoaderWithFS with a non-OS afero.Fs) for a loader
+// that's going to be watched.
+func (l *Loader) Watch(ctx context.Context, onChange func(*Index, error)) error {
+	if _, ok := l.fs.(*afero.OsFs); !ok {
+		return fmt.Errorf("watch requires an on-disk patterns directory, got %T", l.fs)
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create filesystem watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	if err := l.watchRecursive(watcher); err != nil {
+		return err
+	}
+
+	var debounce *time.Timer
+	reload := make(chan struct{}, 1)
+	defer func() {
+		if debounce != nil {
+			debounce.Stop()
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			// A newly created subdirectory needs its own watch - fsnotify
+			// doesn't follow directory creation recursively on its own.
+			if event.Has(fsnotify.Create) {
+				if info, statErr := os.Stat(event.Name); statErr == nil && info.IsDir() {
+					_ = watcher.Add(event.Name)
+				}
+			}
+			if debounce == nil {
+				debounce = time.AfterFunc(watchDebounce, func() {
+					select {
+					case reload <- struct{}{}:
+					default:
+					}
+				})
+			} else {
+				debounce.Reset(watchDebounce)
+			}
+
+		case watchErr, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			onChange(nil, watchErr)
+
+		case <-reload:
+			l.reloadIndex(onChange)
+		}
+	}
+}
+
+// watchRecursive adds every directory under l.basePath (inclusive) to
+// watcher, since fsnotify only watches the directories it's explicitly
+// told about.
+func (l *Loader) watchRecursive(watcher *fsnotify.Watcher) error {
+	err := filepath.Walk(l.basePath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return watcher.Add(path)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to watch %s: %w", l.basePath, err)
+	}
+	return nil
+}
+
+// reloadIndex re-runs the same load sequence PersistentPreRunE uses at
+// startup (load TMKB_PATTERNS_PATH's sources layered over this loader's
+// directory, build an Index, load the trigger registry and synonym map)
+// and reports the result to onChange, so a watched reload produces an
+// Index equivalent to a fresh process start.
+func (l *Loader) reloadIndex(onChange func(*Index, error)) {
+	sources := append(ParsePatternsPath(os.Getenv(PatternsPathEnvVar)), NewDirSource(l))
+	patterns, warnings, err := NewMultiLoader(sources...).LoadAll()
+	if err != nil {
+		onChange(nil, err)
+		return
+	}
+
+	idx := NewIndex()
+	idx.Build(patterns)
+	idx.SetLoadWarnings(warnings)
+
+	registry, err := l.LoadTriggerRegistry()
+	if err != nil {
+		onChange(nil, err)
+		return
+	}
+	idx.SetTriggerRegistry(registry)
+
+	synonyms, err := l.LoadSynonyms()
+	if err != nil {
+		onChange(nil, err)
+		return
+	}
+	idx.SetSynonyms(synonyms)
+
+	onChange(idx, nil)
+}
+
+// validatePath ensures the given path is within the loader's basePath and
+// prevents directory traversal attacks. This is a lexical check: on Linux,
+// LoadFile's actual read additionally goes through readFileConfined's
+// openat2(RESOLVE_BENEATH), which the kernel enforces even if a symlink is
+// swapped into place after this check returns (see confine_linux.go). On
+// other platforms, the EvalSymlinks check below is the only defense against
+// a symlink inside basePath pointing outside it.
 func (l *Loader) validatePath(path string) error {
 	// Clean and resolve the paths to absolute form
 	cleanPath, err := filepath.Abs(filepath.Clean(path))
@@ -137,5 +421,35 @@ func (l *Loader) validatePath(path string) error {
 		return fmt.Errorf("path traversal detected: %s is outside base path %s", path, l.basePath)
 	}
 
+	// The check above is purely lexical, so a symlink inside basePath that
+	// points outside it would pass. Resolve symlinks and re-check
+	// containment against the resolved targets - but only for a real
+	// on-disk filesystem; a MemMapFs path (used throughout this package's
+	// tests) has no symlinks for EvalSymlinks to resolve and would just
+	// fail with "file does not exist".
+	if _, ok := l.fs.(*afero.OsFs); ok {
+		resolvedPath, err := filepath.EvalSymlinks(cleanPath)
+		if err != nil {
+			// A path that doesn't exist isn't a traversal attempt -
+			// nothing to resolve, nothing to escape; the read that
+			// follows reports the missing file.
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return fmt.Errorf("failed to resolve symlinks in %s: %w", path, err)
+		}
+		resolvedBase, err := filepath.EvalSymlinks(cleanBase)
+		if err != nil {
+			return fmt.Errorf("failed to resolve symlinks in base path %s: %w", l.basePath, err)
+		}
+		relResolved, err := filepath.Rel(resolvedBase, resolvedPath)
+		if err != nil {
+			return fmt.Errorf("failed to compute relative path: %w", err)
+		}
+		if strings.HasPrefix(relResolved, "..") || filepath.IsAbs(relResolved) {
+			return fmt.Errorf("path traversal detected: %s resolves outside base path %s", path, l.basePath)
+		}
+	}
+
 	return nil
 }
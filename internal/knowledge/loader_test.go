@@ -1,10 +1,14 @@
 package knowledge
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/spf13/afero"
 )
 
 // TestValidatePath_ValidPaths tests that valid paths within basePath are accepted
@@ -218,6 +222,48 @@ func TestValidatePath_SymlinkTraversal(t *testing.T) {
 	}
 }
 
+// TestLoadAll_MemMapFS tests that NewLoaderWithFS works against an
+// in-memory afero.Fs, with no real files touched.
+func TestLoadAll_MemMapFS(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	const baseDir = "/patterns"
+
+	content := []byte(`threat_pattern:
+  id: TMKB-MEM-001
+  name: In-Memory Pattern
+  severity: high
+  likelihood: medium
+  category: testing
+  language: go
+  framework: test
+  description: Loaded from a MemMapFs
+  agent_summary:
+    threat: Test
+    check: Test
+    fix: Test
+  triggers:
+    keywords: [test]
+  mitigations: []
+  provenance:
+    author: Test
+    date: 2026-02-06
+    public_references: []
+`)
+	if err := afero.WriteFile(fs, filepath.Join(baseDir, "mem.yaml"), content, 0644); err != nil {
+		t.Fatalf("Failed to write pattern to MemMapFs: %v", err)
+	}
+
+	loader := NewLoaderWithFS(fs, baseDir)
+	patterns, err := loader.LoadAll()
+	if err != nil {
+		t.Fatalf("LoadAll returned error: %v, want nil", err)
+	}
+
+	if len(patterns) != 1 || patterns[0].ID != "TMKB-MEM-001" {
+		t.Errorf("LoadAll loaded %v, want a single TMKB-MEM-001 pattern", patterns)
+	}
+}
+
 // TestLoadAll_SecurityIsolation tests that LoadAll respects path boundaries
 func TestLoadAll_SecurityIsolation(t *testing.T) {
 	// Create temporary directory structure
@@ -310,3 +356,341 @@ func TestLoadAll_SecurityIsolation(t *testing.T) {
 		}
 	}
 }
+
+// TestWatch_RejectsNonOSFilesystem verifies Watch refuses to run against
+// an afero.Fs it can't hand to fsnotify, e.g. the MemMapFs tests use
+// elsewhere in this file, instead of silently watching nothing.
+func TestWatch_RejectsNonOSFilesystem(t *testing.T) {
+	loader := NewLoaderWithFS(afero.NewMemMapFs(), "/patterns")
+
+	err := loader.Watch(context.Background(), func(*Index, error) {})
+	if err == nil {
+		t.Fatal("expected Watch to return an error for a non-OS filesystem")
+	}
+}
+
+// TestWatch_ReloadsOnFileChange verifies that writing a new pattern file
+// into a watched directory triggers a debounced reload carrying the new
+// pattern, and that Watch returns ctx.Err() once its context is cancelled.
+func TestWatch_ReloadsOnFileChange(t *testing.T) {
+	baseDir := t.TempDir()
+	loader := NewLoader(baseDir)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	reloaded := make(chan *Index, 1)
+	watchErr := make(chan error, 1)
+
+	go func() {
+		watchErr <- loader.Watch(ctx, func(idx *Index, err error) {
+			if err != nil {
+				t.Logf("reload error: %v", err)
+				return
+			}
+			select {
+			case reloaded <- idx:
+			default:
+			}
+		})
+	}()
+
+	// Give the watcher time to add its initial watch before the write.
+	time.Sleep(50 * time.Millisecond)
+
+	patternContent := []byte(`threat_pattern:
+  id: TMKB-WATCH-001
+  name: Watched Pattern
+  severity: high
+  likelihood: medium
+  category: testing
+  language: go
+  framework: test
+  description: Picked up by Watch
+  agent_summary:
+    threat: Test
+    check: Test
+    fix: Test
+  triggers:
+    keywords: [test]
+  mitigations: []
+  provenance:
+    author: Test
+    date: 2026-02-06
+    public_references: []
+`)
+	if err := os.WriteFile(filepath.Join(baseDir, "watched.yaml"), patternContent, 0644); err != nil {
+		t.Fatalf("failed to write pattern: %v", err)
+	}
+
+	select {
+	case idx := <-reloaded:
+		if idx.GetByID("TMKB-WATCH-001") == nil {
+			t.Error("expected reloaded index to contain the newly written pattern")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for Watch to reload after a file write")
+	}
+
+	cancel()
+	if err := <-watchErr; err != ctx.Err() {
+		t.Errorf("expected Watch to return ctx.Err() after cancellation, got %v", err)
+	}
+}
+
+// TestLoadTriggerRegistry_MissingFile verifies an absent _triggers.yaml
+// isn't an error; it just means no named triggers are defined.
+func TestLoadTriggerRegistry_MissingFile(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	loader := NewLoaderWithFS(fs, "/patterns")
+
+	registry, err := loader.LoadTriggerRegistry()
+	if err != nil {
+		t.Fatalf("LoadTriggerRegistry returned error: %v, want nil", err)
+	}
+	if len(registry) != 0 {
+		t.Errorf("Expected empty registry, got %v", registry)
+	}
+}
+
+// TestLoadTriggerRegistry_ParsesNamedTriggers verifies _triggers.yaml is
+// parsed into a TriggerRegistry and skipped by LoadAll's pattern walk.
+func TestLoadTriggerRegistry_ParsesNamedTriggers(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	const baseDir = "/patterns"
+
+	registryContent := []byte(`triggers:
+  admin-write:
+    all:
+      - field: file
+        regex: "/admin/"
+      - field: action
+        keyword: post
+`)
+	if err := afero.WriteFile(fs, filepath.Join(baseDir, "_triggers.yaml"), registryContent, 0644); err != nil {
+		t.Fatalf("Failed to write trigger registry: %v", err)
+	}
+
+	patternContent := []byte(`threat_pattern:
+  id: TMKB-MEM-002
+  name: Pattern Referencing a Named Trigger
+  severity: high
+  likelihood: medium
+  category: testing
+  language: go
+  framework: test
+  description: Uses pattern_ref
+  agent_summary:
+    threat: Test
+    check: Test
+    fix: Test
+  triggers:
+    any:
+      - pattern_ref: admin-write
+  mitigations: []
+  provenance:
+    author: Test
+    date: 2026-02-06
+    public_references: []
+`)
+	if err := afero.WriteFile(fs, filepath.Join(baseDir, "mem.yaml"), patternContent, 0644); err != nil {
+		t.Fatalf("Failed to write pattern: %v", err)
+	}
+
+	loader := NewLoaderWithFS(fs, baseDir)
+
+	registry, err := loader.LoadTriggerRegistry()
+	if err != nil {
+		t.Fatalf("LoadTriggerRegistry returned error: %v", err)
+	}
+	if _, ok := registry["admin-write"]; !ok {
+		t.Fatalf("Expected registry to contain 'admin-write', got %v", registry)
+	}
+
+	patterns, err := loader.LoadAll()
+	if err != nil {
+		t.Fatalf("LoadAll returned error: %v", err)
+	}
+	if len(patterns) != 1 || patterns[0].ID != "TMKB-MEM-002" {
+		t.Errorf("LoadAll loaded %v, want a single TMKB-MEM-002 pattern (registry file must be skipped)", patterns)
+	}
+
+	ok, err := MatchesTriggers(patterns[0].Triggers, registry, TriggerContext{
+		Actions: []string{"POST /admin/users"},
+		Files:   []string{"routes/admin/users.go"},
+	})
+	if err != nil {
+		t.Fatalf("MatchesTriggers returned error: %v", err)
+	}
+	if !ok {
+		t.Error("expected pattern_ref-based trigger to match")
+	}
+}
+
+// TestLoadSynonyms_MissingFile verifies an absent synonyms.yaml isn't an
+// error; it just means no synonym expansion is defined.
+func TestLoadSynonyms_MissingFile(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	loader := NewLoaderWithFS(fs, "/patterns")
+
+	synonyms, err := loader.LoadSynonyms()
+	if err != nil {
+		t.Fatalf("LoadSynonyms returned error: %v, want nil", err)
+	}
+	if len(synonyms) != 0 {
+		t.Errorf("Expected empty synonym map, got %v", synonyms)
+	}
+}
+
+// TestLoadSynonyms_ParsesGroupsAndSkipsPatternWalk verifies synonyms.yaml
+// is parsed into a SynonymMap and skipped by LoadAll's pattern walk.
+func TestLoadSynonyms_ParsesGroupsAndSkipsPatternWalk(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	const baseDir = "/patterns"
+
+	synonymsContent := []byte(`synonyms:
+  auth:
+    - authn
+    - authentication
+    - login
+`)
+	if err := afero.WriteFile(fs, filepath.Join(baseDir, "synonyms.yaml"), synonymsContent, 0644); err != nil {
+		t.Fatalf("Failed to write synonym map: %v", err)
+	}
+
+	patternContent := []byte(`threat_pattern:
+  id: TMKB-MEM-003
+  name: Pattern Alongside a Synonym Map
+  severity: high
+  likelihood: medium
+  category: testing
+  language: go
+  framework: test
+  description: Test
+  agent_summary:
+    threat: Test
+    check: Test
+    fix: Test
+  triggers:
+    keywords: ["authn"]
+  mitigations: []
+  provenance:
+    author: Test
+    date: 2026-02-06
+    public_references: []
+`)
+	if err := afero.WriteFile(fs, filepath.Join(baseDir, "mem.yaml"), patternContent, 0644); err != nil {
+		t.Fatalf("Failed to write pattern: %v", err)
+	}
+
+	loader := NewLoaderWithFS(fs, baseDir)
+
+	synonyms, err := loader.LoadSynonyms()
+	if err != nil {
+		t.Fatalf("LoadSynonyms returned error: %v", err)
+	}
+	if got := synonyms["auth"]; len(got) != 3 {
+		t.Fatalf("Expected 'auth' to map to 3 synonyms, got %v", got)
+	}
+
+	patterns, err := loader.LoadAll()
+	if err != nil {
+		t.Fatalf("LoadAll returned error: %v", err)
+	}
+	if len(patterns) != 1 || patterns[0].ID != "TMKB-MEM-003" {
+		t.Errorf("LoadAll loaded %v, want a single TMKB-MEM-003 pattern (synonym map file must be skipped)", patterns)
+	}
+}
+
+// TestLoadFile_MergesEnrichedSidecar verifies a patterns/<id>.enriched.yaml
+// sidecar's ExternalRefs are merged into the pattern with that ID, and
+// that the sidecar itself is skipped by LoadAll's pattern walk.
+func TestLoadFile_MergesEnrichedSidecar(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	const baseDir = "/patterns"
+
+	content := []byte(`threat_pattern:
+  id: TMKB-MEM-003
+  name: Pattern With Enrichment
+  severity: high
+  likelihood: medium
+  category: testing
+  language: go
+  framework: test
+  description: Loaded alongside an enriched sidecar
+  agent_summary:
+    threat: Test
+    check: Test
+    fix: Test
+  triggers:
+    keywords: [test]
+  mitigations: []
+  provenance:
+    author: Test
+    date: 2026-02-06
+    public_references: []
+`)
+	if err := afero.WriteFile(fs, filepath.Join(baseDir, "mem.yaml"), content, 0644); err != nil {
+		t.Fatalf("Failed to write pattern: %v", err)
+	}
+
+	sidecar := []byte(`external_refs:
+  - id: CWE-862
+    title: Missing Authorization
+    url: https://cwe.mitre.org/data/definitions/862.html
+`)
+	if err := afero.WriteFile(fs, filepath.Join(baseDir, "TMKB-MEM-003.enriched.yaml"), sidecar, 0644); err != nil {
+		t.Fatalf("Failed to write sidecar: %v", err)
+	}
+
+	loader := NewLoaderWithFS(fs, baseDir)
+	patterns, err := loader.LoadAll()
+	if err != nil {
+		t.Fatalf("LoadAll returned error: %v", err)
+	}
+	if len(patterns) != 1 || patterns[0].ID != "TMKB-MEM-003" {
+		t.Errorf("LoadAll loaded %v, want a single TMKB-MEM-003 pattern (sidecar must be skipped)", patterns)
+	}
+	if len(patterns[0].ExternalRefs) != 1 || patterns[0].ExternalRefs[0].ID != "CWE-862" {
+		t.Errorf("expected ExternalRefs merged from sidecar, got %v", patterns[0].ExternalRefs)
+	}
+}
+
+// TestLoadFile_MissingSidecarIsNotAnError verifies a pattern with no
+// enrichment sidecar loads normally with an empty ExternalRefs.
+func TestLoadFile_MissingSidecarIsNotAnError(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	const baseDir = "/patterns"
+
+	content := []byte(`threat_pattern:
+  id: TMKB-MEM-004
+  name: Pattern Without Enrichment
+  severity: high
+  likelihood: medium
+  category: testing
+  language: go
+  framework: test
+  description: Loaded with no sidecar present
+  agent_summary:
+    threat: Test
+    check: Test
+    fix: Test
+  triggers:
+    keywords: [test]
+  mitigations: []
+  provenance:
+    author: Test
+    date: 2026-02-06
+    public_references: []
+`)
+	if err := afero.WriteFile(fs, filepath.Join(baseDir, "mem.yaml"), content, 0644); err != nil {
+		t.Fatalf("Failed to write pattern: %v", err)
+	}
+
+	loader := NewLoaderWithFS(fs, baseDir)
+	pattern, err := loader.LoadFile(filepath.Join(baseDir, "mem.yaml"))
+	if err != nil {
+		t.Fatalf("LoadFile returned error: %v, want nil", err)
+	}
+	if len(pattern.ExternalRefs) != 0 {
+		t.Errorf("expected no ExternalRefs without a sidecar, got %v", pattern.ExternalRefs)
+	}
+}
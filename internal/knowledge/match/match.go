@@ -0,0 +1,132 @@
+// Package match implements a small pattern-matching language for trigger
+// keywords, mirroring the flavor of matcher Go's testing.MatchString uses:
+// literal text matches itself, `*` matches zero-or-more non-separator
+// characters, `**` matches across separators, `^`/`$` anchor to the start/
+// end of the matched string, `a|b` alternates between branches, and an
+// opt-in `re:` prefix falls through to the full regexp syntax for patterns
+// the glob subset can't express (character classes, quantifiers, and so
+// on). Every Pattern matches case-insensitively, consistent with the
+// case-insensitive keyword matching it replaces.
+package match
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Pattern is a compiled trigger pattern, ready to test strings against.
+type Pattern struct {
+	raw     string
+	re      *regexp.Regexp
+	literal bool
+}
+
+// Compile parses pattern and compiles it into a Pattern. A pattern with
+// none of the glob metacharacters (*, |, ^, $) and no re: prefix compiles
+// to a literal match, identical to the plain substring/equality matching
+// trigger keywords used before this package existed.
+func Compile(pattern string) (*Pattern, error) {
+	if pattern == "" {
+		return nil, fmt.Errorf("match: empty pattern")
+	}
+
+	if source, ok := strings.CutPrefix(pattern, "re:"); ok {
+		if source == "" {
+			return nil, fmt.Errorf("match: empty regex after re: prefix in %q", pattern)
+		}
+		re, err := compileCached("(?i)" + source)
+		if err != nil {
+			return nil, fmt.Errorf("match: invalid regex in %q: %w", pattern, err)
+		}
+		return &Pattern{raw: pattern, re: re, literal: false}, nil
+	}
+
+	if !hasMeta(pattern) {
+		re, err := compileCached("(?i)" + regexp.QuoteMeta(pattern))
+		if err != nil {
+			return nil, fmt.Errorf("match: %q: %w", pattern, err)
+		}
+		return &Pattern{raw: pattern, re: re, literal: true}, nil
+	}
+
+	branches := strings.Split(pattern, "|")
+	translated := make([]string, len(branches))
+	for i, b := range branches {
+		translated[i] = translateGlob(b)
+	}
+	re, err := compileCached("(?i)" + strings.Join(translated, "|"))
+	if err != nil {
+		return nil, fmt.Errorf("match: invalid pattern %q: %w", pattern, err)
+	}
+	return &Pattern{raw: pattern, re: re, literal: false}, nil
+}
+
+// MatchString reports whether s contains a match for p, anchored only
+// where the pattern itself uses ^/$ - otherwise, like the substring
+// matching it replaces, a hit anywhere in s counts as a match.
+func (p *Pattern) MatchString(s string) bool {
+	return p.re.MatchString(s)
+}
+
+// Literal reports whether p is a plain literal with no glob/regex
+// metacharacters - the backward-compatible case callers may want to treat
+// differently from an actual glob/regex hit (e.g. to avoid double-counting
+// a keyword already scored by exact overlap).
+func (p *Pattern) Literal() bool {
+	return p.literal
+}
+
+// String returns the pattern's original, uncompiled source text.
+func (p *Pattern) String() string {
+	return p.raw
+}
+
+// hasMeta reports whether pattern uses any glob metacharacter and so needs
+// translating rather than matching literally.
+func hasMeta(pattern string) bool {
+	return strings.ContainsAny(pattern, "*|^$")
+}
+
+// translateGlob converts one alternation branch of the glob language into
+// a regexp source fragment: ** becomes .* (crosses separators), a lone *
+// becomes [^/]* (stops at a separator), ^/$ at the branch's edges become
+// anchors, and everything else is escaped so it matches literally.
+func translateGlob(branch string) string {
+	var sb strings.Builder
+	for i := 0; i < len(branch); i++ {
+		switch {
+		case strings.HasPrefix(branch[i:], "**"):
+			sb.WriteString(".*")
+			i++ // the loop's i++ consumes the second '*'
+		case branch[i] == '*':
+			sb.WriteString("[^/]*")
+		case branch[i] == '^' && i == 0:
+			sb.WriteByte('^')
+		case branch[i] == '$' && i == len(branch)-1:
+			sb.WriteByte('$')
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(branch[i])))
+		}
+	}
+	return sb.String()
+}
+
+// regexCache holds every regexp.Regexp this package has compiled, keyed by
+// its final (?i)-prefixed source, so repeated Compile calls for the same
+// pattern - and re: patterns shared across many ThreatPatterns - don't pay
+// to recompile it.
+var regexCache sync.Map // map[string]*regexp.Regexp
+
+func compileCached(source string) (*regexp.Regexp, error) {
+	if v, ok := regexCache.Load(source); ok {
+		return v.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(source)
+	if err != nil {
+		return nil, err
+	}
+	regexCache.Store(source, re)
+	return re, nil
+}
@@ -0,0 +1,102 @@
+package match
+
+import "testing"
+
+func TestCompile_Literal(t *testing.T) {
+	p, err := Compile("celery.beat")
+	if err != nil {
+		t.Fatalf("Compile: %v", err)
+	}
+	if !p.Literal() {
+		t.Error("expected a plain string to compile as literal")
+	}
+	if !p.MatchString("a celery.beat worker") {
+		t.Error("expected literal pattern to match as a substring")
+	}
+	if !p.MatchString("A CELERY.BEAT worker") {
+		t.Error("expected literal pattern to match case-insensitively")
+	}
+	if p.MatchString("unrelated text") {
+		t.Error("did not expect literal pattern to match unrelated text")
+	}
+}
+
+func TestCompile_Glob(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		match   string
+		want    bool
+	}{
+		{"star stops at separator", "jwt.*", "jwt.decode", true},
+		{"star does not cross separator", "api/*/users", "api/v1/admin/users", false},
+		{"double star crosses separator", "api/**/users", "api/v1/admin/users", true},
+		{"anchor start", "^admin", "admin_panel", true},
+		{"anchor start mismatch", "^admin", "super_admin", false},
+		{"anchor end", "beat$", "celery.beat", true},
+		{"anchor end mismatch", "beat$", "beatbox", false},
+		{"alternation first branch", "jwt|saml", "jwt token", true},
+		{"alternation second branch", "jwt|saml", "saml assertion", true},
+		{"alternation miss", "jwt|saml", "oauth token", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := Compile(tt.pattern)
+			if err != nil {
+				t.Fatalf("Compile(%q): %v", tt.pattern, err)
+			}
+			if p.Literal() {
+				t.Errorf("expected %q to compile as a glob, not literal", tt.pattern)
+			}
+			if got := p.MatchString(tt.match); got != tt.want {
+				t.Errorf("Compile(%q).MatchString(%q) = %v, want %v", tt.pattern, tt.match, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompile_RegexPrefix(t *testing.T) {
+	p, err := Compile("re:auth[_-]?z")
+	if err != nil {
+		t.Fatalf("Compile: %v", err)
+	}
+	if p.Literal() {
+		t.Error("expected a re: pattern to not be literal")
+	}
+	if !p.MatchString("check authz before continuing") {
+		t.Error("expected re: pattern to match authz")
+	}
+	if !p.MatchString("check auth_z before continuing") {
+		t.Error("expected re: pattern to match auth_z")
+	}
+	if p.MatchString("check auth before continuing") {
+		t.Error("did not expect re: pattern to match bare auth")
+	}
+}
+
+func TestCompile_Errors(t *testing.T) {
+	if _, err := Compile(""); err == nil {
+		t.Error("expected error for empty pattern")
+	}
+	if _, err := Compile("re:"); err == nil {
+		t.Error("expected error for empty regex after re: prefix")
+	}
+	if _, err := Compile("re:("); err == nil {
+		t.Error("expected error for invalid regex")
+	}
+}
+
+func TestCompile_CachesCompiledRegex(t *testing.T) {
+	p1, err := Compile("jwt.*")
+	if err != nil {
+		t.Fatalf("Compile: %v", err)
+	}
+	p2, err := Compile("jwt.*")
+	if err != nil {
+		t.Fatalf("Compile: %v", err)
+	}
+	if p1.re != p2.re {
+		t.Error("expected identical patterns to share a cached *regexp.Regexp")
+	}
+}
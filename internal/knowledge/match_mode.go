@@ -0,0 +1,109 @@
+package knowledge
+
+import "strings"
+
+// MatchMode selects how aggressively MatchContextWithOptions expands a
+// query's tokens before testing them against the index, trading a little
+// retrieval precision for recall against natural-language phrasing.
+type MatchMode string
+
+const (
+	// MatchModeExact is MatchContext's original behavior: query tokens are
+	// matched against the corpus as-is, no stemming or fuzzy fallback.
+	MatchModeExact MatchMode = "exact"
+
+	// MatchModeStemmed additionally matches a query token against any
+	// corpus vocabulary entry that stems to the same root (see stem.go),
+	// so "uploading files" reaches a pattern keyed on "file upload".
+	MatchModeStemmed MatchMode = "stemmed"
+
+	// MatchModeFuzzy is MatchModeStemmed plus a Damerau-Levenshtein
+	// fallback (see fuzzy.go) for query tokens that still have no exact
+	// or stemmed match, catching typos and near-misses.
+	MatchModeFuzzy MatchMode = "fuzzy"
+)
+
+// MatchOptions configures MatchContextWithOptions. The zero value behaves
+// like MatchModeStemmed, the request's "backward-compatible enough"
+// default - MatchContext itself stays pinned to MatchModeExact for callers
+// that need today's exact behavior unchanged.
+type MatchOptions struct {
+	Mode MatchMode
+}
+
+// SynonymMap holds a KB's optional synonym groups, keyed by canonical term
+// (e.g. "auth": ["authn", "authentication", "login"]), loaded from
+// synonyms.yaml by Loader.LoadSynonyms and installed via
+// Index.SetSynonyms.
+type SynonymMap map[string][]string
+
+// buildSynonymGroups expands syn into a lookup from every term that appears
+// anywhere in a group (the key or any of its values) to every other term
+// in that same group, so expandQueryTerms can treat "auth", "authn",
+// "authentication", and "login" as interchangeable regardless of which one
+// a query or a pattern's keywords happen to use.
+func buildSynonymGroups(syn SynonymMap) map[string][]string {
+	groups := make(map[string][]string, len(syn))
+	for key, values := range syn {
+		all := append([]string{key}, values...)
+		for _, term := range all {
+			term = strings.ToLower(term)
+			for _, other := range all {
+				other = strings.ToLower(other)
+				if other == term {
+					continue
+				}
+				groups[term] = append(groups[term], other)
+			}
+		}
+	}
+	return groups
+}
+
+// expandQueryTerms returns terms plus whatever stemming, fuzzy matching, and
+// synonym expansion add for mode, deduplicated. vocabulary is the corpus's
+// known term set (idx.corpusStats.DF's keys) and stemIndex maps a stemmed
+// root to the vocabulary terms that share it - both precomputed once in
+// Index.Build rather than recomputed per query.
+func expandQueryTerms(terms []string, mode MatchMode, vocabulary map[string]int, stemIndex map[string][]string, synonyms map[string][]string) []string {
+	seen := make(map[string]bool, len(terms))
+	expanded := make([]string, 0, len(terms))
+	add := func(t string) {
+		if t == "" || seen[t] {
+			return
+		}
+		seen[t] = true
+		expanded = append(expanded, t)
+	}
+
+	for _, term := range terms {
+		add(term)
+		for _, syn := range synonyms[term] {
+			add(syn)
+		}
+
+		if mode == MatchModeExact {
+			continue
+		}
+
+		_, exact := vocabulary[term]
+		stemMatched := false
+		for _, vocab := range stemIndex[stemPhrase(term)] {
+			stemMatched = true
+			add(vocab)
+		}
+
+		if mode != MatchModeFuzzy || exact || stemMatched {
+			continue
+		}
+
+		threshold := fuzzyThreshold(term)
+		for vocab := range vocabulary {
+			if damerauLevenshtein(term, vocab) <= threshold {
+				add(vocab)
+			}
+		}
+	}
+
+	return expanded
+}
@@ -0,0 +1,159 @@
+// Package matcher implements a structured boolean query tree for
+// filtering patterns by field - a JSON-native alternative to the filter
+// package's string expression syntax, for callers (like MCP clients) that
+// want to build or transmit a query as a JSON object rather than compose
+// expr syntax by hand.
+//
+// Like filter, Matcher evaluates against a generic map[string]interface{}
+// rather than a concrete pattern type, matching the shape patterns take
+// once marshaled to JSON. This keeps matcher free of a dependency on
+// internal/knowledge, which in turn depends on matcher to implement
+// structured queries.
+package matcher
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Operator names the comparison a leaf Matcher applies between the field
+// named by Selector and Value.
+type Operator string
+
+const (
+	OpEq       Operator = "eq"
+	OpNeq      Operator = "neq"
+	OpMatches  Operator = "matches"
+	OpContains Operator = "contains"
+	OpIn       Operator = "in"
+	OpGte      Operator = "gte"
+	OpLte      Operator = "lte"
+)
+
+// Matcher is one node of a structured boolean query tree. Any and All
+// nest sub-matchers as a logical OR/AND; Selector together with Operator
+// and Value describes a leaf condition instead, naming a pattern field by
+// its dotted JSON path (e.g. "severity", "triggers.keywords",
+// "agent_summary.threat"). Exactly one of Selector or Any/All is expected
+// to be set on a given node - a flat tagged-union shape mirroring
+// knowledge.TriggerExpr's Any/All/Keyword/Regex convention, so the JSON
+// encoding falls out of the struct tags with no custom (Un)MarshalJSON
+// needed: {"any":[{"selector":"severity","operator":"in","value":["critical","high"]}]}.
+//
+// A Matcher with neither Selector nor any Any/All entries (the zero
+// value) evaluates to true, matching every pattern - the same "no filter"
+// default as an empty Filter string.
+type Matcher struct {
+	Selector string      `json:"selector,omitempty"`
+	Operator Operator    `json:"operator,omitempty"`
+	Value    interface{} `json:"value,omitempty"`
+
+	Any []Matcher `json:"any,omitempty"`
+	All []Matcher `json:"all,omitempty"`
+}
+
+// NewAll builds an All (logical AND) node from its sub-matchers.
+func NewAll(matchers ...Matcher) Matcher {
+	return Matcher{All: matchers}
+}
+
+// NewAny builds an Any (logical OR) node from its sub-matchers.
+func NewAny(matchers ...Matcher) Matcher {
+	return Matcher{Any: matchers}
+}
+
+// Match builds a leaf Matcher comparing selector to value via op.
+func Match(selector string, op Operator, value interface{}) Matcher {
+	return Matcher{Selector: selector, Operator: op, Value: value}
+}
+
+// IsZero reports whether m is the empty Matcher{}, which Eval treats as
+// "no condition" rather than a leaf with an empty selector.
+func (m Matcher) IsZero() bool {
+	return m.Selector == "" && m.Operator == "" && m.Value == nil && len(m.Any) == 0 && len(m.All) == 0
+}
+
+// Eval walks m against doc, a pattern marshaled to its JSON shape (see
+// filter.Program.Match for the same convention), short-circuiting Any/All
+// evaluation where possible.
+func (m Matcher) Eval(doc map[string]interface{}) (bool, error) {
+	switch {
+	case m.IsZero():
+		return true, nil
+	case len(m.All) > 0:
+		for _, child := range m.All {
+			ok, err := child.Eval(doc)
+			if err != nil {
+				return false, err
+			}
+			if !ok {
+				return false, nil
+			}
+		}
+		return true, nil
+	case len(m.Any) > 0:
+		for _, child := range m.Any {
+			ok, err := child.Eval(doc)
+			if err != nil {
+				return false, err
+			}
+			if ok {
+				return true, nil
+			}
+		}
+		return false, nil
+	default:
+		return m.evalLeaf(doc)
+	}
+}
+
+// evalLeaf evaluates a Selector/Operator/Value leaf node against doc. A
+// selector that resolves to nothing never matches eq/contains/in/matches/
+// gte/lte, but does satisfy neq - absence counts as "not equal".
+func (m Matcher) evalLeaf(doc map[string]interface{}) (bool, error) {
+	if m.Selector == "" {
+		return false, fmt.Errorf("matcher: leaf node missing selector")
+	}
+
+	resolved, ok := resolve(doc, m.Selector)
+	switch m.Operator {
+	case OpEq:
+		return ok && equalValues(resolved, m.Value), nil
+	case OpNeq:
+		return !ok || !equalValues(resolved, m.Value), nil
+	case OpIn:
+		return ok && inValue(resolved, m.Value), nil
+	case OpContains:
+		return ok && containsValue(resolved, m.Value), nil
+	case OpMatches:
+		if !ok {
+			return false, nil
+		}
+		return matchesValue(resolved, m.Value)
+	case OpGte, OpLte:
+		if !ok {
+			return false, nil
+		}
+		return compareValues(resolved, m.Value, m.Operator)
+	default:
+		return false, fmt.Errorf("matcher: unknown operator %q", m.Operator)
+	}
+}
+
+// resolve looks up a dotted selector path (e.g. "triggers.keywords")
+// against doc, a pattern's marshaled JSON form.
+func resolve(doc map[string]interface{}, selector string) (interface{}, bool) {
+	var cur interface{} = doc
+	for _, part := range strings.Split(selector, ".") {
+		m, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		v, ok := m[part]
+		if !ok {
+			return nil, false
+		}
+		cur = v
+	}
+	return cur, true
+}
@@ -0,0 +1,119 @@
+package matcher
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func testDoc() map[string]interface{} {
+	return map[string]interface{}{
+		"id":        "TMKB-AUTHZ-002",
+		"severity":  "critical",
+		"language":  "python",
+		"framework": "flask",
+		"category":  "authorization",
+		"tier":      "A",
+		"triggers": map[string]interface{}{
+			"keywords": []interface{}{"celery", "background job"},
+		},
+		"agent_summary": map[string]interface{}{
+			"threat": "Missing tenant scoping on a background job queue",
+		},
+	}
+}
+
+func TestMatcher_Eval(t *testing.T) {
+	tests := []struct {
+		name string
+		m    Matcher
+		want bool
+	}{
+		{"eq match", Match("severity", OpEq, "critical"), true},
+		{"eq mismatch", Match("severity", OpEq, "low"), false},
+		{"neq match", Match("severity", OpNeq, "low"), true},
+		{"neq on missing selector", Match("nonexistent.field", OpNeq, "low"), true},
+		{"in match", Match("severity", OpIn, []interface{}{"critical", "high"}), true},
+		{"in mismatch", Match("severity", OpIn, []interface{}{"low", "medium"}), false},
+		{"contains on list", Match("triggers.keywords", OpContains, "celery"), true},
+		{"contains miss on list", Match("triggers.keywords", OpContains, "rabbitmq"), false},
+		{"contains on string", Match("agent_summary.threat", OpContains, "tenant"), true},
+		{"matches regex", Match("agent_summary.threat", OpMatches, "(?i)background job"), true},
+		{"matches regex miss", Match("agent_summary.threat", OpMatches, "^nope$"), false},
+		{"gte string fallback", Match("tier", OpGte, "A"), true},
+		{"lte string fallback", Match("tier", OpLte, "Z"), true},
+		{
+			"any", NewAny(
+				Match("triggers.keywords", OpContains, "rabbitmq"),
+				NewAll(
+					Match("category", OpEq, "authorization"),
+					Match("severity", OpIn, []interface{}{"critical", "high"}),
+				),
+			), true,
+		},
+		{
+			"all short-circuits on first false", NewAll(
+				Match("severity", OpEq, "low"),
+				Match("category", OpEq, "authorization"),
+			), false,
+		},
+		{"zero value matches everything", Matcher{}, true},
+	}
+
+	doc := testDoc()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.m.Eval(doc)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Eval() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatcher_EvalErrors(t *testing.T) {
+	doc := testDoc()
+
+	if _, err := (Matcher{Operator: OpEq, Value: "x"}).Eval(doc); err == nil {
+		t.Error("expected error for leaf node missing selector")
+	}
+	if _, err := Match("severity", "bogus", "critical").Eval(doc); err == nil {
+		t.Error("expected error for unknown operator")
+	}
+	if _, err := Match("severity", OpMatches, 123).Eval(doc); err == nil {
+		t.Error("expected error for non-string matches value")
+	}
+	if _, err := Match("severity", OpMatches, "(").Eval(doc); err == nil {
+		t.Error("expected error for invalid regex")
+	}
+}
+
+func TestMatcher_JSONRoundTrip(t *testing.T) {
+	m := NewAny(
+		Match("triggers.keywords", OpContains, "celery"),
+		NewAll(
+			Match("category", OpEq, "authorization"),
+			Match("severity", OpIn, []interface{}{"critical", "high"}),
+		),
+	)
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+
+	var decoded Matcher
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	got, err := decoded.Eval(testDoc())
+	if err != nil {
+		t.Fatalf("eval decoded matcher: %v", err)
+	}
+	if !got {
+		t.Error("expected decoded matcher to match testDoc")
+	}
+}
@@ -0,0 +1,101 @@
+package matcher
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// toFloat reports whether v decodes as a number, for numeric comparisons.
+// json.Unmarshal always produces float64 for a JSON number, but a
+// programmatically-built Matcher (see NewAll/Match) might hold a plain
+// int, so both are accepted.
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+// equalValues compares a and b, preferring a numeric comparison (so 1 and
+// 1.0 match) and falling back to their string representations.
+func equalValues(a, b interface{}) bool {
+	af, aok := toFloat(a)
+	bf, bok := toFloat(b)
+	if aok && bok {
+		return af == bf
+	}
+	return fmt.Sprint(a) == fmt.Sprint(b)
+}
+
+// inValue reports whether needle equals haystack, or (if haystack is a
+// slice, the shape a Matcher's Value takes for the "in" operator) whether
+// needle equals any element of it.
+func inValue(needle, haystack interface{}) bool {
+	items, ok := haystack.([]interface{})
+	if !ok {
+		return equalValues(needle, haystack)
+	}
+	for _, item := range items {
+		if equalValues(needle, item) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsValue reports whether resolved contains value: substring
+// containment for a string field (e.g. agent_summary.threat), element
+// containment for a list field (e.g. triggers.keywords).
+func containsValue(resolved, value interface{}) bool {
+	switch r := resolved.(type) {
+	case []interface{}:
+		for _, item := range r {
+			if equalValues(item, value) {
+				return true
+			}
+		}
+		return false
+	case string:
+		return strings.Contains(r, fmt.Sprint(value))
+	default:
+		return false
+	}
+}
+
+// matchesValue reports whether resolved's string form matches the regular
+// expression held in value.
+func matchesValue(resolved, value interface{}) (bool, error) {
+	pattern, ok := value.(string)
+	if !ok {
+		return false, fmt.Errorf("matcher: matches operator requires a string pattern, got %T", value)
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return false, fmt.Errorf("matcher: invalid regex %q: %w", pattern, err)
+	}
+	return re.MatchString(fmt.Sprint(resolved)), nil
+}
+
+// compareValues numerically compares resolved and value via op (gte or
+// lte), falling back to a lexicographic string comparison when either
+// side isn't a number - e.g. comparing version-like strings.
+func compareValues(resolved, value interface{}, op Operator) (bool, error) {
+	rf, rok := toFloat(resolved)
+	vf, vok := toFloat(value)
+	if rok && vok {
+		if op == OpGte {
+			return rf >= vf, nil
+		}
+		return rf <= vf, nil
+	}
+
+	rs, vs := fmt.Sprint(resolved), fmt.Sprint(value)
+	if op == OpGte {
+		return rs >= vs, nil
+	}
+	return rs <= vs, nil
+}
@@ -4,6 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+
+	"github.com/mark-chris/tmkb/internal/knowledge/projection"
+	"github.com/mark-chris/tmkb/internal/knowledge/sarif"
 )
 
 // OutputFormat specifies the output format
@@ -13,20 +16,132 @@ type OutputFormat string
 const (
 	FormatJSON OutputFormat = "json"
 	FormatText OutputFormat = "text"
+	// FormatRaw renders a line-oriented, script-friendly view: one
+	// record per line, with no indentation, labels, or prose - meant to
+	// be piped into grep/awk/xargs rather than read directly.
+	FormatRaw OutputFormat = "raw"
+	// FormatSARIF renders `validate`'s report as a SARIF 2.1.0 log, for
+	// uploading to GitHub code scanning or other SARIF-consuming tooling.
+	// Unsupported by FormatOutput/FormatPatternDetail; falls back to JSON.
+	FormatSARIF OutputFormat = "sarif"
 )
 
-// FormatOutput formats a query result for display
-func FormatOutput(result QueryResult, format OutputFormat, verbose bool) (string, error) {
+// FormatOutput formats a query result for display. When fields is
+// non-empty, each selector (dotted field path, `[*]`, `[index]`, or
+// `[?(@.field op value)]`) narrows the result the same way Kubernetes
+// downward-API field selectors narrow a resource before it's handed to a
+// caller with a tight token budget.
+func FormatOutput(result QueryResult, format OutputFormat, verbose bool, fields []string) (string, error) {
+	if len(fields) > 0 {
+		projected, err := projectFields(result, fields)
+		if err != nil {
+			return "", err
+		}
+		switch format {
+		case FormatText:
+			return formatProjectedText(projected), nil
+		case FormatRaw:
+			return formatProjectedRaw(projected), nil
+		default:
+			data, err := json.MarshalIndent(projected, "", "  ")
+			if err != nil {
+				return "", fmt.Errorf("failed to marshal JSON: %w", err)
+			}
+			return string(data), nil
+		}
+	}
+
 	switch format {
 	case FormatJSON:
 		return formatJSON(result)
 	case FormatText:
 		return formatText(result, verbose)
+	case FormatRaw:
+		return formatQueryRaw(result), nil
 	default:
 		return formatJSON(result)
 	}
 }
 
+// formatQueryRaw renders one pattern ID per line, the line-oriented
+// default when no --fields/--jsonpath selector narrows the result.
+func formatQueryRaw(result QueryResult) string {
+	ids := make([]string, 0, len(result.Patterns))
+	for _, p := range result.Patterns {
+		ids = append(ids, p.ID)
+	}
+	return strings.Join(ids, "\n")
+}
+
+// formatProjectedRaw renders a flattened selector match per line as its
+// bare value, with no "key: " label - the raw-mode counterpart to
+// formatProjectedText.
+func formatProjectedRaw(projected interface{}) string {
+	var sb strings.Builder
+	flattenRaw(&sb, projected)
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+func flattenRaw(sb *strings.Builder, v interface{}) {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for _, child := range val {
+			flattenRaw(sb, child)
+		}
+	case []interface{}:
+		for _, child := range val {
+			flattenRaw(sb, child)
+		}
+	default:
+		fmt.Fprintf(sb, "%v\n", val)
+	}
+}
+
+// projectFields applies fields to the JSON shape of result, returning a
+// pruned JSON object that preserves result's nested shape.
+func projectFields(result QueryResult, fields []string) (interface{}, error) {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal result: %w", err)
+	}
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal result: %w", err)
+	}
+	proj, err := projection.New(fields)
+	if err != nil {
+		return nil, err
+	}
+	return proj.Project(doc), nil
+}
+
+// formatProjectedText renders a flattened "key: value" line per selector
+// match, one per line, for text-mode consumers.
+func formatProjectedText(projected interface{}) string {
+	var sb strings.Builder
+	flattenText(&sb, "", projected)
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+func flattenText(sb *strings.Builder, prefix string, v interface{}) {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, child := range val {
+			key := k
+			if prefix != "" {
+				key = prefix + "." + k
+			}
+			flattenText(sb, key, child)
+		}
+	case []interface{}:
+		for i, child := range val {
+			flattenText(sb, fmt.Sprintf("%s[%d]", prefix, i), child)
+		}
+	default:
+		fmt.Fprintf(sb, "%s: %v\n", prefix, val)
+	}
+}
+
 func formatJSON(result QueryResult) (string, error) {
 	data, err := json.MarshalIndent(result, "", "  ")
 	if err != nil {
@@ -41,17 +156,48 @@ func formatText(result QueryResult, _ bool) (string, error) {
 	sb.WriteString(fmt.Sprintf("Found %d relevant threat pattern(s)\n", result.PatternCount))
 	sb.WriteString(strings.Repeat("=", 50) + "\n\n")
 
-	for i, p := range result.Patterns {
-		sb.WriteString(fmt.Sprintf("[%d] %s", i+1, p.ID))
-		if p.Name != "" {
-			sb.WriteString(fmt.Sprintf(": %s", p.Name))
+	if len(result.VerbosePatterns) > 0 {
+		for i, p := range result.VerbosePatterns {
+			sb.WriteString(fmt.Sprintf("[%d] %s", i+1, p.ID))
+			if p.Name != "" {
+				sb.WriteString(fmt.Sprintf(": %s", p.Name))
+			}
+			sb.WriteString(fmt.Sprintf(" (Severity: %s | Likelihood: %s)\n", p.Severity, p.Likelihood))
+			sb.WriteString(strings.Repeat("-", 40) + "\n")
+
+			sb.WriteString(fmt.Sprintf("THREAT: %s\n\n", p.Threat))
+			sb.WriteString(fmt.Sprintf("CHECK:  %s\n\n", p.Check))
+			sb.WriteString(fmt.Sprintf("FIX:    %s\n\n", p.Fix))
+
+			if p.Description != "" {
+				sb.WriteString(fmt.Sprintf("DESCRIPTION: %s\n\n", p.Description))
+			}
+
+			if len(p.Mitigations) > 0 {
+				sb.WriteString("MITIGATIONS\n")
+				for _, m := range p.Mitigations {
+					name := m.Name
+					if name == "" {
+						name = m.ID
+					}
+					sb.WriteString(fmt.Sprintf("  [%s] %s\n", m.ID, name))
+				}
+				sb.WriteString("\n")
+			}
 		}
-		sb.WriteString(fmt.Sprintf(" (Severity: %s)\n", p.Severity))
-		sb.WriteString(strings.Repeat("-", 40) + "\n")
+	} else {
+		for i, p := range result.Patterns {
+			sb.WriteString(fmt.Sprintf("[%d] %s", i+1, p.ID))
+			if p.Name != "" {
+				sb.WriteString(fmt.Sprintf(": %s", p.Name))
+			}
+			sb.WriteString(fmt.Sprintf(" (Severity: %s)\n", p.Severity))
+			sb.WriteString(strings.Repeat("-", 40) + "\n")
 
-		sb.WriteString(fmt.Sprintf("THREAT: %s\n\n", p.Threat))
-		sb.WriteString(fmt.Sprintf("CHECK:  %s\n\n", p.Check))
-		sb.WriteString(fmt.Sprintf("FIX:    %s\n\n", p.Fix))
+			sb.WriteString(fmt.Sprintf("THREAT: %s\n\n", p.Threat))
+			sb.WriteString(fmt.Sprintf("CHECK:  %s\n\n", p.Check))
+			sb.WriteString(fmt.Sprintf("FIX:    %s\n\n", p.Fix))
+		}
 	}
 
 	if result.CodePattern != nil {
@@ -67,19 +213,71 @@ func formatText(result QueryResult, _ bool) (string, error) {
 	return sb.String(), nil
 }
 
-// FormatPatternDetail formats a single pattern for detailed display
-func FormatPatternDetail(p *ThreatPattern, format OutputFormat) (string, error) {
+// FormatPatternDetail formats a single pattern for detailed display. See
+// FormatOutput for the fields selector grammar.
+func FormatPatternDetail(p *ThreatPattern, format OutputFormat, fields []string) (string, error) {
+	if len(fields) > 0 {
+		data, err := json.Marshal(p)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		var doc interface{}
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return "", fmt.Errorf("failed to unmarshal JSON: %w", err)
+		}
+		proj, err := projection.New(fields)
+		if err != nil {
+			return "", err
+		}
+		projected := proj.Project(doc)
+		switch format {
+		case FormatText:
+			return formatProjectedText(projected), nil
+		case FormatRaw:
+			return formatProjectedRaw(projected), nil
+		default:
+			out, err := json.MarshalIndent(projected, "", "  ")
+			if err != nil {
+				return "", fmt.Errorf("failed to marshal JSON: %w", err)
+			}
+			return string(out), nil
+		}
+	}
+
 	switch format {
-	case FormatJSON:
+	case FormatText:
+		return formatPatternText(p), nil
+	case FormatRaw:
+		return p.ID, nil
+	default:
 		data, err := json.MarshalIndent(p, "", "  ")
 		if err != nil {
 			return "", fmt.Errorf("failed to marshal JSON: %w", err)
 		}
 		return string(data), nil
+	}
+}
+
+// FormatValidation formats validation results for display, the
+// validate-command counterpart to FormatOutput/FormatPatternDetail. In
+// text mode every pattern is printed when verbose, otherwise only those
+// with errors or warnings; raw mode emits one greppable "PASS|FAIL id"
+// line per pattern; JSON mode emits the full ValidationReport (diagnostics
+// plus per-severity/per-code totals) so CI pipelines and tooling can
+// consume it programmatically instead of scraping the summary line; SARIF
+// mode emits a SARIF 2.1.0 log suitable for `tmkb validate --format sarif >
+// tmkb.sarif` and upload to GitHub code scanning.
+func FormatValidation(patterns []ThreatPattern, results []ValidationResult, format OutputFormat, verbose bool) (string, error) {
+	switch format {
 	case FormatText:
-		return formatPatternText(p), nil
+		return formatValidationText(results, verbose), nil
+	case FormatRaw:
+		return formatValidationRaw(results), nil
+	case FormatSARIF:
+		return formatValidationSARIF(patterns, results)
 	default:
-		data, err := json.MarshalIndent(p, "", "  ")
+		report := BuildReport(patterns, results)
+		data, err := json.MarshalIndent(report, "", "  ")
 		if err != nil {
 			return "", fmt.Errorf("failed to marshal JSON: %w", err)
 		}
@@ -87,6 +285,81 @@ func FormatPatternDetail(p *ThreatPattern, format OutputFormat) (string, error)
 	}
 }
 
+func formatValidationText(results []ValidationResult, verbose bool) string {
+	var sb strings.Builder
+
+	totalErrors, totalWarnings := 0, 0
+	for _, result := range results {
+		totalErrors += len(result.Errors)
+		totalWarnings += len(result.Warnings)
+
+		if len(result.Errors) == 0 && len(result.Warnings) == 0 && !verbose {
+			continue
+		}
+
+		status := "✓"
+		if !result.IsValid {
+			status = "✗"
+		}
+		sb.WriteString(fmt.Sprintf("%s %s\n", status, result.PatternID))
+
+		for _, err := range result.Errors {
+			sb.WriteString(fmt.Sprintf("  ERROR: %s - %s\n", err.Field, err.Message))
+		}
+		for _, warn := range result.Warnings {
+			sb.WriteString(fmt.Sprintf("  WARN:  %s - %s\n", warn.Field, warn.Message))
+		}
+		if len(result.Errors) > 0 || len(result.Warnings) > 0 {
+			sb.WriteString("\n")
+		}
+	}
+
+	sb.WriteString(fmt.Sprintf("\nValidated %d pattern(s): %d error(s), %d warning(s)\n",
+		len(results), totalErrors, totalWarnings))
+
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+func formatValidationRaw(results []ValidationResult) string {
+	lines := make([]string, 0, len(results))
+	for _, result := range results {
+		status := "PASS"
+		if !result.IsValid {
+			status = "FAIL"
+		}
+		lines = append(lines, fmt.Sprintf("%s %s", status, result.PatternID))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// formatValidationSARIF converts a validation run into a SARIF 2.1.0 log:
+// every ValidationError/Warning becomes a sarif.Finding, keyed by the stable
+// ruleID derived from its diagnostic code, with the pattern's source file as
+// its location.
+func formatValidationSARIF(patterns []ThreatPattern, results []ValidationResult) (string, error) {
+	report := BuildReport(patterns, results)
+
+	var findings []sarif.Finding
+	for _, pv := range report.Results {
+		for _, d := range pv.Diagnostics {
+			findings = append(findings, sarif.Finding{
+				RuleID:          ruleID(d.Code),
+				RuleDescription: ruleDescription(d.Code),
+				Severity:        d.Severity,
+				Message:         fmt.Sprintf("%s: %s", d.FieldPath, d.Message),
+				File:            pv.File,
+			})
+		}
+	}
+
+	log := sarif.Build("tmkb validate", "https://github.com/mark-chris/tmkb", findings)
+	data, err := json.MarshalIndent(log, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal SARIF: %w", err)
+	}
+	return string(data), nil
+}
+
 func formatPatternText(p *ThreatPattern) string {
 	var sb strings.Builder
 
@@ -0,0 +1,135 @@
+package knowledge
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/mark-chris/tmkb/internal/knowledge/filter"
+)
+
+// importPattern matches the handful of import/require spellings TMKB cares
+// about across Go, Python, and JS/TS, good enough to feed has_import - it is
+// a heuristic, not a real parser, so it favors recall over precision.
+var importPattern = regexp.MustCompile(`(?m)(?:^\s*import\s+\(?\s*"?([./\w-]+)"?|^\s*from\s+([.\w]+)\s+import|require\(\s*['"]([^'"]+)['"]\s*\))`)
+
+// extractImports pulls every import path importPattern recognizes out of a
+// query context string, in source order, deduplicated. It's a regex
+// heuristic over free-form text (the query context, not a real source
+// file), so it only catches the common single-import-per-line forms a
+// pattern author is likely to describe or paste.
+func extractImports(context string) []string {
+	matches := importPattern.FindAllStringSubmatch(context, -1)
+	if len(matches) == 0 {
+		return nil
+	}
+	seen := make(map[string]bool, len(matches))
+	var imports []string
+	for _, m := range matches {
+		for _, candidate := range m[1:] {
+			if candidate == "" || seen[candidate] {
+				continue
+			}
+			seen[candidate] = true
+			imports = append(imports, candidate)
+		}
+	}
+	return imports
+}
+
+// filenameTokens splits p into the tokens a pattern author is likely to
+// match against, e.g. "internal/auth/handler_test.go" -> ["internal",
+// "auth", "handler", "test", "go"]. An empty path yields no tokens.
+func filenameTokens(p string) []string {
+	if p == "" {
+		return nil
+	}
+	fields := strings.FieldsFunc(p, func(r rune) bool {
+		return r == '/' || r == '\\' || r == '.' || r == '_' || r == '-'
+	})
+	tokens := make([]string, 0, len(fields))
+	for _, f := range fields {
+		if f != "" {
+			tokens = append(tokens, f)
+		}
+	}
+	return tokens
+}
+
+// toInterfaceSlice adapts a []string to the []interface{} the filter
+// package's "in" operator and has_import/has_mitigation helpers expect.
+func toInterfaceSlice(ss []string) []interface{} {
+	out := make([]interface{}, len(ss))
+	for i, s := range ss {
+		out[i] = s
+	}
+	return out
+}
+
+// patternExprContextDoc builds the document a pattern's Triggers.Expr is
+// evaluated against: the query's context, language, framework, extracted
+// keywords, heuristic imports, and the target file's name split into
+// tokens. Unlike patternDoc (a marshaled ThreatPattern, used by --filter),
+// this describes the query, not the candidate pattern - Expr asks "does
+// this query look like X", not "does this pattern have field Y".
+func patternExprContextDoc(opts QueryOptions, queryKeywords []string) map[string]interface{} {
+	return map[string]interface{}{
+		"context":         opts.Context,
+		"language":        opts.Language,
+		"framework":       opts.Framework,
+		"keywords":        toInterfaceSlice(queryKeywords),
+		"imports":         toInterfaceSlice(extractImports(opts.Context)),
+		"filename":        opts.FilePath,
+		"filename_tokens": toInterfaceSlice(filenameTokens(opts.FilePath)),
+	}
+}
+
+// evalPatternExpr runs prog against doc, recovering any panic raised by the
+// filter engine (e.g. a malformed doc value tripping a type assertion deep
+// in a helper function) into an error rather than letting it escape and
+// fail the whole query - a single bad Triggers.Expr should cost that one
+// pattern a match, not every other candidate in the response.
+func evalPatternExpr(prog *filter.Program, doc map[string]interface{}) (matched bool, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			matched, err = false, fmt.Errorf("panic evaluating trigger expression: %v", r)
+		}
+	}()
+	return prog.Match(doc)
+}
+
+// patternExprProbeDoc is a representative patternExprContextDoc used to
+// sanity-check a Triggers.Expr at validate/lint time: since filter.Program
+// is dynamically typed, there's no static type checker to reject a
+// non-boolean expression ahead of time, so ValidatePatternExpr instead
+// compiles the expression and evaluates it once against this probe, which
+// exercises every field an Expr can legally reference. A genuine type
+// error (e.g. age_days(keywords)) surfaces here exactly as it would
+// against a live query.
+func patternExprProbeDoc() map[string]interface{} {
+	return patternExprContextDoc(QueryOptions{
+		Context:   "example query context",
+		Language:  "go",
+		Framework: "gin",
+		FilePath:  path.Join("internal", "auth", "handler.go"),
+	}, []string{"probe", "keyword"})
+}
+
+// ValidatePatternExpr compiles expr and evaluates it once against a probe
+// document, returning a descriptive error on a syntax error, an unknown
+// field/function, or a non-boolean result. An empty expr is valid (no
+// Triggers.Expr set).
+func ValidatePatternExpr(expr string) error {
+	if strings.TrimSpace(expr) == "" {
+		return nil
+	}
+	prog, err := filter.Compile(expr)
+	if err != nil {
+		return fmt.Errorf("compile: %w", err)
+	}
+	if _, err := prog.Match(patternExprProbeDoc()); err != nil {
+		return fmt.Errorf("evaluate: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package knowledge
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractImports(t *testing.T) {
+	tests := []struct {
+		name    string
+		context string
+		want    []string
+	}{
+		{"go import", `import "crypto/md5"`, []string{"crypto/md5"}},
+		{"python import", "import hashlib", []string{"hashlib"}},
+		{"python from-import", "from django.contrib.auth import models", []string{"django.contrib.auth"}},
+		{"js require", `const md5 = require("crypto")`, []string{"crypto"}},
+		{"no imports", "just a plain context string", nil},
+		{"dedup", "import \"crypto/md5\"\nimport \"crypto/md5\"", []string{"crypto/md5"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := extractImports(tt.context)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("extractImports(%q) = %v, want %v", tt.context, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilenameTokens(t *testing.T) {
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{"", nil},
+		{"internal/auth/handler_test.go", []string{"internal", "auth", "handler", "test", "go"}},
+		{"main.py", []string{"main", "py"}},
+	}
+	for _, tt := range tests {
+		got := filenameTokens(tt.path)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("filenameTokens(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestValidatePatternExpr(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		wantErr bool
+	}{
+		{"empty is valid", "", false},
+		{"valid boolean expr", `("jwt" in keywords) and framework != "gin"`, false},
+		{"has_import helper", `has_import("crypto/md5")`, false},
+		{"syntax error", `severity ==`, true},
+		{"non-boolean result", `age_days("2020-01-01")`, true},
+		{"unknown function", `nonsense_fn(context)`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidatePatternExpr(tt.expr)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidatePatternExpr(%q) error = %v, wantErr %v", tt.expr, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestIndex_ExprProgramFor(t *testing.T) {
+	idx := NewIndex()
+	idx.Build([]ThreatPattern{
+		{ID: "TMKB-EXPR-001", Triggers: Triggers{Expr: `language == "go"`}},
+		{ID: "TMKB-NOEXPR-001"},
+		{ID: "TMKB-BADEXPR-001", Triggers: Triggers{Expr: `severity ==`}},
+	})
+
+	if _, ok := idx.exprProgramFor("TMKB-EXPR-001"); !ok {
+		t.Error("expected a compiled program for TMKB-EXPR-001")
+	}
+	if _, ok := idx.exprProgramFor("TMKB-NOEXPR-001"); ok {
+		t.Error("expected no compiled program for a pattern with no triggers.expr")
+	}
+	if _, ok := idx.exprProgramFor("TMKB-BADEXPR-001"); ok {
+		t.Error("expected Build to skip a triggers.expr that fails to compile")
+	}
+}
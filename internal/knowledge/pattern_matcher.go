@@ -0,0 +1,87 @@
+package knowledge
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// PatternMatcher filters patterns using the same --run/--skip regex style as
+// Go's own `go test`: a slash-separated expression matches component-wise
+// against a pattern's hierarchical path (ID, then language, framework,
+// category), so `TMKB-AUTHZ/Python/Flask` narrows to Python/Flask patterns
+// whose ID contains "TMKB-AUTHZ". A plain expression with no slash (e.g.
+// `TMKB-AUTHZ`) matches against the ID alone. Components are regular
+// expressions matched unanchored, exactly like testing.Match.
+type PatternMatcher struct {
+	run  []*regexp.Regexp
+	skip []*regexp.Regexp
+}
+
+// NewPatternMatcher compiles run and skip into a PatternMatcher. Either may
+// be empty to disable that side of the filter.
+func NewPatternMatcher(run, skip string) (*PatternMatcher, error) {
+	m := &PatternMatcher{}
+
+	if run != "" {
+		regexes, err := compilePathExpr(run)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --run expression: %w", err)
+		}
+		m.run = regexes
+	}
+
+	if skip != "" {
+		regexes, err := compilePathExpr(skip)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --skip expression: %w", err)
+		}
+		m.skip = regexes
+	}
+
+	return m, nil
+}
+
+// Matches reports whether p passes the matcher: it must satisfy --run (if
+// set) and must not satisfy --skip (if set).
+func (m *PatternMatcher) Matches(p ThreatPattern) bool {
+	path := []string{p.ID, p.Language, p.Framework, p.Category}
+
+	if m.run != nil && !matchesPath(m.run, path) {
+		return false
+	}
+	if m.skip != nil && matchesPath(m.skip, path) {
+		return false
+	}
+	return true
+}
+
+// compilePathExpr splits a slash-separated expression into its component
+// regexes, mirroring how testing.Match parses "-run" patterns.
+func compilePathExpr(expr string) ([]*regexp.Regexp, error) {
+	parts := strings.Split(expr, "/")
+	regexes := make([]*regexp.Regexp, len(parts))
+	for i, part := range parts {
+		re, err := regexp.Compile(part)
+		if err != nil {
+			return nil, err
+		}
+		regexes[i] = re
+	}
+	return regexes, nil
+}
+
+// matchesPath reports whether every component of regexes matches the
+// corresponding element of path. An expression with fewer components than
+// path leaves the remaining path elements unconstrained.
+func matchesPath(regexes []*regexp.Regexp, path []string) bool {
+	for i, re := range regexes {
+		if i >= len(path) {
+			break
+		}
+		if !re.MatchString(path[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,64 @@
+package knowledge
+
+import "testing"
+
+func matcherTestPatterns() []ThreatPattern {
+	return []ThreatPattern{
+		{ID: "TMKB-AUTHZ-001", Language: "Python", Framework: "Flask", Category: "authorization"},
+		{ID: "TMKB-AUTHZ-002", Language: "Go", Framework: "Gin", Category: "authorization"},
+		{ID: "TMKB-CRYPTO-001", Language: "Java", Framework: "Spring", Category: "cryptography"},
+	}
+}
+
+func TestPatternMatcher(t *testing.T) {
+	patterns := matcherTestPatterns()
+
+	tests := []struct {
+		name string
+		run  string
+		skip string
+		want []string
+	}{
+		{"empty matcher matches everything", "", "", []string{"TMKB-AUTHZ-001", "TMKB-AUTHZ-002", "TMKB-CRYPTO-001"}},
+		{"plain id regex matches against ID only", "AUTHZ", "", []string{"TMKB-AUTHZ-001", "TMKB-AUTHZ-002"}},
+		{"hierarchical id/language", "AUTHZ/Python", "", []string{"TMKB-AUTHZ-001"}},
+		{"hierarchical id/language/framework", "AUTHZ/Go/Gin", "", []string{"TMKB-AUTHZ-002"}},
+		{"skip excludes matches", "", "CRYPTO", []string{"TMKB-AUTHZ-001", "TMKB-AUTHZ-002"}},
+		{"run and skip compose", "AUTHZ", "002", []string{"TMKB-AUTHZ-001"}},
+		{"no match", "NOPE", "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := NewPatternMatcher(tt.run, tt.skip)
+			if err != nil {
+				t.Fatalf("NewPatternMatcher(%q, %q) failed: %v", tt.run, tt.skip, err)
+			}
+
+			var got []string
+			for _, p := range patterns {
+				if m.Matches(p) {
+					got = append(got, p.ID)
+				}
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("Matches() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("Matches() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestNewPatternMatcher_InvalidRegex(t *testing.T) {
+	if _, err := NewPatternMatcher("(", ""); err == nil {
+		t.Error("Expected error for invalid --run regex")
+	}
+	if _, err := NewPatternMatcher("", "("); err == nil {
+		t.Error("Expected error for invalid --skip regex")
+	}
+}
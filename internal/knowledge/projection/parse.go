@@ -0,0 +1,107 @@
+package projection
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type segmentKind int
+
+const (
+	segField segmentKind = iota
+	segWildcard
+	segIndex
+	segFilter
+)
+
+type segment struct {
+	kind  segmentKind
+	field string // segField
+	index int    // segIndex
+	// segFilter
+	filterField string
+	filterOp    string
+	filterValue string
+}
+
+// parseSegments tokenizes a dotted/bracketed selector into segments, e.g.
+// "mitigations[*].id" -> [field:mitigations, wildcard, field:id].
+func parseSegments(raw string) ([]segment, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, fmt.Errorf("empty selector")
+	}
+
+	var segs []segment
+	i := 0
+	for i < len(raw) {
+		switch raw[i] {
+		case '.':
+			i++
+		case '[':
+			end := strings.IndexByte(raw[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated '[' at position %d", i)
+			}
+			inner := raw[i+1 : i+end]
+			seg, err := parseBracket(inner)
+			if err != nil {
+				return nil, err
+			}
+			segs = append(segs, seg)
+			i += end + 1
+		default:
+			j := i
+			for j < len(raw) && raw[j] != '.' && raw[j] != '[' {
+				j++
+			}
+			name := raw[i:j]
+			if name == "" {
+				return nil, fmt.Errorf("empty field name at position %d", i)
+			}
+			segs = append(segs, segment{kind: segField, field: name})
+			i = j
+		}
+	}
+	return segs, nil
+}
+
+// parseBracket parses the contents of a single [...] expression: "*", a
+// numeric index, or a "?(@.field op 'value')" filter predicate.
+func parseBracket(inner string) (segment, error) {
+	inner = strings.TrimSpace(inner)
+	switch {
+	case inner == "*":
+		return segment{kind: segWildcard}, nil
+	case strings.HasPrefix(inner, "?("):
+		if !strings.HasSuffix(inner, ")") {
+			return segment{}, fmt.Errorf("malformed filter expression %q", inner)
+		}
+		body := strings.TrimSuffix(strings.TrimPrefix(inner, "?("), ")")
+		body = strings.TrimPrefix(strings.TrimSpace(body), "@.")
+		return parseFilterBody(body)
+	default:
+		n, err := strconv.Atoi(inner)
+		if err != nil {
+			return segment{}, fmt.Errorf("invalid index %q", inner)
+		}
+		return segment{kind: segIndex, index: n}, nil
+	}
+}
+
+// parseFilterBody handles "field", "field == 'value'", "field != 'value'".
+func parseFilterBody(body string) (segment, error) {
+	for _, op := range []string{"==", "!="} {
+		if idx := strings.Index(body, op); idx >= 0 {
+			field := strings.TrimSpace(body[:idx])
+			value := strings.TrimSpace(body[idx+len(op):])
+			value = strings.Trim(value, `'"`)
+			return segment{kind: segFilter, filterField: field, filterOp: op, filterValue: value}, nil
+		}
+	}
+	field := strings.TrimSpace(body)
+	if field == "" {
+		return segment{}, fmt.Errorf("empty filter predicate")
+	}
+	return segment{kind: segFilter, filterField: field, filterOp: ""}, nil
+}
@@ -0,0 +1,91 @@
+// Package projection implements a minimal JSONPath-style selector grammar
+// for narrowing ThreatPattern output to the fields a caller actually
+// needs — the same idea Kubernetes' downward-API field selectors use to
+// let callers ask for a slice of a resource instead of the whole object.
+//
+// Supported syntax:
+//
+//	id                                   dotted field access
+//	mitigations[*].id                    wildcard over an array
+//	mitigations[0].id                    numeric index
+//	provenance.public_references[?(@.cwe)].url   filter expression
+//
+// Filter expressions support an optional comparison against a literal,
+// e.g. `[?(@.effectiveness == 'high')]`; with no operator, the predicate
+// is "the field is present and non-empty".
+package projection
+
+import "fmt"
+
+// Selector is a single compiled field selector, e.g. "mitigations[*].id".
+type Selector struct {
+	raw      string
+	segments []segment
+}
+
+// Parse compiles a single selector expression.
+func Parse(raw string) (*Selector, error) {
+	segs, err := parseSegments(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid selector %q: %w", raw, err)
+	}
+	return &Selector{raw: raw, segments: segs}, nil
+}
+
+// String returns the original selector text, used as the flattened key.
+func (s *Selector) String() string { return s.raw }
+
+// Projector applies a set of selectors to a document produced by
+// marshaling a ThreatPattern to JSON.
+type Projector struct {
+	selectors []*Selector
+}
+
+// New compiles a set of raw selector strings into a Projector.
+func New(raws []string) (*Projector, error) {
+	p := &Projector{}
+	for _, raw := range raws {
+		sel, err := Parse(raw)
+		if err != nil {
+			return nil, err
+		}
+		p.selectors = append(p.selectors, sel)
+	}
+	return p, nil
+}
+
+// Flatten evaluates every selector against doc and returns one entry per
+// selector-match pair in "key: value" form, suitable for text-mode output.
+// A selector that matches multiple values (via [*] or a filter) produces
+// one entry per match, with its index appended to the key.
+func (p *Projector) Flatten(doc interface{}) []KV {
+	var out []KV
+	for _, sel := range p.selectors {
+		matches := evalSegments(doc, sel.segments)
+		if len(matches) == 1 {
+			out = append(out, KV{Key: sel.raw, Value: matches[0]})
+			continue
+		}
+		for i, v := range matches {
+			out = append(out, KV{Key: fmt.Sprintf("%s[%d]", sel.raw, i), Value: v})
+		}
+	}
+	return out
+}
+
+// KV is a single flattened key/value pair.
+type KV struct {
+	Key   string
+	Value interface{}
+}
+
+// Project evaluates every selector against doc and returns a pruned JSON
+// object that preserves the nested shape of the original document, only
+// keeping the branches the selectors touched.
+func (p *Projector) Project(doc interface{}) interface{} {
+	var pruned interface{}
+	for _, sel := range p.selectors {
+		pruned = projectSegments(pruned, doc, sel.segments)
+	}
+	return pruned
+}
@@ -0,0 +1,94 @@
+package projection
+
+import "testing"
+
+func testDoc() map[string]interface{} {
+	return map[string]interface{}{
+		"id":       "TMKB-AUTHZ-002",
+		"severity": "critical",
+		"mitigations": []interface{}{
+			map[string]interface{}{"id": "M1", "effectiveness": "high"},
+			map[string]interface{}{"id": "M2", "effectiveness": "low"},
+		},
+		"provenance": map[string]interface{}{
+			"public_references": []interface{}{
+				map[string]interface{}{"cwe": "CWE-285", "url": "https://example.com/285"},
+				map[string]interface{}{"owasp": "A01:2021", "url": "https://example.com/a01"},
+			},
+		},
+	}
+}
+
+func TestFlatten_SimpleField(t *testing.T) {
+	proj, err := New([]string{"id", "severity"})
+	if err != nil {
+		t.Fatalf("New error: %v", err)
+	}
+	kvs := proj.Flatten(testDoc())
+	if len(kvs) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(kvs))
+	}
+	if kvs[0].Key != "id" || kvs[0].Value != "TMKB-AUTHZ-002" {
+		t.Errorf("unexpected first entry: %+v", kvs[0])
+	}
+}
+
+func TestFlatten_Wildcard(t *testing.T) {
+	proj, err := New([]string{"mitigations[*].id"})
+	if err != nil {
+		t.Fatalf("New error: %v", err)
+	}
+	kvs := proj.Flatten(testDoc())
+	if len(kvs) != 2 {
+		t.Fatalf("expected 2 entries, got %d: %+v", len(kvs), kvs)
+	}
+	if kvs[0].Value != "M1" || kvs[1].Value != "M2" {
+		t.Errorf("unexpected values: %+v", kvs)
+	}
+}
+
+func TestFlatten_FilterExpression(t *testing.T) {
+	proj, err := New([]string{"provenance.public_references[?(@.cwe)].url"})
+	if err != nil {
+		t.Fatalf("New error: %v", err)
+	}
+	kvs := proj.Flatten(testDoc())
+	if len(kvs) != 1 {
+		t.Fatalf("expected 1 entry, got %d: %+v", len(kvs), kvs)
+	}
+	if kvs[0].Value != "https://example.com/285" {
+		t.Errorf("unexpected value: %+v", kvs[0])
+	}
+}
+
+func TestProject_PreservesShape(t *testing.T) {
+	proj, err := New([]string{"id", "mitigations[*].id"})
+	if err != nil {
+		t.Fatalf("New error: %v", err)
+	}
+	result := proj.Project(testDoc())
+	m, ok := result.(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected map result, got %T", result)
+	}
+	if m["id"] != "TMKB-AUTHZ-002" {
+		t.Errorf("expected id preserved, got %v", m["id"])
+	}
+	mits, ok := m["mitigations"].([]interface{})
+	if !ok || len(mits) != 2 {
+		t.Fatalf("expected 2 mitigations, got %v", m["mitigations"])
+	}
+	first, ok := mits[0].(map[string]interface{})
+	if !ok || first["id"] != "M1" {
+		t.Errorf("expected mitigations[0].id == M1, got %v", mits[0])
+	}
+	if _, hasEffectiveness := first["effectiveness"]; hasEffectiveness {
+		t.Errorf("expected effectiveness to be pruned, got %v", first)
+	}
+}
+
+func TestParse_InvalidSelector(t *testing.T) {
+	if _, err := New([]string{"mitigations[bad"}); err == nil {
+		t.Fatal("expected an error for an unterminated bracket")
+	}
+}
@@ -0,0 +1,157 @@
+package projection
+
+// evalSegments walks doc according to segs and returns every leaf value
+// reached. Wildcard and filter segments can fan out to multiple matches;
+// a field or index segment that doesn't resolve simply yields no matches.
+func evalSegments(doc interface{}, segs []segment) []interface{} {
+	cur := []interface{}{doc}
+	for _, seg := range segs {
+		var next []interface{}
+		for _, v := range cur {
+			next = append(next, applySegment(v, seg)...)
+		}
+		cur = next
+	}
+	return cur
+}
+
+func applySegment(v interface{}, seg segment) []interface{} {
+	switch seg.kind {
+	case segField:
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		val, ok := m[seg.field]
+		if !ok {
+			return nil
+		}
+		return []interface{}{val}
+	case segWildcard:
+		arr, ok := v.([]interface{})
+		if !ok {
+			return nil
+		}
+		return arr
+	case segIndex:
+		arr, ok := v.([]interface{})
+		if !ok || seg.index < 0 || seg.index >= len(arr) {
+			return nil
+		}
+		return []interface{}{arr[seg.index]}
+	case segFilter:
+		arr, ok := v.([]interface{})
+		if !ok {
+			return nil
+		}
+		var out []interface{}
+		for _, item := range arr {
+			if matchesFilter(item, seg) {
+				out = append(out, item)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func matchesFilter(item interface{}, seg segment) bool {
+	m, ok := item.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	val, present := m[seg.filterField]
+	if seg.filterOp == "" {
+		if !present {
+			return false
+		}
+		if s, ok := val.(string); ok {
+			return s != ""
+		}
+		return true
+	}
+	s := stringify(val)
+	switch seg.filterOp {
+	case "==":
+		return present && s == seg.filterValue
+	case "!=":
+		return !present || s != seg.filterValue
+	}
+	return false
+}
+
+func stringify(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return ""
+}
+
+// projectSegments merges the values selected by segs from src into dst,
+// preserving the nested map/slice shape of src along the selected path.
+func projectSegments(dst, src interface{}, segs []segment) interface{} {
+	if len(segs) == 0 {
+		return mergeValue(dst, src)
+	}
+
+	seg := segs[0]
+	rest := segs[1:]
+
+	switch seg.kind {
+	case segField:
+		m, ok := src.(map[string]interface{})
+		if !ok {
+			return dst
+		}
+		v, ok := m[seg.field]
+		if !ok {
+			return dst
+		}
+		dstMap, _ := dst.(map[string]interface{})
+		if dstMap == nil {
+			dstMap = map[string]interface{}{}
+		}
+		dstMap[seg.field] = projectSegments(dstMap[seg.field], v, rest)
+		return dstMap
+	case segWildcard, segIndex, segFilter:
+		srcArr, ok := src.([]interface{})
+		if !ok {
+			return dst
+		}
+		dstArr, _ := dst.([]interface{})
+		if dstArr == nil {
+			dstArr = make([]interface{}, len(srcArr))
+		}
+		for i, item := range srcArr {
+			if !segSelects(seg, i, item) {
+				continue
+			}
+			dstArr[i] = projectSegments(dstArr[i], item, rest)
+		}
+		return dstArr
+	}
+	return dst
+}
+
+func segSelects(seg segment, i int, item interface{}) bool {
+	switch seg.kind {
+	case segWildcard:
+		return true
+	case segIndex:
+		return i == seg.index
+	case segFilter:
+		return matchesFilter(item, seg)
+	}
+	return false
+}
+
+// mergeValue returns src unless dst already has a (possibly partial)
+// value at this position, in which case dst is kept — a composite value
+// is already the accumulation target, and a leaf reached twice holds the
+// same value from the same document.
+func mergeValue(dst, src interface{}) interface{} {
+	if dst == nil {
+		return src
+	}
+	return dst
+}
@@ -1,8 +1,17 @@
 package knowledge
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
 	"sort"
 	"strings"
+
+	"github.com/mark-chris/tmkb/internal/knowledge/filter"
+	"github.com/mark-chris/tmkb/internal/knowledge/matcher"
+	"github.com/mark-chris/tmkb/internal/knowledge/projection"
 )
 
 // QueryOptions configures a query
@@ -11,19 +20,119 @@ type QueryOptions struct {
 	Language  string
 	Framework string
 	Category  string
+	CWE       string          // CWE identifier filter, e.g. "CWE-79"
+	OWASP     string          // OWASP Top 10 identifier filter, e.g. "A03:2021"
+	Filter    string          // expr-style boolean expression, e.g. `severity == "critical"`
+	Matcher   matcher.Matcher // structured boolean query tree, a JSON-native alternative to Filter; zero value matches everything
+	FilePath  string          // file path to evaluate against a pattern's "file"-field trigger leaves
 	Limit     int
 	Verbosity string // "agent" or "human"
+
+	// EnforcementScope is the caller type ("agent", "human", "ci", "ide")
+	// used to resolve each result's effective enforcement Action via
+	// ResolveEnforcement. Empty means no scope-specific override applies.
+	EnforcementScope string
+	Budget           int    // max tokens of patterns to pack into the response; 0 disables budget packing
+	Model            string // model name used to pick a token encoding for Budget, e.g. "gpt-4o"
+
+	// Ranker selects the relevance-scoring formula: "" or "hybrid" (default)
+	// uses CalculateRelevanceBM25F (field-boosted BM25) against idx's
+	// precomputed CorpusStats with fixed tuning (defaultRankerK1=1.5,
+	// bm25B=0.75, DefaultFieldBoosts) - replacing the old ad-hoc
+	// keyword-overlap formula, which remains available standalone as
+	// CalculateRelevance; "bm25" uses CalculateRelevanceBM25 against idx's
+	// precomputed KeywordStats; "bm25f" uses CalculateRelevanceBM25F as
+	// well, but tuned by K1/B/FieldBoosts below instead of the fixed
+	// defaults.
+	Ranker string
+
+	// K1, B, and FieldBoosts tune the "bm25f" ranker only; "hybrid"/""
+	// uses its own fixed defaults (see Ranker) and "bm25" ignores them
+	// entirely. K1/B of 0 fall back to CalculateRelevanceBM25F's defaults
+	// (1.2/0.75); a zero-value FieldBoosts falls back to
+	// DefaultFieldBoosts.
+	K1          float64
+	B           float64
+	FieldBoosts FieldBoosts
+
+	// MatchMode selects MatchContextWithOptions's query-expansion mode:
+	// "exact" (literal terms only), "stemmed" (default - also match
+	// terms sharing a stemmed root), or "fuzzy" (stemmed plus an edit-
+	// distance fallback for typos/near-misses). Empty behaves like
+	// "stemmed", matching MatchContextWithOptions's own zero-value
+	// default.
+	MatchMode string
+
+	// MinScore, when > 0, drops any candidate whose relevance score falls
+	// below it after ranking - pruning irrelevant matches rather than
+	// relying on a fixed --limit count. Only meaningful when Context is
+	// set (no score is ever computed otherwise); a no-context query
+	// ignores MinScore entirely.
+	MinScore float64
+
+	// MaxTokens, when > 0, switches result building over to a
+	// ResponseBuilder: a 0/1 knapsack over a page of candidates maximizing
+	// total relevance score within MaxTokens, instead of the plain
+	// per-pattern Limit loop or packByBudget's greedy first-fit. It also
+	// enables Cursor-based pagination via QueryResult.NextCursor, and (with
+	// Verbosity "compressed") the low-fidelity Threat+Fix-only tier. Limit
+	// and Budget are ignored when MaxTokens is set.
+	MaxTokens int
+	Cursor    string // opaque pagination cursor from a prior QueryResult.NextCursor
+
+	// Ctx, when non-nil, is checked for cancellation while scoring
+	// candidates, so a caller running Query on a goroutine it can cancel
+	// (e.g. the MCP server, aborting a long verbose-tier query over a
+	// large index when the client sends notifications/cancelled) isn't
+	// stuck waiting for it to run to completion. Nil, the default for the
+	// CLI and every other caller, disables the check entirely.
+	Ctx context.Context
+
+	// Fields and Include select which branches of each candidate's full
+	// ThreatPattern to return, using the same selector grammar as the CLI's
+	// --fields flag (see package projection): dotted field access,
+	// "mitigations[*].id" wildcards, and "[?(@.field)]" filters. Include is
+	// a synonym for Fields used when Fields is empty, so a caller can pass
+	// whichever name reads better; both populate QueryResult.ProjectedPatterns
+	// instead of the fixed-shape Patterns/CompressedPatterns tiers, letting an
+	// agent ask for e.g. just "id", "severity", and
+	// "mitigations[*].code_examples[*].secure_code" instead of the full
+	// agent-summary payload.
+	Fields  []string
+	Include []string
+
+	// Exclude removes dotted paths (no wildcard/filter syntax - see
+	// excludePath) from each candidate's projected document after
+	// Fields/Include has been applied, for trimming a handful of unwanted
+	// fields out of an otherwise-wanted shape.
+	Exclude []string
+
+	// TokenBudget, when > 0, drops patterns from the tail of whichever
+	// output tier Query populated (ProjectedPatterns if Fields/Include/Exclude
+	// was set, otherwise Patterns) until the JSON-encoded result fits,
+	// setting TokenLimitReached - the field already existed but previously
+	// nothing populated it outside packByBudget/ResponseBuilder. Ignored
+	// when MaxTokens is set, which has its own knapsack-based budget.
+	TokenBudget int
 }
 
 // QueryResult holds the results of a query
 type QueryResult struct {
-	PatternCount      int                     `json:"pattern_count"`
-	PatternsIncluded  int                     `json:"patterns_included"`
-	TokenCount        int                     `json:"token_count,omitempty"`
-	TokenLimitReached bool                    `json:"token_limit_reached,omitempty"`
-	Patterns          []PatternOutput         `json:"patterns,omitempty"`
-	VerbosePatterns   []PatternOutputVerbose  `json:"verbose_patterns,omitempty"`
-	CodePattern       *CodePatternOutput      `json:"code_pattern,omitempty"`
+	PatternCount       int                       `json:"pattern_count"`
+	PatternsIncluded   int                       `json:"patterns_included"`
+	TokenCount         int                       `json:"token_count,omitempty"`
+	TokenLimitReached  bool                      `json:"token_limit_reached,omitempty"`
+	Patterns           []PatternOutput           `json:"patterns,omitempty"`
+	CompressedPatterns []PatternOutputCompressed `json:"compressed_patterns,omitempty"`
+	VerbosePatterns    []PatternOutputVerbose    `json:"verbose_patterns,omitempty"`
+	CodePattern        *CodePatternOutput        `json:"code_pattern,omitempty"`
+	NextCursor         string                    `json:"next_cursor,omitempty"`
+
+	// ProjectedPatterns holds one projected document per candidate in
+	// Patterns, built from the candidate's full ThreatPattern instead of the
+	// fixed-shape PatternOutput, when QueryOptions.Fields/Include/Exclude was
+	// set (see projectPatterns). Omitted otherwise.
+	ProjectedPatterns []interface{} `json:"projected_patterns,omitempty"`
 }
 
 // PatternOutput is the agent-facing summary of a pattern
@@ -34,23 +143,46 @@ type PatternOutput struct {
 	Threat   string `json:"threat"`
 	Check    string `json:"check"`
 	Fix      string `json:"fix"`
+	Action   string `json:"action,omitempty"`
+
+	// Score is the raw relevance score the ranker assigned this pattern,
+	// for debugging ranking decisions (see QueryOptions.Ranker/MinScore).
+	// Only populated when Context was set; omitted (the zero value) for a
+	// no-context query sorted by severity instead.
+	Score float64 `json:"score,omitempty"`
+}
+
+// PatternOutputCompressed is a lower-fidelity tier between "agent" and
+// nothing at all: Threat and Fix only (~40 tokens/pattern), so a
+// ResponseBuilder token budget can fit more patterns at lower fidelity.
+type PatternOutputCompressed struct {
+	ID     string `json:"id"`
+	Threat string `json:"threat"`
+	Fix    string `json:"fix"`
 }
 
 // PatternOutputVerbose is the human-facing detailed output
 type PatternOutputVerbose struct {
-	ID                string                `json:"id"`
-	Name              string                `json:"name"`
-	Severity          string                `json:"severity"`
-	Likelihood        string                `json:"likelihood"`
-	Threat            string                `json:"threat"`
-	Check             string                `json:"check"`
-	Fix               string                `json:"fix"`
-	Description       string                `json:"description"`
-	AttackScenario    *AttackScenarioOutput `json:"attack_scenario,omitempty"`
-	Mitigations       []MitigationVerbose   `json:"mitigations"`
-	RelatedPatterns   []string              `json:"related_patterns,omitempty"`
-	CWEReferences     []string              `json:"cwe_references,omitempty"`
-	OWASPReferences   []string              `json:"owasp_references,omitempty"`
+	ID              string                `json:"id"`
+	Name            string                `json:"name"`
+	Severity        string                `json:"severity"`
+	Likelihood      string                `json:"likelihood"`
+	Threat          string                `json:"threat"`
+	Check           string                `json:"check"`
+	Fix             string                `json:"fix"`
+	Action          string                `json:"action,omitempty"`
+	Description     string                `json:"description"`
+	AttackScenario  *AttackScenarioOutput `json:"attack_scenario,omitempty"`
+	Mitigations     []MitigationVerbose   `json:"mitigations"`
+	RelatedPatterns []string              `json:"related_patterns,omitempty"`
+	CWEReferences   []string              `json:"cwe_references,omitempty"`
+	OWASPReferences []string              `json:"owasp_references,omitempty"`
+
+	// ExternalRefs carries whatever canonical CVE/CWE/OWASP metadata an
+	// enrichment pass attached to the pattern (see ThreatPattern.ExternalRefs),
+	// so --verbosity human can show authoritative CWE names and CVSS
+	// scores without a pattern author hand-copying them.
+	ExternalRefs []ExternalRef `json:"external_refs,omitempty"`
 }
 
 // AttackScenarioOutput provides full attack scenario details
@@ -88,19 +220,82 @@ type CodePatternOutput struct {
 	SecureTemplate string `json:"secure_template"`
 }
 
+// triggerExprMatchBonus is added to a candidate's relevance score when it
+// was pulled in via Triggers.Any/All rather than keyword overlap, so an
+// author-specified boolean trigger always outranks the fuzzier
+// bag-of-words score from CalculateRelevance.
+const triggerExprMatchBonus = 1000.0
+
+// filterExprMatchBonus is added to a candidate's relevance score when its
+// Triggers.Expr (the filter-engine boolean expression, distinct from the
+// Any/All tree triggerExprMatchBonus rewards) matched the query context. A
+// pattern can earn both bonuses at once if it sets both mechanisms.
+const filterExprMatchBonus = 1000.0
+
+// scoreEpsilon bounds how close two patterns' relevance scores must be for
+// sortByRelevance to treat them as tied and fall through to the
+// severity/likelihood tiebreakers. BM25-family scores are continuous and
+// almost never land on the exact same float, so an exact equality check
+// (as the old ad-hoc formula could get away with) would make severity an
+// effectively dead tiebreaker.
+const scoreEpsilon = 1e-6
+
+// filePathValues wraps a possibly-empty file path into the []string
+// TriggerContext.Files expects, omitting it entirely when unset so a
+// "file"-field leaf with no --file given never spuriously matches "".
+func filePathValues(path string) []string {
+	if path == "" {
+		return nil
+	}
+	return []string{path}
+}
+
 // patternWithScore holds a pattern and its relevance score
 type patternWithScore struct {
 	pattern *ThreatPattern
 	score   float64
 }
 
-// Query executes a query against the index
-func Query(idx *Index, opts QueryOptions) QueryResult {
+// Query executes a query against the index. It returns an error when
+// opts.Filter fails to compile (the returned *filter.CompileError carries a
+// line/column into the expression for the caller to surface), when a
+// trigger expression, the filter or opts.Matcher fails to evaluate, when a
+// pattern fails to marshal, when opts.Cursor is invalid, when opts.Ctx is
+// cancelled during scoring, or when a Fields selector fails to parse.
+func Query(idx *Index, opts QueryOptions) (QueryResult, error) {
 	var candidates []*ThreatPattern
 
 	// Start with context-based matching if provided
+	var triggerMatched map[string]bool
 	if opts.Context != "" {
-		candidates = idx.MatchContext(opts.Context)
+		candidates = idx.MatchContextWithOptions(opts.Context, MatchOptions{Mode: MatchMode(opts.MatchMode)})
+
+		// Patterns whose Triggers.Any/All boolean expression matches are
+		// reachable even without bag-of-words keyword overlap, so merge
+		// them in alongside MatchContextWithOptions's substring-based
+		// candidates.
+		extra, err := idx.MatchTriggerExpr(TriggerContext{
+			Context: opts.Context,
+			Actions: []string{opts.Context},
+			Files:   filePathValues(opts.FilePath),
+		})
+		if err != nil {
+			return QueryResult{}, fmt.Errorf("evaluate trigger expression: %w", err)
+		}
+		if len(extra) > 0 {
+			triggerMatched = make(map[string]bool, len(extra))
+			seen := make(map[string]bool, len(candidates))
+			for _, p := range candidates {
+				seen[p.ID] = true
+			}
+			for _, p := range extra {
+				triggerMatched[p.ID] = true
+				if !seen[p.ID] {
+					seen[p.ID] = true
+					candidates = append(candidates, p)
+				}
+			}
+		}
 	} else {
 		// Otherwise get all patterns
 		all := idx.GetAll()
@@ -109,6 +304,52 @@ func Query(idx *Index, opts QueryOptions) QueryResult {
 		}
 	}
 
+	// Apply the expr filter before any other filtering/scoring so it
+	// composes with --language/--framework/--category the same way a
+	// WHERE clause composes with other query predicates.
+	if strings.TrimSpace(opts.Filter) != "" {
+		prog, err := filter.Compile(opts.Filter)
+		if err != nil {
+			return QueryResult{}, fmt.Errorf("compile filter: %w", err)
+		}
+		var filtered []*ThreatPattern
+		for _, p := range candidates {
+			doc, err := patternDoc(p)
+			if err != nil {
+				return QueryResult{}, fmt.Errorf("marshal pattern %s: %w", p.ID, err)
+			}
+			ok, err := prog.Match(doc)
+			if err != nil {
+				return QueryResult{}, fmt.Errorf("evaluate filter: %w", err)
+			}
+			if ok {
+				filtered = append(filtered, p)
+			}
+		}
+		candidates = filtered
+	}
+
+	// Apply the structured Matcher tree the same way as the expr Filter
+	// above - before language/framework/etc - so the two compose rather
+	// than one silently overriding the other when both are set.
+	if !opts.Matcher.IsZero() {
+		var filtered []*ThreatPattern
+		for _, p := range candidates {
+			doc, err := patternDoc(p)
+			if err != nil {
+				return QueryResult{}, fmt.Errorf("marshal pattern %s: %w", p.ID, err)
+			}
+			ok, err := opts.Matcher.Eval(doc)
+			if err != nil {
+				return QueryResult{}, fmt.Errorf("evaluate matcher: %w", err)
+			}
+			if ok {
+				filtered = append(filtered, p)
+			}
+		}
+		candidates = filtered
+	}
+
 	// Filter by language if specified
 	if opts.Language != "" {
 		candidates = filterByLanguage(candidates, opts.Language)
@@ -124,15 +365,99 @@ func Query(idx *Index, opts QueryOptions) QueryResult {
 		candidates = filterByCategory(candidates, opts.Category)
 	}
 
+	// Filter by CWE if specified
+	if opts.CWE != "" {
+		candidates = filterByCWE(candidates, opts.CWE)
+	}
+
+	// Filter by OWASP if specified
+	if opts.OWASP != "" {
+		candidates = filterByOWASP(candidates, opts.OWASP)
+	}
+
+	// Evaluate each remaining candidate's Triggers.Expr, if it has one,
+	// against the query context and drop non-matches - the same
+	// compose-after-the-structural-filters placement as the expr Filter and
+	// Matcher above. A candidate with no compiled program (no Expr set, or
+	// one that failed to compile at Build) passes through unfiltered.
+	var exprMatched map[string]bool
+	if len(candidates) > 0 {
+		doc := patternExprContextDoc(opts, ExtractKeywords(opts.Context))
+		var filtered []*ThreatPattern
+		for _, p := range candidates {
+			prog, ok := idx.exprProgramFor(p.ID)
+			if !ok {
+				filtered = append(filtered, p)
+				continue
+			}
+			matched, err := evalPatternExpr(prog, doc)
+			if err != nil {
+				// A runtime panic/error inside one pattern's Expr is a
+				// pattern-level problem, not a query-level failure - warn
+				// and drop just this candidate rather than failing Query
+				// for every other candidate in the response.
+				log.Printf("Warning: pattern %s: triggers.expr: %v", p.ID, err)
+				continue
+			}
+			if !matched {
+				continue
+			}
+			if exprMatched == nil {
+				exprMatched = make(map[string]bool)
+			}
+			exprMatched[p.ID] = true
+			filtered = append(filtered, p)
+		}
+		candidates = filtered
+	}
+
 	// Sort by relevance if context provided, otherwise by severity
+	var relevanceScores []float64
 	if opts.Context != "" && strings.TrimSpace(opts.Context) != "" {
 		// Extract keywords from context
 		queryKeywords := ExtractKeywords(opts.Context)
 
 		// Calculate relevance scores
+		stats := idx.KeywordStats()
+		corpusStats := idx.CorpusStats()
 		scored := make([]patternWithScore, len(candidates))
 		for i, p := range candidates {
-			score := CalculateRelevance(queryKeywords, p.Triggers.Keywords)
+			if opts.Ctx != nil {
+				select {
+				case <-opts.Ctx.Done():
+					return QueryResult{}, opts.Ctx.Err()
+				default:
+				}
+			}
+			var score float64
+			switch opts.Ranker {
+			case "bm25":
+				score = CalculateRelevanceBM25(queryKeywords, p.Triggers.Keywords, stats)
+			case "bm25f":
+				k1, b := opts.K1, opts.B
+				if k1 == 0 {
+					k1 = bm25K1
+				}
+				if b == 0 {
+					b = bm25B
+				}
+				score = CalculateRelevanceBM25F(queryKeywords, *p, corpusStats, k1, b, opts.FieldBoosts)
+			default:
+				// "" and "hybrid": field-boosted BM25F with its own fixed
+				// tuning, replacing the old ad-hoc keyword-overlap formula
+				// (CalculateRelevance, still available standalone).
+				score = CalculateRelevanceBM25F(queryKeywords, *p, corpusStats, defaultRankerK1, bm25B, FieldBoosts{})
+			}
+			score = calculateRelevancePatterns(score, opts.Context, idx.compiledTriggersFor(p.ID))
+			if triggerMatched[p.ID] {
+				// An explicit boolean trigger match is a more precise
+				// signal than keyword overlap, so it outranks any
+				// bag-of-words score.
+				score += triggerExprMatchBonus
+			}
+			if exprMatched[p.ID] {
+				score += filterExprMatchBonus
+			}
 			scored[i] = patternWithScore{
 				pattern: p,
 				score:   score,
@@ -143,14 +468,51 @@ func Query(idx *Index, opts QueryOptions) QueryResult {
 		sortByRelevance(scored)
 
 		// Extract sorted patterns
+		relevanceScores = make([]float64, len(scored))
 		for i, s := range scored {
 			candidates[i] = s.pattern
+			relevanceScores[i] = s.score
 		}
 	} else {
 		// Sort by severity (critical > high > medium > low) then by likelihood
 		sortBySeverity(candidates)
 	}
 
+	// Drop candidates scoring below MinScore, keeping candidates and
+	// relevanceScores aligned. A no-context query never reaches here with
+	// a non-nil relevanceScores, so MinScore is naturally a no-op then.
+	if opts.MinScore > 0 && relevanceScores != nil {
+		filteredCandidates := candidates[:0:0]
+		filteredScores := relevanceScores[:0:0]
+		for i, p := range candidates {
+			if relevanceScores[i] < opts.MinScore {
+				continue
+			}
+			filteredCandidates = append(filteredCandidates, p)
+			filteredScores = append(filteredScores, relevanceScores[i])
+		}
+		candidates = filteredCandidates
+		relevanceScores = filteredScores
+	}
+
+	// A ResponseBuilder manages its own windowing/pagination across
+	// multiple calls, so it sees the full (unlimited) candidate list rather
+	// than being cut down to Limit up front.
+	if opts.MaxTokens > 0 {
+		rb := NewResponseBuilder(opts.Model, opts.MaxTokens, opts.Verbosity, opts.EnforcementScope,
+			EnforcementContext{Language: opts.Language, Framework: opts.Framework})
+		result, err := rb.Build(candidates, relevanceScores, opts.Cursor)
+		if err != nil {
+			return QueryResult{}, fmt.Errorf("invalid cursor: %w", err)
+		}
+		if len(candidates) > 0 {
+			if codePattern := extractCodePattern(candidates[0], opts.Language, opts.Framework); codePattern != nil {
+				result.CodePattern = codePattern
+			}
+		}
+		return result, nil
+	}
+
 	// Apply limit (default to 3 for agent output)
 	limit := opts.Limit
 	if limit <= 0 {
@@ -162,6 +524,9 @@ func Query(idx *Index, opts QueryOptions) QueryResult {
 	}
 	if len(candidates) > limit {
 		candidates = candidates[:limit]
+		if len(relevanceScores) > limit {
+			relevanceScores = relevanceScores[:limit]
+		}
 	}
 
 	// Build output
@@ -170,31 +535,281 @@ func Query(idx *Index, opts QueryOptions) QueryResult {
 		Patterns:     make([]PatternOutput, 0, len(candidates)),
 	}
 
+	switch {
+	case opts.Budget > 0:
+		packByBudget(&result, candidates, relevanceScores, opts)
+	case opts.Verbosity == "human":
+		// Human verbosity gets the full per-pattern detail (mitigations,
+		// attack scenario, references) that MCP's single-pattern reads use,
+		// rather than the compact agent_summary-only PatternOutput.
+		// candidates is already cut down to limit above; buildVerboseResponse
+		// takes its own cap separately (for its single-pattern caller), so
+		// pass len(candidates) rather than limit itself, which may still be
+		// a sentinel like math.MaxInt32 for "unbounded" callers.
+		verbose := buildVerboseResponse(candidates, len(candidates), opts.EnforcementScope,
+			EnforcementContext{Language: opts.Language, Framework: opts.Framework})
+		result.Patterns = nil
+		result.VerbosePatterns = verbose.VerbosePatterns
+	default:
+		for i, p := range candidates {
+			output := PatternOutput{
+				ID:       p.ID,
+				Severity: p.Severity,
+				Threat:   p.AgentSummary.Threat,
+				Check:    p.AgentSummary.Check,
+				Fix:      p.AgentSummary.Fix,
+				Action:   string(ResolveEnforcement(p, opts.EnforcementScope, EnforcementContext{Language: opts.Language, Framework: opts.Framework})),
+			}
+			if i < len(relevanceScores) {
+				output.Score = relevanceScores[i]
+			}
+
+			result.Patterns = append(result.Patterns, output)
+		}
+		// Report an approximate token count even without an explicit
+		// Budget/TokenBudget, so agent callers can see roughly how much
+		// response they got back; TokenBudget below overrides this with an
+		// exact accounting if set.
+		result.TokenCount = approximateAgentTokenCount(opts.Model, result.Patterns)
+	}
+
+	if len(result.VerbosePatterns) > 0 {
+		result.PatternsIncluded = len(result.VerbosePatterns)
+	} else {
+		result.PatternsIncluded = len(result.Patterns)
+	}
+
+	if err := projectPatterns(&result, candidates, opts); err != nil {
+		return QueryResult{}, err
+	}
+	if opts.TokenBudget > 0 {
+		applyTokenBudget(&result, opts)
+	}
+
+	// Add code pattern from most relevant match
+	if len(candidates) > 0 {
+		codePattern := extractCodePattern(candidates[0], opts.Language, opts.Framework)
+		if codePattern != nil {
+			result.CodePattern = codePattern
+		}
+	}
+
+	return result, nil
+}
+
+// projectPatterns populates result.ProjectedPatterns from candidates'
+// full ThreatPattern documents when opts.Fields, opts.Include, or
+// opts.Exclude is set; a no-op otherwise. Fields takes priority over
+// Include when both are given (Include only exists as the friendlier name
+// for the same mechanism); Exclude applies after either.
+func projectPatterns(result *QueryResult, candidates []*ThreatPattern, opts QueryOptions) error {
+	fields := opts.Fields
+	if len(fields) == 0 {
+		fields = opts.Include
+	}
+	if len(fields) == 0 && len(opts.Exclude) == 0 {
+		return nil
+	}
+
+	var proj *projection.Projector
+	if len(fields) > 0 {
+		p, err := projection.New(fields)
+		if err != nil {
+			return err
+		}
+		proj = p
+	}
+
+	result.ProjectedPatterns = make([]interface{}, 0, len(candidates))
 	for _, p := range candidates {
+		doc, err := patternDoc(p)
+		if err != nil {
+			return err
+		}
+
+		var out interface{} = doc
+		if proj != nil {
+			out = proj.Project(doc)
+		}
+		for _, path := range opts.Exclude {
+			out = excludePath(out, path)
+		}
+		result.ProjectedPatterns = append(result.ProjectedPatterns, out)
+	}
+	return nil
+}
+
+// excludePath deletes a single dotted path (no "mitigations[*]" wildcard
+// syntax - just field names, e.g. "mitigations.code_examples") from doc,
+// descending into any array it meets along the way rather than requiring
+// the caller to spell out "[*]" at every level.
+func excludePath(doc interface{}, path string) interface{} {
+	return deletePath(doc, strings.Split(path, "."))
+}
+
+// deletePath implements excludePath's recursive walk, mutating maps and
+// slices in place since projectPatterns always hands it a throwaway
+// document freshly built by patternDoc/projection.Project.
+func deletePath(doc interface{}, segments []string) interface{} {
+	if len(segments) == 0 {
+		return doc
+	}
+	switch v := doc.(type) {
+	case map[string]interface{}:
+		if len(segments) == 1 {
+			delete(v, segments[0])
+			return v
+		}
+		if child, ok := v[segments[0]]; ok {
+			v[segments[0]] = deletePath(child, segments[1:])
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = deletePath(item, segments)
+		}
+		return v
+	default:
+		return doc
+	}
+}
+
+// applyTokenBudget greedily drops patterns from the tail of whichever
+// output tier Query populated - ProjectedPatterns if
+// Fields/Include/Exclude was set, otherwise Patterns - until the remaining
+// entries' JSON encoding fits opts.TokenBudget, mirroring packByBudget's
+// "always keep at least one" rule so a single oversized match doesn't
+// produce an empty response.
+func applyTokenBudget(result *QueryResult, opts QueryOptions) {
+	counter, err := NewTokenCounterForModel(opts.Model)
+	if err != nil {
+		log.Printf("Warning: token counter initialization failed: %v, using approximation", err)
+	}
+
+	if len(result.ProjectedPatterns) > 0 {
+		kept, total, limitReached := fitWithinBudget(len(result.ProjectedPatterns), opts.TokenBudget, func(i int) int {
+			data, _ := json.Marshal(result.ProjectedPatterns[i])
+			return counter.CountTokens(string(data))
+		})
+		result.ProjectedPatterns = result.ProjectedPatterns[:kept]
+		result.TokenCount = total
+		if limitReached {
+			result.TokenLimitReached = true
+		}
+		return
+	}
+
+	kept, total, limitReached := fitWithinBudget(len(result.Patterns), opts.TokenBudget, func(i int) int {
+		data, _ := json.Marshal(result.Patterns[i])
+		return counter.CountTokens(string(data))
+	})
+	result.Patterns = result.Patterns[:kept]
+	result.PatternsIncluded = kept
+	result.TokenCount = total
+	if limitReached {
+		result.TokenLimitReached = true
+	}
+}
+
+// fitWithinBudget returns how many of n leading items fit within budget
+// tokens (costOf reporting each item's cost by index), their total cost,
+// and whether the budget cut the list short of n - always keeping the
+// first item even if it alone exceeds budget.
+func fitWithinBudget(n, budget int, costOf func(i int) int) (kept, total int, limitReached bool) {
+	for i := 0; i < n; i++ {
+		cost := costOf(i)
+		if i > 0 && total+cost > budget {
+			return i, total, true
+		}
+		total += cost
+		kept = i + 1
+		if i == 0 && total > budget {
+			return kept, total, true
+		}
+	}
+	return kept, total, false
+}
+
+// approximateAgentTokenCount sums the token cost of each pattern's
+// agent_summary fields (packByBudget's accounting without the
+// description), so a query that hit neither Budget nor TokenBudget still
+// reports a TokenCount reflecting what it actually returned.
+func approximateAgentTokenCount(model string, patterns []PatternOutput) int {
+	counter, err := NewTokenCounterForModel(model)
+	if err != nil {
+		log.Printf("Warning: token counter initialization failed: %v, using approximation", err)
+	}
+	total := 0
+	for _, p := range patterns {
+		total += counter.CountTokens(p.Threat + p.Check + p.Fix)
+	}
+	return total
+}
+
+// packByBudget greedily appends candidates (already sorted by relevance or
+// severity) to result.Patterns, counting the tokens of each pattern's
+// agent_summary plus description, until the next pattern would push the
+// running total over opts.Budget. It always includes at least one pattern
+// so a single oversized match doesn't produce an empty response. A token
+// counter that fails to initialize (e.g. no network to fetch the tiktoken
+// encoding) is non-fatal, as in NewResponseBuilder: CountTokens falls back
+// to its character-count approximation rather than failing the whole
+// query. scores parallels candidates (nil for a no-context, severity-sorted
+// query) and is copied onto each PatternOutput.Score verbatim.
+func packByBudget(result *QueryResult, candidates []*ThreatPattern, scores []float64, opts QueryOptions) {
+	counter, err := NewTokenCounterForModel(opts.Model)
+	if err != nil {
+		log.Printf("Warning: token counter initialization failed: %v, using approximation", err)
+	}
+
+	total := 0
+	for i, p := range candidates {
 		output := PatternOutput{
 			ID:       p.ID,
 			Severity: p.Severity,
 			Threat:   p.AgentSummary.Threat,
 			Check:    p.AgentSummary.Check,
 			Fix:      p.AgentSummary.Fix,
+			Action:   string(ResolveEnforcement(p, opts.EnforcementScope, EnforcementContext{Language: opts.Language, Framework: opts.Framework})),
+		}
+		if i < len(scores) {
+			output.Score = scores[i]
 		}
-
 		if opts.Verbosity == "human" {
 			output.Name = p.Name
 		}
 
+		patternTokens := counter.CountTokens(output.Threat + output.Check + output.Fix + p.Description)
+
+		if len(result.Patterns) > 0 && total+patternTokens > opts.Budget {
+			result.TokenLimitReached = true
+			break
+		}
+
 		result.Patterns = append(result.Patterns, output)
-	}
+		total += patternTokens
 
-	// Add code pattern from most relevant match
-	if len(candidates) > 0 {
-		codePattern := extractCodePattern(candidates[0], opts.Language, opts.Framework)
-		if codePattern != nil {
-			result.CodePattern = codePattern
+		if len(result.Patterns) == 1 && total > opts.Budget {
+			result.TokenLimitReached = true
+			break
 		}
 	}
 
-	return result
+	result.TokenCount = total
+}
+
+// patternDoc marshals p to the same JSON shape exposed by `tmkb get`, for
+// use as the evaluation environment of a compiled filter.Program.
+func patternDoc(p *ThreatPattern) (map[string]interface{}, error) {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
 }
 
 // filterByLanguage filters patterns by programming language
@@ -233,6 +848,44 @@ func filterByCategory(patterns []*ThreatPattern, category string) []*ThreatPatte
 	return filtered
 }
 
+// filterByCWE filters patterns to those referencing the given CWE
+// identifier. A malformed cwe yields no matches, same as an unknown one.
+func filterByCWE(patterns []*ThreatPattern, cwe string) []*ThreatPattern {
+	id, err := ParseCWE(cwe)
+	if err != nil {
+		return nil
+	}
+	var filtered []*ThreatPattern
+	for _, p := range patterns {
+		for _, c := range p.CWEs() {
+			if c == id {
+				filtered = append(filtered, p)
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+// filterByOWASP filters patterns to those referencing the given OWASP Top
+// 10 identifier. A malformed owasp yields no matches, same as an unknown one.
+func filterByOWASP(patterns []*ThreatPattern, owasp string) []*ThreatPattern {
+	id, err := ParseOWASP(owasp)
+	if err != nil {
+		return nil
+	}
+	var filtered []*ThreatPattern
+	for _, p := range patterns {
+		for _, o := range p.OWASPs() {
+			if o == id {
+				filtered = append(filtered, p)
+				break
+			}
+		}
+	}
+	return filtered
+}
+
 // sortByRelevance sorts patterns by relevance score (highest first),
 // with severity and likelihood as tiebreakers
 func sortByRelevance(scored []patternWithScore) {
@@ -250,9 +903,11 @@ func sortByRelevance(scored []patternWithScore) {
 	}
 
 	sort.Slice(scored, func(i, j int) bool {
-		// Primary: relevance score (higher is better)
-		if scored[i].score != scored[j].score {
-			return scored[i].score > scored[j].score
+		// Primary: relevance score (higher is better); scores within
+		// scoreEpsilon of each other are treated as a tie so continuous
+		// BM25-family scores don't make severity/likelihood dead code.
+		if diff := scored[i].score - scored[j].score; math.Abs(diff) > scoreEpsilon {
+			return diff > 0
 		}
 
 		// Secondary: severity (critical > high > medium > low)
@@ -307,13 +962,26 @@ func sortBySeverity(patterns []*ThreatPattern) {
 	})
 }
 
-// extractCodePattern finds the best code example for the query
+// extractCodePattern finds the best code example for the query: the most
+// effective mitigation's example matching (language, framework), falling
+// back to any mitigation with code at all.
 func extractCodePattern(p *ThreatPattern, language, framework string) *CodePatternOutput {
 	if len(p.Mitigations) == 0 {
 		return nil
 	}
 
-	// Find the most effective mitigation with code examples
+	if out := findCodeExample(p, language, framework); out != nil {
+		return out
+	}
+	return findAnyCodeExample(p)
+}
+
+// findCodeExample looks for a code example in a "high"-effectiveness
+// mitigation matching language and framework. A CodeExample tagged
+// Framework "any" always matches, regardless of the requested framework -
+// the convention patterns use for framework-agnostic examples (e.g. a
+// Flask query falling back to a generic example).
+func findCodeExample(p *ThreatPattern, language, framework string) *CodePatternOutput {
 	for _, m := range p.Mitigations {
 		if m.Effectiveness != "high" {
 			continue
@@ -322,7 +990,8 @@ func extractCodePattern(p *ThreatPattern, language, framework string) *CodePatte
 			if language != "" && !strings.EqualFold(ex.Language, language) {
 				continue
 			}
-			if framework != "" && framework != "any" && !strings.Contains(strings.ToLower(ex.Framework), strings.ToLower(framework)) {
+			if framework != "" && framework != "any" && !strings.EqualFold(ex.Framework, "any") &&
+				!strings.Contains(strings.ToLower(ex.Framework), strings.ToLower(framework)) {
 				continue
 			}
 			if ex.SecureCode != "" {
@@ -334,8 +1003,12 @@ func extractCodePattern(p *ThreatPattern, language, framework string) *CodePatte
 			}
 		}
 	}
+	return nil
+}
 
-	// Fallback: any mitigation with code
+// findAnyCodeExample returns the first code example with secure code from
+// any mitigation, ignoring language/framework entirely.
+func findAnyCodeExample(p *ThreatPattern) *CodePatternOutput {
 	for _, m := range p.Mitigations {
 		for _, ex := range m.CodeExamples {
 			if ex.SecureCode != "" {
@@ -347,6 +1020,5 @@ func extractCodePattern(p *ThreatPattern, language, framework string) *CodePatte
 			}
 		}
 	}
-
 	return nil
 }
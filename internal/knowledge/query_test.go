@@ -1,6 +1,8 @@
 package knowledge
 
 import (
+	"encoding/json"
+	"strings"
 	"testing"
 )
 
@@ -92,7 +94,10 @@ func TestQuery_RelevanceSorting_BackgroundJob(t *testing.T) {
 		Limit:   3,
 	}
 
-	result := Query(idx, opts)
+	result, err := Query(idx, opts)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
 
 	// Should return patterns, with AUTHZ-001 ranked highest due to keyword matches
 	if len(result.Patterns) == 0 {
@@ -114,7 +119,10 @@ func TestQuery_RelevanceSorting_MultiTenant(t *testing.T) {
 		Limit:   3,
 	}
 
-	result := Query(idx, opts)
+	result, err := Query(idx, opts)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
 
 	if len(result.Patterns) == 0 {
 		t.Fatal("Expected patterns in result, got none")
@@ -135,7 +143,10 @@ func TestQuery_RelevanceSorting_JWT(t *testing.T) {
 		Limit:   3,
 	}
 
-	result := Query(idx, opts)
+	result, err := Query(idx, opts)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
 
 	if len(result.Patterns) == 0 {
 		t.Fatal("Expected patterns in result, got none")
@@ -147,7 +158,11 @@ func TestQuery_RelevanceSorting_JWT(t *testing.T) {
 	}
 }
 
-// TestQuery_SeverityTiebreaker tests that severity breaks ties when relevance is equal
+// TestQuery_SeverityTiebreaker tests that severity breaks ties when relevance is equal.
+// The two fixtures are identical in every scored field (Triggers.Keywords,
+// AgentSummary, Name, Description) and differ only in ID/Severity/Likelihood,
+// so their BM25F document lengths - and thus their scores - tie exactly
+// rather than merely within scoreEpsilon.
 func TestQuery_SeverityTiebreaker(t *testing.T) {
 	patterns := []ThreatPattern{
 		{
@@ -177,7 +192,7 @@ func TestQuery_SeverityTiebreaker(t *testing.T) {
 				Keywords: []string{"authorization"},
 			},
 			AgentSummary: AgentSummary{
-				Threat: "Critical auth issue",
+				Threat: "Auth issue",
 				Check:  "Check auth",
 				Fix:    "Fix auth",
 			},
@@ -192,7 +207,10 @@ func TestQuery_SeverityTiebreaker(t *testing.T) {
 		Limit:   2,
 	}
 
-	result := Query(idx, opts)
+	result, err := Query(idx, opts)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
 
 	if len(result.Patterns) != 2 {
 		t.Fatalf("Expected 2 patterns, got %d", len(result.Patterns))
@@ -213,7 +231,10 @@ func TestQuery_BackwardCompatibility_NoContext(t *testing.T) {
 		Limit: 3,
 	}
 
-	result := Query(idx, opts)
+	result, err := Query(idx, opts)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
 
 	if len(result.Patterns) == 0 {
 		t.Fatal("Expected patterns in result, got none")
@@ -237,7 +258,10 @@ func TestQuery_BackwardCompatibility_EmptyContext(t *testing.T) {
 		Limit:   3,
 	}
 
-	result := Query(idx, opts)
+	result, err := Query(idx, opts)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
 
 	if len(result.Patterns) == 0 {
 		t.Fatal("Expected patterns in result, got none")
@@ -259,7 +283,10 @@ func TestQuery_ContextWithNoMatches(t *testing.T) {
 		Limit:   3,
 	}
 
-	result := Query(idx, opts)
+	result, err := Query(idx, opts)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
 
 	// MatchContext will return no matches, so Query should fall back to all patterns
 	// This is actually current behavior - no matches means no results
@@ -315,7 +342,10 @@ func TestQuery_RelevanceOverridesSeverity(t *testing.T) {
 		Limit:   2,
 	}
 
-	result := Query(idx, opts)
+	result, err := Query(idx, opts)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
 
 	if len(result.Patterns) != 2 {
 		t.Fatalf("Expected 2 patterns, got %d", len(result.Patterns))
@@ -340,7 +370,10 @@ func TestQuery_AgentMode_TokenLimit(t *testing.T) {
 		Limit:     0,       // Use default
 	}
 
-	result := Query(idx, opts)
+	result, err := Query(idx, opts)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
 
 	// Should use agent mode defaults
 	if result.TokenCount == 0 {
@@ -369,7 +402,10 @@ func TestQuery_VerboseMode_NoTokenLimit(t *testing.T) {
 		Limit:     0,       // Use default
 	}
 
-	result := Query(idx, opts)
+	result, err := Query(idx, opts)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
 
 	// Should use verbose mode
 	if result.TokenCount != 0 {
@@ -435,9 +471,704 @@ func TestQuery_VerboseMode_DefaultLimit(t *testing.T) {
 		Limit:     0, // Default should be 10
 	}
 
-	result := Query(idx, opts)
+	result, err := Query(idx, opts)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
 
 	if result.PatternsIncluded != 10 {
 		t.Errorf("Expected default limit of 10 in verbose mode, got %d", result.PatternsIncluded)
 	}
 }
+
+// TestQuery_BudgetPacking verifies that a small --budget value stops
+// packing before the full (higher) --limit is reached and reports
+// truncation.
+func TestQuery_BudgetPacking(t *testing.T) {
+	patterns := make([]ThreatPattern, 10)
+	for i := 0; i < 10; i++ {
+		patterns[i] = ThreatPattern{
+			ID:         "TMKB-BUDGET-00" + string(rune('1'+i)),
+			Name:       "Budget Pattern " + string(rune('1'+i)),
+			Severity:   "high",
+			Likelihood: "medium",
+			Category:   "authorization",
+			Language:   "python",
+			Framework:  "flask",
+			Triggers: Triggers{
+				Keywords: []string{"authorization"},
+			},
+			AgentSummary: AgentSummary{
+				Threat: "Background jobs lose auth context when dispatched to a queue",
+				Check:  "Verify the handler re-checks authorization before acting",
+				Fix:    "Pass the user ID and re-validate permissions in the worker",
+			},
+			Description: "A long-form description of the authorization gap that repeats across every test pattern so each one costs a comparable number of tokens to pack.",
+		}
+	}
+
+	idx := NewIndex()
+	idx.Build(patterns)
+
+	opts := QueryOptions{
+		Context: "authorization",
+		Limit:   10,
+		Budget:  60,
+		Model:   "gpt-4o",
+	}
+
+	result, err := Query(idx, opts)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+
+	if len(result.Patterns) == 0 {
+		t.Fatal("Expected at least one packed pattern")
+	}
+	if len(result.Patterns) >= 10 {
+		t.Errorf("Expected the budget to stop packing before all 10 patterns, got %d", len(result.Patterns))
+	}
+	if !result.TokenLimitReached {
+		t.Error("Expected token_limit_reached to be true once the budget is exceeded")
+	}
+	if result.TokenCount <= 0 {
+		t.Error("Expected token_count to be set")
+	}
+	if result.PatternsIncluded != len(result.Patterns) {
+		t.Errorf("Expected patterns_included to match packed count, got %d for %d patterns",
+			result.PatternsIncluded, len(result.Patterns))
+	}
+}
+
+// TestQuery_BudgetPacking_UnrecognizedModelStillWorks verifies a Claude/Gemini
+// (or otherwise unrecognized) --model still produces a usable counter via the
+// cl100k_base heuristic fallback instead of erroring.
+func TestQuery_BudgetPacking_UnrecognizedModelStillWorks(t *testing.T) {
+	idx := createTestIndex()
+
+	opts := QueryOptions{
+		Context: "background job authorization",
+		Budget:  2000,
+		Model:   "claude-3-opus",
+	}
+
+	result, err := Query(idx, opts)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(result.Patterns) == 0 {
+		t.Error("Expected at least one packed pattern")
+	}
+	if result.TokenLimitReached {
+		t.Error("Expected a generous budget not to be exceeded")
+	}
+}
+
+// TestQuery_TriggerExprMatch verifies a pattern reachable only via
+// Triggers.Any/All (no keyword overlap with the query context at all) is
+// still surfaced by Query, and ranks above a pure keyword match.
+func TestQuery_TriggerExprMatch(t *testing.T) {
+	patterns := []ThreatPattern{
+		{
+			ID:         "TMKB-CELERY-001",
+			Name:       "Celery Task Authorization",
+			Severity:   "medium",
+			Likelihood: "medium",
+			Category:   "authorization",
+			Triggers: Triggers{
+				Any: []TriggerExpr{
+					{Field: "file", Regex: `celery/.*\.py$`},
+				},
+			},
+			AgentSummary: AgentSummary{Threat: "t", Check: "c", Fix: "f"},
+		},
+		{
+			ID:         "TMKB-GENERIC-001",
+			Name:       "Generic Authorization",
+			Severity:   "critical",
+			Likelihood: "high",
+			Category:   "authorization",
+			Triggers: Triggers{
+				Keywords: []string{"authorization"},
+			},
+			AgentSummary: AgentSummary{Threat: "t", Check: "c", Fix: "f"},
+		},
+	}
+
+	idx := NewIndex()
+	idx.Build(patterns)
+
+	opts := QueryOptions{
+		Context:  "authorization check",
+		FilePath: "tasks/celery/worker.py",
+		Limit:    2,
+	}
+
+	result, err := Query(idx, opts)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(result.Patterns) != 2 {
+		t.Fatalf("Expected both patterns to be candidates, got %d", len(result.Patterns))
+	}
+	if result.Patterns[0].ID != "TMKB-CELERY-001" {
+		t.Errorf("Expected the trigger-expression match to rank first, got %s", result.Patterns[0].ID)
+	}
+}
+
+// TestQuery_TriggerExprNoFilePath verifies a "file"-field trigger doesn't
+// spuriously match when --file is never supplied.
+func TestQuery_TriggerExprNoFilePath(t *testing.T) {
+	patterns := []ThreatPattern{
+		{
+			ID:       "TMKB-CELERY-001",
+			Name:     "Celery Task Authorization",
+			Severity: "medium",
+			Category: "authorization",
+			Triggers: Triggers{
+				Any: []TriggerExpr{
+					{Field: "file", Regex: `celery/.*\.py$`},
+				},
+			},
+			AgentSummary: AgentSummary{Threat: "t", Check: "c", Fix: "f"},
+		},
+	}
+
+	idx := NewIndex()
+	idx.Build(patterns)
+
+	result, err := Query(idx, QueryOptions{Context: "authorization check"})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(result.Patterns) != 0 {
+		t.Errorf("Expected no match without --file, got %d", len(result.Patterns))
+	}
+}
+
+// TestQuery_PatternExprDropsNonMatches verifies a pattern with a
+// triggers.expr is excluded from results when the expression doesn't
+// match the query context, even though it would otherwise be a
+// keyword-overlap candidate. It drives the expression off --file rather
+// than --framework/--language, since those two have their own strict
+// filterByFramework/filterByLanguage pre-filters upstream of Expr that a
+// pattern with no Framework/Language field set would never survive.
+func TestQuery_PatternExprDropsNonMatches(t *testing.T) {
+	patterns := []ThreatPattern{
+		{
+			ID:       "TMKB-JWT-NONTEST-001",
+			Name:     "JWT pattern, excluded from test files",
+			Severity: "high",
+			Category: "authorization",
+			Triggers: Triggers{
+				Keywords: []string{"jwt"},
+				Expr:     `("jwt" in keywords) and not ("test" in filename_tokens)`,
+			},
+			AgentSummary: AgentSummary{Threat: "t", Check: "c", Fix: "f"},
+		},
+	}
+
+	idx := NewIndex()
+	idx.Build(patterns)
+
+	result, err := Query(idx, QueryOptions{Context: "jwt validation", FilePath: "handlers/test_routes.go"})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(result.Patterns) != 0 {
+		t.Errorf("expected triggers.expr to exclude a test-file query, got %d pattern(s)", len(result.Patterns))
+	}
+
+	result, err = Query(idx, QueryOptions{Context: "jwt validation", FilePath: "handlers/routes.go"})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(result.Patterns) != 1 || result.Patterns[0].ID != "TMKB-JWT-NONTEST-001" {
+		t.Errorf("expected triggers.expr to include a non-test-file query, got %+v", result.Patterns)
+	}
+}
+
+// TestQuery_PatternExprMatchBonus verifies a triggers.expr match outranks a
+// plain keyword-overlap candidate even when the latter has more keyword
+// overlap with the query.
+func TestQuery_PatternExprMatchBonus(t *testing.T) {
+	patterns := []ThreatPattern{
+		{
+			ID:       "TMKB-EXPR-001",
+			Name:     "Expr-matched pattern",
+			Severity: "low",
+			Category: "authorization",
+			Language: "go",
+			Triggers: Triggers{
+				Keywords: []string{"auth"},
+				Expr:     `language == "go"`,
+			},
+			AgentSummary: AgentSummary{Threat: "t", Check: "c", Fix: "f"},
+		},
+		{
+			ID:       "TMKB-KEYWORD-001",
+			Name:     "Keyword-only pattern",
+			Severity: "critical",
+			Category: "authorization",
+			Language: "go",
+			Triggers: Triggers{
+				Keywords: []string{"auth", "authorization", "access control"},
+			},
+			AgentSummary: AgentSummary{Threat: "t", Check: "c", Fix: "f"},
+		},
+	}
+
+	idx := NewIndex()
+	idx.Build(patterns)
+
+	result, err := Query(idx, QueryOptions{Context: "auth authorization access control", Language: "go", Limit: 2})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(result.Patterns) != 2 {
+		t.Fatalf("expected both patterns as candidates, got %d", len(result.Patterns))
+	}
+	if result.Patterns[0].ID != "TMKB-EXPR-001" {
+		t.Errorf("expected the triggers.expr match to rank first, got %s", result.Patterns[0].ID)
+	}
+}
+
+// TestQuery_PatternExprRuntimeErrorIsWarningNotFailure verifies a
+// triggers.expr that fails at evaluation time (here, an unknown field) only
+// drops that one candidate rather than failing the whole query.
+func TestQuery_PatternExprRuntimeErrorIsWarningNotFailure(t *testing.T) {
+	patterns := []ThreatPattern{
+		{
+			ID:       "TMKB-BADEXPR-001",
+			Name:     "Broken expr",
+			Severity: "medium",
+			Category: "authorization",
+			Triggers: Triggers{
+				Keywords: []string{"auth"},
+				Expr:     `no_such_field == "x"`,
+			},
+			AgentSummary: AgentSummary{Threat: "t", Check: "c", Fix: "f"},
+		},
+		{
+			ID:       "TMKB-OK-001",
+			Name:     "Fine pattern",
+			Severity: "medium",
+			Category: "authorization",
+			Triggers: Triggers{
+				Keywords: []string{"auth"},
+			},
+			AgentSummary: AgentSummary{Threat: "t", Check: "c", Fix: "f"},
+		},
+	}
+
+	idx := NewIndex()
+	idx.Build(patterns)
+
+	result, err := Query(idx, QueryOptions{Context: "auth check", Limit: 2})
+	if err != nil {
+		t.Fatalf("expected a broken triggers.expr to degrade to a warning, not fail Query, got %v", err)
+	}
+	if len(result.Patterns) != 1 || result.Patterns[0].ID != "TMKB-OK-001" {
+		t.Errorf("expected only the pattern with a working expr, got %+v", result.Patterns)
+	}
+}
+
+// TestQuery_CWEFilter verifies opts.CWE narrows candidates to patterns
+// referencing that CWE.
+func TestQuery_CWEFilter(t *testing.T) {
+	patterns := []ThreatPattern{
+		{
+			ID:       "TMKB-AUTHZ-010",
+			Severity: "high",
+			Provenance: Provenance{
+				PublicReferences: []PublicReference{{CWE: "CWE-352"}},
+			},
+			AgentSummary: AgentSummary{Threat: "t", Check: "c", Fix: "f"},
+		},
+		{
+			ID:       "TMKB-AUTHZ-011",
+			Severity: "high",
+			Provenance: Provenance{
+				PublicReferences: []PublicReference{{CWE: "CWE-862"}},
+			},
+			AgentSummary: AgentSummary{Threat: "t", Check: "c", Fix: "f"},
+		},
+	}
+	idx := NewIndex()
+	idx.Build(patterns)
+
+	result, err := Query(idx, QueryOptions{CWE: "CWE-352"})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(result.Patterns) != 1 || result.Patterns[0].ID != "TMKB-AUTHZ-010" {
+		t.Errorf("Expected only TMKB-AUTHZ-010, got %v", result.Patterns)
+	}
+}
+
+// TestQuery_OWASPFilter verifies opts.OWASP narrows candidates to patterns
+// referencing that OWASP Top 10 category.
+func TestQuery_OWASPFilter(t *testing.T) {
+	patterns := []ThreatPattern{
+		{
+			ID:       "TMKB-AUTHZ-010",
+			Severity: "high",
+			Provenance: Provenance{
+				PublicReferences: []PublicReference{{OWASP: "A01:2021"}},
+			},
+			AgentSummary: AgentSummary{Threat: "t", Check: "c", Fix: "f"},
+		},
+		{
+			ID:       "TMKB-AUTHZ-011",
+			Severity: "high",
+			Provenance: Provenance{
+				PublicReferences: []PublicReference{{OWASP: "A03:2021"}},
+			},
+			AgentSummary: AgentSummary{Threat: "t", Check: "c", Fix: "f"},
+		},
+	}
+	idx := NewIndex()
+	idx.Build(patterns)
+
+	result, err := Query(idx, QueryOptions{OWASP: "A03:2021"})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(result.Patterns) != 1 || result.Patterns[0].ID != "TMKB-AUTHZ-011" {
+		t.Errorf("Expected only TMKB-AUTHZ-011, got %v", result.Patterns)
+	}
+}
+
+// TestQuery_RankerBM25 verifies that opts.Ranker == "bm25" is actually wired
+// into Query's scoring loop and still produces the expected top match.
+func TestQuery_RankerBM25(t *testing.T) {
+	idx := createTestIndex()
+
+	opts := QueryOptions{
+		Context: "multi-tenant organization data isolation",
+		Ranker:  "bm25",
+		Limit:   3,
+	}
+
+	result, err := Query(idx, opts)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+
+	if len(result.Patterns) == 0 {
+		t.Fatal("Expected patterns in result, got none")
+	}
+
+	if result.Patterns[0].ID != "TMKB-AUTHZ-002" {
+		t.Errorf("Expected TMKB-AUTHZ-002 first under bm25 ranker, got %s", result.Patterns[0].ID)
+	}
+}
+
+// TestQuery_RankerDefaultMatchesHybrid confirms an empty Ranker and an
+// explicit "hybrid" Ranker resolve to the same scoring formula (both fall
+// into Query's default case), so existing callers leaving Ranker unset see
+// identical behavior to naming it explicitly.
+func TestQuery_RankerDefaultMatchesHybrid(t *testing.T) {
+	idx := createTestIndex()
+
+	hybrid, err := Query(idx, QueryOptions{Context: "background job authorization", Limit: 3})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	explicit, err := Query(idx, QueryOptions{Context: "background job authorization", Ranker: "hybrid", Limit: 3})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+
+	if len(hybrid.Patterns) == 0 || len(explicit.Patterns) == 0 {
+		t.Fatal("Expected patterns in both results")
+	}
+	if hybrid.Patterns[0].ID != explicit.Patterns[0].ID {
+		t.Errorf("Expected default ranker to match explicit \"hybrid\", got %s vs %s", hybrid.Patterns[0].ID, explicit.Patterns[0].ID)
+	}
+}
+
+// TestQuery_MatchModeWired verifies opts.MatchMode reaches
+// MatchContextWithOptions: an exact-mode query over a morphological
+// variant of the pattern's only keyword finds nothing, while the same
+// query with MatchMode "fuzzy" retrieves it.
+func TestQuery_MatchModeWired(t *testing.T) {
+	idx := NewIndex()
+	idx.Build([]ThreatPattern{
+		{
+			ID:       "TMKB-MATCHMODE-001",
+			Name:     "Match Mode Test Pattern",
+			Triggers: Triggers{Keywords: []string{"authorization"}},
+		},
+	})
+
+	exact, err := Query(idx, QueryOptions{Context: "autorization check", MatchMode: "exact", Limit: 3})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(exact.Patterns) != 0 {
+		t.Fatalf("Query(MatchMode=exact) = %v, want no matches on a typo'd context", exact.Patterns)
+	}
+
+	fuzzy, err := Query(idx, QueryOptions{Context: "autorization check", MatchMode: "fuzzy", Limit: 3})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(fuzzy.Patterns) != 1 || fuzzy.Patterns[0].ID != "TMKB-MATCHMODE-001" {
+		t.Fatalf("Query(MatchMode=fuzzy) = %v, want [TMKB-MATCHMODE-001]", fuzzy.Patterns)
+	}
+}
+
+// TestQuery_PatternOutputExposesScore verifies a context-scored query
+// surfaces the raw relevance score on each PatternOutput, rather than
+// discarding it once sorting is done.
+func TestQuery_PatternOutputExposesScore(t *testing.T) {
+	idx := createTestIndex()
+
+	result, err := Query(idx, QueryOptions{Context: "background job authorization", Limit: 3})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(result.Patterns) == 0 {
+		t.Fatal("Expected patterns in result, got none")
+	}
+	if result.Patterns[0].Score <= 0 {
+		t.Errorf("Expected a positive score on the top match, got %v", result.Patterns[0].Score)
+	}
+}
+
+// TestQuery_MinScorePrunesLowRelevanceMatches verifies opts.MinScore drops
+// candidates whose score falls below the threshold, independent of Limit.
+func TestQuery_MinScorePrunesLowRelevanceMatches(t *testing.T) {
+	idx := createTestIndex()
+
+	baseline, err := Query(idx, QueryOptions{Context: "background job authorization", Limit: 10})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(baseline.Patterns) < 2 {
+		t.Fatalf("expected at least 2 baseline matches, got %d", len(baseline.Patterns))
+	}
+
+	// Set the threshold just above the weakest match's score, so it alone
+	// should be pruned.
+	threshold := baseline.Patterns[len(baseline.Patterns)-1].Score + 0.01
+
+	pruned, err := Query(idx, QueryOptions{Context: "background job authorization", Limit: 10, MinScore: threshold})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(pruned.Patterns) != len(baseline.Patterns)-1 {
+		t.Fatalf("expected MinScore to prune exactly 1 match, got %d patterns (baseline had %d)", len(pruned.Patterns), len(baseline.Patterns))
+	}
+	for _, p := range pruned.Patterns {
+		if p.Score < threshold {
+			t.Errorf("pattern %s scored %v, below MinScore %v", p.ID, p.Score, threshold)
+		}
+	}
+}
+
+// TestQuery_MaxTokensPagination verifies opts.MaxTokens switches Query over
+// to ResponseBuilder's knapsack+pagination path, and that a subsequent call
+// with the returned Cursor resumes from where the first page left off.
+func TestQuery_MaxTokensPagination(t *testing.T) {
+	// More than responsePageWindow patterns, so Build's first call doesn't
+	// already see the whole candidate set in a single page.
+	const numPatterns = 25
+	patterns := make([]ThreatPattern, numPatterns)
+	for i := 0; i < numPatterns; i++ {
+		patterns[i] = ThreatPattern{
+			ID:       "TMKB-PAGE-" + string(rune('A'+i)),
+			Severity: "high",
+			Category: "authorization",
+			Triggers: Triggers{Keywords: []string{"authorization"}},
+			AgentSummary: AgentSummary{
+				Threat: "Background jobs lose auth context when dispatched to a queue",
+				Check:  "Verify the handler re-checks authorization before acting",
+				Fix:    "Pass the user ID and re-validate permissions in the worker",
+			},
+		}
+	}
+
+	idx := NewIndex()
+	idx.Build(patterns)
+
+	opts := QueryOptions{
+		Context:   "authorization",
+		MaxTokens: 100000,
+		Model:     "gpt-4o",
+	}
+
+	page1, err := Query(idx, opts)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(page1.Patterns) == 0 {
+		t.Fatal("Expected at least one packed pattern")
+	}
+	if page1.PatternCount != numPatterns {
+		t.Errorf("Expected pattern_count=%d (full candidate set, not Limit-truncated), got %d", numPatterns, page1.PatternCount)
+	}
+	if page1.NextCursor == "" {
+		t.Fatal("Expected a next_cursor since more candidates remain beyond the page window")
+	}
+
+	opts.Cursor = page1.NextCursor
+	page2, err := Query(idx, opts)
+	if err != nil {
+		t.Fatalf("Query with cursor failed: %v", err)
+	}
+	if len(page2.Patterns) == 0 {
+		t.Fatal("Expected page 2 to include packed patterns")
+	}
+	if page1.Patterns[0].ID == page2.Patterns[0].ID {
+		t.Error("Expected page 2 to return different patterns than page 1")
+	}
+}
+
+// TestQuery_MaxTokensCompressedTier verifies opts.Verbosity "compressed"
+// populates CompressedPatterns instead of Patterns when MaxTokens is set.
+func TestQuery_MaxTokensCompressedTier(t *testing.T) {
+	idx := createTestIndex()
+
+	result, err := Query(idx, QueryOptions{
+		Context:   "authorization",
+		MaxTokens: 500,
+		Verbosity: "compressed",
+	})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+
+	if len(result.Patterns) != 0 {
+		t.Errorf("Expected no Patterns in compressed tier, got %d", len(result.Patterns))
+	}
+	if len(result.CompressedPatterns) == 0 {
+		t.Error("Expected compressed patterns in result")
+	}
+}
+
+// TestQuery_FieldsProjectsPatterns verifies opts.Fields narrows each
+// result down to a projected document built from the full ThreatPattern,
+// reaching fields (like a mitigation's secure_code) that PatternOutput
+// never exposes.
+func TestQuery_FieldsProjectsPatterns(t *testing.T) {
+	patterns := []ThreatPattern{
+		{
+			ID:       "TMKB-FIELDS-001",
+			Severity: "critical",
+			Category: "authorization",
+			Triggers: Triggers{Keywords: []string{"authorization"}},
+			Mitigations: []Mitigation{
+				{
+					ID: "mit-1",
+					CodeExamples: []CodeExample{
+						{Language: "python", SecureCode: "require_permission(user, resource)"},
+					},
+				},
+			},
+		},
+	}
+	idx := NewIndex()
+	idx.Build(patterns)
+
+	result, err := Query(idx, QueryOptions{
+		Context: "authorization",
+		Fields:  []string{"id", "severity", "mitigations[*].code_examples[*].secure_code"},
+	})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+
+	if len(result.ProjectedPatterns) != 1 {
+		t.Fatalf("Expected 1 projected pattern, got %d", len(result.ProjectedPatterns))
+	}
+	data, err := json.Marshal(result.ProjectedPatterns[0])
+	if err != nil {
+		t.Fatalf("failed to marshal projected pattern: %v", err)
+	}
+	if !strings.Contains(string(data), "require_permission") {
+		t.Errorf("Expected projected pattern to include the secure_code field, got: %s", data)
+	}
+	if strings.Contains(string(data), "category") {
+		t.Errorf("Expected category to be pruned from the projected pattern, got: %s", data)
+	}
+}
+
+// TestQuery_ExcludeDropsPath verifies opts.Exclude removes a field from
+// the projected document built by opts.Fields.
+func TestQuery_ExcludeDropsPath(t *testing.T) {
+	idx := createTestIndex()
+
+	result, err := Query(idx, QueryOptions{
+		Context: "authorization",
+		Fields:  []string{"id", "severity", "description"},
+		Exclude: []string{"description"},
+	})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+
+	if len(result.ProjectedPatterns) == 0 {
+		t.Fatal("Expected at least one projected pattern")
+	}
+	data, err := json.Marshal(result.ProjectedPatterns[0])
+	if err != nil {
+		t.Fatalf("failed to marshal projected pattern: %v", err)
+	}
+	if strings.Contains(string(data), "description") {
+		t.Errorf("Expected description to be excluded, got: %s", data)
+	}
+	if !strings.Contains(string(data), `"id"`) {
+		t.Errorf("Expected id to survive the exclude, got: %s", data)
+	}
+}
+
+// TestQuery_TokenBudgetDropsTail verifies opts.TokenBudget drops patterns
+// from the tail of result.Patterns once the budget is exceeded, setting
+// TokenLimitReached - same contract as the older opts.Budget, but
+// triggered by a separate option so it composes with Fields/Include.
+func TestQuery_TokenBudgetDropsTail(t *testing.T) {
+	patterns := make([]ThreatPattern, 10)
+	for i := 0; i < 10; i++ {
+		patterns[i] = ThreatPattern{
+			ID:         "TMKB-TOKENBUDGET-00" + string(rune('1'+i)),
+			Name:       "Token Budget Pattern " + string(rune('1'+i)),
+			Severity:   "high",
+			Likelihood: "medium",
+			Category:   "authorization",
+			Triggers:   Triggers{Keywords: []string{"authorization"}},
+			AgentSummary: AgentSummary{
+				Threat: "Background jobs lose auth context when dispatched to a queue",
+				Check:  "Verify the handler re-checks authorization before acting",
+				Fix:    "Pass the user ID and re-validate permissions in the worker",
+			},
+		}
+	}
+	idx := NewIndex()
+	idx.Build(patterns)
+
+	result, err := Query(idx, QueryOptions{
+		Context:     "authorization",
+		Limit:       10,
+		TokenBudget: 60,
+		Model:       "gpt-4o",
+	})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+
+	if len(result.Patterns) == 0 {
+		t.Fatal("Expected at least one packed pattern")
+	}
+	if len(result.Patterns) >= 10 {
+		t.Errorf("Expected the token budget to stop packing before all 10 patterns, got %d", len(result.Patterns))
+	}
+	if !result.TokenLimitReached {
+		t.Error("Expected token_limit_reached to be true once the budget is exceeded")
+	}
+	if result.PatternsIncluded != len(result.Patterns) {
+		t.Errorf("Expected patterns_included to match packed count, got %d for %d patterns",
+			result.PatternsIncluded, len(result.Patterns))
+	}
+}
@@ -0,0 +1,93 @@
+package knowledge
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// cweFormat matches a normalized CWE identifier, e.g. "CWE-79".
+var cweFormat = regexp.MustCompile(`^CWE-[0-9]+$`)
+
+// owaspFormat matches a normalized OWASP Top 10 identifier, e.g. "A03:2021".
+var owaspFormat = regexp.MustCompile(`^A[0-9]{2}:[0-9]{4}$`)
+
+// ParseCWE validates and normalizes a CWE identifier such as "cwe-79" into
+// its canonical "CWE-<number>" form.
+func ParseCWE(s string) (string, error) {
+	id := strings.ToUpper(strings.TrimSpace(s))
+	if !cweFormat.MatchString(id) {
+		return "", fmt.Errorf("invalid CWE identifier %q: expected format CWE-<number>, e.g. CWE-79", s)
+	}
+	return id, nil
+}
+
+// ParseOWASP validates and normalizes an OWASP Top 10 identifier such as
+// "a03:2021" into its canonical "A<rank>:<year>" form.
+func ParseOWASP(s string) (string, error) {
+	id := strings.ToUpper(strings.TrimSpace(s))
+	if !owaspFormat.MatchString(id) {
+		return "", fmt.Errorf("invalid OWASP identifier %q: expected format A<rank>:<year>, e.g. A03:2021", s)
+	}
+	return id, nil
+}
+
+// CWEs returns the distinct CWE identifiers referenced by p's provenance,
+// normalized to their canonical form. References that don't parse as a
+// valid CWE (flagged separately by Validate) are skipped rather than
+// failing the whole pattern.
+func (p *ThreatPattern) CWEs() []string {
+	var ids []string
+	seen := make(map[string]bool)
+	for _, ref := range p.Provenance.PublicReferences {
+		if ref.CWE == "" {
+			continue
+		}
+		id, err := ParseCWE(ref.CWE)
+		if err != nil || seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// OWASPs returns the distinct OWASP identifiers referenced by p's
+// provenance, normalized to their canonical form.
+func (p *ThreatPattern) OWASPs() []string {
+	var ids []string
+	seen := make(map[string]bool)
+	for _, ref := range p.Provenance.PublicReferences {
+		if ref.OWASP == "" {
+			continue
+		}
+		id, err := ParseOWASP(ref.OWASP)
+		if err != nil || seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// CoverageGaps returns the entries of targets (CWE identifiers such as
+// "CWE-79") referenced by zero patterns in idx, preserving targets' order.
+// A malformed target is reported back verbatim (uppercased) rather than
+// silently dropped, so a typo in the target list still surfaces as a gap
+// instead of vanishing from the report.
+func CoverageGaps(idx *Index, targets []string) []string {
+	var gaps []string
+	for _, t := range targets {
+		id, err := ParseCWE(t)
+		if err != nil {
+			gaps = append(gaps, strings.ToUpper(strings.TrimSpace(t)))
+			continue
+		}
+		if len(idx.GetByCWE(id)) == 0 {
+			gaps = append(gaps, id)
+		}
+	}
+	return gaps
+}
@@ -0,0 +1,190 @@
+package knowledge
+
+import "testing"
+
+func TestParseCWE(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{"Valid", "CWE-79", "CWE-79", false},
+		{"Lowercase normalized", "cwe-79", "CWE-79", false},
+		{"Whitespace trimmed", "  CWE-79  ", "CWE-79", false},
+		{"Missing number", "CWE-", "", true},
+		{"Wrong prefix", "OWASP-79", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseCWE(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseCWE(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if err == nil && got != tt.want {
+				t.Errorf("ParseCWE(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseOWASP(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{"Valid", "A03:2021", "A03:2021", false},
+		{"Lowercase normalized", "a03:2021", "A03:2021", false},
+		{"Missing year", "A03", "", true},
+		{"Wrong format", "CWE-79", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseOWASP(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseOWASP(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if err == nil && got != tt.want {
+				t.Errorf("ParseOWASP(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestThreatPattern_CWEsDedupesAndSkipsInvalid(t *testing.T) {
+	p := ThreatPattern{
+		Provenance: Provenance{
+			PublicReferences: []PublicReference{
+				{CWE: "CWE-79"},
+				{CWE: "cwe-79"},
+				{CWE: "not-a-cwe"},
+				{OWASP: "A03:2021"},
+			},
+		},
+	}
+
+	cwes := p.CWEs()
+	if len(cwes) != 1 || cwes[0] != "CWE-79" {
+		t.Errorf("Expected [CWE-79], got %v", cwes)
+	}
+
+	owasps := p.OWASPs()
+	if len(owasps) != 1 || owasps[0] != "A03:2021" {
+		t.Errorf("Expected [A03:2021], got %v", owasps)
+	}
+}
+
+func TestIndex_GetByCWEAndOWASP(t *testing.T) {
+	patterns := []ThreatPattern{
+		{
+			ID: "TMKB-AUTHZ-001",
+			Provenance: Provenance{
+				PublicReferences: []PublicReference{{CWE: "CWE-862"}},
+			},
+		},
+		{
+			ID: "TMKB-AUTHZ-002",
+			Provenance: Provenance{
+				PublicReferences: []PublicReference{{CWE: "CWE-352", OWASP: "A01:2021"}},
+			},
+		},
+	}
+	idx := NewIndex()
+	idx.Build(patterns)
+
+	if got := idx.GetByCWE("cwe-862"); len(got) != 1 || got[0].ID != "TMKB-AUTHZ-001" {
+		t.Errorf("GetByCWE(cwe-862) = %v, want [TMKB-AUTHZ-001]", got)
+	}
+	if got := idx.GetByOWASP("a01:2021"); len(got) != 1 || got[0].ID != "TMKB-AUTHZ-002" {
+		t.Errorf("GetByOWASP(a01:2021) = %v, want [TMKB-AUTHZ-002]", got)
+	}
+	if got := idx.GetByCWE("not-a-cwe"); got != nil {
+		t.Errorf("GetByCWE(not-a-cwe) = %v, want nil", got)
+	}
+	if got := idx.GetByCWE("CWE-999"); got != nil {
+		t.Errorf("GetByCWE(CWE-999) = %v, want nil", got)
+	}
+}
+
+// TestIndex_RelatedByReference verifies Build stamps RelatedByReference
+// with the IDs of other patterns sharing a CWE or OWASP reference, without
+// requiring an explicit RelatedPatterns entry.
+func TestIndex_RelatedByReference(t *testing.T) {
+	patterns := []ThreatPattern{
+		{
+			ID: "TMKB-AUTHZ-001",
+			Provenance: Provenance{
+				PublicReferences: []PublicReference{{CWE: "CWE-862"}},
+			},
+		},
+		{
+			ID: "TMKB-AUTHZ-002",
+			Provenance: Provenance{
+				PublicReferences: []PublicReference{{CWE: "CWE-862"}, {OWASP: "A01:2021"}},
+			},
+		},
+		{
+			ID: "TMKB-AUTHZ-003",
+			Provenance: Provenance{
+				PublicReferences: []PublicReference{{OWASP: "A01:2021"}},
+			},
+		},
+		{
+			ID: "TMKB-CRYPTO-001",
+		},
+	}
+	idx := NewIndex()
+	idx.Build(patterns)
+
+	want := map[string][]string{
+		"TMKB-AUTHZ-001":  {"TMKB-AUTHZ-002"},
+		"TMKB-AUTHZ-002":  {"TMKB-AUTHZ-001", "TMKB-AUTHZ-003"},
+		"TMKB-AUTHZ-003":  {"TMKB-AUTHZ-002"},
+		"TMKB-CRYPTO-001": nil,
+	}
+
+	for id, expected := range want {
+		p := idx.GetByID(id)
+		if p == nil {
+			t.Fatalf("pattern %s not found", id)
+		}
+		if len(p.RelatedByReference) != len(expected) {
+			t.Errorf("%s: RelatedByReference = %v, want %v", id, p.RelatedByReference, expected)
+			continue
+		}
+		for i, e := range expected {
+			if p.RelatedByReference[i] != e {
+				t.Errorf("%s: RelatedByReference[%d] = %q, want %q", id, i, p.RelatedByReference[i], e)
+			}
+		}
+	}
+}
+
+func TestCoverageGaps(t *testing.T) {
+	patterns := []ThreatPattern{
+		{
+			ID: "TMKB-AUTHZ-001",
+			Provenance: Provenance{
+				PublicReferences: []PublicReference{{CWE: "CWE-862"}},
+			},
+		},
+	}
+	idx := NewIndex()
+	idx.Build(patterns)
+
+	gaps := CoverageGaps(idx, []string{"CWE-862", "CWE-79", "not-a-cwe"})
+
+	want := []string{"CWE-79", "NOT-A-CWE"}
+	if len(gaps) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, gaps)
+	}
+	for i, g := range want {
+		if gaps[i] != g {
+			t.Errorf("gaps[%d] = %q, want %q", i, gaps[i], g)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+// Package regression runs the same YAML fixture format as
+// knowledge/bench, but as pass/fail assertions rather than aggregate
+// latency/precision metrics: each Case's query must return exactly its
+// expected ranked pattern IDs, so a ranking regression fails the run
+// instead of quietly lowering a score.
+package regression
+
+import (
+	"fmt"
+
+	"github.com/mark-chris/tmkb/internal/knowledge"
+	"github.com/mark-chris/tmkb/internal/knowledge/bench"
+)
+
+// CaseResult is the pass/fail outcome of a single bench.Case.
+type CaseResult struct {
+	Name     string
+	Passed   bool
+	Got      []string
+	Expected []string
+	Err      error
+}
+
+// Report summarizes a regression run across all cases.
+type Report struct {
+	Cases  []CaseResult
+	Passed int
+	Failed int
+}
+
+// Run executes every case against idx and asserts that the query's
+// ranked pattern IDs match Case.ExpectedIDs exactly, in order.
+func Run(idx *knowledge.Index, cases []bench.Case) (*Report, error) {
+	report := &Report{Cases: make([]CaseResult, 0, len(cases))}
+
+	for _, c := range cases {
+		opts := knowledge.QueryOptions{
+			Context:   c.Context,
+			Language:  c.Language,
+			Framework: c.Framework,
+			Limit:     len(c.ExpectedIDs),
+			Verbosity: "human",
+		}
+
+		result, err := knowledge.Query(idx, opts)
+		if err != nil {
+			return nil, fmt.Errorf("case %q: %w", c.Name, err)
+		}
+
+		got := make([]string, 0, len(result.VerbosePatterns))
+		for _, p := range result.VerbosePatterns {
+			got = append(got, p.ID)
+		}
+
+		cr := CaseResult{
+			Name:     c.Name,
+			Got:      got,
+			Expected: c.ExpectedIDs,
+			Passed:   idsEqual(got, c.ExpectedIDs),
+		}
+		report.Cases = append(report.Cases, cr)
+		if cr.Passed {
+			report.Passed++
+		} else {
+			report.Failed++
+		}
+	}
+
+	return report, nil
+}
+
+// idsEqual reports whether got and expected contain the same pattern IDs
+// in the same order.
+func idsEqual(got, expected []string) bool {
+	if len(got) != len(expected) {
+		return false
+	}
+	for i := range got {
+		if got[i] != expected[i] {
+			return false
+		}
+	}
+	return true
+}
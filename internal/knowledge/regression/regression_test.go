@@ -0,0 +1,73 @@
+package regression
+
+import (
+	"testing"
+
+	"github.com/mark-chris/tmkb/internal/cli/testutil"
+	"github.com/mark-chris/tmkb/internal/knowledge"
+	"github.com/mark-chris/tmkb/internal/knowledge/bench"
+)
+
+func TestRun_PassesOnExactMatch(t *testing.T) {
+	fixture := testutil.SetupTestPatterns(t)
+	defer fixture.Cleanup()
+
+	idx := knowledge.NewIndex()
+	idx.Build(fixture.Patterns)
+
+	cases := []bench.Case{
+		{Name: "background job", Context: "background job authorization", ExpectedIDs: []string{"TMKB-TEST-001"}},
+	}
+
+	report, err := Run(idx, cases)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if report.Passed != 1 || report.Failed != 0 {
+		t.Fatalf("expected 1 pass/0 fail, got %d pass/%d fail", report.Passed, report.Failed)
+	}
+}
+
+func TestRun_FailsOnMismatch(t *testing.T) {
+	fixture := testutil.SetupTestPatterns(t)
+	defer fixture.Cleanup()
+
+	idx := knowledge.NewIndex()
+	idx.Build(fixture.Patterns)
+
+	cases := []bench.Case{
+		{Name: "wrong expectation", Context: "background job authorization", ExpectedIDs: []string{"TMKB-NOT-REAL"}},
+	}
+
+	report, err := Run(idx, cases)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if report.Passed != 0 || report.Failed != 1 {
+		t.Fatalf("expected 0 pass/1 fail, got %d pass/%d fail", report.Passed, report.Failed)
+	}
+	if report.Cases[0].Passed {
+		t.Error("expected case result to report failure")
+	}
+}
+
+func TestIdsEqual(t *testing.T) {
+	tests := []struct {
+		name          string
+		got, expected []string
+		want          bool
+	}{
+		{"equal", []string{"A", "B"}, []string{"A", "B"}, true},
+		{"different order", []string{"B", "A"}, []string{"A", "B"}, false},
+		{"different length", []string{"A"}, []string{"A", "B"}, false},
+		{"both empty", nil, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := idsEqual(tt.got, tt.expected); got != tt.want {
+				t.Errorf("idsEqual(%v, %v) = %v, want %v", tt.got, tt.expected, got, tt.want)
+			}
+		})
+	}
+}
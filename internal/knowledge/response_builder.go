@@ -2,78 +2,230 @@ package knowledge
 
 import (
 	"encoding/json"
+	"fmt"
 	"log"
+	"sort"
+	"strconv"
 )
 
-// tokenLimit is the maximum token count for agent-mode responses.
-const tokenLimit = 500
+// responsePageWindow bounds how many candidates ResponseBuilder.Build
+// considers in a single knapsack pass. Without it, paginating a large
+// result set would mean re-solving an ever-larger knapsack on every page
+// and could select far-future candidates into an early page - a fixed
+// window keeps each page's cost bounded and its selection local to the
+// patterns actually being paged through right now.
+const responsePageWindow = 20
 
-// buildAgentResponse builds a token-limited response for agent consumption
-func buildAgentResponse(candidates []*ThreatPattern, limit int) QueryResult {
-	counter, err := NewTokenCounter()
+// ResponseBuilder packs ranked candidates into a token-budgeted,
+// cursor-paginated QueryResult. Where packByBudget's greedy first-fit walks
+// candidates in relevance order until the next one would overflow the
+// budget, Build solves a 0/1 knapsack over each page's candidate window,
+// maximizing total relevance score within MaxTokens - so a cheaper,
+// still-relevant pattern later in the window isn't permanently stranded
+// behind an earlier one that only just fit.
+type ResponseBuilder struct {
+	Counter   *TokenCounter
+	MaxTokens int
+	Verbosity string // "agent" (default) or "compressed"
+	Scope     string
+	Context   EnforcementContext
+}
+
+// NewResponseBuilder creates a ResponseBuilder using the token encoding for
+// model (see NewTokenCounterForModel). A counter that fails to initialize
+// (e.g. no network to fetch the tiktoken encoding) is non-fatal: CountTokens
+// falls back to its character-count approximation.
+func NewResponseBuilder(model string, maxTokens int, verbosity, scope string, ctx EnforcementContext) *ResponseBuilder {
+	counter, err := NewTokenCounterForModel(model)
 	if err != nil {
-		log.Printf("Warning: Token counter initialization failed: %v, using approximation", err)
+		log.Printf("Warning: token counter initialization failed: %v, using approximation", err)
+	}
+	return &ResponseBuilder{
+		Counter:   counter,
+		MaxTokens: maxTokens,
+		Verbosity: verbosity,
+		Scope:     scope,
+		Context:   ctx,
 	}
+}
 
-	result := QueryResult{
-		PatternCount: len(candidates),
-		Patterns:     make([]PatternOutput, 0, limit),
+// Build selects a knapsack-maximizing subset of the candidates visible from
+// cursor within a bounded window, maximizing total value (scores, parallel
+// to candidates; nil derives a rank-based weight favoring earlier/more
+// relevant candidates instead) under rb.MaxTokens. It returns the populated
+// QueryResult, whose NextCursor resumes pagination from the first
+// unconsidered candidate - empty once every candidate has been seen.
+func (rb *ResponseBuilder) Build(candidates []*ThreatPattern, scores []float64, cursor string) (QueryResult, error) {
+	offset, err := decodeCursor(cursor)
+	if err != nil {
+		return QueryResult{}, err
+	}
+	if offset > len(candidates) {
+		offset = len(candidates)
 	}
 
-	totalTokens := 0
-	patternsAdded := 0
+	end := offset + responsePageWindow
+	if end > len(candidates) {
+		end = len(candidates)
+	}
+	window := candidates[offset:end]
 
-	for i, p := range candidates {
-		if i >= limit {
-			break
+	costs := make([]int, len(window))
+	values := make([]float64, len(window))
+	for i, p := range window {
+		costs[i] = rb.patternTokens(p)
+		if scores != nil && offset+i < len(scores) {
+			values[i] = scores[offset+i]
+		} else {
+			values[i] = float64(len(window) - i)
 		}
+	}
 
-		output := PatternOutput{
-			ID:       p.ID,
-			Severity: p.Severity,
-			Threat:   p.AgentSummary.Threat,
-			Check:    p.AgentSummary.Check,
-			Fix:      p.AgentSummary.Fix,
-		}
+	selected := knapsack(costs, values, rb.MaxTokens)
 
-		// Calculate tokens for this pattern
-		patternJSON, _ := json.Marshal(output)
-		patternTokens := counter.CountTokens(string(patternJSON))
+	tokenLimitReached := false
+	if len(selected) == 0 && len(window) > 0 {
+		// Nothing fit the budget at all; include the top candidate anyway
+		// so a single oversized match doesn't produce an empty page.
+		selected = []int{0}
+		tokenLimitReached = true
+	}
 
-		// Check if adding this pattern would exceed limit
-		if patternsAdded > 0 && totalTokens+patternTokens > tokenLimit {
-			result.TokenLimitReached = true
-			break
-		}
+	total := 0
+	for _, i := range selected {
+		total += costs[i]
+	}
+	if total > rb.MaxTokens {
+		tokenLimitReached = true
+	}
+	if len(selected) < len(window) {
+		tokenLimitReached = true
+	}
 
-		// Add pattern
-		result.Patterns = append(result.Patterns, output)
-		totalTokens += patternTokens
-		patternsAdded++
+	result := QueryResult{PatternCount: len(candidates)}
 
-		// If first pattern alone exceeds limit, mark it but continue
-		if patternsAdded == 1 && totalTokens > tokenLimit {
-			result.TokenLimitReached = true
-			break
+	if rb.Verbosity == "compressed" {
+		result.CompressedPatterns = make([]PatternOutputCompressed, 0, len(selected))
+		for _, i := range selected {
+			p := window[i]
+			result.CompressedPatterns = append(result.CompressedPatterns, PatternOutputCompressed{
+				ID:     p.ID,
+				Threat: p.AgentSummary.Threat,
+				Fix:    p.AgentSummary.Fix,
+			})
+		}
+		result.PatternsIncluded = len(result.CompressedPatterns)
+	} else {
+		result.Patterns = make([]PatternOutput, 0, len(selected))
+		for _, i := range selected {
+			p := window[i]
+			output := PatternOutput{
+				ID:       p.ID,
+				Severity: p.Severity,
+				Threat:   p.AgentSummary.Threat,
+				Check:    p.AgentSummary.Check,
+				Fix:      p.AgentSummary.Fix,
+				Action:   string(ResolveEnforcement(p, rb.Scope, rb.Context)),
+			}
+			if scores != nil && offset+i < len(scores) {
+				output.Score = scores[offset+i]
+			}
+			result.Patterns = append(result.Patterns, output)
 		}
+		result.PatternsIncluded = len(result.Patterns)
 	}
 
-	result.PatternsIncluded = len(result.Patterns)
-	result.TokenCount = totalTokens
+	result.TokenCount = total
+	result.TokenLimitReached = tokenLimitReached
+	if end < len(candidates) {
+		result.NextCursor = encodeCursor(end)
+	}
+
+	return result, nil
+}
+
+// patternTokens counts the tokens of p's output fields at rb.Verbosity, so
+// the budget reflects what the caller actually receives.
+func (rb *ResponseBuilder) patternTokens(p *ThreatPattern) int {
+	if rb.Verbosity == "compressed" {
+		data, _ := json.Marshal(PatternOutputCompressed{ID: p.ID, Threat: p.AgentSummary.Threat, Fix: p.AgentSummary.Fix})
+		return rb.Counter.CountTokens(string(data))
+	}
+	data, _ := json.Marshal(PatternOutput{
+		ID:       p.ID,
+		Severity: p.Severity,
+		Threat:   p.AgentSummary.Threat,
+		Check:    p.AgentSummary.Check,
+		Fix:      p.AgentSummary.Fix,
+	})
+	return rb.Counter.CountTokens(string(data))
+}
+
+// encodeCursor turns a candidate-slice offset into an opaque cursor string;
+// 0 encodes to "" so a fresh query's implicit "start from the beginning"
+// doesn't require a caller to pass anything.
+func encodeCursor(offset int) string {
+	if offset <= 0 {
+		return ""
+	}
+	return strconv.Itoa(offset)
+}
+
+// decodeCursor parses a cursor produced by encodeCursor back into an
+// offset, rejecting anything else so a tampered or hand-written cursor
+// fails loudly rather than silently resetting to page 1.
+func decodeCursor(cursor string) (int, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+	offset, err := strconv.Atoi(cursor)
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("invalid cursor %q: expected a non-negative integer offset", cursor)
+	}
+	return offset, nil
+}
+
+// knapsack solves 0/1 knapsack: the subset of item indices (costs/values
+// parallel, capacity the budget) maximizing total value with total cost <=
+// capacity. Returns indices in ascending order.
+func knapsack(costs []int, values []float64, capacity int) []int {
+	n := len(costs)
+	if capacity <= 0 || n == 0 {
+		return nil
+	}
 
-	// Add code pattern from most relevant match
-	if len(candidates) > 0 {
-		codePattern := extractCodePattern(candidates[0], "", "")
-		if codePattern != nil {
-			result.CodePattern = codePattern
+	dp := make([]float64, capacity+1)
+	keep := make([][]bool, n)
+	for i := 0; i < n; i++ {
+		keep[i] = make([]bool, capacity+1)
+		cost, val := costs[i], values[i]
+		if cost > capacity {
+			continue
+		}
+		for c := capacity; c >= cost; c-- {
+			if dp[c-cost]+val > dp[c] {
+				dp[c] = dp[c-cost] + val
+				keep[i][c] = true
+			}
 		}
 	}
 
-	return result
+	var selected []int
+	c := capacity
+	for i := n - 1; i >= 0; i-- {
+		if keep[i][c] {
+			selected = append(selected, i)
+			c -= costs[i]
+		}
+	}
+	sort.Ints(selected)
+	return selected
 }
 
-// buildVerboseResponse builds a comprehensive response for human consumption
-func buildVerboseResponse(candidates []*ThreatPattern, limit int) QueryResult {
+// buildVerboseResponse builds a comprehensive response for human
+// consumption. scope and ctx resolve each pattern's effective enforcement
+// Action via ResolveEnforcement.
+func buildVerboseResponse(candidates []*ThreatPattern, limit int, scope string, ctx EnforcementContext) QueryResult {
 	result := QueryResult{
 		PatternCount:    len(candidates),
 		VerbosePatterns: make([]PatternOutputVerbose, 0, limit),
@@ -85,14 +237,16 @@ func buildVerboseResponse(candidates []*ThreatPattern, limit int) QueryResult {
 		}
 
 		verbose := PatternOutputVerbose{
-			ID:          p.ID,
-			Name:        p.Name,
-			Severity:    p.Severity,
-			Likelihood:  p.Likelihood,
-			Threat:      p.AgentSummary.Threat,
-			Check:       p.AgentSummary.Check,
-			Fix:         p.AgentSummary.Fix,
-			Description: p.Description,
+			ID:           p.ID,
+			Name:         p.Name,
+			Severity:     p.Severity,
+			Likelihood:   p.Likelihood,
+			Threat:       p.AgentSummary.Threat,
+			Check:        p.AgentSummary.Check,
+			Fix:          p.AgentSummary.Fix,
+			Action:       string(ResolveEnforcement(p, scope, ctx)),
+			Description:  p.Description,
+			ExternalRefs: p.ExternalRefs,
 		}
 
 		// Add attack scenario (Tier A patterns)
@@ -141,6 +295,14 @@ func buildVerboseResponse(candidates []*ThreatPattern, limit int) QueryResult {
 	return result
 }
 
+// BuildVerbosePattern builds the verbose ("human") output for a single
+// pattern, e.g. for an MCP resource read addressing one pattern directly
+// rather than through a relevance-ranked Query.
+func BuildVerbosePattern(p *ThreatPattern, scope string, ctx EnforcementContext) PatternOutputVerbose {
+	result := buildVerboseResponse([]*ThreatPattern{p}, 1, scope, ctx)
+	return result.VerbosePatterns[0]
+}
+
 // convertCodeExamples converts CodeExample to CodeExampleVerbose
 func convertCodeExamples(examples []CodeExample) []CodeExampleVerbose {
 	verbose := make([]CodeExampleVerbose, len(examples))
@@ -0,0 +1,146 @@
+// Package sarif builds a minimal SARIF 2.1.0 log (the subset GitHub code
+// scanning and most other consumers need: tool/driver/rules plus
+// results/locations) from a flat list of findings. It knows nothing about
+// tmkb's own types so the knowledge package can convert a ValidationReport
+// into []Finding without creating an import cycle.
+package sarif
+
+// SchemaURI and Version identify this package's output as SARIF 2.1.0.
+const (
+	SchemaURI = "https://raw.githubusercontent.com/oasis-tcs/sarif-spec/master/Schemata/sarif-schema-2.1.0.json"
+	Version   = "2.1.0"
+)
+
+// Finding is one input record: a single diagnostic to render as a SARIF
+// result. RuleDescription is only used the first time RuleID is seen, to
+// populate the run's rule catalog.
+type Finding struct {
+	RuleID          string
+	RuleDescription string
+	Severity        string // "error", "warning", or anything else (mapped to "note")
+	Message         string
+	File            string
+}
+
+// Log is the top-level SARIF document.
+type Log struct {
+	Schema  string `json:"$schema"`
+	Version string `json:"version"`
+	Runs    []Run  `json:"runs"`
+}
+
+// Run is a single analysis run: one tool, its rule catalog, and the
+// results it produced.
+type Run struct {
+	Tool    Tool     `json:"tool"`
+	Results []Result `json:"results"`
+}
+
+// Tool describes the analyzer that produced the run.
+type Tool struct {
+	Driver Driver `json:"driver"`
+}
+
+// Driver is the analyzer itself: name, version, and the rules it can emit.
+type Driver struct {
+	Name           string `json:"name"`
+	InformationURI string `json:"informationUri,omitempty"`
+	Version        string `json:"version,omitempty"`
+	Rules          []Rule `json:"rules"`
+}
+
+// Rule is one entry in the driver's rule catalog.
+type Rule struct {
+	ID               string  `json:"id"`
+	ShortDescription Message `json:"shortDescription"`
+}
+
+// Message is SARIF's wrapper for a plain-text string.
+type Message struct {
+	Text string `json:"text"`
+}
+
+// Result is a single finding, tied back to its rule and source location.
+type Result struct {
+	RuleID    string     `json:"ruleId"`
+	Level     string     `json:"level"` // "error", "warning", or "note"
+	Message   Message    `json:"message"`
+	Locations []Location `json:"locations,omitempty"`
+}
+
+// Location points a Result at the file it was found in.
+type Location struct {
+	PhysicalLocation PhysicalLocation `json:"physicalLocation"`
+}
+
+// PhysicalLocation wraps the artifact (file) a Location refers to.
+type PhysicalLocation struct {
+	ArtifactLocation ArtifactLocation `json:"artifactLocation"`
+}
+
+// ArtifactLocation identifies a file by URI (a relative path is a valid URI).
+type ArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+// Build assembles a Log for a single tool run from findings, in order,
+// de-duplicating the rule catalog by RuleID (first description wins).
+func Build(toolName, toolInformationURI string, findings []Finding) Log {
+	var rules []Rule
+	seen := make(map[string]bool)
+
+	results := make([]Result, 0, len(findings))
+	for _, f := range findings {
+		if !seen[f.RuleID] {
+			seen[f.RuleID] = true
+			rules = append(rules, Rule{
+				ID:               f.RuleID,
+				ShortDescription: Message{Text: f.RuleDescription},
+			})
+		}
+
+		result := Result{
+			RuleID:  f.RuleID,
+			Level:   levelFor(f.Severity),
+			Message: Message{Text: f.Message},
+		}
+		if f.File != "" {
+			result.Locations = []Location{{
+				PhysicalLocation: PhysicalLocation{
+					ArtifactLocation: ArtifactLocation{URI: f.File},
+				},
+			}}
+		}
+		results = append(results, result)
+	}
+
+	return Log{
+		Schema:  SchemaURI,
+		Version: Version,
+		Runs: []Run{
+			{
+				Tool: Tool{
+					Driver: Driver{
+						Name:           toolName,
+						InformationURI: toolInformationURI,
+						Rules:          rules,
+					},
+				},
+				Results: results,
+			},
+		},
+	}
+}
+
+// levelFor maps tmkb's error/warning severities onto SARIF's result levels;
+// anything else (e.g. a future "info" severity) becomes a "note".
+func levelFor(severity string) string {
+	switch severity {
+	case "error":
+		return "error"
+	case "warning":
+		return "warning"
+	default:
+		return "note"
+	}
+}
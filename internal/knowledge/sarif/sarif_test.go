@@ -0,0 +1,53 @@
+package sarif
+
+import "testing"
+
+func TestBuild_RuleDeduplication(t *testing.T) {
+	findings := []Finding{
+		{RuleID: "TMKB-VAL-missing-agent-summary", RuleDescription: "agent_summary field missing", Severity: "error", Message: "agent_summary.threat required", File: "patterns/a.yaml"},
+		{RuleID: "TMKB-VAL-missing-agent-summary", RuleDescription: "agent_summary field missing", Severity: "error", Message: "agent_summary.check required", File: "patterns/a.yaml"},
+		{RuleID: "TMKB-VAL-no-keywords", RuleDescription: "triggers.keywords is empty", Severity: "warning", Message: "no keywords defined", File: "patterns/b.yaml"},
+	}
+
+	log := Build("tmkb", "https://example.com/tmkb", findings)
+
+	if log.Version != Version {
+		t.Errorf("expected version %s, got %s", Version, log.Version)
+	}
+	if len(log.Runs) != 1 {
+		t.Fatalf("expected 1 run, got %d", len(log.Runs))
+	}
+
+	run := log.Runs[0]
+	if len(run.Tool.Driver.Rules) != 2 {
+		t.Errorf("expected 2 deduplicated rules, got %d", len(run.Tool.Driver.Rules))
+	}
+	if len(run.Results) != 3 {
+		t.Errorf("expected 3 results, got %d", len(run.Results))
+	}
+}
+
+func TestBuild_SeverityMapping(t *testing.T) {
+	findings := []Finding{
+		{RuleID: "r1", Severity: "error", Message: "m1"},
+		{RuleID: "r2", Severity: "warning", Message: "m2"},
+		{RuleID: "r3", Severity: "info", Message: "m3"},
+	}
+
+	log := Build("tmkb", "", findings)
+	results := log.Runs[0].Results
+
+	want := []string{"error", "warning", "note"}
+	for i, w := range want {
+		if results[i].Level != w {
+			t.Errorf("result %d: expected level %s, got %s", i, w, results[i].Level)
+		}
+	}
+}
+
+func TestBuild_LocationOmittedWithoutFile(t *testing.T) {
+	log := Build("tmkb", "", []Finding{{RuleID: "r1", Severity: "error", Message: "m1"}})
+	if len(log.Runs[0].Results[0].Locations) != 0 {
+		t.Error("expected no locations when Finding.File is empty")
+	}
+}
@@ -1,6 +1,7 @@
 package knowledge
 
 import (
+	"math"
 	"strings"
 )
 
@@ -75,3 +76,360 @@ func CalculateRelevance(queryKeywords, patternKeywords []string) float64 {
 
 	return matchWeight + coverageRatio
 }
+
+// patternMatchWeight is added to a pattern's score for every compiled
+// glob/regex trigger pattern (see the match package) that matches the
+// query context, calibrated to equal one literal keyword match's
+// matchWeight contribution in CalculateRelevance (matchCount*2.0 for a
+// single match) so a glob/regex hit ranks the same as a literal n-gram
+// overlap rather than over- or under-counting it.
+const patternMatchWeight = 2.0
+
+// calculateRelevancePatterns adds patternMatchWeight to base for every
+// compiled, non-literal trigger in compiled that matches context. Literal
+// entries are skipped since CalculateRelevance/CalculateRelevanceBM25
+// already scored them via keyword overlap; scoring them again here would
+// double-count the same hit.
+func calculateRelevancePatterns(base float64, context string, compiled []compiledTrigger) float64 {
+	score := base
+	for _, ct := range compiled {
+		if ct.pattern.Literal() {
+			continue
+		}
+		if ct.pattern.MatchString(context) {
+			score += patternMatchWeight
+		}
+	}
+	return score
+}
+
+// matchStopwords holds common English words ExtractKeywords still emits as
+// 1-grams but that add no discriminating signal to MatchContext's BM25
+// retrieval pass - nearly every pattern's document frequency for them
+// approaches the corpus size, so keeping them as query terms would pull in
+// patterns on no meaningful overlap at all.
+var matchStopwords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true,
+	"be": true, "but": true, "by": true, "for": true, "from": true, "has": true,
+	"he": true, "in": true, "is": true, "it": true, "its": true, "of": true,
+	"on": true, "or": true, "that": true, "the": true, "this": true,
+	"these": true, "those": true, "to": true, "was": true, "were": true,
+	"will": true, "with": true,
+}
+
+// matchContextKeywords tokenizes a MatchContext query the same way
+// ExtractKeywords does (1/2/3-grams, lowercased) but additionally drops
+// single-word stopwords, since MatchContext uses these tokens to decide
+// which patterns are retrieved at all, not just to rank them.
+func matchContextKeywords(context string) []string {
+	keywords := ExtractKeywords(context)
+	filtered := keywords[:0:0]
+	for _, kw := range keywords {
+		if !strings.Contains(kw, " ") && matchStopwords[kw] {
+			continue
+		}
+		filtered = append(filtered, kw)
+	}
+	return filtered
+}
+
+// matchContextFieldBoosts is DefaultFieldBoosts with Name zeroed out, for
+// MatchContext's own BM25F retrieval pass: Name is a weak, incidental
+// signal (a pattern's title happening to share a word with the query)
+// that's fine for re-ranking an already-retrieved candidate (see Query's
+// ranker, which uses DefaultFieldBoosts including Name), but too loose for
+// deciding whether to retrieve a pattern at all - a pattern whose only
+// intended trigger is a Triggers.Any/All file-path expression shouldn't
+// surface just because its Name happens to overlap the query context.
+var matchContextFieldBoosts = FieldBoosts{
+	Keywords:     DefaultFieldBoosts().Keywords,
+	Actions:      DefaultFieldBoosts().Actions,
+	AgentSummary: DefaultFieldBoosts().AgentSummary,
+	Default:      DefaultFieldBoosts().Default,
+}
+
+// bm25K1 and bm25B are the standard Okapi BM25 tuning defaults: k1 controls
+// term-frequency saturation, b controls document-length normalization.
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// defaultRankerK1 is the term-frequency saturation parameter for the
+// default ("" / "hybrid") ranker's BM25F scoring - see Query's ranker
+// switch. It runs a touch hotter than bm25K1 so a handful of strong term
+// matches saturate less aggressively, since the default ranker (unlike the
+// explicit "bm25f" opt-in) isn't tunable via --k1/--b.
+const defaultRankerK1 = 1.5
+
+// KeywordStats holds corpus-wide statistics precomputed once at KB load
+// time (see NewKeywordStats), needed by CalculateRelevanceBM25: each
+// keyword's document frequency and the corpus's average pattern-keyword
+// count.
+type KeywordStats struct {
+	N     int
+	DF    map[string]int
+	AvgDL float64
+}
+
+// NewKeywordStats computes BM25 corpus statistics from every pattern's
+// Triggers.Keywords.
+func NewKeywordStats(patterns []ThreatPattern) KeywordStats {
+	stats := KeywordStats{N: len(patterns), DF: make(map[string]int)}
+
+	totalLen := 0
+	for _, p := range patterns {
+		totalLen += len(p.Triggers.Keywords)
+
+		seen := make(map[string]bool, len(p.Triggers.Keywords))
+		for _, kw := range p.Triggers.Keywords {
+			seen[strings.ToLower(kw)] = true
+		}
+		for kw := range seen {
+			stats.DF[kw]++
+		}
+	}
+
+	if stats.N > 0 {
+		stats.AvgDL = float64(totalLen) / float64(stats.N)
+	}
+	return stats
+}
+
+// ngramWeight boosts a matched query term by how many words it spans,
+// since ExtractKeywords already produces 1/2/3-grams and a longer matched
+// phrase is a stronger relevance signal than a single overlapping word.
+func ngramWeight(term string) float64 {
+	switch strings.Count(strings.TrimSpace(term), " ") {
+	case 0:
+		return 1.0
+	case 1:
+		return 1.5
+	default:
+		return 2.0
+	}
+}
+
+// FieldBoosts weights how much a query term matching each document field
+// contributes to CalculateRelevanceBM25F's score, relative to a term
+// matched nowhere but Description ("rest", weight 1.0 by convention - see
+// DefaultFieldBoosts). Triggers.Keywords is an author's explicit,
+// deliberately curated trigger list, so it's weighted highest; Actions is
+// the same kind of curated list but narrower in scope (verbs, not topics),
+// so it sits just below Keywords; AgentSummary and Name are progressively
+// less targeted signals.
+type FieldBoosts struct {
+	Keywords     float64
+	Actions      float64
+	AgentSummary float64
+	Name         float64
+	Default      float64
+}
+
+// DefaultFieldBoosts returns the field weights CalculateRelevanceBM25F uses
+// when a caller's FieldBoosts is the zero value.
+func DefaultFieldBoosts() FieldBoosts {
+	return FieldBoosts{Keywords: 3.0, Actions: 2.5, AgentSummary: 2.0, Name: 1.5, Default: 1.0}
+}
+
+// orDefault substitutes DefaultFieldBoosts for the zero value, so
+// QueryOptions.FieldBoosts can be left unset without every caller needing
+// to know the defaults.
+func (fb FieldBoosts) orDefault() FieldBoosts {
+	if fb == (FieldBoosts{}) {
+		return DefaultFieldBoosts()
+	}
+	return fb
+}
+
+// CorpusStats holds corpus-wide BM25F statistics precomputed once at KB
+// load time (see NewCorpusStats), needed by CalculateRelevanceBM25F: a
+// term's document frequency and the corpus's average document length, both
+// measured over each pattern's full document - Triggers.Keywords,
+// Triggers.Actions, AgentSummary.Threat/Check/Fix, Name and Description
+// concatenated together.
+type CorpusStats struct {
+	N     int
+	DF    map[string]int
+	AvgDL float64
+}
+
+// patternFields extracts CalculateRelevanceBM25F's scored fields from p,
+// each tokenized into the same 1/2/3-gram vocabulary ExtractKeywords
+// produces for the query side, so query and document terms are directly
+// comparable. Description is the "rest" field FieldBoosts.Default weights -
+// every other field a pattern carries (mitigations, tier, provenance, ...)
+// is deliberately left out of the document, since it's prose aimed at
+// remediation rather than matching a query context.
+type patternFields struct {
+	Keywords     []string
+	Actions      []string
+	AgentSummary []string
+	Name         []string
+	Description  []string
+}
+
+func extractPatternFields(p ThreatPattern) patternFields {
+	return patternFields{
+		Keywords:     ExtractKeywords(strings.Join(p.Triggers.Keywords, " ")),
+		Actions:      ExtractKeywords(strings.Join(p.Triggers.Actions, " ")),
+		AgentSummary: ExtractKeywords(p.AgentSummary.Threat + " " + p.AgentSummary.Check + " " + p.AgentSummary.Fix),
+		Name:         ExtractKeywords(p.Name),
+		Description:  ExtractKeywords(p.Description),
+	}
+}
+
+func (f patternFields) all() []string {
+	all := make([]string, 0, len(f.Keywords)+len(f.Actions)+len(f.AgentSummary)+len(f.Name)+len(f.Description))
+	all = append(all, f.Keywords...)
+	all = append(all, f.Actions...)
+	all = append(all, f.AgentSummary...)
+	all = append(all, f.Name...)
+	all = append(all, f.Description...)
+	return all
+}
+
+// NewCorpusStats computes BM25F corpus statistics from every pattern's
+// concatenated Triggers.Keywords + Triggers.Actions + AgentSummary + Name +
+// Description document.
+func NewCorpusStats(patterns []ThreatPattern) CorpusStats {
+	stats := CorpusStats{N: len(patterns), DF: make(map[string]int)}
+
+	totalLen := 0
+	for _, p := range patterns {
+		tokens := extractPatternFields(p).all()
+		totalLen += len(tokens)
+
+		seen := make(map[string]bool, len(tokens))
+		for _, t := range tokens {
+			seen[strings.ToLower(t)] = true
+		}
+		for t := range seen {
+			stats.DF[t]++
+		}
+	}
+
+	if stats.N > 0 {
+		stats.AvgDL = float64(totalLen) / float64(stats.N)
+	}
+	return stats
+}
+
+func termFreq(tokens []string) map[string]int {
+	tf := make(map[string]int, len(tokens))
+	for _, t := range tokens {
+		tf[strings.ToLower(t)]++
+	}
+	return tf
+}
+
+// CalculateRelevanceBM25F scores p's relevance to queryKeywords using
+// field-boosted Okapi BM25 (BM25F): each query term's raw per-field
+// frequency is weighted by boosts before the standard BM25 term-frequency
+// saturation is applied, so a hit in Triggers.Keywords counts for more than
+// the same term appearing only in Name. stats must come from NewCorpusStats
+// over the same corpus p is drawn from. k1 and b are the usual BM25 tuning
+// knobs (defaults 1.2 and 0.75 - see bm25K1/bm25B); boosts.orDefault()
+// substitutes DefaultFieldBoosts for a zero-value FieldBoosts. Returns 0.0
+// if queryKeywords is empty, stats has no documents, or p's document is
+// empty.
+func CalculateRelevanceBM25F(queryKeywords []string, p ThreatPattern, stats CorpusStats, k1, b float64, boosts FieldBoosts) float64 {
+	if len(queryKeywords) == 0 || stats.N == 0 {
+		return 0.0
+	}
+	boosts = boosts.orDefault()
+
+	fields := extractPatternFields(p)
+	docLen := float64(len(fields.Keywords) + len(fields.Actions) + len(fields.AgentSummary) + len(fields.Name) + len(fields.Description))
+	if docLen == 0 {
+		return 0.0
+	}
+
+	tfKeywords := termFreq(fields.Keywords)
+	tfActions := termFreq(fields.Actions)
+	tfAgentSummary := termFreq(fields.AgentSummary)
+	tfName := termFreq(fields.Name)
+	tfDescription := termFreq(fields.Description)
+
+	var lengthNorm float64
+	if stats.AvgDL > 0 {
+		lengthNorm = b * docLen / stats.AvgDL
+	}
+
+	var score float64
+	seen := make(map[string]bool, len(queryKeywords))
+	for _, term := range queryKeywords {
+		termLower := strings.ToLower(term)
+		if seen[termLower] {
+			continue
+		}
+		seen[termLower] = true
+
+		weightedTF := float64(tfKeywords[termLower])*boosts.Keywords +
+			float64(tfActions[termLower])*boosts.Actions +
+			float64(tfAgentSummary[termLower])*boosts.AgentSummary +
+			float64(tfName[termLower])*boosts.Name +
+			float64(tfDescription[termLower])*boosts.Default
+		if weightedTF == 0 {
+			continue
+		}
+
+		df := float64(stats.DF[termLower])
+		n := float64(stats.N)
+		idf := math.Log((n-df+0.5)/(df+0.5) + 1)
+
+		tfComponent := (weightedTF * (k1 + 1)) / (weightedTF + k1*(1-b+lengthNorm))
+		score += idf * tfComponent * ngramWeight(termLower)
+	}
+
+	return score
+}
+
+// CalculateRelevanceBM25 scores a pattern's keyword overlap with
+// queryKeywords using Okapi BM25, as an alternative to CalculateRelevance's
+// hybrid formula: IDF(t) = ln((N - df(t) + 0.5)/(df(t) + 0.5) + 1), summed
+// over each matched term's (tf*(k1+1))/(tf + k1*(1-b+b*|d|/avgdl)),
+// weighted by ngramWeight. stats must come from NewKeywordStats over the
+// same corpus patternKeywords is drawn from. Returns 0.0 if either
+// keyword list is empty or stats has no documents.
+func CalculateRelevanceBM25(queryKeywords, patternKeywords []string, stats KeywordStats) float64 {
+	if len(queryKeywords) == 0 || len(patternKeywords) == 0 || stats.N == 0 {
+		return 0.0
+	}
+
+	tf := make(map[string]int, len(patternKeywords))
+	for _, kw := range patternKeywords {
+		tf[strings.ToLower(kw)]++
+	}
+	docLen := float64(len(patternKeywords))
+
+	var lengthNorm float64
+	if stats.AvgDL > 0 {
+		lengthNorm = bm25B * docLen / stats.AvgDL
+	}
+
+	var score float64
+	seen := make(map[string]bool, len(queryKeywords))
+	for _, term := range queryKeywords {
+		termLower := strings.ToLower(term)
+		if seen[termLower] {
+			continue
+		}
+		seen[termLower] = true
+
+		freq, matched := tf[termLower]
+		if !matched {
+			continue
+		}
+
+		df := float64(stats.DF[termLower])
+		n := float64(stats.N)
+		idf := math.Log((n-df+0.5)/(df+0.5) + 1)
+
+		tfComponent := (float64(freq) * (bm25K1 + 1)) / (float64(freq) + bm25K1*(1-bm25B+lengthNorm))
+
+		score += idf * tfComponent * ngramWeight(termLower)
+	}
+
+	return score
+}
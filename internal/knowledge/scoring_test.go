@@ -302,3 +302,151 @@ func floatEqual(a, b float64) bool {
 	}
 	return diff < epsilon
 }
+
+func TestNewKeywordStats_DocFrequencyAndAvgDL(t *testing.T) {
+	patterns := []ThreatPattern{
+		{Triggers: Triggers{Keywords: []string{"tenant", "isolation"}}},
+		{Triggers: Triggers{Keywords: []string{"tenant", "authorization"}}},
+	}
+
+	stats := NewKeywordStats(patterns)
+
+	if stats.N != 2 {
+		t.Errorf("expected N=2, got %d", stats.N)
+	}
+	if stats.DF["tenant"] != 2 {
+		t.Errorf("expected df(tenant)=2, got %d", stats.DF["tenant"])
+	}
+	if stats.DF["isolation"] != 1 {
+		t.Errorf("expected df(isolation)=1, got %d", stats.DF["isolation"])
+	}
+	if !floatEqual(stats.AvgDL, 2.0) {
+		t.Errorf("expected avgdl=2.0, got %v", stats.AvgDL)
+	}
+}
+
+func TestCalculateRelevanceBM25_NoMatches(t *testing.T) {
+	stats := NewKeywordStats([]ThreatPattern{
+		{Triggers: Triggers{Keywords: []string{"tenant"}}},
+	})
+	score := CalculateRelevanceBM25([]string{"jwt"}, []string{"tenant"}, stats)
+	if score != 0.0 {
+		t.Errorf("expected 0.0 for no overlap, got %v", score)
+	}
+}
+
+func TestCalculateRelevanceBM25_RarerTermScoresHigher(t *testing.T) {
+	// "tenant" appears in every document (uninformative); "celery" is rare.
+	patterns := []ThreatPattern{
+		{Triggers: Triggers{Keywords: []string{"tenant", "celery"}}},
+		{Triggers: Triggers{Keywords: []string{"tenant", "isolation"}}},
+		{Triggers: Triggers{Keywords: []string{"tenant", "authorization"}}},
+	}
+	stats := NewKeywordStats(patterns)
+
+	scoreCommon := CalculateRelevanceBM25([]string{"tenant"}, []string{"tenant", "celery"}, stats)
+	scoreRare := CalculateRelevanceBM25([]string{"celery"}, []string{"tenant", "celery"}, stats)
+
+	if scoreRare <= scoreCommon {
+		t.Errorf("expected rarer term 'celery' (score %v) to outscore common term 'tenant' (score %v)", scoreRare, scoreCommon)
+	}
+}
+
+func TestCalculateRelevanceBM25_NgramMatchWeightsHigher(t *testing.T) {
+	stats := NewKeywordStats([]ThreatPattern{
+		{Triggers: Triggers{Keywords: []string{"background job", "background"}}},
+	})
+
+	score1gram := CalculateRelevanceBM25([]string{"background"}, []string{"background job", "background"}, stats)
+	score2gram := CalculateRelevanceBM25([]string{"background job"}, []string{"background job", "background"}, stats)
+
+	if score2gram <= score1gram {
+		t.Errorf("expected 2-gram match (score %v) to outweigh 1-gram match (score %v)", score2gram, score1gram)
+	}
+}
+
+func TestNewCorpusStats_DocFrequencyAndAvgDL(t *testing.T) {
+	patterns := []ThreatPattern{
+		{Triggers: Triggers{Keywords: []string{"tenant", "isolation"}}, Name: "Tenant Isolation"},
+		{Triggers: Triggers{Keywords: []string{"tenant", "authorization"}}, Name: "Tenant Authorization"},
+	}
+
+	stats := NewCorpusStats(patterns)
+
+	if stats.N != 2 {
+		t.Errorf("expected N=2, got %d", stats.N)
+	}
+	if stats.DF["tenant"] != 2 {
+		t.Errorf("expected df(tenant)=2, got %d", stats.DF["tenant"])
+	}
+	if stats.DF["isolation"] != 1 {
+		t.Errorf("expected df(isolation)=1, got %d", stats.DF["isolation"])
+	}
+}
+
+func TestCalculateRelevanceBM25F_NoMatches(t *testing.T) {
+	stats := NewCorpusStats([]ThreatPattern{
+		{Triggers: Triggers{Keywords: []string{"tenant"}}},
+	})
+	score := CalculateRelevanceBM25F([]string{"jwt"}, ThreatPattern{Triggers: Triggers{Keywords: []string{"tenant"}}}, stats, bm25K1, bm25B, FieldBoosts{})
+	if score != 0.0 {
+		t.Errorf("expected 0.0 for no overlap, got %v", score)
+	}
+}
+
+// TestCalculateRelevanceBM25F_RareTermSurfacesCorrectPattern is the
+// golden-path case field boosting exists for: a broad pattern whose
+// keywords happen to include the common term "authorization" should not
+// outrank a narrower pattern whose keywords hit the rare query term
+// "celery", even though "authorization" appears in far more patterns in
+// this corpus.
+func TestCalculateRelevanceBM25F_RareTermSurfacesCorrectPattern(t *testing.T) {
+	celeryPattern := ThreatPattern{
+		Name:         "Celery Task Deserialization",
+		Triggers:     Triggers{Keywords: []string{"celery", "task queue", "worker"}},
+		AgentSummary: AgentSummary{Threat: "Untrusted celery task payloads executed via pickle deserialization."},
+	}
+	authPattern := ThreatPattern{
+		Name:         "Missing Authorization Check",
+		Triggers:     Triggers{Keywords: []string{"authorization", "access control", "permission"}},
+		AgentSummary: AgentSummary{Threat: "Endpoint missing an authorization check on a sensitive action."},
+	}
+	corpus := []ThreatPattern{celeryPattern, authPattern}
+	// Pad the corpus with several more patterns that also mention
+	// "authorization" in keywords, so it's the common term and "celery"
+	// stays rare, mirroring how a real knowledge base skews toward
+	// broadly-applicable categories like authorization.
+	for i := 0; i < 5; i++ {
+		corpus = append(corpus, ThreatPattern{
+			Name:     "Other Authorization Pattern",
+			Triggers: Triggers{Keywords: []string{"authorization", "session"}},
+		})
+	}
+	stats := NewCorpusStats(corpus)
+
+	queryKeywords := ExtractKeywords("celery worker background task processing")
+
+	celeryScore := CalculateRelevanceBM25F(queryKeywords, celeryPattern, stats, bm25K1, bm25B, FieldBoosts{})
+	authScore := CalculateRelevanceBM25F(queryKeywords, authPattern, stats, bm25K1, bm25B, FieldBoosts{})
+
+	if celeryScore <= authScore {
+		t.Errorf("expected celery pattern (score %v) to outrank the more common authorization pattern (score %v) for a celery-specific query", celeryScore, authScore)
+	}
+}
+
+// TestCalculateRelevanceBM25F_ActionsFieldContributesScore verifies a query
+// term matched only in Triggers.Actions (not Keywords/Name/AgentSummary/
+// Description) still earns a nonzero score, confirming Actions is wired
+// into the scored document rather than silently ignored.
+func TestCalculateRelevanceBM25F_ActionsFieldContributesScore(t *testing.T) {
+	pattern := ThreatPattern{
+		Name:     "Raw SQL Query Construction",
+		Triggers: Triggers{Keywords: []string{"database"}, Actions: []string{"concatenate"}},
+	}
+	stats := NewCorpusStats([]ThreatPattern{pattern})
+
+	score := CalculateRelevanceBM25F([]string{"concatenate"}, pattern, stats, bm25K1, bm25B, FieldBoosts{})
+	if score <= 0.0 {
+		t.Errorf("expected a nonzero score for a term matched only in triggers.actions, got %v", score)
+	}
+}
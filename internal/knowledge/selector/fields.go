@@ -0,0 +1,158 @@
+package selector
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/mark-chris/tmkb/internal/knowledge"
+)
+
+// tierOrdinal gives tiers a numeric ordering so expressions like
+// "tier>=2" can compare against them; A is the strictest/most-detailed
+// tier, so it sorts first.
+var tierOrdinal = map[string]int{"A": 1, "B": 2}
+
+func matchField(p *knowledge.ThreatPattern, field, op, value string) bool {
+	switch strings.ToLower(field) {
+	case "id":
+		return matchGlobOrCompare(p.ID, op, value)
+	case "lang", "language":
+		return matchGlobOrCompare(p.Language, op, value)
+	case "framework":
+		return matchGlobOrCompare(p.Framework, op, value)
+	case "category":
+		return matchGlobOrCompare(p.Category, op, value)
+	case "severity":
+		return matchGlobOrCompare(p.Severity, op, value)
+	case "tier":
+		return matchTier(p.Tier, op, value)
+	case "tag":
+		return matchTag(p, value)
+	default:
+		// An unrecognized field never matches, rather than erroring at
+		// eval time; Compile already validated the syntax, and a typo'd
+		// field name should behave like "selects nothing" not a panic.
+		return false
+	}
+}
+
+// matchGlobOrCompare handles the string fields (id, lang, framework,
+// category, severity): ":" and "==" do a case-insensitive glob match
+// (supporting * and ?), "!=" negates it.
+func matchGlobOrCompare(fieldValue, op, value string) bool {
+	matched := globMatch(fieldValue, value)
+	switch op {
+	case ":", "==":
+		return matched
+	case "!=":
+		return !matched
+	default:
+		return false
+	}
+}
+
+func globMatch(s, pattern string) bool {
+	re, err := globToRegexp(pattern)
+	if err != nil {
+		return false
+	}
+	return re.MatchString(s)
+}
+
+func globToRegexp(pattern string) (*regexp.Regexp, error) {
+	var sb strings.Builder
+	sb.WriteString("(?i)^")
+	for _, r := range pattern {
+		switch r {
+		case '*':
+			sb.WriteString(".*")
+		case '?':
+			sb.WriteString(".")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	sb.WriteString("$")
+	return regexp.Compile(sb.String())
+}
+
+// matchTier supports both direct letter comparison ("tier:A") and
+// numeric ordinal comparison ("tier>=2"), since Tier is a letter grade
+// (A/B) in the schema but requests like "tier>=2" think of it as a rank.
+func matchTier(tier, op, value string) bool {
+	if n, err := strconv.Atoi(value); err == nil {
+		got, ok := tierOrdinal[strings.ToUpper(tier)]
+		if !ok {
+			return false
+		}
+		switch op {
+		case "==", ":":
+			return got == n
+		case "!=":
+			return got != n
+		case ">":
+			return got > n
+		case ">=":
+			return got >= n
+		case "<":
+			return got < n
+		case "<=":
+			return got <= n
+		}
+		return false
+	}
+
+	switch op {
+	case ":", "==":
+		return strings.EqualFold(tier, value)
+	case "!=":
+		return !strings.EqualFold(tier, value)
+	default:
+		return false
+	}
+}
+
+// matchTag matches "tag:owasp-<code>" and "tag:cwe-<code>" against the
+// pattern's public references, and any other value against its
+// generalizes_to tags. Reference codes are compared after stripping
+// non-alphanumeric characters, since the schema stores full titles (e.g.
+// "A01:2021-Broken Access Control") rather than bare codes.
+func matchTag(p *knowledge.ThreatPattern, value string) bool {
+	lower := strings.ToLower(value)
+	switch {
+	case strings.HasPrefix(lower, "owasp-"):
+		want := normalizeRefCode(strings.TrimPrefix(lower, "owasp-"))
+		for _, ref := range p.Provenance.PublicReferences {
+			if strings.Contains(normalizeRefCode(ref.OWASP), want) {
+				return true
+			}
+		}
+		return false
+	case strings.HasPrefix(lower, "cwe-"):
+		want := normalizeRefCode(strings.TrimPrefix(lower, "cwe-"))
+		for _, ref := range p.Provenance.PublicReferences {
+			if strings.Contains(normalizeRefCode(ref.CWE), want) {
+				return true
+			}
+		}
+		return false
+	default:
+		for _, g := range p.GeneralizesTo {
+			if strings.EqualFold(g, value) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func normalizeRefCode(s string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,132 @@
+package selector
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokField
+	tokOp
+	tokValue
+	tokAnd
+	tokOr
+	tokLParen
+	tokRParen
+)
+
+type token struct {
+	kind tokenKind
+	lit  string
+}
+
+// lexState tracks what kind of token is expected next, since a bare
+// identifier means different things depending on position: a field name
+// at the start of a clause, never reused mid-clause because a clause is
+// always field-op-value.
+type lexState int
+
+const (
+	stateField lexState = iota
+	stateOp
+	stateValue
+)
+
+// lex tokenizes a --select expression. Fields (bare identifiers), operators
+// (: == != >= <= > <), values (runs of non-space/paren/&/| characters, or
+// quoted strings), &&, ||, and parens are the whole grammar.
+func lex(src string) ([]token, error) {
+	var toks []token
+	i := 0
+	state := stateField
+
+	for i < len(src) {
+		r := rune(src[i])
+
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			toks = append(toks, token{tokLParen, "("})
+			i++
+			state = stateField
+		case r == ')':
+			toks = append(toks, token{tokRParen, ")"})
+			i++
+		case strings.HasPrefix(src[i:], "&&"):
+			toks = append(toks, token{tokAnd, "&&"})
+			i += 2
+			state = stateField
+		case strings.HasPrefix(src[i:], "||"):
+			toks = append(toks, token{tokOr, "||"})
+			i += 2
+			state = stateField
+		case state == stateField:
+			start := i
+			for i < len(src) && (unicode.IsLetter(rune(src[i])) || unicode.IsDigit(rune(src[i])) || src[i] == '_') {
+				i++
+			}
+			if i == start {
+				return nil, fmt.Errorf("selector %q: expected field name at position %d", src, start)
+			}
+			toks = append(toks, token{tokField, src[start:i]})
+			state = stateOp
+		case state == stateOp:
+			op, n, err := lexOp(src[i:])
+			if err != nil {
+				return nil, fmt.Errorf("selector %q: %w", src, err)
+			}
+			toks = append(toks, token{tokOp, op})
+			i += n
+			state = stateValue
+		case r == '"' || r == '\'':
+			quote := r
+			start := i + 1
+			end := strings.IndexByte(src[start:], byte(quote))
+			if end < 0 {
+				return nil, fmt.Errorf("selector %q: unterminated string literal", src)
+			}
+			toks = append(toks, token{tokValue, src[start : start+end]})
+			i = start + end + 1
+			state = stateField
+		default: // state == stateValue, unquoted
+			start := i
+			for i < len(src) && !unicode.IsSpace(rune(src[i])) && src[i] != '(' && src[i] != ')' && src[i] != '&' && src[i] != '|' {
+				i++
+			}
+			toks = append(toks, token{tokValue, src[start:i]})
+			state = stateField
+		}
+	}
+
+	if state != stateField {
+		return nil, fmt.Errorf("selector %q: unexpected end of expression", src)
+	}
+
+	toks = append(toks, token{tokEOF, ""})
+	return toks, nil
+}
+
+func lexOp(s string) (op string, n int, err error) {
+	switch {
+	case strings.HasPrefix(s, "=="):
+		return "==", 2, nil
+	case strings.HasPrefix(s, "!="):
+		return "!=", 2, nil
+	case strings.HasPrefix(s, ">="):
+		return ">=", 2, nil
+	case strings.HasPrefix(s, "<="):
+		return "<=", 2, nil
+	case strings.HasPrefix(s, ":"):
+		return ":", 1, nil
+	case strings.HasPrefix(s, ">"):
+		return ">", 1, nil
+	case strings.HasPrefix(s, "<"):
+		return "<", 1, nil
+	}
+	return "", 0, fmt.Errorf("expected an operator (: == != >= <= > <), got %q", s[0])
+}
@@ -0,0 +1,91 @@
+package selector
+
+import "fmt"
+
+type parser struct {
+	tokens []token
+	pos    int
+	src    string
+}
+
+func (p *parser) peek() token { return p.tokens[p.pos] }
+func (p *parser) atEnd() bool { return p.peek().kind == tokEOF }
+func (p *parser) advance() token {
+	t := p.tokens[p.pos]
+	if p.pos < len(p.tokens)-1 {
+		p.pos++
+	}
+	return t
+}
+
+// parseOr handles ||, the lowest-precedence operator.
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokOr {
+		p.advance()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{l: left, r: right}
+	}
+	return left, nil
+}
+
+// parseAnd handles &&, which binds tighter than ||.
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokAnd {
+		p.advance()
+		right, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{l: left, r: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseTerm() (node, error) {
+	if p.peek().kind == tokLParen {
+		p.advance()
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek().kind != tokRParen {
+			return nil, fmt.Errorf("selector %q: expected ')'", p.src)
+		}
+		p.advance()
+		return inner, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (node, error) {
+	field := p.peek()
+	if field.kind != tokField {
+		return nil, fmt.Errorf("selector %q: expected a field name, got %q", p.src, field.lit)
+	}
+	p.advance()
+
+	op := p.peek()
+	if op.kind != tokOp {
+		return nil, fmt.Errorf("selector %q: expected an operator after %q", p.src, field.lit)
+	}
+	p.advance()
+
+	value := p.peek()
+	if value.kind != tokValue {
+		return nil, fmt.Errorf("selector %q: expected a value after %q%s", p.src, field.lit, op.lit)
+	}
+	p.advance()
+
+	return comparisonNode{field: field.lit, op: op.lit, value: value.lit}, nil
+}
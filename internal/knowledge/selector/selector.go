@@ -0,0 +1,86 @@
+// Package selector implements the small label-selector-style expression
+// language used by the --select flags of the CLI's validate, get and query
+// commands; the MCP server does not accept selector expressions. It is
+// deliberately simpler than the
+// knowledge/filter expr grammar: selectors compare a fixed set of pattern
+// attributes against a value using "field:value" (equality/glob) or
+// comparison operators, combined with && and ||, e.g.:
+//
+//	id:TMKB-TEST-*
+//	lang:python && framework:flask
+//	category:auth || category:crypto
+//	tier>=2
+//	tag:owasp-a01
+//
+// Compile parses such an expression into a Selector, a reusable predicate
+// over knowledge.ThreatPattern.
+package selector
+
+import (
+	"fmt"
+
+	"github.com/mark-chris/tmkb/internal/knowledge"
+)
+
+// Selector is a compiled --select expression.
+type Selector struct {
+	src  string
+	expr node
+}
+
+// Compile parses src into a Selector ready to Match patterns.
+func Compile(src string) (*Selector, error) {
+	toks, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: toks, src: src}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if !p.atEnd() {
+		tok := p.peek()
+		return nil, fmt.Errorf("selector %q: unexpected token %q", src, tok.lit)
+	}
+	return &Selector{src: src, expr: expr}, nil
+}
+
+// Match reports whether p satisfies the selector.
+func (s *Selector) Match(p *knowledge.ThreatPattern) bool {
+	return s.expr.eval(p)
+}
+
+// Filter returns the subset of patterns matching the selector.
+func Filter(patterns []knowledge.ThreatPattern, sel *Selector) []knowledge.ThreatPattern {
+	kept := make([]knowledge.ThreatPattern, 0, len(patterns))
+	for _, p := range patterns {
+		p := p
+		if sel.Match(&p) {
+			kept = append(kept, p)
+		}
+	}
+	return kept
+}
+
+type node interface {
+	eval(p *knowledge.ThreatPattern) bool
+}
+
+type andNode struct{ l, r node }
+
+func (n andNode) eval(p *knowledge.ThreatPattern) bool { return n.l.eval(p) && n.r.eval(p) }
+
+type orNode struct{ l, r node }
+
+func (n orNode) eval(p *knowledge.ThreatPattern) bool { return n.l.eval(p) || n.r.eval(p) }
+
+type comparisonNode struct {
+	field string
+	op    string
+	value string
+}
+
+func (n comparisonNode) eval(p *knowledge.ThreatPattern) bool {
+	return matchField(p, n.field, n.op, n.value)
+}
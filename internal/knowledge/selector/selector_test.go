@@ -0,0 +1,102 @@
+package selector
+
+import (
+	"testing"
+
+	"github.com/mark-chris/tmkb/internal/knowledge"
+)
+
+func testPattern() knowledge.ThreatPattern {
+	return knowledge.ThreatPattern{
+		ID:            "TMKB-TEST-001",
+		Tier:          "A",
+		Category:      "authorization",
+		Language:      "python",
+		Framework:     "flask",
+		Severity:      "critical",
+		GeneralizesTo: []string{"background-jobs"},
+		Provenance: knowledge.Provenance{
+			PublicReferences: []knowledge.PublicReference{
+				{OWASP: "A01:2021-Broken Access Control", CWE: "CWE-285"},
+			},
+		},
+	}
+}
+
+func TestCompileAndMatch(t *testing.T) {
+	p := testPattern()
+
+	tests := []struct {
+		name string
+		expr string
+		want bool
+	}{
+		{"id glob", "id:TMKB-TEST-*", true},
+		{"id glob no match", "id:TMKB-OTHER-*", false},
+		{"id exact case-insensitive", "id:tmkb-test-001", true},
+		{"lang equality", "lang:python", true},
+		{"lang and framework", "lang:python && framework:flask", true},
+		{"lang and framework, one false", "lang:python && framework:django", false},
+		{"category or", "category:auth || category:crypto", false},
+		{"category or match", "category:authorization || category:crypto", true},
+		{"tier ordinal gte", "tier>=1", true},
+		{"tier ordinal gte false", "tier>=2", false},
+		{"tier ordinal lt", "tier<2", true},
+		{"tier letter equality", "tier:A", true},
+		{"tag owasp", "tag:owasp-a01", true},
+		{"tag owasp no match", "tag:owasp-a05", false},
+		{"tag cwe", "tag:cwe-285", true},
+		{"tag generic", "tag:background-jobs", true},
+		{"not equal", "lang!=java", true},
+		{"parens", "(lang:python || lang:java) && tier:A", true},
+		{"unknown field never matches", "bogus:whatever", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sel, err := Compile(tt.expr)
+			if err != nil {
+				t.Fatalf("Compile(%q) error: %v", tt.expr, err)
+			}
+			if got := sel.Match(&p); got != tt.want {
+				t.Errorf("Match(%q) = %v, want %v", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompile_SyntaxErrors(t *testing.T) {
+	tests := []string{
+		"",
+		"lang:",
+		":python",
+		"lang:python &&",
+		"(lang:python",
+		"lang:python)",
+	}
+
+	for _, expr := range tests {
+		t.Run(expr, func(t *testing.T) {
+			if _, err := Compile(expr); err == nil {
+				t.Errorf("Compile(%q): expected an error, got none", expr)
+			}
+		})
+	}
+}
+
+func TestFilter(t *testing.T) {
+	patterns := []knowledge.ThreatPattern{
+		testPattern(),
+		{ID: "TMKB-TEST-002", Tier: "B", Language: "go"},
+	}
+
+	sel, err := Compile("lang:python")
+	if err != nil {
+		t.Fatalf("Compile error: %v", err)
+	}
+
+	got := Filter(patterns, sel)
+	if len(got) != 1 || got[0].ID != "TMKB-TEST-001" {
+		t.Errorf("Filter() = %v, want only TMKB-TEST-001", got)
+	}
+}
@@ -0,0 +1,386 @@
+package knowledge
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// PatternSource loads a set of patterns from somewhere - a local
+// directory, an HTTP(S)-fetched tarball, or a git repository -
+// normalizing each into the same []ThreatPattern shape LoadAll produces
+// from a single directory, so MultiLoader can merge several sources
+// without caring where any one of them came from.
+type PatternSource interface {
+	// Load returns every pattern the source provides.
+	Load() ([]ThreatPattern, error)
+	// Root identifies the source for precedence logging and duplicate-ID
+	// warnings, e.g. a directory path, a tarball URL, or a git remote.
+	Root() string
+}
+
+// DirSource is a PatternSource backed by a single on-disk (or afero)
+// directory - the same thing NewLoader has always loaded from. It's also
+// what HTTPSource and GitSource resolve to once their content lands on
+// disk: both fetch/clone into a cache directory, then hand that
+// directory to a DirSource.
+type DirSource struct {
+	loader *Loader
+}
+
+// NewDirSource wraps an existing *Loader as a PatternSource.
+func NewDirSource(l *Loader) *DirSource {
+	return &DirSource{loader: l}
+}
+
+func (d *DirSource) Load() ([]ThreatPattern, error) { return d.loader.LoadAll() }
+func (d *DirSource) Root() string                   { return d.loader.basePath }
+
+// defaultSourceCacheDir is where HTTPSource/GitSource cache fetched
+// content, overridable via TMKB_CACHE_DIR for tests and sandboxed
+// environments without a writable home directory.
+func defaultSourceCacheDir() string {
+	if dir := os.Getenv("TMKB_CACHE_DIR"); dir != "" {
+		return dir
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(os.TempDir(), "tmkb-cache")
+	}
+	return filepath.Join(home, ".tmkb", "cache")
+}
+
+// cacheKey derives a stable, filesystem-safe directory name from parts
+// (a URL, or a repo+ref pair), so repeated loads reuse the same
+// extracted/cloned copy instead of re-fetching every time - mirroring
+// internal/enrich's diskCache, which keys its own cache entries the same
+// way.
+func cacheKey(parts ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+// HTTPSource is a PatternSource that fetches a .tar.gz pack of pattern
+// YAML from a URL, caching the extracted contents on disk under cacheDir
+// (keyed by URL) so a process restart doesn't re-download an unchanged
+// pack.
+type HTTPSource struct {
+	url      string
+	cacheDir string
+	client   *http.Client
+}
+
+// NewHTTPSource creates an HTTPSource for url, caching extracted
+// tarballs under cacheDir (defaultSourceCacheDir() if empty).
+func NewHTTPSource(url, cacheDir string) *HTTPSource {
+	if cacheDir == "" {
+		cacheDir = defaultSourceCacheDir()
+	}
+	return &HTTPSource{url: url, cacheDir: filepath.Join(cacheDir, "http"), client: http.DefaultClient}
+}
+
+func (h *HTTPSource) Root() string { return h.url }
+
+// Load fetches and extracts h.url's tarball on first use (subsequent
+// calls, including across process restarts, reuse the cached
+// extraction), then loads patterns from the extracted directory exactly
+// as DirSource would.
+func (h *HTTPSource) Load() ([]ThreatPattern, error) {
+	dest := filepath.Join(h.cacheDir, cacheKey(h.url))
+	if _, err := os.Stat(dest); os.IsNotExist(err) {
+		if err := h.fetchAndExtract(dest); err != nil {
+			return nil, fmt.Errorf("failed to fetch pattern pack %s: %w", h.url, err)
+		}
+	}
+	return NewDirSource(NewLoader(dest)).Load()
+}
+
+func (h *HTTPSource) fetchAndExtract(dest string) error {
+	resp, err := h.client.Get(h.url)
+	if err != nil {
+		return fmt.Errorf("failed to download: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	gz, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to open gzip stream: %w", err)
+	}
+	defer gz.Close()
+
+	// Extract into a sibling temp directory and rename into place once
+	// complete, so a crash or error mid-extraction can't leave a
+	// half-written cache entry that a later Load mistakes for a
+	// successful fetch.
+	tmp := dest + ".tmp"
+	if err := os.RemoveAll(tmp); err != nil {
+		return err
+	}
+	if err := extractTar(tar.NewReader(gz), tmp); err != nil {
+		os.RemoveAll(tmp)
+		return err
+	}
+	return os.Rename(tmp, dest)
+}
+
+// extractTar writes every regular file/directory entry in tr under dest,
+// rejecting any entry whose resolved path would escape dest (a "../" in
+// a malicious tarball) - the same traversal protection
+// Loader.validatePath gives a local directory, applied here since a
+// fetched tarball is untrusted input.
+func extractTar(tr *tar.Reader, dest string) error {
+	if err := os.MkdirAll(dest, 0755); err != nil {
+		return err
+	}
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(dest, hdr.Name)
+		if rel, err := filepath.Rel(dest, target); err != nil || strings.HasPrefix(rel, "..") {
+			return fmt.Errorf("tar entry %q escapes destination", hdr.Name)
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			if err := writeTarFile(target, tr, hdr); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func writeTarFile(target string, r io.Reader, hdr *tar.Header) error {
+	f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(hdr.Mode))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(f, r)
+	return err
+}
+
+// GitSource is a PatternSource that clones (or, on a later Load, pulls)
+// a git repository into a cache directory and loads patterns from the
+// checkout's root, exactly as DirSource would.
+type GitSource struct {
+	repo     string
+	ref      string
+	cacheDir string
+}
+
+// NewGitSource creates a GitSource for repo at ref (a branch or tag, or
+// empty for the remote's default branch). Clones are cached under
+// cacheDir (defaultSourceCacheDir() if empty), keyed by repo+ref, and a
+// later Load updates the existing clone by fetching ref and hard-resetting
+// onto it (see pull) rather than re-cloning.
+func NewGitSource(repo, ref, cacheDir string) *GitSource {
+	if cacheDir == "" {
+		cacheDir = defaultSourceCacheDir()
+	}
+	return &GitSource{repo: repo, ref: ref, cacheDir: filepath.Join(cacheDir, "git")}
+}
+
+func (g *GitSource) Root() string {
+	if g.ref != "" {
+		return g.repo + "#" + g.ref
+	}
+	return g.repo
+}
+
+func (g *GitSource) Load() ([]ThreatPattern, error) {
+	dest := filepath.Join(g.cacheDir, cacheKey(g.repo, g.ref))
+	if _, err := os.Stat(filepath.Join(dest, ".git")); os.IsNotExist(err) {
+		if err := g.clone(dest); err != nil {
+			return nil, fmt.Errorf("failed to clone %s: %w", g.Root(), err)
+		}
+	} else if err := g.pull(dest); err != nil {
+		return nil, fmt.Errorf("failed to update %s: %w", g.Root(), err)
+	}
+
+	return NewDirSource(NewLoader(dest)).Load()
+}
+
+func (g *GitSource) clone(dest string) error {
+	args := []string{"clone", "--depth", "1"}
+	if g.ref != "" {
+		args = append(args, "--branch", g.ref)
+	}
+	args = append(args, g.repo, dest)
+	out, err := exec.Command("git", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s: %w", strings.TrimSpace(string(out)), err)
+	}
+	return nil
+}
+
+// pull refreshes an existing clone to the latest state of g.ref via
+// fetch+reset rather than `git pull`. A tag (or any ref) checkout leaves
+// the clone in detached HEAD with no upstream branch configured, so `git
+// pull --ff-only` silently reports "up to date" without fetching new
+// commits; fetching the ref directly and hard-resetting onto it works
+// uniformly for branches and tags.
+func (g *GitSource) pull(dest string) error {
+	refSpec := g.ref
+	if refSpec == "" {
+		refSpec = "HEAD"
+	}
+	if out, err := exec.Command("git", "-C", dest, "fetch", "--depth", "1", "origin", refSpec).CombinedOutput(); err != nil {
+		return fmt.Errorf("%s: %w", strings.TrimSpace(string(out)), err)
+	}
+	if out, err := exec.Command("git", "-C", dest, "reset", "--hard", "FETCH_HEAD").CombinedOutput(); err != nil {
+		return fmt.Errorf("%s: %w", strings.TrimSpace(string(out)), err)
+	}
+	return nil
+}
+
+// MultiLoader merges patterns from several PatternSources, consulted in
+// precedence order: the first source to claim a pattern ID wins, and a
+// later source's same-ID pattern is dropped with a warning - the same
+// semantics a PATH lookup uses when two directories both have an
+// executable by that name.
+type MultiLoader struct {
+	sources []PatternSource
+}
+
+// NewMultiLoader creates a MultiLoader over sources, highest-precedence
+// first.
+func NewMultiLoader(sources ...PatternSource) *MultiLoader {
+	return &MultiLoader{sources: sources}
+}
+
+// LoadAll loads every source in precedence order and merges the results
+// by ID. warnings holds one human-readable message per duplicate ID
+// dropped, for a caller to log and/or install via Index.SetLoadWarnings.
+func (m *MultiLoader) LoadAll() (patterns []ThreatPattern, warnings []string, err error) {
+	winner := make(map[string]string) // pattern ID -> the Root() that won it
+
+	for _, src := range m.sources {
+		loaded, err := src.Load()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to load patterns from %s: %w", src.Root(), err)
+		}
+		for _, p := range loaded {
+			if existing, ok := winner[p.ID]; ok {
+				warnings = append(warnings, fmt.Sprintf(
+					"duplicate pattern id %s: keeping the copy from %s, ignoring the one from %s",
+					p.ID, existing, src.Root()))
+				continue
+			}
+			winner[p.ID] = src.Root()
+			patterns = append(patterns, p)
+		}
+	}
+
+	return patterns, warnings, nil
+}
+
+// PatternsPathEnvVar is the PATH-style environment variable (colon-
+// separated on Unix, semicolon on Windows, per filepath.SplitList)
+// listing additional pattern sources to layer on top of the CLI's
+// --patterns directory, highest-precedence first - so a user can combine
+// built-in patterns with organization-local and per-project packs
+// without copying files into a single directory. internal/cli reads it
+// directly; it's named here, next to ParsePatternsPath, since the two
+// are meant to be used together.
+const PatternsPathEnvVar = "TMKB_PATTERNS_PATH"
+
+// ParsePatternsPath splits a PATH-style pattern source list (as found in
+// TMKB_PATTERNS_PATH) into PatternSources, in the precedence order
+// MultiLoader expects: earlier entries win on a duplicate pattern ID.
+// Each entry is either a local directory, an http(s):// URL to a .tar.gz
+// pattern pack, or a "git::<repo>[#ref]" remote. A git remote must be
+// given in URL form (e.g. "git::ssh://git@host/org/repo.git#v1"); the
+// scp-style shorthand ("git@host:org/repo.git") isn't supported here,
+// since its bare colon is indistinguishable from the list separator.
+func ParsePatternsPath(pathList string) []PatternSource {
+	var sources []PatternSource
+	for _, entry := range splitPatternsPathList(pathList) {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		sources = append(sources, parsePatternSourceEntry(entry))
+	}
+	return sources
+}
+
+// splitPatternsPathList splits on filepath.ListSeparator like
+// filepath.SplitList, except it won't split in the middle of a "://" URL
+// scheme or a "git::" source-kind marker - both of which are themselves
+// colons on Unix, where ListSeparator is also a colon. A plain
+// filepath.SplitList would shred "https://host/pack.tar.gz" into
+// "https", "//host/pack.tar.gz". On Windows, ListSeparator is ';', which
+// doesn't collide with either syntax, so this degrades to an ordinary
+// split there.
+func splitPatternsPathList(pathList string) []string {
+	sep := byte(filepath.ListSeparator)
+	if sep != ':' {
+		return strings.Split(pathList, string(filepath.ListSeparator))
+	}
+
+	var parts []string
+	var cur strings.Builder
+	prevWasColon := false
+	for i := 0; i < len(pathList); i++ {
+		c := pathList[i]
+		partOfMarker := c == sep && (prevWasColon ||
+			(i+1 < len(pathList) && pathList[i+1] == ':') ||
+			(i+2 < len(pathList) && pathList[i+1] == '/' && pathList[i+2] == '/'))
+		if c == sep && !partOfMarker {
+			parts = append(parts, cur.String())
+			cur.Reset()
+			prevWasColon = false
+			continue
+		}
+		cur.WriteByte(c)
+		prevWasColon = c == sep
+	}
+	parts = append(parts, cur.String())
+	return parts
+}
+
+// parsePatternSourceEntry classifies a single TMKB_PATTERNS_PATH entry
+// and builds the PatternSource it describes.
+func parsePatternSourceEntry(entry string) PatternSource {
+	switch {
+	case strings.HasPrefix(entry, "git::"):
+		repo, ref := splitGitRef(strings.TrimPrefix(entry, "git::"))
+		return NewGitSource(repo, ref, "")
+	case strings.HasPrefix(entry, "http://"), strings.HasPrefix(entry, "https://"):
+		return NewHTTPSource(entry, "")
+	default:
+		return NewDirSource(NewLoader(entry))
+	}
+}
+
+// splitGitRef splits a "git::" entry's "<repo>[#ref]" into its parts.
+func splitGitRef(spec string) (repo, ref string) {
+	if i := strings.LastIndex(spec, "#"); i >= 0 {
+		return spec[:i], spec[i+1:]
+	}
+	return spec, ""
+}
@@ -0,0 +1,290 @@
+package knowledge
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func writePatternFile(t *testing.T, dir, filename, id string) {
+	t.Helper()
+	content := `threat_pattern:
+  id: ` + id + `
+  name: Test Pattern
+  severity: high
+  category: testing
+  language: go
+  description: A test pattern
+  agent_summary:
+    threat: Test
+    check: Test
+    fix: Test
+  mitigations: []
+  provenance:
+    author: Test
+    date: 2026-02-06
+    public_references: []
+`
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, filename), []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write pattern file: %v", err)
+	}
+}
+
+func TestDirSource_Load(t *testing.T) {
+	dir := t.TempDir()
+	writePatternFile(t, dir, "p1.yaml", "TMKB-SRC-001")
+
+	src := NewDirSource(NewLoader(dir))
+	patterns, err := src.Load()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(patterns) != 1 || patterns[0].ID != "TMKB-SRC-001" {
+		t.Errorf("Load() = %v, want a single TMKB-SRC-001 pattern", patterns)
+	}
+	if src.Root() != dir {
+		t.Errorf("Root() = %q, want %q", src.Root(), dir)
+	}
+}
+
+func TestMultiLoader_FirstSourceWinsOnDuplicateID(t *testing.T) {
+	highPrecedence := t.TempDir()
+	lowPrecedence := t.TempDir()
+	writePatternFile(t, highPrecedence, "p.yaml", "TMKB-DUP-001")
+	writePatternFile(t, lowPrecedence, "p.yaml", "TMKB-DUP-001")
+
+	ml := NewMultiLoader(
+		NewDirSource(NewLoader(highPrecedence)),
+		NewDirSource(NewLoader(lowPrecedence)),
+	)
+	patterns, warnings, err := ml.LoadAll()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(patterns) != 1 {
+		t.Fatalf("expected the duplicate to be merged into one pattern, got %d", len(patterns))
+	}
+	if len(warnings) != 1 {
+		t.Fatalf("expected one duplicate-ID warning, got %v", warnings)
+	}
+	if !bytes.Contains([]byte(warnings[0]), []byte("TMKB-DUP-001")) {
+		t.Errorf("expected the warning to name the duplicate ID, got %q", warnings[0])
+	}
+}
+
+func TestMultiLoader_MergesDistinctIDs(t *testing.T) {
+	dirA := t.TempDir()
+	dirB := t.TempDir()
+	writePatternFile(t, dirA, "a.yaml", "TMKB-SRC-A")
+	writePatternFile(t, dirB, "b.yaml", "TMKB-SRC-B")
+
+	ml := NewMultiLoader(NewDirSource(NewLoader(dirA)), NewDirSource(NewLoader(dirB)))
+	patterns, warnings, err := ml.LoadAll()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(patterns) != 2 {
+		t.Errorf("expected both patterns merged, got %d", len(patterns))
+	}
+	if len(warnings) != 0 {
+		t.Errorf("expected no warnings, got %v", warnings)
+	}
+}
+
+// buildTarGz packs files (name -> content) into a gzip-compressed tar
+// archive, the shape HTTPSource expects a pattern pack to be.
+func buildTarGz(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		hdr := &tar.Header{Name: name, Mode: 0644, Size: int64(len(content))}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatalf("failed to write tar header: %v", err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatalf("failed to write tar content: %v", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("failed to close tar writer: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("failed to close gzip writer: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestHTTPSource_FetchesAndCachesTarball(t *testing.T) {
+	patternYAML := `threat_pattern:
+  id: TMKB-HTTP-001
+  name: Fetched Pattern
+  severity: medium
+  category: testing
+  language: go
+  description: Fetched over HTTP
+  agent_summary:
+    threat: Test
+    check: Test
+    fix: Test
+  mitigations: []
+  provenance:
+    author: Test
+    date: 2026-02-06
+    public_references: []
+`
+	archive := buildTarGz(t, map[string]string{"pattern.yaml": patternYAML})
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/gzip")
+		w.Write(archive)
+	}))
+	defer server.Close()
+
+	cacheDir := t.TempDir()
+	src := NewHTTPSource(server.URL+"/pack.tar.gz", cacheDir)
+
+	patterns, err := src.Load()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(patterns) != 1 || patterns[0].ID != "TMKB-HTTP-001" {
+		t.Fatalf("Load() = %v, want a single TMKB-HTTP-001 pattern", patterns)
+	}
+
+	// A second Load should reuse the cached extraction, not refetch.
+	if _, err := src.Load(); err != nil {
+		t.Fatalf("expected no error on cached reload, got %v", err)
+	}
+	if requests != 1 {
+		t.Errorf("expected exactly one HTTP request across two Loads, got %d", requests)
+	}
+}
+
+func TestGitSource_ClonesLocalRepo(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available in this environment")
+	}
+
+	repoDir := t.TempDir()
+	writePatternFile(t, repoDir, "p.yaml", "TMKB-GIT-001")
+	runGit(t, repoDir, "init", "-q", "-b", "main")
+	runGit(t, repoDir, "config", "user.email", "test@example.com")
+	runGit(t, repoDir, "config", "user.name", "Test")
+	runGit(t, repoDir, "add", ".")
+	runGit(t, repoDir, "commit", "-q", "-m", "initial")
+
+	cacheDir := t.TempDir()
+	src := NewGitSource(repoDir, "main", cacheDir)
+
+	patterns, err := src.Load()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(patterns) != 1 || patterns[0].ID != "TMKB-GIT-001" {
+		t.Fatalf("Load() = %v, want a single TMKB-GIT-001 pattern", patterns)
+	}
+
+	// A second Load should pull the existing clone rather than re-clone.
+	if _, err := src.Load(); err != nil {
+		t.Fatalf("expected no error on repeat load (pull), got %v", err)
+	}
+}
+
+func TestGitSource_PullUpdatesPinnedTag(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available in this environment")
+	}
+
+	repoDir := t.TempDir()
+	writePatternFile(t, repoDir, "p.yaml", "TMKB-GIT-002")
+	runGit(t, repoDir, "init", "-q", "-b", "main")
+	runGit(t, repoDir, "config", "user.email", "test@example.com")
+	runGit(t, repoDir, "config", "user.name", "Test")
+	runGit(t, repoDir, "add", ".")
+	runGit(t, repoDir, "commit", "-q", "-m", "initial")
+	runGit(t, repoDir, "tag", "-f", "v1")
+
+	cacheDir := t.TempDir()
+	src := NewGitSource(repoDir, "v1", cacheDir)
+
+	patterns, err := src.Load()
+	if err != nil {
+		t.Fatalf("expected no error on initial clone, got %v", err)
+	}
+	if len(patterns) != 1 || patterns[0].ID != "TMKB-GIT-002" {
+		t.Fatalf("Load() = %v, want a single TMKB-GIT-002 pattern", patterns)
+	}
+
+	// Move the tag to a new commit adding a second pattern, then Load
+	// again: the clone is pinned to a tag (detached HEAD, no upstream
+	// branch), so a naive `git pull --ff-only` would report "up to date"
+	// without fetching the new commit.
+	writePatternFile(t, repoDir, "p2.yaml", "TMKB-GIT-003")
+	runGit(t, repoDir, "add", ".")
+	runGit(t, repoDir, "commit", "-q", "-m", "second")
+	runGit(t, repoDir, "tag", "-f", "v1")
+
+	patterns, err = src.Load()
+	if err != nil {
+		t.Fatalf("expected no error on repeat load, got %v", err)
+	}
+	if len(patterns) != 2 {
+		t.Errorf("expected the moved tag's new pattern to be picked up, got %v", patterns)
+	}
+}
+
+func runGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v failed: %v\n%s", args, err, out)
+	}
+}
+
+func TestParsePatternsPath_ClassifiesEntries(t *testing.T) {
+	list := "/local/dir" + string(os.PathListSeparator) +
+		"https://example.com/pack.tar.gz" + string(os.PathListSeparator) +
+		"git::https://example.com/org/repo.git#v1"
+
+	sources := ParsePatternsPath(list)
+	if len(sources) != 3 {
+		t.Fatalf("expected 3 sources, got %d", len(sources))
+	}
+
+	if _, ok := sources[0].(*DirSource); !ok {
+		t.Errorf("expected sources[0] to be a *DirSource, got %T", sources[0])
+	}
+	if _, ok := sources[1].(*HTTPSource); !ok {
+		t.Errorf("expected sources[1] to be an *HTTPSource, got %T", sources[1])
+	}
+	git, ok := sources[2].(*GitSource)
+	if !ok {
+		t.Fatalf("expected sources[2] to be a *GitSource, got %T", sources[2])
+	}
+	if git.Root() != "https://example.com/org/repo.git#v1" {
+		t.Errorf("GitSource.Root() = %q, want %q", git.Root(), "https://example.com/org/repo.git#v1")
+	}
+}
+
+func TestParsePatternsPath_EmptyEntriesSkipped(t *testing.T) {
+	list := "" + string(os.PathListSeparator) + "/some/dir" + string(os.PathListSeparator)
+	sources := ParsePatternsPath(list)
+	if len(sources) != 1 {
+		t.Fatalf("expected empty entries to be skipped, got %d sources", len(sources))
+	}
+}
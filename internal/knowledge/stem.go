@@ -0,0 +1,74 @@
+package knowledge
+
+import "strings"
+
+// stemSuffixes lists common English inflectional endings, each ahead of any
+// shorter entry it ends with (e.g. "ization" before "ation", "ies" before
+// "s"), so a word matches its longest applicable suffix (e.g. "studies" strips
+// "ies" before a shorter, wrong-fit rule could strip just "s"). This is a
+// deliberately small suffix-stripping stemmer rather than a full Porter/
+// Snowball implementation - good enough to fold "uploading"/"uploaded"/
+// "uploads" onto "upload" for MatchContextWithOptions's stemmed/fuzzy
+// modes, without pulling in a stemming dependency for one feature.
+var stemSuffixes = []struct {
+	suffix      string
+	replacement string
+	minStemLen  int
+}{
+	{"ational", "ate", 3},
+	{"ization", "ize", 3},
+	{"ies", "y", 2},
+	{"ing", "", 3},
+	{"ation", "ate", 3},
+	{"ement", "", 3},
+	{"ed", "", 3},
+	{"s", "", 3},
+}
+
+// sibilantEndings lists the letter-endings after which English pluralizes
+// with "-es" rather than a bare "-s" (box/boxes, wish/wishes, catch/
+// catches) - stem special-cases these so only a genuine "-es" plural is
+// stripped down to that ending, rather than every word that happens to end
+// in "es" (e.g. "node"/"nodes", "file"/"files", both -s plurals of a word
+// already ending in a silent "e").
+var sibilantEndings = []string{"ch", "sh", "ss", "x", "z"}
+
+// stem reduces word to a crude root form by stripping the longest matching
+// suffix in stemSuffixes (checked after the "-es" special case above),
+// provided what's left is still at least minStemLen characters - short
+// enough and a suffix strip would mangle the word into something
+// unrecognizable (e.g. "as" -> "a").
+func stem(word string) string {
+	word = strings.ToLower(word)
+
+	if strings.HasSuffix(word, "es") {
+		stripped := strings.TrimSuffix(word, "es")
+		for _, ending := range sibilantEndings {
+			if strings.HasSuffix(stripped, ending) && len(stripped) >= 3 {
+				return stripped
+			}
+		}
+	}
+
+	for _, rule := range stemSuffixes {
+		if strings.HasSuffix(word, rule.suffix) {
+			stripped := strings.TrimSuffix(word, rule.suffix)
+			if len(stripped) < rule.minStemLen {
+				continue
+			}
+			return stripped + rule.replacement
+		}
+	}
+	return word
+}
+
+// stemPhrase stems phrase word-by-word and rejoins it, so multi-word
+// ExtractKeywords n-grams (e.g. "background jobs") stem consistently with
+// single words ("background job").
+func stemPhrase(phrase string) string {
+	words := strings.Fields(phrase)
+	for i, w := range words {
+		words[i] = stem(w)
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,37 @@
+package knowledge
+
+import "testing"
+
+func TestStem(t *testing.T) {
+	tests := []struct {
+		word string
+		want string
+	}{
+		{"uploading", "upload"},
+		{"uploaded", "upload"},
+		{"uploads", "upload"},
+		{"studies", "study"},
+		{"authorization", "authorize"},
+		{"auth", "auth"},
+		{"as", "as"},
+		{"nodes", "node"},   // plural of a word already ending in silent "e" - bare "-s", not "-es"
+		{"files", "file"},   // same: must not collapse to "fil"
+		{"boxes", "box"},    // genuine "-es" plural (sibilant ending) - strip the whole "-es"
+		{"wishes", "wish"},  // sibilant "sh" ending
+		{"catches", "catch"}, // sibilant "ch" ending
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.word, func(t *testing.T) {
+			if got := stem(tt.word); got != tt.want {
+				t.Errorf("stem(%q) = %q, want %q", tt.word, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStemPhrase(t *testing.T) {
+	if got, want := stemPhrase("background jobs"), "background job"; got != want {
+		t.Errorf("stemPhrase(%q) = %q, want %q", "background jobs", got, want)
+	}
+}
@@ -1,6 +1,8 @@
 package knowledge
 
 import (
+	"strings"
+
 	tiktoken "github.com/pkoukk/tiktoken-go"
 )
 
@@ -18,6 +20,25 @@ func NewTokenCounter() (*TokenCounter, error) {
 	return &TokenCounter{encoder: enc}, nil
 }
 
+// NewTokenCounterForModel creates a token counter using the tiktoken encoding
+// appropriate for model (cl100k_base for GPT-3.5/4, o200k_base for GPT-4o,
+// etc, via tiktoken's own model tables). tiktoken has no notion of Claude or
+// Gemini models, so for those we fall back to cl100k_base as a rough
+// approximation — close enough for budget packing, not exact token parity.
+// Any other unrecognized model name falls back the same way.
+func NewTokenCounterForModel(model string) (*TokenCounter, error) {
+	lower := strings.ToLower(model)
+	if strings.Contains(lower, "claude") || strings.Contains(lower, "gemini") {
+		return NewTokenCounter()
+	}
+
+	enc, err := tiktoken.EncodingForModel(model)
+	if err != nil {
+		return NewTokenCounter()
+	}
+	return &TokenCounter{encoder: enc}, nil
+}
+
 // CountTokens counts the number of tokens in the given text
 // Falls back to character/4 approximation if encoder is unavailable
 func (tc *TokenCounter) CountTokens(text string) int {
@@ -63,6 +63,63 @@ func TestTokenCounter_CountTokens_LongText(t *testing.T) {
 	}
 }
 
+func TestNewTokenCounterForModel_GPT4o(t *testing.T) {
+	counter, err := NewTokenCounterForModel("gpt-4o")
+	if err != nil {
+		t.Fatalf("NewTokenCounterForModel(\"gpt-4o\") failed: %v", err)
+	}
+
+	if counter.CountTokens("Hello world") < 1 {
+		t.Error("expected gpt-4o counter to produce a positive token count")
+	}
+}
+
+func TestNewTokenCounterForModel_GPT4(t *testing.T) {
+	counter, err := NewTokenCounterForModel("gpt-4")
+	if err != nil {
+		t.Fatalf("NewTokenCounterForModel(\"gpt-4\") failed: %v", err)
+	}
+
+	if counter.CountTokens("Hello world") < 1 {
+		t.Error("expected gpt-4 counter to produce a positive token count")
+	}
+}
+
+func TestNewTokenCounterForModel_ClaudeHeuristic(t *testing.T) {
+	counter, err := NewTokenCounterForModel("claude-3-opus")
+	if err != nil {
+		t.Fatalf("NewTokenCounterForModel(\"claude-3-opus\") failed: %v", err)
+	}
+
+	if counter.CountTokens("Hello world") < 1 {
+		t.Error("expected claude fallback counter to produce a positive token count")
+	}
+}
+
+func TestNewTokenCounterForModel_GeminiHeuristic(t *testing.T) {
+	counter, err := NewTokenCounterForModel("gemini-1.5-pro")
+	if err != nil {
+		t.Fatalf("NewTokenCounterForModel(\"gemini-1.5-pro\") failed: %v", err)
+	}
+
+	if counter.CountTokens("Hello world") < 1 {
+		t.Error("expected gemini fallback counter to produce a positive token count")
+	}
+}
+
+func TestNewTokenCounterForModel_UnknownModel(t *testing.T) {
+	// An unrecognized model name should still fall back to cl100k_base
+	// rather than erroring out.
+	counter, err := NewTokenCounterForModel("some-future-model")
+	if err != nil {
+		t.Fatalf("NewTokenCounterForModel(\"some-future-model\") failed: %v", err)
+	}
+
+	if counter.CountTokens("Hello world") < 1 {
+		t.Error("expected fallback counter to produce a positive token count")
+	}
+}
+
 func TestTokenCounter_Fallback(t *testing.T) {
 	// Test fallback when encoder is nil
 	counter := &TokenCounter{encoder: nil}
@@ -0,0 +1,132 @@
+package knowledge
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// TriggerRegistry holds named TriggerExpr definitions shared across
+// patterns, keyed by name, so an author can define a trigger once (e.g.
+// "admin-write") and reference it from many patterns via
+// TriggerExpr.PatternRef instead of repeating the same expression.
+type TriggerRegistry map[string]TriggerExpr
+
+// TriggerContext holds the values a TriggerExpr is evaluated against: the
+// raw query context string, the actions implied by it, and any file
+// path(s) under consideration.
+type TriggerContext struct {
+	Context string
+	Actions []string
+	Files   []string
+}
+
+// MatchesTriggers reports whether t's boolean trigger expression matches
+// tc. A pattern with neither Any nor All set never matches here - its
+// Keywords/Actions/FilePatterns keep being scored by CalculateRelevance
+// and matched by Index.MatchContext exactly as before, so adding Any/All
+// to a pattern is additive rather than a migration. When both Any and All
+// are set, both must hold: Any requires at least one of its branches to
+// match, All requires every one of its branches to match.
+func MatchesTriggers(t Triggers, registry TriggerRegistry, tc TriggerContext) (bool, error) {
+	if len(t.Any) == 0 && len(t.All) == 0 {
+		return false, nil
+	}
+	if len(t.Any) > 0 {
+		ok, err := evalTriggerAny(t.Any, registry, tc)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	if len(t.All) > 0 {
+		ok, err := evalTriggerAll(t.All, registry, tc)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// evalTriggerExpr dispatches a single TriggerExpr node: a PatternRef is
+// resolved against registry and evaluated in its place, a nested
+// Any/All group recurses, and anything else is evaluated as a leaf.
+func evalTriggerExpr(e TriggerExpr, registry TriggerRegistry, tc TriggerContext) (bool, error) {
+	switch {
+	case e.PatternRef != "":
+		ref, ok := registry[e.PatternRef]
+		if !ok {
+			return false, fmt.Errorf("trigger pattern_ref %q not found in trigger registry", e.PatternRef)
+		}
+		return evalTriggerExpr(ref, registry, tc)
+	case len(e.Any) > 0:
+		return evalTriggerAny(e.Any, registry, tc)
+	case len(e.All) > 0:
+		return evalTriggerAll(e.All, registry, tc)
+	default:
+		return evalTriggerLeaf(e, tc)
+	}
+}
+
+func evalTriggerAny(exprs []TriggerExpr, registry TriggerRegistry, tc TriggerContext) (bool, error) {
+	for _, e := range exprs {
+		ok, err := evalTriggerExpr(e, registry, tc)
+		if err != nil {
+			return false, err
+		}
+		if ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func evalTriggerAll(exprs []TriggerExpr, registry TriggerRegistry, tc TriggerContext) (bool, error) {
+	for _, e := range exprs {
+		ok, err := evalTriggerExpr(e, registry, tc)
+		if err != nil {
+			return false, err
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// evalTriggerLeaf matches a leaf's Keyword (substring, case-insensitive)
+// or Regex against whichever field values it targets.
+func evalTriggerLeaf(e TriggerExpr, tc TriggerContext) (bool, error) {
+	var values []string
+	switch e.Field {
+	case "action":
+		values = tc.Actions
+	case "file":
+		values = tc.Files
+	default: // "" or "context"
+		values = []string{tc.Context}
+	}
+
+	if e.Regex != "" {
+		re, err := regexp.Compile(e.Regex)
+		if err != nil {
+			return false, fmt.Errorf("invalid trigger regex %q: %w", e.Regex, err)
+		}
+		for _, v := range values {
+			if re.MatchString(v) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+
+	kw := strings.ToLower(e.Keyword)
+	if kw == "" {
+		return false, nil
+	}
+	for _, v := range values {
+		if strings.Contains(strings.ToLower(v), kw) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
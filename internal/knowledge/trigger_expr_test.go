@@ -0,0 +1,134 @@
+package knowledge
+
+import "testing"
+
+func TestMatchesTriggers_NoExpression(t *testing.T) {
+	ok, err := MatchesTriggers(Triggers{Keywords: []string{"foo"}}, nil, TriggerContext{Context: "foo bar"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ok {
+		t.Error("expected no match when Any/All are both unset")
+	}
+}
+
+func TestMatchesTriggers_Any(t *testing.T) {
+	triggers := Triggers{
+		Any: []TriggerExpr{
+			{Keyword: "celery"},
+			{Keyword: "sidekiq"},
+		},
+	}
+
+	ok, err := MatchesTriggers(triggers, nil, TriggerContext{Context: "a sidekiq worker"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !ok {
+		t.Error("expected Any to match when one branch matches")
+	}
+
+	ok, err = MatchesTriggers(triggers, nil, TriggerContext{Context: "a resque worker"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ok {
+		t.Error("expected Any to not match when no branch matches")
+	}
+}
+
+func TestMatchesTriggers_All(t *testing.T) {
+	triggers := Triggers{
+		All: []TriggerExpr{
+			{Field: "file", Regex: `/admin/`},
+			{Field: "action", Keyword: "post"},
+		},
+	}
+
+	ok, err := MatchesTriggers(triggers, nil, TriggerContext{
+		Actions: []string{"POST /admin/users"},
+		Files:   []string{"routes/admin/users.go"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !ok {
+		t.Error("expected All to match when every branch matches")
+	}
+
+	ok, err = MatchesTriggers(triggers, nil, TriggerContext{
+		Actions: []string{"GET /admin/users"},
+		Files:   []string{"routes/admin/users.go"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ok {
+		t.Error("expected All to not match when one branch fails")
+	}
+}
+
+func TestMatchesTriggers_NestedGroup(t *testing.T) {
+	triggers := Triggers{
+		Any: []TriggerExpr{
+			{
+				All: []TriggerExpr{
+					{Field: "file", Regex: `/admin/`},
+					{Field: "action", Keyword: "post"},
+				},
+			},
+			{Field: "file", Regex: `celery/.*\.py$`},
+		},
+	}
+
+	ok, _ := MatchesTriggers(triggers, nil, TriggerContext{Files: []string{"tasks/celery/worker.py"}})
+	if !ok {
+		t.Error("expected nested Any to match via the second branch")
+	}
+
+	ok, _ = MatchesTriggers(triggers, nil, TriggerContext{Files: []string{"tasks/other/worker.py"}})
+	if ok {
+		t.Error("expected nested Any to not match when neither branch matches")
+	}
+}
+
+func TestMatchesTriggers_PatternRef(t *testing.T) {
+	registry := TriggerRegistry{
+		"admin-write": {
+			All: []TriggerExpr{
+				{Field: "file", Regex: `/admin/`},
+				{Field: "action", Keyword: "post"},
+			},
+		},
+	}
+	triggers := Triggers{Any: []TriggerExpr{{PatternRef: "admin-write"}}}
+
+	ok, err := MatchesTriggers(triggers, registry, TriggerContext{
+		Actions: []string{"POST /admin/users"},
+		Files:   []string{"routes/admin/users.go"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !ok {
+		t.Error("expected pattern_ref to resolve and match")
+	}
+}
+
+func TestMatchesTriggers_PatternRefNotFound(t *testing.T) {
+	triggers := Triggers{Any: []TriggerExpr{{PatternRef: "nonexistent"}}}
+
+	_, err := MatchesTriggers(triggers, TriggerRegistry{}, TriggerContext{Context: "anything"})
+	if err == nil {
+		t.Fatal("expected error for unresolvable pattern_ref, got none")
+	}
+}
+
+func TestMatchesTriggers_InvalidRegex(t *testing.T) {
+	triggers := Triggers{Any: []TriggerExpr{{Regex: "("}}}
+
+	_, err := MatchesTriggers(triggers, nil, TriggerContext{Context: "anything"})
+	if err == nil {
+		t.Fatal("expected error for invalid regex, got none")
+	}
+}
@@ -0,0 +1,325 @@
+package knowledge
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/mark-chris/tmkb/internal/knowledge/match"
+)
+
+func TestIndex_MatchContext_LiteralBackwardCompat(t *testing.T) {
+	idx := NewIndex()
+	idx.Build([]ThreatPattern{
+		{ID: "TMKB-LITERAL-001", Triggers: Triggers{Keywords: []string{"celery beat", "background job"}}},
+	})
+
+	got := idx.MatchContext("scheduling a celery beat task")
+	if len(got) != 1 || got[0].ID != "TMKB-LITERAL-001" {
+		t.Fatalf("MatchContext() = %v, want [TMKB-LITERAL-001]", got)
+	}
+
+	if matches := idx.MatchContext("nothing relevant here"); len(matches) != 0 {
+		t.Errorf("MatchContext() = %v, want no matches", matches)
+	}
+}
+
+func TestIndex_MatchContext_GlobAndRegexPatterns(t *testing.T) {
+	idx := NewIndex()
+	idx.Build([]ThreatPattern{
+		{ID: "TMKB-GLOB-001", Triggers: Triggers{Keywords: []string{"jwt.*"}}},
+		{ID: "TMKB-REGEX-001", Triggers: Triggers{Keywords: []string{"re:auth[_-]?z"}}},
+		{ID: "TMKB-NOMATCH-001", Triggers: Triggers{Keywords: []string{"celery.beat"}}},
+	})
+
+	got := idx.MatchContext("jwt.decode raised an exception")
+	if len(got) != 1 || got[0].ID != "TMKB-GLOB-001" {
+		t.Fatalf("MatchContext() for glob pattern = %v, want [TMKB-GLOB-001]", got)
+	}
+
+	got = idx.MatchContext("missing auth_z check on this route")
+	if len(got) != 1 || got[0].ID != "TMKB-REGEX-001" {
+		t.Fatalf("MatchContext() for regex pattern = %v, want [TMKB-REGEX-001]", got)
+	}
+}
+
+// TestIndex_MatchContext_BM25RankedByScore verifies MatchContext retrieves
+// a pattern on bag-of-words overlap alone (no literal substring of any
+// keyword present in the context) and orders multiple matches by
+// descending BM25F relevance rather than map-iteration order.
+func TestIndex_MatchContext_BM25RankedByScore(t *testing.T) {
+	idx := NewIndex()
+	idx.Build([]ThreatPattern{
+		{
+			ID:       "TMKB-BM25-WEAK-001",
+			Triggers: Triggers{Keywords: []string{"authorization"}},
+		},
+		{
+			ID: "TMKB-BM25-STRONG-001",
+			Triggers: Triggers{Keywords: []string{
+				"authorization", "background job", "celery", "async worker",
+			}},
+		},
+	})
+
+	got := idx.MatchContext("running an async background job that needs authorization in celery")
+	if len(got) != 2 {
+		t.Fatalf("MatchContext() = %v, want 2 matches", got)
+	}
+	if got[0].ID != "TMKB-BM25-STRONG-001" {
+		t.Errorf("MatchContext()[0] = %s, want the pattern with more overlapping keywords ranked first", got[0].ID)
+	}
+}
+
+// TestIndex_MatchContext_NameOnlyOverlapDoesNotRetrieve verifies a pattern
+// whose only overlap with the context is its Name (not Keywords/Actions/
+// AgentSummary/Description) isn't retrieved by the BM25 pass - Name is too
+// weak/incidental a signal to decide retrieval, only re-ranking (see
+// matchContextFieldBoosts).
+func TestIndex_MatchContext_NameOnlyOverlapDoesNotRetrieve(t *testing.T) {
+	idx := NewIndex()
+	idx.Build([]ThreatPattern{
+		{ID: "TMKB-NAMEONLY-001", Name: "Celery Task Authorization"},
+	})
+
+	if got := idx.MatchContext("authorization check"); len(got) != 0 {
+		t.Errorf("MatchContext() = %v, want no matches on Name-only overlap", got)
+	}
+}
+
+// TestIndex_MatchContextWithOptions_Stemmed verifies the stemmed mode
+// retrieves a pattern keyed on "file upload" from a context phrased as
+// "uploading files", which MatchModeExact's literal terms can't bridge.
+func TestIndex_MatchContextWithOptions_Stemmed(t *testing.T) {
+	idx := NewIndex()
+	idx.Build([]ThreatPattern{
+		{ID: "TMKB-STEM-001", Triggers: Triggers{Keywords: []string{"file upload"}}},
+	})
+
+	if got := idx.MatchContext("uploading files from users"); len(got) != 0 {
+		t.Fatalf("MatchContext() (exact mode) = %v, want no match for a morphological variant", got)
+	}
+
+	got := idx.MatchContextWithOptions("uploading files from users", MatchOptions{Mode: MatchModeStemmed})
+	if len(got) != 1 || got[0].ID != "TMKB-STEM-001" {
+		t.Fatalf("MatchContextWithOptions(stemmed) = %v, want [TMKB-STEM-001]", got)
+	}
+}
+
+// TestIndex_MatchContextWithOptions_Fuzzy verifies the fuzzy mode retrieves
+// a pattern keyed on "authorization" from a context containing a one-edit
+// typo of that word.
+func TestIndex_MatchContextWithOptions_Fuzzy(t *testing.T) {
+	idx := NewIndex()
+	idx.Build([]ThreatPattern{
+		{ID: "TMKB-FUZZY-001", Triggers: Triggers{Keywords: []string{"authorization"}}},
+	})
+
+	got := idx.MatchContextWithOptions("autorization check on this route", MatchOptions{Mode: MatchModeFuzzy})
+	if len(got) != 1 || got[0].ID != "TMKB-FUZZY-001" {
+		t.Fatalf("MatchContextWithOptions(fuzzy) = %v, want [TMKB-FUZZY-001]", got)
+	}
+}
+
+// TestIndex_MatchContextWithOptions_Synonyms verifies synonym expansion
+// applies even in MatchModeExact, since it's an author-declared equivalence
+// rather than a fuzziness trade-off.
+func TestIndex_MatchContextWithOptions_Synonyms(t *testing.T) {
+	idx := NewIndex()
+	idx.Build([]ThreatPattern{
+		{ID: "TMKB-SYN-001", Triggers: Triggers{Keywords: []string{"authn"}}},
+	})
+	idx.SetSynonyms(SynonymMap{"auth": {"authn", "authentication", "login"}})
+
+	got := idx.MatchContextWithOptions("login flow needs a review", MatchOptions{Mode: MatchModeExact})
+	if len(got) != 1 || got[0].ID != "TMKB-SYN-001" {
+		t.Fatalf("MatchContextWithOptions(exact, with synonyms) = %v, want [TMKB-SYN-001]", got)
+	}
+}
+
+// TestIndex_MatchContext_UnaffectedByStemmedDefault locks in that
+// MatchContext itself still behaves exactly like MatchModeExact - the
+// stemmed default only applies to callers of MatchContextWithOptions (or
+// Query) that leave MatchOptions.Mode/QueryOptions.MatchMode unset.
+func TestIndex_MatchContext_UnaffectedByStemmedDefault(t *testing.T) {
+	idx := NewIndex()
+	idx.Build([]ThreatPattern{
+		{ID: "TMKB-NOSTEM-001", Triggers: Triggers{Keywords: []string{"file upload"}}},
+	})
+
+	if got := idx.MatchContext("uploading files"); len(got) != 0 {
+		t.Errorf("MatchContext() = %v, want no match (MatchContext stays MatchModeExact)", got)
+	}
+}
+
+func TestCalculateRelevancePatterns(t *testing.T) {
+	literal, err := match.Compile("celery.beat")
+	if err != nil {
+		t.Fatalf("compile literal: %v", err)
+	}
+	glob, err := match.Compile("jwt.*")
+	if err != nil {
+		t.Fatalf("compile glob: %v", err)
+	}
+
+	compiled := []compiledTrigger{
+		{raw: "celery.beat", pattern: literal},
+		{raw: "jwt.*", pattern: glob},
+	}
+
+	base := 1.0
+	got := calculateRelevancePatterns(base, "jwt.decode failed", compiled)
+	want := base + patternMatchWeight
+	if got != want {
+		t.Errorf("calculateRelevancePatterns() = %v, want %v (literal hit should not be double-counted)", got, want)
+	}
+
+	got = calculateRelevancePatterns(base, "nothing relevant here", compiled)
+	if got != base {
+		t.Errorf("calculateRelevancePatterns() = %v, want %v (no glob/regex hit)", got, base)
+	}
+}
+
+func TestValidate_InvalidTriggerPattern(t *testing.T) {
+	p := validPatternFixture()
+	p.Triggers.Keywords = append(p.Triggers.Keywords, "re:(")
+
+	result := Validate(p)
+	if result.IsValid {
+		t.Fatal("expected Validate to reject an unparseable trigger pattern")
+	}
+
+	found := false
+	for _, e := range result.Errors {
+		if e.Code == CodeInvalidTriggerPattern {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected a %s error, got %+v", CodeInvalidTriggerPattern, result.Errors)
+	}
+}
+
+func TestValidate_InvalidTriggerExpr(t *testing.T) {
+	p := validPatternFixture()
+	p.Triggers.Expr = `severity ==`
+
+	result := Validate(p)
+	if result.IsValid {
+		t.Fatal("expected Validate to reject an unparseable triggers.expr")
+	}
+
+	found := false
+	for _, e := range result.Errors {
+		if e.Code == CodeInvalidTriggerExpr {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected a %s error, got %+v", CodeInvalidTriggerExpr, result.Errors)
+	}
+}
+
+func TestValidateSet_DuplicateID(t *testing.T) {
+	patterns := []ThreatPattern{
+		{ID: "TMKB-DUP-001", SourceFile: "a.yaml", Triggers: Triggers{Keywords: []string{"foo"}}},
+		{ID: "TMKB-DUP-001", SourceFile: "b.yaml", Triggers: Triggers{Keywords: []string{"bar"}}},
+	}
+
+	errs := ValidateSet(patterns)
+	if len(errs) != 1 || errs[0].Code != CodeDuplicateID {
+		t.Fatalf("ValidateSet() = %+v, want a single %s error", errs, CodeDuplicateID)
+	}
+	if errs[0].Severity != "error" {
+		t.Errorf("duplicate id severity = %s, want error", errs[0].Severity)
+	}
+}
+
+func TestValidateSet_ConflictingScope(t *testing.T) {
+	patterns := []ThreatPattern{
+		{
+			ID: "TMKB-CONFLICT-001", Category: "authz", Language: "python",
+			Triggers: Triggers{Keywords: []string{"celery", "background job"}},
+		},
+		{
+			ID: "TMKB-CONFLICT-002", Category: "authz", Language: "python",
+			Triggers: Triggers{Keywords: []string{"background job", "celery"}},
+		},
+		{
+			ID: "TMKB-DIFFERENT-001", Category: "crypto", Language: "python",
+			Triggers: Triggers{Keywords: []string{"celery", "background job"}},
+		},
+	}
+
+	errs := ValidateSet(patterns)
+	if len(errs) != 1 || errs[0].Code != CodeDuplicateConflict {
+		t.Fatalf("ValidateSet() = %+v, want a single %s error", errs, CodeDuplicateConflict)
+	}
+	if !strings.Contains(errs[0].PatternID, "TMKB-CONFLICT-001") || !strings.Contains(errs[0].PatternID, "TMKB-CONFLICT-002") {
+		t.Errorf("conflict PatternID = %q, want both conflicting ids", errs[0].PatternID)
+	}
+}
+
+func TestValidateSet_ConflictingScopeIgnoresKeywordCase(t *testing.T) {
+	patterns := []ThreatPattern{
+		{
+			ID: "TMKB-CASE-001", Category: "authz", Language: "python",
+			Triggers: Triggers{Keywords: []string{"Celery", "Background Job"}},
+		},
+		{
+			ID: "TMKB-CASE-002", Category: "authz", Language: "python",
+			Triggers: Triggers{Keywords: []string{"celery", "background job"}},
+		},
+	}
+
+	errs := ValidateSet(patterns)
+	if len(errs) != 1 || errs[0].Code != CodeDuplicateConflict {
+		t.Fatalf("ValidateSet() = %+v, want a single %s error despite differing keyword case", errs, CodeDuplicateConflict)
+	}
+}
+
+func TestValidateSet_OverusedKeyword(t *testing.T) {
+	patterns := make([]ThreatPattern, 0, maxSharedKeywordPatterns+1)
+	for i := 0; i < maxSharedKeywordPatterns+1; i++ {
+		patterns = append(patterns, ThreatPattern{
+			ID:       fmt.Sprintf("TMKB-SHARED-%03d", i),
+			Category: fmt.Sprintf("cat-%d", i), // distinct scopes, so this isn't also a conflict hit
+			Triggers: Triggers{Keywords: []string{"auth"}},
+		})
+	}
+
+	errs := ValidateSet(patterns)
+	if len(errs) != 1 || errs[0].Code != CodeOverusedKeyword {
+		t.Fatalf("ValidateSet() = %+v, want a single %s warning", errs, CodeOverusedKeyword)
+	}
+	if errs[0].Severity != "warning" {
+		t.Errorf("overused keyword severity = %s, want warning", errs[0].Severity)
+	}
+}
+
+func TestValidateSet_NoConflicts(t *testing.T) {
+	patterns := []ThreatPattern{validPatternFixture()}
+	if errs := ValidateSet(patterns); len(errs) != 0 {
+		t.Errorf("ValidateSet() = %+v, want no conflicts for a single pattern", errs)
+	}
+}
+
+// validPatternFixture returns a pattern that passes Validate cleanly, so
+// tests can mutate one field and assert on the single diagnostic that adds.
+func validPatternFixture() ThreatPattern {
+	return ThreatPattern{
+		ID:          "TMKB-TEST-001",
+		Name:        "Test Pattern",
+		Tier:        "B",
+		Category:    "test",
+		Severity:    "medium",
+		Description: "A fixture pattern used to test Validate in isolation.",
+		AgentSummary: AgentSummary{
+			Threat: "threat", Check: "check", Fix: "fix",
+		},
+		Triggers: Triggers{Keywords: []string{"test"}},
+		Mitigations: []Mitigation{
+			{ID: "MIT-001", Description: "fix it"},
+		},
+	}
+}
@@ -20,6 +20,12 @@ type ThreatPattern struct {
 	// Generalization
 	GeneralizesTo []string `yaml:"generalizes_to,omitempty" json:"generalizes_to,omitempty"`
 
+	// SourceFile is the path the pattern was loaded from. It is not part of
+	// the YAML schema; the loader stamps it onto the pattern after parsing,
+	// so it is omitted from yaml (un)marshaling but reported in JSON output
+	// for tooling such as `tmkb validate`.
+	SourceFile string `yaml:"-" json:"source_file,omitempty"`
+
 	// Provenance
 	Provenance Provenance `yaml:"provenance" json:"provenance"`
 
@@ -45,11 +51,45 @@ type ThreatPattern struct {
 	// Related patterns
 	RelatedPatterns []RelatedPattern `yaml:"related_patterns,omitempty" json:"related_patterns,omitempty"`
 
+	// RelatedByReference lists the IDs of other patterns sharing at least
+	// one CWE or OWASP reference with this one. It is not part of the YAML
+	// schema; Index.Build stamps it in once the full corpus is known, so
+	// patterns surface as related even when authors forget to add an
+	// explicit RelatedPatterns entry.
+	RelatedByReference []string `yaml:"-" json:"related_by_reference,omitempty"`
+
 	// Testing guidance (Tier A only)
 	Testing *Testing `yaml:"testing,omitempty" json:"testing,omitempty"`
 
 	// Validation results
 	Validation *Validation `yaml:"validation,omitempty" json:"validation,omitempty"`
+
+	// Enforcement actions a caller should take when this pattern matches,
+	// scoped by caller type (see ScopedAction). Resolved via
+	// ResolveEnforcement rather than read directly.
+	EnforcementActions []ScopedAction `yaml:"enforcement_actions,omitempty" json:"enforcement_actions,omitempty"`
+
+	// ExternalRefs holds canonical metadata (CVE/CWE titles, CVSS scores,
+	// publish dates, related CWEs) that an enrichment pass resolved for
+	// this pattern's CWEReferences/OWASPReferences/Provenance.PublicReferences
+	// - see internal/enrich and Loader.mergeSidecar. Not hand-authored in
+	// the pattern's own YAML: populated entirely from a
+	// patterns/<id>.enriched.yaml sidecar at load time, so pattern authors
+	// never hand-copy CWE names or CVSS scores.
+	ExternalRefs []ExternalRef `yaml:"external_refs,omitempty" json:"external_refs,omitempty"`
+}
+
+// ExternalRef is one piece of canonical external metadata an enrichment
+// pass attached to a pattern - a CVE's NVD record, a CWE's MITRE
+// definition, or an OWASP Top 10 category - keyed by the identifier it
+// enriches (e.g. "CWE-862", "CVE-2021-44228").
+type ExternalRef struct {
+	ID            string   `yaml:"id" json:"id"`
+	Title         string   `yaml:"title,omitempty" json:"title,omitempty"`
+	URL           string   `yaml:"url,omitempty" json:"url,omitempty"`
+	CVSS          float64  `yaml:"cvss,omitempty" json:"cvss,omitempty"`
+	PublishedDate string   `yaml:"published_date,omitempty" json:"published_date,omitempty"`
+	RelatedCWEs   []string `yaml:"related_cwes,omitempty" json:"related_cwes,omitempty"`
 }
 
 // Provenance tracks the source of the threat pattern
@@ -67,11 +107,59 @@ type PublicReference struct {
 	URL   string `yaml:"url,omitempty" json:"url,omitempty"`
 }
 
-// Triggers define when an agent should query this pattern
+// Triggers define when an agent should query this pattern. Keywords,
+// Actions, and FilePatterns are flat bag-of-words lists; Index.MatchContext
+// retrieves a pattern on BM25F term overlap with its Keywords and Actions
+// (and AgentSummary/Description text), or on substring containment of an
+// Actions entry in the context. A Keywords entry may also be a glob or regex trigger
+// pattern (see the match package) instead of a literal word - e.g.
+// "jwt.*", "api/**/users", or "re:auth[_-]?z" - compiled once at
+// Index.Build time and scored the same as a literal hit. Any and All
+// additionally let an author express
+// richer boolean conditions - "(path matches /admin/* AND method ==
+// POST) OR file matches **/celery/*.py" - evaluated as a hard yes/no
+// gate by MatchesTriggers rather than a relevance score. A pattern with
+// neither Any nor All set behaves exactly as before: it is retrieved and
+// ranked on its flat lists alone, where overlap on any one term suffices.
 type Triggers struct {
 	Keywords     []string `yaml:"keywords" json:"keywords"`
 	Actions      []string `yaml:"actions" json:"actions"`
 	FilePatterns []string `yaml:"file_patterns" json:"file_patterns"`
+
+	Any []TriggerExpr `yaml:"any,omitempty" json:"any,omitempty"`
+	All []TriggerExpr `yaml:"all,omitempty" json:"all,omitempty"`
+
+	// Expr is an optional boolean expression, in the same grammar as
+	// --filter (see internal/knowledge/filter), evaluated against the
+	// query's context rather than the pattern document itself - see
+	// exprContextDoc for the fields it exposes (context, language,
+	// framework, keywords, imports, filename, filename_tokens). Unlike
+	// Any/All's structured leaf/group tree, Expr lets an author write a
+	// single free-form condition such as `("jwt" in keywords) and
+	// framework != "gin"`. Query drops any candidate whose Expr doesn't
+	// match; Index.Build precompiles it once (see Index.exprPrograms) so
+	// a hot-path query only ever evaluates the compiled program.
+	Expr string `yaml:"expr,omitempty" json:"expr,omitempty"`
+}
+
+// TriggerExpr is one node of a boolean trigger expression tree: a leaf
+// matching a Keyword or Regex against the query's context, action, or
+// file path (selected by Field); a PatternRef naming a trigger reused
+// from the KB's top-level trigger registry (see TriggerRegistry) instead
+// of repeating the same expression across patterns; or a nested Any/All
+// group. Exactly one of Keyword/Regex, PatternRef, Any, or All is
+// expected to be set on a given node.
+type TriggerExpr struct {
+	// Field selects what a leaf matches against: "context" (default),
+	// "action", or "file".
+	Field   string `yaml:"field,omitempty" json:"field,omitempty"`
+	Keyword string `yaml:"keyword,omitempty" json:"keyword,omitempty"`
+	Regex   string `yaml:"regex,omitempty" json:"regex,omitempty"`
+
+	PatternRef string `yaml:"pattern_ref,omitempty" json:"pattern_ref,omitempty"`
+
+	Any []TriggerExpr `yaml:"any,omitempty" json:"any,omitempty"`
+	All []TriggerExpr `yaml:"all,omitempty" json:"all,omitempty"`
 }
 
 // Differentiation explains why TMKB adds value beyond LLM knowledge
@@ -2,27 +2,114 @@ package knowledge
 
 import (
 	"fmt"
+	"sort"
 	"strings"
+
+	"github.com/mark-chris/tmkb/internal/knowledge/match"
 )
 
 // ValidationError represents a single validation error
 type ValidationError struct {
-	PatternID string
-	Field     string
-	Message   string
-	Severity  string // "error" or "warning"
+	PatternID string `json:"pattern_id"`
+	Code      string `json:"code"`
+	Field     string `json:"field"`
+	Message   string `json:"message"`
+	Severity  string `json:"severity"` // "error" or "warning"
 }
 
 func (e ValidationError) String() string {
 	return fmt.Sprintf("[%s] %s: %s - %s", e.Severity, e.PatternID, e.Field, e.Message)
 }
 
+// Diagnostic codes, stable identifiers for the checks in Validate. CI
+// pipelines and the structured JSON report (see ValidationReport) key off
+// these rather than the free-form message text.
+const (
+	CodeRequiredField         = "TMKB001" // required field is empty
+	CodeInvalidTier           = "TMKB002" // tier is not A or B
+	CodeInvalidSeverity       = "TMKB003" // severity is not a recognized level
+	CodeMissingAgentSummary   = "TMKB004" // agent_summary.threat/check/fix missing
+	CodeAgentSummaryBudget    = "TMKB005" // agent_summary likely exceeds the token budget
+	CodeNoKeywords            = "TMKB006" // triggers.keywords is empty
+	CodeNoMitigations         = "TMKB007" // mitigations is empty
+	CodeIncompleteMitigation  = "TMKB008" // mitigation missing id or description
+	CodeMissingAttackScenario = "TMKB009" // Tier A pattern missing attack_scenario/narrative
+	CodeMissingPreconditions  = "TMKB010" // Tier A attack_scenario.preconditions recommended
+	CodeMissingGeneralizesTo  = "TMKB011" // Tier A generalizes_to recommended
+	CodeMissingPrinciples     = "TMKB012" // Tier A security_principles recommended
+	CodeMissingCodeExample    = "TMKB013" // Tier A mitigation missing code examples
+	CodeUnknownLanguage       = "TMKB014" // language is set but not recognized
+	CodeMissingProvenance     = "TMKB015" // provenance.source_type recommended
+	CodeInvalidTriggerPattern = "TMKB016" // triggers.keywords entry fails to compile as a match.Pattern
+	CodeInvalidTriggerExpr    = "TMKB017" // triggers.expr fails to compile, or doesn't evaluate to a boolean
+	CodeDuplicateID           = "TMKB018" // two or more patterns share the same id
+	CodeDuplicateConflict     = "TMKB019" // two or more patterns share category/framework/language/keywords
+	CodeOverusedKeyword       = "TMKB020" // a keyword is shared by too many patterns to discriminate between them
+)
+
+// codeSlugs maps each diagnostic code to the stable, kebab-case slug used
+// to build its SARIF ruleId (e.g. "TMKB-VAL-missing-agent-summary"), and
+// doubles as that rule's short description.
+var codeSlugs = map[string]struct {
+	slug        string
+	description string
+}{
+	CodeRequiredField:         {"required-field", "Required field is empty"},
+	CodeInvalidTier:           {"invalid-tier", "Tier is not 'A' or 'B'"},
+	CodeInvalidSeverity:       {"invalid-severity", "Severity is not a recognized level"},
+	CodeMissingAgentSummary:   {"missing-agent-summary", "agent_summary.threat/check/fix is missing"},
+	CodeAgentSummaryBudget:    {"agent-summary-budget", "agent_summary likely exceeds the token budget"},
+	CodeNoKeywords:            {"no-keywords", "triggers.keywords is empty"},
+	CodeNoMitigations:         {"no-mitigations", "mitigations is empty"},
+	CodeIncompleteMitigation:  {"incomplete-mitigation", "mitigation is missing id or description"},
+	CodeMissingAttackScenario: {"missing-attack-scenario", "Tier A pattern missing attack_scenario/narrative"},
+	CodeMissingPreconditions:  {"missing-preconditions", "Tier A attack_scenario.preconditions recommended"},
+	CodeMissingGeneralizesTo:  {"missing-generalizes-to", "Tier A generalizes_to recommended"},
+	CodeMissingPrinciples:     {"missing-principles", "Tier A security_principles recommended"},
+	CodeMissingCodeExample:    {"missing-code-example", "Tier A mitigation missing code examples"},
+	CodeUnknownLanguage:       {"unknown-language", "language is set but not recognized"},
+	CodeMissingProvenance:     {"missing-provenance", "provenance.source_type recommended"},
+	CodeInvalidTriggerPattern: {"invalid-trigger-pattern", "triggers.keywords entry fails to compile as a match.Pattern"},
+	CodeInvalidTriggerExpr:    {"invalid-trigger-expr", "triggers.expr fails to compile, or doesn't evaluate to a boolean"},
+	CodeDuplicateID:           {"duplicate-id", "id is reused by more than one pattern"},
+	CodeDuplicateConflict:     {"duplicate-conflict", "patterns share category/framework/language and keywords"},
+	CodeOverusedKeyword:       {"overused-keyword", "keyword is shared by too many patterns"},
+}
+
+// ruleID returns the stable SARIF ruleId for a diagnostic code, e.g.
+// "TMKB004" -> "TMKB-VAL-missing-agent-summary". Codes with no known slug
+// (shouldn't happen outside of tests) fall back to the code itself.
+func ruleID(code string) string {
+	if s, ok := codeSlugs[code]; ok {
+		return "TMKB-VAL-" + s.slug
+	}
+	return "TMKB-VAL-" + code
+}
+
+// ruleDescription returns the human-readable short description for a
+// diagnostic code's SARIF rule entry.
+func ruleDescription(code string) string {
+	if s, ok := codeSlugs[code]; ok {
+		return s.description
+	}
+	return code
+}
+
+// knownLanguages are the languages tmkb's code-example extraction and
+// --language filtering are expected to work with. Other values aren't
+// rejected outright (new ecosystems show up before patterns are written for
+// them), just flagged so authors can catch typos.
+var knownLanguages = map[string]bool{
+	"go": true, "python": true, "javascript": true, "typescript": true,
+	"java": true, "ruby": true, "csharp": true, "php": true, "rust": true,
+}
+
 // ValidationResult holds all validation errors for a pattern
 type ValidationResult struct {
-	PatternID string
-	IsValid   bool
-	Errors    []ValidationError
-	Warnings  []ValidationError
+	PatternID string            `json:"pattern_id"`
+	IsValid   bool              `json:"is_valid"`
+	Errors    []ValidationError `json:"errors"`
+	Warnings  []ValidationError `json:"warnings"`
 }
 
 // Validate validates a single pattern
@@ -44,49 +131,75 @@ func Validate(p ThreatPattern) ValidationResult {
 
 	// Validate tier value
 	if p.Tier != "" && p.Tier != "A" && p.Tier != "B" {
-		result.addError(p.ID, "tier", "must be 'A' or 'B'")
+		result.addError(p.ID, CodeInvalidTier, "tier", "must be 'A' or 'B'")
 	}
 
 	// Validate severity value
 	validSeverities := map[string]bool{"critical": true, "high": true, "medium": true, "low": true}
 	if p.Severity != "" && !validSeverities[strings.ToLower(p.Severity)] {
-		result.addError(p.ID, "severity", "must be critical, high, medium, or low")
+		result.addError(p.ID, CodeInvalidSeverity, "severity", "must be critical, high, medium, or low")
 	}
 
 	// Validate agent summary
 	if p.AgentSummary.Threat == "" {
-		result.addError(p.ID, "agent_summary.threat", "required")
+		result.addError(p.ID, CodeMissingAgentSummary, "agent_summary.threat", "required")
 	}
 	if p.AgentSummary.Check == "" {
-		result.addError(p.ID, "agent_summary.check", "required")
+		result.addError(p.ID, CodeMissingAgentSummary, "agent_summary.check", "required")
 	}
 	if p.AgentSummary.Fix == "" {
-		result.addError(p.ID, "agent_summary.fix", "required")
+		result.addError(p.ID, CodeMissingAgentSummary, "agent_summary.fix", "required")
 	}
 
 	// Check agent summary token count (rough estimate: 1 token ≈ 4 chars)
 	agentSummaryLen := len(p.AgentSummary.Threat) + len(p.AgentSummary.Check) + len(p.AgentSummary.Fix)
 	if agentSummaryLen > 400 { // ~100 tokens
-		result.addWarning(p.ID, "agent_summary", 
+		result.addWarning(p.ID, CodeAgentSummaryBudget, "agent_summary",
 			fmt.Sprintf("may exceed 100 tokens (approx %d chars)", agentSummaryLen))
 	}
 
-	// Validate triggers
-	if len(p.Triggers.Keywords) == 0 {
-		result.addWarning(p.ID, "triggers.keywords", "no keywords defined")
+	// Validate triggers. A pattern with a boolean triggers.any/all
+	// expression has an alternative way to be matched, so an empty flat
+	// keyword list isn't worth flagging for it.
+	if len(p.Triggers.Keywords) == 0 && len(p.Triggers.Any) == 0 && len(p.Triggers.All) == 0 {
+		result.addWarning(p.ID, CodeNoKeywords, "triggers.keywords", "no keywords defined")
+	}
+
+	// Validate that every keyword - literal or a glob/regex trigger
+	// pattern - compiles, so a typo'd pattern fails fast in CI rather than
+	// silently never matching anything at query time.
+	for i, kw := range p.Triggers.Keywords {
+		if _, err := match.Compile(kw); err != nil {
+			result.addError(p.ID, CodeInvalidTriggerPattern,
+				fmt.Sprintf("triggers.keywords[%d]", i), err.Error())
+		}
+	}
+
+	// Validate triggers.expr the same way: compile it (and, since the
+	// filter engine is dynamically typed, probe-evaluate it) now so a typo
+	// or a non-boolean result is caught in CI rather than silently dropping
+	// every candidate at query time - see ValidatePatternExpr.
+	if err := ValidatePatternExpr(p.Triggers.Expr); err != nil {
+		result.addError(p.ID, CodeInvalidTriggerExpr, "triggers.expr", err.Error())
+	}
+
+	// Validate language
+	if p.Language != "" && !knownLanguages[strings.ToLower(p.Language)] {
+		result.addWarning(p.ID, CodeUnknownLanguage, "language",
+			fmt.Sprintf("unknown language: %s", p.Language))
 	}
 
 	// Validate mitigations
 	if len(p.Mitigations) == 0 {
-		result.addError(p.ID, "mitigations", "at least one mitigation required")
+		result.addError(p.ID, CodeNoMitigations, "mitigations", "at least one mitigation required")
 	}
 
 	for i, m := range p.Mitigations {
 		if m.ID == "" {
-			result.addError(p.ID, fmt.Sprintf("mitigations[%d].id", i), "required")
+			result.addError(p.ID, CodeIncompleteMitigation, fmt.Sprintf("mitigations[%d].id", i), "required")
 		}
 		if m.Description == "" {
-			result.addError(p.ID, fmt.Sprintf("mitigations[%d].description", i), "required")
+			result.addError(p.ID, CodeIncompleteMitigation, fmt.Sprintf("mitigations[%d].description", i), "required")
 		}
 	}
 
@@ -97,7 +210,7 @@ func Validate(p ThreatPattern) ValidationResult {
 
 	// Provenance requirements
 	if p.Provenance.SourceType == "" {
-		result.addWarning(p.ID, "provenance.source_type", "recommended for traceability")
+		result.addWarning(p.ID, CodeMissingProvenance, "provenance.source_type", "recommended for traceability")
 	}
 
 	return result
@@ -106,24 +219,24 @@ func Validate(p ThreatPattern) ValidationResult {
 func (r *ValidationResult) validateTierA(p ThreatPattern) {
 	// Tier A requires attack scenario
 	if p.AttackScenario == nil {
-		r.addError(p.ID, "attack_scenario", "required for Tier A patterns")
+		r.addError(p.ID, CodeMissingAttackScenario, "attack_scenario", "required for Tier A patterns")
 	} else {
 		if p.AttackScenario.Narrative == "" {
-			r.addError(p.ID, "attack_scenario.narrative", "required for Tier A patterns")
+			r.addError(p.ID, CodeMissingAttackScenario, "attack_scenario.narrative", "required for Tier A patterns")
 		}
 		if len(p.AttackScenario.Preconditions) == 0 {
-			r.addWarning(p.ID, "attack_scenario.preconditions", "recommended for Tier A patterns")
+			r.addWarning(p.ID, CodeMissingPreconditions, "attack_scenario.preconditions", "recommended for Tier A patterns")
 		}
 	}
 
 	// Tier A requires generalizes_to
 	if len(p.GeneralizesTo) == 0 {
-		r.addWarning(p.ID, "generalizes_to", "recommended for Tier A patterns")
+		r.addWarning(p.ID, CodeMissingGeneralizesTo, "generalizes_to", "recommended for Tier A patterns")
 	}
 
 	// Tier A requires security principles
 	if len(p.SecurityPrinciples) == 0 {
-		r.addWarning(p.ID, "security_principles", "recommended for Tier A patterns")
+		r.addWarning(p.ID, CodeMissingPrinciples, "security_principles", "recommended for Tier A patterns")
 	}
 
 	// Tier A requires code examples in mitigations
@@ -135,14 +248,14 @@ func (r *ValidationResult) validateTierA(p ThreatPattern) {
 		}
 	}
 	if !hasCodeExample {
-		r.addWarning(p.ID, "mitigations", "Tier A patterns should have code examples")
+		r.addWarning(p.ID, CodeMissingCodeExample, "mitigations", "Tier A patterns should have code examples")
 	}
 
 	// Tier A should have both vulnerable and secure code
 	for i, m := range p.Mitigations {
 		for j, ex := range m.CodeExamples {
 			if ex.VulnerableCode == "" && ex.SecureCode == "" {
-				r.addWarning(p.ID, 
+				r.addWarning(p.ID, CodeMissingCodeExample,
 					fmt.Sprintf("mitigations[%d].code_examples[%d]", i, j),
 					"should have vulnerable_code and/or secure_code")
 			}
@@ -152,23 +265,25 @@ func (r *ValidationResult) validateTierA(p ThreatPattern) {
 
 func (r *ValidationResult) checkRequired(patternID, field, value string) {
 	if value == "" {
-		r.addError(patternID, field, "required field is empty")
+		r.addError(patternID, CodeRequiredField, field, "required field is empty")
 	}
 }
 
-func (r *ValidationResult) addError(patternID, field, message string) {
+func (r *ValidationResult) addError(patternID, code, field, message string) {
 	r.IsValid = false
 	r.Errors = append(r.Errors, ValidationError{
 		PatternID: patternID,
+		Code:      code,
 		Field:     field,
 		Message:   message,
 		Severity:  "error",
 	})
 }
 
-func (r *ValidationResult) addWarning(patternID, field, message string) {
+func (r *ValidationResult) addWarning(patternID, code, field, message string) {
 	r.Warnings = append(r.Warnings, ValidationError{
 		PatternID: patternID,
+		Code:      code,
 		Field:     field,
 		Message:   message,
 		Severity:  "warning",
@@ -183,3 +298,257 @@ func ValidateAll(patterns []ThreatPattern) []ValidationResult {
 	}
 	return results
 }
+
+// maxSharedKeywordPatterns caps how many patterns may share a single
+// triggers.keywords entry before ValidateSet flags it: past this point the
+// keyword no longer narrows down which pattern matched, it just fires
+// alongside everything else that uses it.
+const maxSharedKeywordPatterns = 8
+
+// ValidateSet checks for conflicts across the whole pattern set that
+// Validate, looking at one pattern at a time, can't see: two patterns
+// sharing an id (Index.Build's byID map would silently let the later one
+// win), two patterns with the same category/framework/language and
+// keyword set (they'd always match the same queries, so one is either
+// redundant or mis-scoped), and keywords shared by so many patterns they
+// no longer discriminate between them. Unlike Validate/ValidateAll this
+// can't be run per-pattern - it needs the full loaded set - so it's kept
+// as its own entry point rather than folded into Validate.
+func ValidateSet(patterns []ThreatPattern) []ValidationError {
+	var errs []ValidationError
+
+	byID := make(map[string][]ThreatPattern)
+	for _, p := range patterns {
+		if p.ID == "" {
+			continue // Validate already flags a missing id
+		}
+		byID[p.ID] = append(byID[p.ID], p)
+	}
+	for id, dupes := range byID {
+		if len(dupes) < 2 {
+			continue
+		}
+		errs = append(errs, ValidationError{
+			PatternID: id,
+			Code:      CodeDuplicateID,
+			Field:     "id",
+			Message: fmt.Sprintf("id is reused by %d patterns (%s); the last one loaded silently shadows the rest in Index.Build's byID map",
+				len(dupes), strings.Join(sourceFiles(dupes), ", ")),
+			Severity: "error",
+		})
+	}
+
+	byConflictKey := make(map[string][]ThreatPattern)
+	for _, p := range patterns {
+		// An empty keyword set isn't a meaningful fingerprint - plenty of
+		// patterns rely solely on triggers.any/all/expr - so it's excluded
+		// from this comparison rather than flagged as a mass conflict.
+		if len(p.Triggers.Keywords) == 0 {
+			continue
+		}
+		byConflictKey[conflictKey(p)] = append(byConflictKey[conflictKey(p)], p)
+	}
+	for _, dupes := range byConflictKey {
+		if len(dupes) < 2 {
+			continue
+		}
+		ids := patternIDs(dupes)
+		errs = append(errs, ValidationError{
+			PatternID: strings.Join(ids, ","),
+			Code:      CodeDuplicateConflict,
+			Field:     "category/framework/language/triggers.keywords",
+			Message: fmt.Sprintf("patterns %s share the same category, framework, language, and keyword set, so they always match the same queries (%s)",
+				strings.Join(ids, ", "), strings.Join(sourceFiles(dupes), ", ")),
+			Severity: "error",
+		})
+	}
+
+	patternsByKeyword := make(map[string][]string)
+	for _, p := range patterns {
+		seen := make(map[string]bool, len(p.Triggers.Keywords))
+		for _, kw := range p.Triggers.Keywords {
+			kw = strings.ToLower(kw)
+			if seen[kw] {
+				continue
+			}
+			seen[kw] = true
+			patternsByKeyword[kw] = append(patternsByKeyword[kw], p.ID)
+		}
+	}
+	for kw, ids := range patternsByKeyword {
+		if len(ids) <= maxSharedKeywordPatterns {
+			continue
+		}
+		sort.Strings(ids)
+		errs = append(errs, ValidationError{
+			PatternID: strings.Join(ids, ","),
+			Code:      CodeOverusedKeyword,
+			Field:     "triggers.keywords",
+			Message:   fmt.Sprintf("keyword %q is shared by %d patterns, too common to discriminate between them", kw, len(ids)),
+			Severity:  "warning",
+		})
+	}
+
+	sort.Slice(errs, func(i, j int) bool { return errs[i].PatternID < errs[j].PatternID })
+	return errs
+}
+
+// ValidateSetResults runs ValidateSet and wraps each finding as a
+// ValidationResult, so cross-pattern conflicts can be appended to
+// ValidateAll's per-pattern results and flow through the same
+// BuildReport/FormatValidation pipeline `tmkb validate` already uses.
+func ValidateSetResults(patterns []ThreatPattern) []ValidationResult {
+	conflicts := ValidateSet(patterns)
+	results := make([]ValidationResult, 0, len(conflicts))
+	for _, e := range conflicts {
+		result := ValidationResult{PatternID: e.PatternID, IsValid: true}
+		if e.Severity == "warning" {
+			result.Warnings = []ValidationError{e}
+		} else {
+			result.IsValid = false
+			result.Errors = []ValidationError{e}
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// conflictKey returns a fingerprint of the scope/trigger fields that, if
+// shared between two patterns, means they'd match identical queries:
+// category, framework, language, and the sorted keyword set.
+func conflictKey(p ThreatPattern) string {
+	keywords := make([]string, len(p.Triggers.Keywords))
+	for i, kw := range p.Triggers.Keywords {
+		keywords[i] = strings.ToLower(kw)
+	}
+	sort.Strings(keywords)
+	return strings.ToLower(p.Category) + "|" + strings.ToLower(p.Framework) + "|" +
+		strings.ToLower(p.Language) + "|" + strings.Join(keywords, ",")
+}
+
+// patternIDs returns the sorted ids of patterns, for deterministic
+// diagnostic messages.
+func patternIDs(patterns []ThreatPattern) []string {
+	ids := make([]string, len(patterns))
+	for i, p := range patterns {
+		ids[i] = p.ID
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// sourceFiles returns the de-duplicated source files patterns were loaded
+// from, falling back to a pattern's id when SourceFile wasn't stamped
+// (e.g. patterns built in-memory by a test).
+func sourceFiles(patterns []ThreatPattern) []string {
+	seen := make(map[string]bool, len(patterns))
+	var files []string
+	for _, p := range patterns {
+		f := p.SourceFile
+		if f == "" {
+			f = p.ID
+		}
+		if seen[f] {
+			continue
+		}
+		seen[f] = true
+		files = append(files, f)
+	}
+	return files
+}
+
+// Diagnostic is a single validation finding, the machine-readable
+// counterpart to ValidationError: FieldPath and Line are broken out
+// separately from the human-facing Message so tooling (CI annotations,
+// the MCP server) can locate the finding without parsing prose.
+type Diagnostic struct {
+	Severity  string `json:"severity"` // "error", "warning", or "info"
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	FieldPath string `json:"field_path,omitempty"`
+	Line      int    `json:"line,omitempty"`
+}
+
+// PatternValidation is the per-pattern entry in a ValidationReport.
+type PatternValidation struct {
+	ID          string       `json:"id"`
+	File        string       `json:"file,omitempty"`
+	Status      string       `json:"status"` // "ok", "warn", or "fail"
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
+
+// Totals aggregates diagnostic counts across a validation run, broken down
+// by severity and by diagnostic code, so a CI pipeline can gate on either
+// axis without re-walking Results.
+type Totals struct {
+	BySeverity map[string]int `json:"by_severity"`
+	ByCode     map[string]int `json:"by_code"`
+}
+
+// ValidationReport is a machine-readable summary of a validation run,
+// modeled on kube-bench's JSON report: a list of per-pattern results plus
+// aggregate totals.
+type ValidationReport struct {
+	Results []PatternValidation `json:"results"`
+	Totals  Totals              `json:"totals"`
+}
+
+// BuildReport converts ValidateAll's per-pattern results into a
+// ValidationReport, pairing each with its pattern's source file and
+// aggregating totals across the whole run.
+func BuildReport(patterns []ThreatPattern, results []ValidationResult) ValidationReport {
+	files := make(map[string]string, len(patterns))
+	for _, p := range patterns {
+		files[p.ID] = p.SourceFile
+	}
+
+	report := ValidationReport{
+		Results: make([]PatternValidation, 0, len(results)),
+		Totals: Totals{
+			BySeverity: make(map[string]int),
+			ByCode:     make(map[string]int),
+		},
+	}
+
+	for _, result := range results {
+		pv := PatternValidation{
+			ID:          result.PatternID,
+			File:        files[result.PatternID],
+			Diagnostics: make([]Diagnostic, 0, len(result.Errors)+len(result.Warnings)),
+		}
+
+		for _, e := range result.Errors {
+			pv.Diagnostics = append(pv.Diagnostics, diagnosticFrom(e))
+		}
+		for _, w := range result.Warnings {
+			pv.Diagnostics = append(pv.Diagnostics, diagnosticFrom(w))
+		}
+
+		switch {
+		case len(result.Errors) > 0:
+			pv.Status = "fail"
+		case len(result.Warnings) > 0:
+			pv.Status = "warn"
+		default:
+			pv.Status = "ok"
+		}
+
+		for _, d := range pv.Diagnostics {
+			report.Totals.BySeverity[d.Severity]++
+			report.Totals.ByCode[d.Code]++
+		}
+
+		report.Results = append(report.Results, pv)
+	}
+
+	return report
+}
+
+func diagnosticFrom(e ValidationError) Diagnostic {
+	return Diagnostic{
+		Severity:  e.Severity,
+		Code:      e.Code,
+		Message:   e.Message,
+		FieldPath: e.Field,
+	}
+}
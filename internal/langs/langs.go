@@ -0,0 +1,141 @@
+// Package langs describes the languages and frameworks TMKB knows how to
+// validate queries and code examples against. It exists so the supported
+// set isn't hardcoded at each call site (MCP parameter validation, the
+// `tmkb languages` command, pattern code-example selection) - adding a
+// language means registering a Plugin here, not touching every consumer.
+package langs
+
+import "strings"
+
+// Plugin describes one supported language and the frameworks recognized
+// for it.
+type Plugin struct {
+	// ID is the language identifier as used in pattern YAML and CLI/MCP
+	// parameters, e.g. "python" or "typescript".
+	ID string
+	// Frameworks lists the framework identifiers valid for this language,
+	// e.g. "flask", "django". Matched case-insensitively.
+	Frameworks []string
+	// FilePatterns are glob patterns (as used by filepath.Match) typical of
+	// source files in this language, e.g. "*.py".
+	FilePatterns []string
+}
+
+// SupportsFramework reports whether framework is valid for this plugin.
+// An empty framework or the universal "any" sentinel is always supported.
+func (p Plugin) SupportsFramework(framework string) bool {
+	if framework == "" || strings.EqualFold(framework, "any") {
+		return true
+	}
+	for _, fw := range p.Frameworks {
+		if strings.EqualFold(fw, framework) {
+			return true
+		}
+	}
+	return false
+}
+
+// Registry holds the set of known language plugins, keyed case-insensitively
+// by Plugin.ID.
+type Registry struct {
+	plugins map[string]Plugin
+	order   []string
+}
+
+// NewRegistry creates an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{plugins: make(map[string]Plugin)}
+}
+
+// Register adds or replaces a plugin. Registration order is preserved for
+// Plugins(), so re-registering an existing ID doesn't move it.
+func (r *Registry) Register(p Plugin) {
+	key := strings.ToLower(p.ID)
+	if _, exists := r.plugins[key]; !exists {
+		r.order = append(r.order, key)
+	}
+	r.plugins[key] = p
+}
+
+// Get returns the plugin registered under id, if any.
+func (r *Registry) Get(id string) (Plugin, bool) {
+	p, ok := r.plugins[strings.ToLower(id)]
+	return p, ok
+}
+
+// Plugins returns all registered plugins in registration order.
+func (r *Registry) Plugins() []Plugin {
+	plugins := make([]Plugin, 0, len(r.order))
+	for _, key := range r.order {
+		plugins = append(plugins, r.plugins[key])
+	}
+	return plugins
+}
+
+// IsValidLanguage reports whether id is a registered language.
+func (r *Registry) IsValidLanguage(id string) bool {
+	_, ok := r.Get(id)
+	return ok
+}
+
+// IsValidFramework reports whether framework is valid for language id. An
+// empty framework or "any" is always valid, even for an unregistered
+// language - callers that only have a framework to check (no language
+// context) should use IsValidFrameworkAnyLanguage instead.
+func (r *Registry) IsValidFramework(id, framework string) bool {
+	if framework == "" || strings.EqualFold(framework, "any") {
+		return true
+	}
+	p, ok := r.Get(id)
+	if !ok {
+		return false
+	}
+	return p.SupportsFramework(framework)
+}
+
+// IsValidFrameworkAnyLanguage reports whether framework is recognized by at
+// least one registered plugin. Use this where a framework must be validated
+// independently of a language parameter.
+func (r *Registry) IsValidFrameworkAnyLanguage(framework string) bool {
+	if framework == "" || strings.EqualFold(framework, "any") {
+		return true
+	}
+	for _, key := range r.order {
+		if r.plugins[key].SupportsFramework(framework) {
+			return true
+		}
+	}
+	return false
+}
+
+// Default returns a Registry populated with TMKB's first-class language
+// plugins.
+func Default() *Registry {
+	r := NewRegistry()
+	r.Register(Plugin{
+		ID:           "python",
+		Frameworks:   []string{"flask", "django", "fastapi", "celery"},
+		FilePatterns: []string{"*.py"},
+	})
+	r.Register(Plugin{
+		ID:           "javascript",
+		Frameworks:   []string{"express", "next.js"},
+		FilePatterns: []string{"*.js", "*.jsx"},
+	})
+	r.Register(Plugin{
+		ID:           "typescript",
+		Frameworks:   []string{"express", "next.js"},
+		FilePatterns: []string{"*.ts", "*.tsx"},
+	})
+	r.Register(Plugin{
+		ID:           "go",
+		Frameworks:   []string{"net/http", "gin"},
+		FilePatterns: []string{"*.go"},
+	})
+	r.Register(Plugin{
+		ID:           "java",
+		Frameworks:   []string{"spring"},
+		FilePatterns: []string{"*.java"},
+	})
+	return r
+}
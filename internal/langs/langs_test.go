@@ -0,0 +1,128 @@
+package langs
+
+import "testing"
+
+func TestPlugin_SupportsFramework(t *testing.T) {
+	p := Plugin{ID: "python", Frameworks: []string{"flask", "django"}}
+
+	tests := []struct {
+		name      string
+		framework string
+		want      bool
+	}{
+		{"Exact match", "flask", true},
+		{"Case insensitive", "FLASK", true},
+		{"Any sentinel", "any", true},
+		{"Empty", "", true},
+		{"Unsupported", "fastapi", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := p.SupportsFramework(tt.framework); got != tt.want {
+				t.Errorf("SupportsFramework(%q) = %v, want %v", tt.framework, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegistry_RegisterAndGet(t *testing.T) {
+	r := NewRegistry()
+	r.Register(Plugin{ID: "Python", Frameworks: []string{"flask"}})
+
+	p, ok := r.Get("python")
+	if !ok {
+		t.Fatal("expected lookup by lowercase id to find the plugin")
+	}
+	if p.ID != "Python" {
+		t.Errorf("Get returned ID %q, want original casing %q", p.ID, "Python")
+	}
+
+	if _, ok := r.Get("ruby"); ok {
+		t.Error("expected lookup for unregistered language to fail")
+	}
+}
+
+func TestRegistry_RegisterPreservesOrder(t *testing.T) {
+	r := NewRegistry()
+	r.Register(Plugin{ID: "go"})
+	r.Register(Plugin{ID: "python"})
+	r.Register(Plugin{ID: "go", Frameworks: []string{"gin"}}) // re-register, shouldn't move
+
+	plugins := r.Plugins()
+	if len(plugins) != 2 {
+		t.Fatalf("expected 2 plugins, got %d", len(plugins))
+	}
+	if plugins[0].ID != "go" || plugins[1].ID != "python" {
+		t.Errorf("expected order [go python], got [%s %s]", plugins[0].ID, plugins[1].ID)
+	}
+	if len(plugins[0].Frameworks) != 1 {
+		t.Error("expected re-registration to replace the plugin's fields")
+	}
+}
+
+func TestRegistry_IsValidLanguage(t *testing.T) {
+	r := Default()
+	if !r.IsValidLanguage("Python") {
+		t.Error("expected python to be valid (case insensitive)")
+	}
+	if r.IsValidLanguage("ruby") {
+		t.Error("expected ruby to be invalid")
+	}
+}
+
+func TestRegistry_IsValidFramework(t *testing.T) {
+	r := Default()
+
+	tests := []struct {
+		name      string
+		language  string
+		framework string
+		want      bool
+	}{
+		{"Python Flask", "python", "flask", true},
+		{"Python Django", "python", "django", true},
+		{"Python wrong framework", "python", "spring", false},
+		{"Any sentinel", "python", "any", true},
+		{"Empty framework", "python", "", true},
+		{"Unregistered language", "ruby", "rails", false},
+		{"Go net/http", "go", "net/http", true},
+		{"Java Spring", "java", "spring", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := r.IsValidFramework(tt.language, tt.framework); got != tt.want {
+				t.Errorf("IsValidFramework(%q, %q) = %v, want %v", tt.language, tt.framework, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegistry_IsValidFrameworkAnyLanguage(t *testing.T) {
+	r := Default()
+
+	if !r.IsValidFrameworkAnyLanguage("spring") {
+		t.Error("expected spring to be valid across the registry")
+	}
+	if !r.IsValidFrameworkAnyLanguage("") {
+		t.Error("expected empty framework to be valid")
+	}
+	if r.IsValidFrameworkAnyLanguage("rails") {
+		t.Error("expected rails to be invalid across the registry")
+	}
+}
+
+func TestDefault_RegistersExpectedPlugins(t *testing.T) {
+	r := Default()
+	want := []string{"python", "javascript", "typescript", "go", "java"}
+	plugins := r.Plugins()
+	if len(plugins) != len(want) {
+		t.Fatalf("expected %d plugins, got %d", len(want), len(plugins))
+	}
+	for i, id := range want {
+		if plugins[i].ID != id {
+			t.Errorf("plugin %d = %q, want %q", i, plugins[i].ID, id)
+		}
+	}
+}
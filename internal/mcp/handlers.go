@@ -1,77 +1,246 @@
 package mcp
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sync"
+
+	"github.com/mark-chris/tmkb/internal/knowledge"
 )
 
-// Handler is a function that handles an MCP request
-type Handler func(*Server, json.RawMessage) (interface{}, error)
+// maxBatchConcurrency bounds how many elements of a JSON-RPC batch are
+// dispatched at once, so a single oversized batch can't spin up an
+// unbounded number of goroutines against the shared index.
+const maxBatchConcurrency = 8
+
+// Handler is a function that handles an MCP request and returns a result
+// to be wrapped in a JSON-RPC response. sessionID identifies which
+// session's state (handshake progress, capabilities, ...) the handler
+// should read and mutate. ctx is cancelled if the client sends
+// notifications/cancelled for this request's id, and carries its
+// progressToken (see progressTokenFromContext) for handlers that call
+// Server.sendProgress.
+type Handler func(s *Server, sessionID string, ctx context.Context, params json.RawMessage) (interface{}, error)
+
+// NotificationHandler is a function that handles a JSON-RPC notification.
+// Unlike Handler, it has no return value: per the JSON-RPC 2.0 spec a
+// server MUST NOT reply to a notification, so there is nothing to wrap
+// in a response frame.
+type NotificationHandler func(s *Server, sessionID string, params json.RawMessage)
 
 // handlers maps method names to handler functions
 var handlers = map[string]Handler{
-	"initialize": handleInitialize,
-	"tools/list": handleToolsList,
-	"tools/call": handleToolsCall,
+	"initialize":     handleInitialize,
+	"tools/list":     handleToolsList,
+	"tools/call":     handleToolsCall,
+	"resources/list": handleResourcesList,
+	"resources/read": handleResourcesRead,
+	"prompts/list":   handlePromptsList,
+	"prompts/get":    handlePromptsGet,
 }
 
-// handleMessage processes a single JSON-RPC message
-func (s *Server) handleMessage(msg []byte) ([]byte, error) {
-	// Try to parse as request first
-	var req JSONRPCRequest
-	if err := json.Unmarshal(msg, &req); err == nil && req.ID != nil {
-		return s.handleRequest(&req)
+// notificationHandlers maps notification method names to handler
+// functions, dispatched without ever producing a response frame.
+var notificationHandlers = map[string]NotificationHandler{
+	"notifications/initialized": handleInitializedNotification,
+	"notifications/cancelled":   handleCancelledNotification,
+}
+
+// handleInitializedNotification completes the initialize handshake once
+// the client acknowledges the server's capabilities. If s.StrictValidation
+// is set and the current Index has cross-pattern conflicts (see
+// knowledge.ValidateSet), the session is left in stateInitializing rather
+// than advanced: per the JSON-RPC spec a notification gets no response,
+// so there's nothing to report the failure through except the server log
+// and every subsequent request's "not initialized" error.
+func handleInitializedNotification(s *Server, sessionID string, _ json.RawMessage) {
+	sess := s.sessionFor(sessionID)
+	if sess.getState() != stateInitializing {
+		return
+	}
+	if s.StrictValidation {
+		if conflicts := knowledge.ValidateSet(s.Index().GetAll()); len(conflicts) > 0 {
+			s.logger().Printf("refusing to initialize session %s: %d pattern conflict(s) found by validate --strict, first: %s", sessionID, len(conflicts), conflicts[0].Message)
+			return
+		}
 	}
+	sess.setState(stateInitialized)
+}
 
-	// Try to parse as notification
-	var notif JSONRPCNotification
-	if err := json.Unmarshal(msg, &notif); err == nil && notif.Method != "" {
-		return s.handleNotification(&notif)
+// handleCancelledNotification cancels the context a still-running
+// handler for requestId is observing, if any. A request whose handler has
+// already returned (the common case, since most handlers are fast) is a
+// silent no-op - cancellation is best-effort, not a guarantee.
+func handleCancelledNotification(s *Server, sessionID string, params json.RawMessage) {
+	var fields map[string]json.RawMessage
+	if err := numberDecoder(params).Decode(&fields); err != nil {
+		return
+	}
+	raw, ok := fields["requestId"]
+	if !ok {
+		return
+	}
+	var id RequestID
+	if err := id.UnmarshalJSON(raw); err != nil || id.IsNull() {
+		return
 	}
+	s.sessionFor(sessionID).cancel(id)
+}
 
-	// Invalid message
-	errResp := createErrorResponse(ErrCodeInvalidRequest, ErrMsgInvalidRequest, nil, nil)
-	return json.Marshal(errResp)
+// handleMessage processes one JSON-RPC message for the given session,
+// which may be a single request/notification object or a batch (array)
+// of them. It returns a nil slice when there is nothing to write back to
+// the client: empty input, a lone notification, or a batch consisting
+// entirely of notifications.
+func (s *Server) handleMessage(sessionID string, msg []byte) ([]byte, error) {
+	trimmed := bytes.TrimSpace(msg)
+	if len(trimmed) == 0 {
+		return nil, nil
+	}
+
+	if !json.Valid(trimmed) {
+		errResp := createErrorResponse(ErrCodeParseError, ErrMsgParseError, nil, RequestID{})
+		return json.Marshal(errResp)
+	}
+
+	if trimmed[0] == '[' {
+		return s.handleBatch(sessionID, trimmed)
+	}
+	return s.handleSingle(sessionID, trimmed)
 }
 
-// handleRequest processes a JSON-RPC request
-func (s *Server) handleRequest(req *JSONRPCRequest) ([]byte, error) {
-	// Validate JSON-RPC version
-	if req.JSONRPC != "2.0" {
-		errResp := createErrorResponse(ErrCodeInvalidRequest, "Invalid jsonrpc version", nil, req.ID)
+// handleBatch processes a JSON-RPC batch: each element is dispatched
+// independently (with up to maxBatchConcurrency running at once) and the
+// non-empty responses are collected into a single JSON array, per the
+// spec's batch semantics. The spec allows responses in arbitrary order
+// since each carries its own id, so dispatch order need not match
+// completion order - only the original element order, which is what
+// results is indexed by.
+func (s *Server) handleBatch(sessionID string, data []byte) ([]byte, error) {
+	var elements []json.RawMessage
+	if err := json.Unmarshal(data, &elements); err != nil {
+		errResp := createErrorResponse(ErrCodeInvalidRequest, ErrMsgInvalidRequest, nil, RequestID{})
+		return json.Marshal(errResp)
+	}
+	if len(elements) == 0 {
+		errResp := createErrorResponse(ErrCodeInvalidRequest, ErrMsgInvalidRequest, nil, RequestID{})
 		return json.Marshal(errResp)
 	}
 
-	// Look up handler
+	results := make([][]byte, len(elements))
+	errs := make([]error, len(elements))
+	sem := make(chan struct{}, maxBatchConcurrency)
+	var wg sync.WaitGroup
+	for i, elem := range elements {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, elem json.RawMessage) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i], errs[i] = s.handleSingle(sessionID, elem)
+		}(i, elem)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	responses := make([]json.RawMessage, 0, len(elements))
+	for _, resp := range results {
+		if len(resp) > 0 {
+			responses = append(responses, resp)
+		}
+	}
+
+	if len(responses) == 0 {
+		// Batch was entirely notifications: no response frame at all.
+		return nil, nil
+	}
+	return json.Marshal(responses)
+}
+
+// handleSingle processes one JSON-RPC request or notification object.
+func (s *Server) handleSingle(sessionID string, data []byte) ([]byte, error) {
+	if !hasID(data) {
+		var notif JSONRPCNotification
+		if err := json.Unmarshal(data, &notif); err != nil || notif.Method == "" {
+			// Malformed notification-shaped message: the spec forbids
+			// replying to notifications, so it is dropped silently
+			// rather than reported as an error.
+			return nil, nil
+		}
+		s.dispatchNotification(sessionID, &notif)
+		return nil, nil
+	}
+
+	req, err := parseRequest(data)
+	if err != nil {
+		errResp := createErrorResponse(ErrCodeInvalidRequest, ErrMsgInvalidRequest, nil, RequestID{})
+		return json.Marshal(errResp)
+	}
+	return s.handleRequest(sessionID, req)
+}
+
+// handleRequest dispatches a parsed JSON-RPC request to its Handler,
+// wrapped in RecoveryMiddleware/LoggingMiddleware/TimingMiddleware (see
+// chain) so a single broken handler can panic without crashing the
+// server. It registers a cancelable context for the request's lifetime,
+// keyed by req.ID, so a notifications/cancelled naming this id (e.g. from
+// another element of the same batch, dispatched concurrently) can abort
+// it, and stashes req.Params's progressToken (if any) and the request's
+// method/id on that context, the former for the handler to report
+// progress against via Server.sendProgress and the latter for the
+// middleware chain to log and time against.
+func (s *Server) handleRequest(sessionID string, req *JSONRPCRequest) ([]byte, error) {
 	handler, ok := handlers[req.Method]
 	if !ok {
 		errResp := createErrorResponse(ErrCodeMethodNotFound, fmt.Sprintf("Method not found: %s", req.Method), nil, req.ID)
 		return json.Marshal(errResp)
 	}
+	wrapped := chain(handler, RecoveryMiddleware, LoggingMiddleware, TimingMiddleware)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ctx = withRequestInfo(ctx, requestInfo{Method: req.Method, ID: req.ID})
+	sess := s.sessionFor(sessionID)
+	ctx = withProtocolVersion(ctx, sess.getProtocolVersion())
+	sess.registerCancel(req.ID, cancel)
+	defer sess.clearCancel(req.ID)
 
-	// Call handler
-	result, err := handler(s, req.Params)
+	if token, ok := extractProgressToken(req.Params); ok {
+		ctx = withProgressToken(ctx, token)
+	}
+
+	result, err := wrapped(s, sessionID, ctx, req.Params)
 	if err != nil {
+		var pe *panicError
+		if errors.As(err, &pe) {
+			msg := ErrMsgInternalError
+			if s.Debug {
+				msg = pe.Error()
+			}
+			errResp := createErrorResponse(ErrCodeInternalError, msg, nil, req.ID)
+			return json.Marshal(errResp)
+		}
 		// Handler returned an error - this is a protocol error
 		errResp := createErrorResponse(ErrCodeInvalidParams, err.Error(), nil, req.ID)
 		return json.Marshal(errResp)
 	}
 
-	// Success response
 	resp := createResponse(result, req.ID)
 	return json.Marshal(resp)
 }
 
-// handleNotification processes a JSON-RPC notification
-func (s *Server) handleNotification(notif *JSONRPCNotification) ([]byte, error) {
-	// Handle initialized notification
-	if notif.Method == "notifications/initialized" {
-		if s.getState() == stateInitializing {
-			s.setState(stateInitialized)
-		}
-		return []byte{}, nil // No response for notifications
+// dispatchNotification routes a notification to its NotificationHandler.
+// Unknown notifications are ignored per the JSON-RPC spec.
+func (s *Server) dispatchNotification(sessionID string, notif *JSONRPCNotification) {
+	if handler, ok := notificationHandlers[notif.Method]; ok {
+		handler(s, sessionID, notif.Params)
 	}
-
-	// Unknown notification - ignore per JSON-RPC spec
-	return []byte{}, nil
 }
@@ -13,13 +13,13 @@ func TestHandleMessage_Initialize(t *testing.T) {
 
 	req := JSONRPCRequest{
 		JSONRPC: "2.0",
-		ID:      1,
+		ID:      NewRequestID(1),
 		Method:  "initialize",
 		Params:  json.RawMessage(`{"protocolVersion":"2025-11-25","capabilities":{}}`),
 	}
 	reqData, _ := json.Marshal(req)
 
-	respData, err := srv.handleMessage(reqData)
+	respData, err := srv.handleMessage(defaultSessionID, reqData)
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
@@ -29,9 +29,7 @@ func TestHandleMessage_Initialize(t *testing.T) {
 		t.Fatalf("failed to parse response: %v", err)
 	}
 
-	// JSON unmarshals numbers as float64
-	idFloat, ok := resp.ID.(float64)
-	if !ok || idFloat != 1 {
+	if resp.ID.String() != "1" {
 		t.Errorf("expected id 1, got %v", resp.ID)
 	}
 }
@@ -42,12 +40,12 @@ func TestHandleMessage_MethodNotFound(t *testing.T) {
 
 	req := JSONRPCRequest{
 		JSONRPC: "2.0",
-		ID:      1,
+		ID:      NewRequestID(1),
 		Method:  "unknown/method",
 	}
 	reqData, _ := json.Marshal(req)
 
-	respData, err := srv.handleMessage(reqData)
+	respData, err := srv.handleMessage(defaultSessionID, reqData)
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
@@ -73,7 +71,7 @@ func TestHandleMessage_InitializedNotification(t *testing.T) {
 	}
 	notifData, _ := json.Marshal(notif)
 
-	respData, err := srv.handleMessage(notifData)
+	respData, err := srv.handleMessage(defaultSessionID, notifData)
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
@@ -87,3 +85,55 @@ func TestHandleMessage_InitializedNotification(t *testing.T) {
 		t.Errorf("expected state Initialized, got %v", srv.getState())
 	}
 }
+
+// TestHandleMessage_InitializedNotification_StrictRefusesConflictingIndex
+// verifies a server with StrictValidation set never reaches
+// stateInitialized when its Index's patterns conflict, per
+// knowledge.ValidateSet.
+func TestHandleMessage_InitializedNotification_StrictRefusesConflictingIndex(t *testing.T) {
+	idx := knowledge.NewIndex()
+	idx.Build([]knowledge.ThreatPattern{
+		{ID: "TMKB-DUP-001", Triggers: knowledge.Triggers{Keywords: []string{"foo"}}},
+		{ID: "TMKB-DUP-001", Triggers: knowledge.Triggers{Keywords: []string{"bar"}}},
+	})
+
+	srv := NewServer(idx)
+	srv.StrictValidation = true
+	srv.setState(stateInitializing)
+
+	notif := JSONRPCNotification{JSONRPC: "2.0", Method: "notifications/initialized"}
+	notifData, _ := json.Marshal(notif)
+
+	if _, err := srv.handleMessage(defaultSessionID, notifData); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if srv.getState() != stateInitializing {
+		t.Errorf("expected state to stay Initializing, got %v", srv.getState())
+	}
+}
+
+// TestHandleMessage_InitializedNotification_StrictAllowsCleanIndex verifies
+// StrictValidation doesn't block initialization when the Index has no
+// conflicts.
+func TestHandleMessage_InitializedNotification_StrictAllowsCleanIndex(t *testing.T) {
+	idx := knowledge.NewIndex()
+	idx.Build([]knowledge.ThreatPattern{
+		{ID: "TMKB-OK-001", Triggers: knowledge.Triggers{Keywords: []string{"foo"}}},
+	})
+
+	srv := NewServer(idx)
+	srv.StrictValidation = true
+	srv.setState(stateInitializing)
+
+	notif := JSONRPCNotification{JSONRPC: "2.0", Method: "notifications/initialized"}
+	notifData, _ := json.Marshal(notif)
+
+	if _, err := srv.handleMessage(defaultSessionID, notifData); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if srv.getState() != stateInitialized {
+		t.Errorf("expected state Initialized, got %v", srv.getState())
+	}
+}
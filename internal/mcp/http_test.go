@@ -0,0 +1,148 @@
+package mcp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark-chris/tmkb/internal/knowledge"
+)
+
+func TestHandleMCPPost_InitializeAssignsSession(t *testing.T) {
+	idx := knowledge.NewIndex()
+	srv := NewServer(idx)
+
+	body := `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-11-25","capabilities":{}}}`
+	req := httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+
+	srv.handleMCPPost(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	sessionID := rec.Header().Get(sessionHeader)
+	if sessionID == "" {
+		t.Fatal("expected a generated Mcp-Session-Id header")
+	}
+	if !strings.Contains(rec.Body.String(), `"protocolVersion":"2025-11-25"`) {
+		t.Errorf("expected initialize response, got %s", rec.Body.String())
+	}
+	if srv.sessionFor(sessionID).getState() != stateInitializing {
+		t.Errorf("expected session state Initializing, got %v", srv.sessionFor(sessionID).getState())
+	}
+}
+
+func TestHandleMCPPost_NotificationOnlyReturns202(t *testing.T) {
+	idx := knowledge.NewIndex()
+	srv := NewServer(idx)
+
+	body := `{"jsonrpc":"2.0","method":"notifications/initialized"}`
+	req := httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(body))
+	req.Header.Set(sessionHeader, "session-a")
+	rec := httptest.NewRecorder()
+
+	srv.handleMCPPost(rec, req)
+
+	if rec.Code != http.StatusAccepted {
+		t.Errorf("expected 202, got %d", rec.Code)
+	}
+	if rec.Body.Len() != 0 {
+		t.Errorf("expected empty body for a notification, got %s", rec.Body.String())
+	}
+}
+
+// TestHandleMCPPost_SessionIsolation verifies that two Mcp-Session-Id
+// values never share handshake state: initializing session A must not
+// make tools/list succeed against session B.
+func TestHandleMCPPost_SessionIsolation(t *testing.T) {
+	idx := knowledge.NewIndex()
+	srv := NewServer(idx)
+
+	initBody := `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-11-25","capabilities":{}}}`
+	initReq := httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(initBody))
+	initReq.Header.Set(sessionHeader, "session-a")
+	initRec := httptest.NewRecorder()
+	srv.handleMCPPost(initRec, initReq)
+
+	initializedBody := `{"jsonrpc":"2.0","method":"notifications/initialized"}`
+	notifReq := httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(initializedBody))
+	notifReq.Header.Set(sessionHeader, "session-a")
+	notifRec := httptest.NewRecorder()
+	srv.handleMCPPost(notifRec, notifReq)
+
+	if srv.sessionFor("session-a").getState() != stateInitialized {
+		t.Fatalf("expected session-a to be Initialized, got %v", srv.sessionFor("session-a").getState())
+	}
+
+	listBody := `{"jsonrpc":"2.0","id":2,"method":"tools/list"}`
+	listReq := httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(listBody))
+	listReq.Header.Set(sessionHeader, "session-b")
+	listRec := httptest.NewRecorder()
+	srv.handleMCPPost(listRec, listReq)
+
+	if !strings.Contains(listRec.Body.String(), "not initialized") {
+		t.Errorf("expected session-b to still be uninitialized, got %s", listRec.Body.String())
+	}
+}
+
+func TestHandleMCPStream_RequiresSessionHeader(t *testing.T) {
+	idx := knowledge.NewIndex()
+	srv := NewServer(idx)
+
+	req := httptest.NewRequest(http.MethodGet, "/mcp", nil)
+	rec := httptest.NewRecorder()
+
+	srv.handleMCPStream(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 without a session header, got %d", rec.Code)
+	}
+}
+
+func TestHandleMCPStream_DisconnectsWithClientContext(t *testing.T) {
+	idx := knowledge.NewIndex()
+	srv := NewServer(idx)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest(http.MethodGet, "/mcp", nil).WithContext(ctx)
+	req.Header.Set(sessionHeader, "session-a")
+	rec := httptest.NewRecorder()
+
+	done := make(chan struct{})
+	go func() {
+		srv.handleMCPStream(rec, req)
+		close(done)
+	}()
+
+	// Give the handler a moment to write its headers before disconnecting.
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("handleMCPStream did not return after client disconnect")
+	}
+
+	if rec.Header().Get("Content-Type") != "text/event-stream" {
+		t.Errorf("expected text/event-stream content type, got %q", rec.Header().Get("Content-Type"))
+	}
+}
+
+func TestHandleMCP_MethodNotAllowed(t *testing.T) {
+	idx := knowledge.NewIndex()
+	srv := NewServer(idx)
+
+	req := httptest.NewRequest(http.MethodDelete, "/mcp", nil)
+	rec := httptest.NewRecorder()
+
+	srv.handleMCP(rec, req)
+
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405, got %d", rec.Code)
+	}
+}
@@ -1,8 +1,10 @@
 package mcp
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 )
 
 // initializeParams represents the initialize request parameters
@@ -12,10 +14,88 @@ type initializeParams struct {
 	ClientInfo      map[string]interface{} `json:"clientInfo,omitempty"`
 }
 
+// negotiateProtocolVersion picks the protocol version an initialize
+// response should report, per the MCP spec: echo the client's requested
+// version if the server supports it, otherwise offer supported[0] (the
+// server's newest) and let the client decide whether to disconnect.
+// requested must be a non-empty string - an unparseable or missing
+// protocolVersion is a client error, not something to silently coerce.
+func negotiateProtocolVersion(requested string, supported []string) (string, error) {
+	if strings.TrimSpace(requested) == "" {
+		return "", fmt.Errorf("protocolVersion is required")
+	}
+	if len(supported) == 0 {
+		return "", fmt.Errorf("server has no supported protocol versions configured")
+	}
+	for _, v := range supported {
+		if v == requested {
+			return requested, nil
+		}
+	}
+	return supported[0], nil
+}
+
+// baseCapabilities are the capabilities object fields every supported
+// protocol version advertises. capabilitiesForVersion layers
+// experimentalCapabilities on top of a copy of this.
+func baseCapabilities() map[string]interface{} {
+	return map[string]interface{}{
+		"tools": map[string]interface{}{
+			"listChanged": false,
+		},
+		"resources": map[string]interface{}{
+			"listChanged": false,
+		},
+		"prompts": map[string]interface{}{
+			"listChanged": false,
+		},
+	}
+}
+
+// experimentalCapability is one capabilities field override that only
+// applies to protocol versions at or after MinVersion. MCP versions are
+// YYYY-MM-DD dates, so a plain string comparison orders them correctly.
+type experimentalCapability struct {
+	MinVersion string
+	Section    string
+	Key        string
+	Value      interface{}
+}
+
+// experimentalCapabilities lists every version-gated capability override
+// applied on top of baseCapabilities. Empty today since TMKB supports a
+// single protocol version, but gives the next supported revision
+// somewhere to toggle a feature - e.g. tools.listChanged once the server
+// actually emits the matching notification - without touching
+// handleInitialize itself.
+var experimentalCapabilities []experimentalCapability
+
+// capabilitiesForVersion returns the capabilities object an initialize
+// response advertises for a negotiated protocolVersion: baseCapabilities
+// with every experimentalCapability whose MinVersion is <= version
+// applied on top.
+func capabilitiesForVersion(version string) map[string]interface{} {
+	caps := baseCapabilities()
+	for _, exp := range experimentalCapabilities {
+		if version < exp.MinVersion {
+			continue
+		}
+		section, ok := caps[exp.Section].(map[string]interface{})
+		if !ok {
+			section = map[string]interface{}{}
+			caps[exp.Section] = section
+		}
+		section[exp.Key] = exp.Value
+	}
+	return caps
+}
+
 // handleInitialize handles the initialize request
-func handleInitialize(s *Server, params json.RawMessage) (interface{}, error) {
+func handleInitialize(s *Server, sessionID string, ctx context.Context, params json.RawMessage) (interface{}, error) {
+	sess := s.sessionFor(sessionID)
+
 	// Check if already initialized
-	state := s.getState()
+	state := sess.getState()
 	if state != stateNotInitialized {
 		return nil, fmt.Errorf("already initialized")
 	}
@@ -26,31 +106,21 @@ func handleInitialize(s *Server, params json.RawMessage) (interface{}, error) {
 		return nil, fmt.Errorf("invalid initialize params: %w", err)
 	}
 
-	// Version negotiation: support 2025-11-25 only
-	protocolVersion := "2025-11-25"
-	if p.ProtocolVersion != protocolVersion {
-		// Client requested unsupported version, respond with our version
-		// Client may disconnect if incompatible
-		protocolVersion = "2025-11-25"
+	protocolVersion, err := negotiateProtocolVersion(p.ProtocolVersion, s.SupportedVersions)
+	if err != nil {
+		return nil, err
 	}
 
 	// Store protocol version and client capabilities
-	s.mu.Lock()
-	s.protocolVersion = protocolVersion
-	s.clientCapabilities = p.Capabilities
-	s.mu.Unlock()
+	sess.setHandshake(protocolVersion, p.Capabilities)
 
 	// Transition to initializing state
-	s.setState(stateInitializing)
+	sess.setState(stateInitializing)
 
 	// Build response
 	result := map[string]interface{}{
 		"protocolVersion": protocolVersion,
-		"capabilities": map[string]interface{}{
-			"tools": map[string]interface{}{
-				"listChanged": false,
-			},
-		},
+		"capabilities":    capabilitiesForVersion(protocolVersion),
 		"serverInfo": map[string]interface{}{
 			"name":        "tmkb",
 			"version":     "0.1.0",
@@ -1,7 +1,11 @@
 package mcp
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/mark-chris/tmkb/internal/knowledge"
@@ -21,7 +25,7 @@ func TestHandleInitialize_Success(t *testing.T) {
 	}
 	paramsJSON, _ := json.Marshal(params)
 
-	result, err := handleInitialize(srv, paramsJSON)
+	result, err := handleInitialize(srv, defaultSessionID, context.Background(), paramsJSON)
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
@@ -36,6 +40,134 @@ func TestHandleInitialize_Success(t *testing.T) {
 	}
 }
 
+// TestHandleInitialize_VersionNegotiation covers negotiateProtocolVersion's
+// cases end to end through handleInitialize: an exact match, an older
+// still-supported version, an unknown version (server offers its
+// newest), and malformed/missing input (a JSON-RPC error, not a silent
+// coercion).
+func TestHandleInitialize_VersionNegotiation(t *testing.T) {
+	tests := []struct {
+		name           string
+		supported      []string
+		requested      string
+		omitVersion    bool
+		wantErr        bool
+		wantNegotiated string
+	}{
+		{
+			name:           "matching version is echoed back",
+			supported:      []string{"2025-11-25"},
+			requested:      "2025-11-25",
+			wantNegotiated: "2025-11-25",
+		},
+		{
+			name:           "older supported version is echoed back",
+			supported:      []string{"2025-11-25", "2025-06-18"},
+			requested:      "2025-06-18",
+			wantNegotiated: "2025-06-18",
+		},
+		{
+			name:           "unknown version falls back to the server's newest",
+			supported:      []string{"2025-11-25", "2025-06-18"},
+			requested:      "1999-01-01",
+			wantNegotiated: "2025-11-25",
+		},
+		{
+			name:        "empty protocolVersion is a JSON-RPC error",
+			supported:   []string{"2025-11-25"},
+			omitVersion: true,
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idx := knowledge.NewIndex()
+			srv := NewServer(idx)
+			srv.SupportedVersions = tt.supported
+
+			params := map[string]interface{}{
+				"capabilities": map[string]interface{}{},
+			}
+			if !tt.omitVersion {
+				params["protocolVersion"] = tt.requested
+			}
+			paramsJSON, _ := json.Marshal(params)
+
+			result, err := handleInitialize(srv, defaultSessionID, context.Background(), paramsJSON)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error for missing/malformed protocolVersion")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			resultMap := result.(map[string]interface{})
+			if resultMap["protocolVersion"] != tt.wantNegotiated {
+				t.Errorf("expected negotiated version %q, got %v", tt.wantNegotiated, resultMap["protocolVersion"])
+			}
+			if got := srv.sessionFor(defaultSessionID).getProtocolVersion(); got != tt.wantNegotiated {
+				t.Errorf("expected session to persist negotiated version %q, got %q", tt.wantNegotiated, got)
+			}
+		})
+	}
+}
+
+// TestHandleInitialize_MalformedParams covers params that fail to parse
+// at all (not just an empty/missing protocolVersion).
+func TestHandleInitialize_MalformedParams(t *testing.T) {
+	idx := knowledge.NewIndex()
+	srv := NewServer(idx)
+
+	_, err := handleInitialize(srv, defaultSessionID, context.Background(), json.RawMessage(`{"protocolVersion": 42}`))
+	if err == nil {
+		t.Fatal("expected an error for a non-string protocolVersion")
+	}
+}
+
+// TestHandleRequest_ProtocolVersionAvailableToHandlers verifies the
+// negotiated protocol version is threaded through ctx to later handlers
+// in the same session via protocolVersionFromContext, not just returned
+// in the initialize response.
+func TestHandleRequest_ProtocolVersionAvailableToHandlers(t *testing.T) {
+	idx := knowledge.NewIndex()
+	srv := NewServer(idx)
+
+	initReq := JSONRPCRequest{
+		JSONRPC: "2.0",
+		ID:      NewRequestID(1),
+		Method:  "initialize",
+		Params:  json.RawMessage(`{"protocolVersion":"2025-11-25","capabilities":{}}`),
+	}
+	initData, _ := json.Marshal(initReq)
+	if _, err := srv.handleMessage(defaultSessionID, initData); err != nil {
+		t.Fatalf("initialize failed: %v", err)
+	}
+
+	var seen string
+	var sawVersion bool
+	withFakeHandler(t, "test/version", func(s *Server, sessionID string, ctx context.Context, params json.RawMessage) (interface{}, error) {
+		seen, sawVersion = protocolVersionFromContext(ctx)
+		return map[string]interface{}{}, nil
+	})
+
+	req := JSONRPCRequest{JSONRPC: "2.0", ID: NewRequestID(2), Method: "test/version"}
+	reqData, _ := json.Marshal(req)
+	if _, err := srv.handleMessage(defaultSessionID, reqData); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if !sawVersion {
+		t.Fatal("expected the handler to see a negotiated protocol version in ctx")
+	}
+	if seen != "2025-11-25" {
+		t.Errorf("expected protocol version 2025-11-25, got %q", seen)
+	}
+}
+
 func TestHandleInitialize_DuplicateInit(t *testing.T) {
 	idx := knowledge.NewIndex()
 	srv := NewServer(idx)
@@ -47,8 +179,171 @@ func TestHandleInitialize_DuplicateInit(t *testing.T) {
 	}
 	paramsJSON, _ := json.Marshal(params)
 
-	_, err := handleInitialize(srv, paramsJSON)
+	_, err := handleInitialize(srv, defaultSessionID, context.Background(), paramsJSON)
 	if err == nil {
 		t.Fatal("expected error for duplicate initialization")
 	}
 }
+
+// TestHandleInitialize_Batching covers the JSON-RPC 2.0 batch and
+// numeric-id-preservation behavior that sits around the initialize
+// handshake: array requests, notification-only batches, mixed batches,
+// and integer ids that must not pick up a spurious ".0".
+func TestHandleInitialize_Batching(t *testing.T) {
+	initializeLine := func(id string) string {
+		return `{"jsonrpc":"2.0","id":` + id + `,"method":"initialize","params":{"protocolVersion":"2025-11-25","capabilities":{}}}`
+	}
+
+	tests := []struct {
+		name        string
+		input       string
+		wantNil     bool
+		wantArray   bool
+		wantIDRaw   string // substring the response id must contain, e.g. `"id":7`
+		wantEntries int
+	}{
+		{
+			name:      "single request preserves integer id",
+			input:     initializeLine("7"),
+			wantIDRaw: `"id":7`,
+		},
+		{
+			name:    "lone notification produces no response",
+			input:   `{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+			wantNil: true,
+		},
+		{
+			name:        "batch of two requests returns two responses",
+			input:       `[` + initializeLine("1") + `,` + initializeLine(`"two"`) + `]`,
+			wantArray:   true,
+			wantEntries: 2, // each initialize yields an entry, whether it succeeds or fails as already initializing
+		},
+		{
+			name:        "batch mixing a request and a notification omits the notification",
+			input:       `[` + initializeLine(`2`) + `,{"jsonrpc":"2.0","method":"notifications/cancelled"}]`,
+			wantArray:   true,
+			wantEntries: 1,
+		},
+		{
+			name:    "batch of only notifications produces no response",
+			input:   `[{"jsonrpc":"2.0","method":"notifications/initialized"},{"jsonrpc":"2.0","method":"notifications/cancelled"}]`,
+			wantNil: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idx := knowledge.NewIndex()
+			srv := NewServer(idx)
+
+			resp, err := srv.handleMessage(defaultSessionID, []byte(tt.input))
+			if err != nil {
+				t.Fatalf("handleMessage error: %v", err)
+			}
+
+			if tt.wantNil {
+				if len(resp) != 0 {
+					t.Fatalf("expected no response, got %s", resp)
+				}
+				return
+			}
+			if len(resp) == 0 {
+				t.Fatal("expected a response, got none")
+			}
+
+			if tt.wantArray {
+				var arr []json.RawMessage
+				if err := json.Unmarshal(resp, &arr); err != nil {
+					t.Fatalf("expected a JSON array response, got %s: %v", resp, err)
+				}
+				if len(arr) == 0 {
+					t.Fatal("expected at least one response entry in the batch")
+				}
+				if tt.wantEntries > 0 && len(arr) != tt.wantEntries {
+					t.Fatalf("expected %d response entries, got %d: %s", tt.wantEntries, len(arr), resp)
+				}
+				return
+			}
+
+			if tt.wantIDRaw != "" && !bytes.Contains(resp, []byte(tt.wantIDRaw)) {
+				t.Errorf("expected response to contain %s, got %s", tt.wantIDRaw, resp)
+			}
+		})
+	}
+}
+
+// TestHandleInitialize_NumericIDPreservation verifies that integer and
+// float ids round-trip through handleMessage without being coerced to
+// float64 (which would render a large or exact integer id as N.0).
+func TestHandleInitialize_NumericIDPreservation(t *testing.T) {
+	tests := []struct {
+		name   string
+		idJSON string
+		want   string
+	}{
+		{"integer id", "42", `"id":42`},
+		{"float id", "1.5", `"id":1.5`},
+		{"string id", `"abc"`, `"id":"abc"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idx := knowledge.NewIndex()
+			srv := NewServer(idx)
+
+			input := `{"jsonrpc":"2.0","id":` + tt.idJSON + `,"method":"initialize","params":{"protocolVersion":"2025-11-25","capabilities":{}}}`
+			resp, err := srv.handleMessage(defaultSessionID, []byte(input))
+			if err != nil {
+				t.Fatalf("handleMessage error: %v", err)
+			}
+			if !bytes.Contains(resp, []byte(tt.want)) {
+				t.Errorf("expected response to contain %s, got %s", tt.want, resp)
+			}
+		})
+	}
+}
+
+// TestHandleBatch_LargeBatchCorrelatesByID verifies that a batch larger
+// than maxBatchConcurrency still returns exactly one response per
+// request, each correlated back to its own id regardless of the
+// concurrent dispatch order underneath.
+func TestHandleBatch_LargeBatchCorrelatesByID(t *testing.T) {
+	idx := knowledge.NewIndex()
+	srv := NewServer(idx)
+
+	const n = maxBatchConcurrency*3 + 1
+	elements := make([]string, n)
+	for i := 0; i < n; i++ {
+		elements[i] = `{"jsonrpc":"2.0","id":` + strconv.Itoa(i) + `,"method":"tools/list"}`
+	}
+	input := "[" + strings.Join(elements, ",") + "]"
+
+	resp, err := srv.handleMessage(defaultSessionID, []byte(input))
+	if err != nil {
+		t.Fatalf("handleMessage error: %v", err)
+	}
+
+	var arr []json.RawMessage
+	if err := json.Unmarshal(resp, &arr); err != nil {
+		t.Fatalf("expected a JSON array response, got %s: %v", resp, err)
+	}
+	if len(arr) != n {
+		t.Fatalf("expected %d responses, got %d", n, len(arr))
+	}
+
+	seen := make(map[string]bool, n)
+	for _, entry := range arr {
+		var partial struct {
+			ID json.Number `json:"id"`
+		}
+		if err := json.Unmarshal(entry, &partial); err != nil {
+			t.Fatalf("failed to decode response entry id: %v", err)
+		}
+		seen[partial.ID.String()] = true
+	}
+	for i := 0; i < n; i++ {
+		if !seen[strconv.Itoa(i)] {
+			t.Errorf("missing response for id %d", i)
+		}
+	}
+}
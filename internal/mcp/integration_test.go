@@ -45,8 +45,7 @@ func TestIntegration_FullSession(t *testing.T) {
 	if err := json.Unmarshal([]byte(lines[0]), &initResp); err != nil {
 		t.Fatalf("failed to parse init response: %v", err)
 	}
-	idFloat, ok := initResp.ID.(float64)
-	if !ok || idFloat != 1 {
+	if initResp.ID.String() != "1" {
 		t.Errorf("expected id 1, got %v", initResp.ID)
 	}
 
@@ -55,8 +54,7 @@ func TestIntegration_FullSession(t *testing.T) {
 	if err := json.Unmarshal([]byte(lines[1]), &listResp); err != nil {
 		t.Fatalf("failed to parse list response: %v", err)
 	}
-	idFloat, ok = listResp.ID.(float64)
-	if !ok || idFloat != 2 {
+	if listResp.ID.String() != "2" {
 		t.Errorf("expected id 2, got %v", listResp.ID)
 	}
 
@@ -65,8 +63,7 @@ func TestIntegration_FullSession(t *testing.T) {
 	if err := json.Unmarshal([]byte(lines[2]), &callResp); err != nil {
 		t.Fatalf("failed to parse call response: %v", err)
 	}
-	idFloat, ok = callResp.ID.(float64)
-	if !ok || idFloat != 3 {
+	if callResp.ID.String() != "3" {
 		t.Errorf("expected id 3, got %v", callResp.ID)
 	}
 
@@ -111,8 +108,7 @@ func TestIntegration_ErrorRecovery(t *testing.T) {
 	if err := json.Unmarshal([]byte(lines[1]), &listResp); err != nil {
 		t.Fatalf("failed to parse list response: %v", err)
 	}
-	idFloat, ok := listResp.ID.(float64)
-	if !ok || idFloat != 2 {
+	if listResp.ID.String() != "2" {
 		t.Errorf("expected id 2, got %v", listResp.ID)
 	}
 }
@@ -123,7 +119,7 @@ func TestIntegration_ValidationErrors(t *testing.T) {
 	srv.setState(stateInitialized)
 
 	// Tools/call with invalid language
-	input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"tmkb_query","arguments":{"context":"test","language":"java"}}}
+	input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"tmkb_query","arguments":{"context":"test","language":"cobol"}}}
 `
 
 	var output bytes.Buffer
@@ -1,23 +1,127 @@
 package mcp
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"strconv"
 )
 
-// JSONRPCRequest represents a JSON-RPC 2.0 request
+// idKind records which of the JSON-RPC 2.0 spec's three allowed id shapes
+// a RequestID holds. The zero value, idKindNull, matches RequestID's zero
+// value so an unset RequestID{} marshals as a JSON null exactly like one
+// explicitly decoded from "id": null.
+type idKind int
+
+const (
+	idKindNull idKind = iota
+	idKindString
+	idKindNumber
+)
+
+// RequestID represents a JSON-RPC 2.0 request or response id, which the
+// spec restricts to a JSON string, a JSON number, or JSON null - nothing
+// else, including objects, arrays, and booleans. raw holds the exact JSON
+// token (quotes included for a string) rather than a decoded Go value, so
+// a numeric id round-trips byte-for-byte: an integer like 42 stays "42"
+// instead of becoming "42.0" via float64, and an id beyond float64's
+// 53-bit precision doesn't silently lose digits.
+type RequestID struct {
+	raw  string
+	kind idKind
+}
+
+// NewRequestID builds a RequestID from a Go value, for internal
+// construction and tests. Supported kinds are string, json.Number, int,
+// int64, and nil (producing the null id); anything else panics, since it
+// would mean a caller is trying to construct an id shape the spec forbids.
+func NewRequestID(v interface{}) RequestID {
+	switch t := v.(type) {
+	case nil:
+		return RequestID{kind: idKindNull}
+	case string:
+		raw, _ := json.Marshal(t)
+		return RequestID{raw: string(raw), kind: idKindString}
+	case json.Number:
+		return RequestID{raw: string(t), kind: idKindNumber}
+	case int:
+		return RequestID{raw: strconv.Itoa(t), kind: idKindNumber}
+	case int64:
+		return RequestID{raw: strconv.FormatInt(t, 10), kind: idKindNumber}
+	default:
+		panic(fmt.Sprintf("mcp: unsupported RequestID value %T", v))
+	}
+}
+
+// IsNull reports whether id is the JSON null id - including RequestID's
+// zero value, which is used as the null id for responses built before a
+// request's own id is known (e.g. a parse error).
+func (id RequestID) IsNull() bool {
+	return id.kind == idKindNull
+}
+
+// String returns id's exact JSON token: digits for a number, a
+// double-quoted string for a string id, or "null".
+func (id RequestID) String() string {
+	if id.kind == idKindNull {
+		return "null"
+	}
+	return id.raw
+}
+
+// MarshalJSON writes id's exact original token back out, so a numeric id
+// round-trips without going through float64.
+func (id RequestID) MarshalJSON() ([]byte, error) {
+	if id.kind == idKindNull {
+		return []byte("null"), nil
+	}
+	return []byte(id.raw), nil
+}
+
+// UnmarshalJSON accepts only a JSON string, a JSON number (decoded via
+// json.Number so its original digits are preserved), or JSON null,
+// rejecting objects, arrays, and booleans as the spec requires.
+func (id *RequestID) UnmarshalJSON(data []byte) error {
+	trimmed := bytes.TrimSpace(data)
+	switch {
+	case bytes.Equal(trimmed, []byte("null")):
+		*id = RequestID{kind: idKindNull}
+		return nil
+	case len(trimmed) > 0 && trimmed[0] == '"':
+		var s string
+		if err := json.Unmarshal(trimmed, &s); err != nil {
+			return fmt.Errorf("invalid string id: %w", err)
+		}
+		*id = RequestID{raw: string(trimmed), kind: idKindString}
+		return nil
+	case len(trimmed) > 0 && (trimmed[0] == '-' || (trimmed[0] >= '0' && trimmed[0] <= '9')):
+		var n json.Number
+		if err := numberDecoder(trimmed).Decode(&n); err != nil {
+			return fmt.Errorf("invalid number id: %w", err)
+		}
+		*id = RequestID{raw: string(n), kind: idKindNumber}
+		return nil
+	default:
+		return errors.New("id must be a string, number, or null")
+	}
+}
+
+// JSONRPCRequest represents a JSON-RPC 2.0 request. ID is parsed by
+// parseRequest/RequestID.UnmarshalJSON, which preserves a numeric id's
+// original digits instead of coercing it through float64.
 type JSONRPCRequest struct {
 	JSONRPC string          `json:"jsonrpc"`
 	Method  string          `json:"method"`
 	Params  json.RawMessage `json:"params,omitempty"`
-	ID      interface{}     `json:"id,omitempty"`
+	ID      RequestID       `json:"id,omitempty"`
 }
 
 // JSONRPCResponse represents a JSON-RPC 2.0 response
 type JSONRPCResponse struct {
 	JSONRPC string      `json:"jsonrpc"`
 	Result  interface{} `json:"result"`
-	ID      interface{} `json:"id"`
+	ID      RequestID   `json:"id"`
 }
 
 // JSONRPCError represents a JSON-RPC 2.0 error object
@@ -31,7 +135,7 @@ type JSONRPCError struct {
 type JSONRPCErrorResponse struct {
 	JSONRPC string       `json:"jsonrpc"`
 	Error   JSONRPCError `json:"error"`
-	ID      interface{}  `json:"id"`
+	ID      RequestID    `json:"id"`
 }
 
 // JSONRPCNotification represents a JSON-RPC 2.0 notification (request without ID)
@@ -41,22 +145,65 @@ type JSONRPCNotification struct {
 	Params  json.RawMessage `json:"params,omitempty"`
 }
 
-// parseRequest parses and validates a JSON-RPC request
+// numberDecoder returns a json.Decoder configured to decode numbers as
+// json.Number instead of float64, so ids keep their original integer or
+// float shape across a parse/marshal round trip.
+func numberDecoder(data []byte) *json.Decoder {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec
+}
+
+// parseRequest parses and validates a single (non-batch) JSON-RPC
+// request, rejecting an id of any shape RequestID.UnmarshalJSON rejects.
 func parseRequest(data []byte) (*JSONRPCRequest, error) {
-	var req JSONRPCRequest
-	if err := json.Unmarshal(data, &req); err != nil {
+	var fields map[string]json.RawMessage
+	if err := numberDecoder(data).Decode(&fields); err != nil {
 		return nil, err
 	}
 
+	req := &JSONRPCRequest{}
+	if raw, ok := fields["jsonrpc"]; ok {
+		if err := json.Unmarshal(raw, &req.JSONRPC); err != nil {
+			return nil, err
+		}
+	}
+	if raw, ok := fields["method"]; ok {
+		if err := json.Unmarshal(raw, &req.Method); err != nil {
+			return nil, err
+		}
+	}
+	if raw, ok := fields["params"]; ok {
+		req.Params = raw
+	}
+	if raw, ok := fields["id"]; ok {
+		if err := req.ID.UnmarshalJSON(raw); err != nil {
+			return nil, err
+		}
+	}
+
 	if req.JSONRPC != "2.0" {
 		return nil, errors.New("invalid or missing jsonrpc version")
 	}
 
-	return &req, nil
+	return req, nil
+}
+
+// hasID reports whether data (a JSON-RPC request object) carries an "id"
+// member at all. Per the JSON-RPC 2.0 spec, a request is a notification
+// if and only if the id member is entirely absent - an explicit `"id":
+// null` is a (discouraged but valid) request expecting a null-id response.
+func hasID(data []byte) bool {
+	var fields map[string]json.RawMessage
+	if err := numberDecoder(data).Decode(&fields); err != nil {
+		return false
+	}
+	_, ok := fields["id"]
+	return ok
 }
 
 // createResponse creates a JSON-RPC 2.0 success response
-func createResponse(result interface{}, id interface{}) JSONRPCResponse {
+func createResponse(result interface{}, id RequestID) JSONRPCResponse {
 	return JSONRPCResponse{
 		JSONRPC: "2.0",
 		Result:  result,
@@ -65,7 +212,7 @@ func createResponse(result interface{}, id interface{}) JSONRPCResponse {
 }
 
 // createErrorResponse creates a JSON-RPC 2.0 error response
-func createErrorResponse(code int, message string, data interface{}, id interface{}) JSONRPCErrorResponse {
+func createErrorResponse(code int, message string, data interface{}, id RequestID) JSONRPCErrorResponse {
 	return JSONRPCErrorResponse{
 		JSONRPC: "2.0",
 		Error: JSONRPCError{
@@ -23,8 +23,8 @@ func TestParseRequest_ValidRequest(t *testing.T) {
 	if req.Method != "initialize" {
 		t.Errorf("expected method initialize, got %s", req.Method)
 	}
-	if req.ID == nil {
-		t.Error("expected ID to be non-nil")
+	if req.ID.IsNull() {
+		t.Error("expected ID to be non-null")
 	}
 }
 
@@ -51,7 +51,7 @@ func TestParseRequest_MissingJSONRPC(t *testing.T) {
 
 func TestCreateResponse_Success(t *testing.T) {
 	result := map[string]string{"status": "ok"}
-	id := 123
+	id := NewRequestID(123)
 
 	resp := createResponse(result, id)
 
@@ -69,7 +69,7 @@ func TestCreateResponse_Success(t *testing.T) {
 func TestCreateErrorResponse_ProtocolError(t *testing.T) {
 	code := -32600
 	message := "Invalid Request"
-	id := 456
+	id := NewRequestID(456)
 
 	resp := createErrorResponse(code, message, nil, id)
 
@@ -86,3 +86,41 @@ func TestCreateErrorResponse_ProtocolError(t *testing.T) {
 		t.Errorf("expected id %v, got %v", id, resp.ID)
 	}
 }
+
+// TestRequestID_RejectsObjectAndArray verifies RequestID.UnmarshalJSON
+// rejects JSON shapes the spec forbids as an id.
+func TestRequestID_RejectsObjectAndArray(t *testing.T) {
+	for _, input := range []string{`{}`, `[]`, `true`, `{"a":1}`} {
+		var id RequestID
+		if err := id.UnmarshalJSON([]byte(input)); err == nil {
+			t.Errorf("expected an error unmarshaling id %s, got none", input)
+		}
+	}
+}
+
+// TestRequestID_PreservesIntegerPrecision verifies a numeric id round-trips
+// through Unmarshal/Marshal without being coerced through float64, which
+// would lose precision beyond 2^53 and reformat small integers as "1.0".
+func TestRequestID_PreservesIntegerPrecision(t *testing.T) {
+	const large = `9223372036854775807`
+	var id RequestID
+	if err := id.UnmarshalJSON([]byte(large)); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	data, err := id.MarshalJSON()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if string(data) != large {
+		t.Errorf("expected id to round-trip as %s, got %s", large, data)
+	}
+}
+
+// TestParseRequest_RejectsObjectID verifies a request with an object id is
+// rejected rather than silently accepted as an interface{} id would be.
+func TestParseRequest_RejectsObjectID(t *testing.T) {
+	input := []byte(`{"jsonrpc":"2.0","method":"initialize","id":{"nested":true}}`)
+	if _, err := parseRequest(input); err == nil {
+		t.Fatal("expected an error for an object id")
+	}
+}
@@ -0,0 +1,177 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// Middleware wraps a Handler with cross-cutting behavior - panic
+// recovery, logging, timing - without the wrapped handler needing to
+// know it's wrapped. See chain for composing several into one Handler.
+type Middleware func(Handler) Handler
+
+// chain builds a single Handler that runs h through mw in the order
+// given, so the first middleware listed is the outermost layer (it sees
+// the request first and the response/error last). RecoveryMiddleware
+// belongs first so it catches a panic raised anywhere in the chain,
+// including a bug in a later middleware.
+func chain(h Handler, mw ...Middleware) Handler {
+	for i := len(mw) - 1; i >= 0; i-- {
+		h = mw[i](h)
+	}
+	return h
+}
+
+// requestInfoKey is the context key under which handleRequest stashes a
+// request's method and id, for RecoveryMiddleware/LoggingMiddleware/
+// TimingMiddleware to retrieve without Handler's signature needing to
+// carry them directly (see progressTokenKey for the same pattern).
+type requestInfoKey struct{}
+
+// requestInfo is the method/id pair withRequestInfo stashes on ctx.
+type requestInfo struct {
+	Method string
+	ID     RequestID
+}
+
+// withRequestInfo returns a copy of ctx carrying info for later retrieval
+// by requestInfoFromContext.
+func withRequestInfo(ctx context.Context, info requestInfo) context.Context {
+	return context.WithValue(ctx, requestInfoKey{}, info)
+}
+
+// requestInfoFromContext returns the requestInfo stashed in ctx by
+// handleRequest, and whether one was present at all.
+func requestInfoFromContext(ctx context.Context) (requestInfo, bool) {
+	info, ok := ctx.Value(requestInfoKey{}).(requestInfo)
+	return info, ok
+}
+
+// panicError wraps a panic RecoveryMiddleware caught, so handleRequest can
+// tell it apart from an ordinary Handler error and report it as a
+// JSON-RPC internal error (-32603) instead of invalid params (-32602).
+// The stack is always logged by RecoveryMiddleware; Error() only includes
+// it (and therefore only reaches the client's response) when debug is set,
+// mirroring Server.Debug at the time the panic was recovered.
+type panicError struct {
+	value interface{}
+	stack []byte
+	debug bool
+}
+
+func (e *panicError) Error() string {
+	if e.debug {
+		return fmt.Sprintf("panic: %v\n%s", e.value, e.stack)
+	}
+	return fmt.Sprintf("panic: %v", e.value)
+}
+
+// RecoveryMiddleware converts a panic raised anywhere in next (including
+// a later middleware) into a *panicError return value instead of letting
+// it unwind past handleRequest and take the whole server down. The full
+// stack is always written to s.logger(); whether it also reaches the
+// client depends on Server.Debug (see panicError.Error).
+func RecoveryMiddleware(next Handler) Handler {
+	return func(s *Server, sessionID string, ctx context.Context, params json.RawMessage) (result interface{}, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				stack := debug.Stack()
+				method := "unknown"
+				if info, ok := requestInfoFromContext(ctx); ok {
+					method = info.Method
+				}
+				s.logger().Printf("[PANIC] method=%s recovered: %v\n%s", method, r, stack)
+				result, err = nil, &panicError{value: r, stack: stack, debug: s.Debug}
+			}
+		}()
+		return next(s, sessionID, ctx, params)
+	}
+}
+
+// LoggingMiddleware logs every request's method, id, duration, and error
+// (if any) to s.logger().
+func LoggingMiddleware(next Handler) Handler {
+	return func(s *Server, sessionID string, ctx context.Context, params json.RawMessage) (interface{}, error) {
+		start := time.Now()
+		result, err := next(s, sessionID, ctx, params)
+		elapsed := time.Since(start)
+
+		method, id := "unknown", "null"
+		if info, ok := requestInfoFromContext(ctx); ok {
+			method, id = info.Method, info.ID.String()
+		}
+		if err != nil {
+			s.logger().Printf("method=%s id=%s duration=%s error=%v", method, id, elapsed, err)
+		} else {
+			s.logger().Printf("method=%s id=%s duration=%s", method, id, elapsed)
+		}
+		return result, err
+	}
+}
+
+// TimingMiddleware records next's latency under its method name in
+// s.metrics, so Server.MethodLatencies can report per-method timing
+// history.
+func TimingMiddleware(next Handler) Handler {
+	return func(s *Server, sessionID string, ctx context.Context, params json.RawMessage) (interface{}, error) {
+		start := time.Now()
+		result, err := next(s, sessionID, ctx, params)
+		elapsed := time.Since(start)
+
+		method := "unknown"
+		if info, ok := requestInfoFromContext(ctx); ok {
+			method = info.Method
+		}
+		s.metrics.record(method, elapsed)
+		return result, err
+	}
+}
+
+// requestMetrics accumulates per-method request latencies recorded by
+// TimingMiddleware. mu guards concurrent recording since a JSON-RPC
+// batch's elements dispatch from several goroutines at once (see
+// handleBatch).
+type requestMetrics struct {
+	mu        sync.Mutex
+	latencies map[string][]time.Duration
+}
+
+func newRequestMetrics() *requestMetrics {
+	return &requestMetrics{latencies: make(map[string][]time.Duration)}
+}
+
+func (m *requestMetrics) record(method string, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.latencies[method] = append(m.latencies[method], d)
+}
+
+func (m *requestMetrics) get(method string) []time.Duration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make([]time.Duration, len(m.latencies[method]))
+	copy(out, m.latencies[method])
+	return out
+}
+
+// MethodLatencies returns every latency TimingMiddleware has recorded for
+// method so far, in call order. Intended for tests and ad-hoc
+// diagnostics - there is no MCP endpoint that surfaces this today.
+func (s *Server) MethodLatencies(method string) []time.Duration {
+	return s.metrics.get(method)
+}
+
+// logger returns Server.Logger, falling back to log.Default() for a
+// Server built as a bare struct literal (e.g. in a test) rather than via
+// NewServer.
+func (s *Server) logger() *log.Logger {
+	if s.Logger != nil {
+		return s.Logger
+	}
+	return log.Default()
+}
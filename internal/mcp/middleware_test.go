@@ -0,0 +1,155 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/mark-chris/tmkb/internal/knowledge"
+)
+
+// withFakeHandler registers a Handler under method for the duration of a
+// test, restoring (or deleting) whatever was there before on cleanup, so
+// tests can exercise handleRequest's dispatch/middleware path against a
+// handler built for the occasion without permanently mutating the shared
+// handlers map.
+func withFakeHandler(t *testing.T, method string, h Handler) {
+	t.Helper()
+	prev, had := handlers[method]
+	handlers[method] = h
+	t.Cleanup(func() {
+		if had {
+			handlers[method] = prev
+		} else {
+			delete(handlers, method)
+		}
+	})
+}
+
+func TestHandleRequest_PanicIsRecovered(t *testing.T) {
+	idx := knowledge.NewIndex()
+	srv := NewServer(idx)
+	var logBuf bytes.Buffer
+	srv.Logger = log.New(&logBuf, "", 0)
+
+	withFakeHandler(t, "test/panic", func(s *Server, sessionID string, ctx context.Context, params json.RawMessage) (interface{}, error) {
+		panic("boom")
+	})
+
+	req := JSONRPCRequest{JSONRPC: "2.0", ID: NewRequestID(1), Method: "test/panic"}
+	reqData, _ := json.Marshal(req)
+
+	respData, err := srv.handleMessage(defaultSessionID, reqData)
+	if err != nil {
+		t.Fatalf("expected the server to survive the panic, got transport error: %v", err)
+	}
+
+	var resp JSONRPCErrorResponse
+	if err := json.Unmarshal(respData, &resp); err != nil {
+		t.Fatalf("expected a well-formed JSON-RPC error response, got %q (parse error: %v)", respData, err)
+	}
+	if resp.Error.Code != ErrCodeInternalError {
+		t.Errorf("expected code %d, got %d", ErrCodeInternalError, resp.Error.Code)
+	}
+	if resp.ID.String() != "1" {
+		t.Errorf("expected id 1, got %v", resp.ID)
+	}
+
+	if !bytes.Contains(logBuf.Bytes(), []byte("boom")) {
+		t.Errorf("expected the recovered panic to be logged, got %q", logBuf.String())
+	}
+
+	// The server itself must still be usable after a handler panics.
+	pingReq := JSONRPCRequest{JSONRPC: "2.0", ID: NewRequestID(2), Method: "tools/list"}
+	pingData, _ := json.Marshal(pingReq)
+	if _, err := srv.handleMessage(defaultSessionID, pingData); err != nil {
+		t.Errorf("expected server to keep handling requests after a panic, got %v", err)
+	}
+}
+
+func TestHandleRequest_PanicMessageHiddenUnlessDebug(t *testing.T) {
+	idx := knowledge.NewIndex()
+	srv := NewServer(idx)
+	srv.Logger = log.New(&bytes.Buffer{}, "", 0)
+
+	withFakeHandler(t, "test/panic", func(s *Server, sessionID string, ctx context.Context, params json.RawMessage) (interface{}, error) {
+		panic("sensitive stack detail")
+	})
+
+	req := JSONRPCRequest{JSONRPC: "2.0", ID: NewRequestID(1), Method: "test/panic"}
+	reqData, _ := json.Marshal(req)
+
+	respData, _ := srv.handleMessage(defaultSessionID, reqData)
+	var resp JSONRPCErrorResponse
+	if err := json.Unmarshal(respData, &resp); err != nil {
+		t.Fatalf("failed to parse error response: %v", err)
+	}
+	if resp.Error.Message != ErrMsgInternalError {
+		t.Errorf("expected the generic internal error message with Debug unset, got %q", resp.Error.Message)
+	}
+
+	srv.Debug = true
+	respData, _ = srv.handleMessage(defaultSessionID, reqData)
+	if err := json.Unmarshal(respData, &resp); err != nil {
+		t.Fatalf("failed to parse error response: %v", err)
+	}
+	if !bytes.Contains([]byte(resp.Error.Message), []byte("sensitive stack detail")) {
+		t.Errorf("expected the panic detail in the response with Debug set, got %q", resp.Error.Message)
+	}
+}
+
+func TestHandleRequest_NonPanicErrorStaysInvalidParams(t *testing.T) {
+	idx := knowledge.NewIndex()
+	srv := NewServer(idx)
+	srv.Logger = log.New(&bytes.Buffer{}, "", 0)
+
+	withFakeHandler(t, "test/error", func(s *Server, sessionID string, ctx context.Context, params json.RawMessage) (interface{}, error) {
+		return nil, errBoom
+	})
+
+	req := JSONRPCRequest{JSONRPC: "2.0", ID: NewRequestID(1), Method: "test/error"}
+	reqData, _ := json.Marshal(req)
+
+	respData, _ := srv.handleMessage(defaultSessionID, reqData)
+	var resp JSONRPCErrorResponse
+	if err := json.Unmarshal(respData, &resp); err != nil {
+		t.Fatalf("failed to parse error response: %v", err)
+	}
+	if resp.Error.Code != ErrCodeInvalidParams {
+		t.Errorf("expected an ordinary handler error to still map to %d, got %d", ErrCodeInvalidParams, resp.Error.Code)
+	}
+}
+
+func TestTimingMiddleware_RecordsLatency(t *testing.T) {
+	idx := knowledge.NewIndex()
+	srv := NewServer(idx)
+	srv.Logger = log.New(&bytes.Buffer{}, "", 0)
+
+	withFakeHandler(t, "test/slow", func(s *Server, sessionID string, ctx context.Context, params json.RawMessage) (interface{}, error) {
+		time.Sleep(time.Millisecond)
+		return map[string]interface{}{}, nil
+	})
+
+	req := JSONRPCRequest{JSONRPC: "2.0", ID: NewRequestID(1), Method: "test/slow"}
+	reqData, _ := json.Marshal(req)
+	if _, err := srv.handleMessage(defaultSessionID, reqData); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	latencies := srv.MethodLatencies("test/slow")
+	if len(latencies) != 1 {
+		t.Fatalf("expected 1 recorded latency, got %d", len(latencies))
+	}
+	if latencies[0] <= 0 {
+		t.Errorf("expected a positive recorded latency, got %v", latencies[0])
+	}
+}
+
+var errBoom = &testError{"boom"}
+
+type testError struct{ msg string }
+
+func (e *testError) Error() string { return e.msg }
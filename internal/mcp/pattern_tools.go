@@ -0,0 +1,325 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mark-chris/tmkb/internal/knowledge"
+)
+
+// defaultListPatternsLimit is tmkb_list_patterns's page size when the
+// caller omits "limit".
+const defaultListPatternsLimit = 20
+
+// getPatternToolDefinition returns the MCP tool definition for
+// tmkb_get_pattern.
+func getPatternToolDefinition() map[string]interface{} {
+	return map[string]interface{}{
+		"name":        "tmkb_get_pattern",
+		"description": "Fetch a single threat pattern by its TMKB-xxx id, returning its full verbose detail without running a relevance query. Complements the resources/read surface for clients that only speak tools.",
+		"inputSchema": map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"id": map[string]interface{}{
+					"type":        "string",
+					"minLength":   1,
+					"description": "Pattern id, e.g. 'TMKB-001'",
+				},
+			},
+			"required":             []string{"id"},
+			"additionalProperties": false,
+		},
+	}
+}
+
+// invokeGetPatternTool is tmkb_get_pattern's Tool.Invoke.
+func (s *Server) invokeGetPatternTool(ctx context.Context, args map[string]interface{}) (string, error) {
+	id, _ := args["id"].(string)
+	if strings.TrimSpace(id) == "" {
+		return "", fmt.Errorf("id must be non-empty")
+	}
+
+	pattern := s.Index().GetByID(id)
+	if pattern == nil {
+		return "", fmt.Errorf("unknown pattern: %s", id)
+	}
+
+	verbose := knowledge.BuildVerbosePattern(pattern, "", knowledge.EnforcementContext{})
+	text, err := json.Marshal(verbose)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal pattern: %w", err)
+	}
+	return string(text), nil
+}
+
+// listPatternsToolDefinition returns the MCP tool definition for
+// tmkb_list_patterns.
+func listPatternsToolDefinition() map[string]interface{} {
+	return map[string]interface{}{
+		"name":        "tmkb_list_patterns",
+		"description": "List threat patterns in the knowledge base, optionally filtered by severity, language, or framework, paginated via cursor.",
+		"inputSchema": map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"severity": map[string]interface{}{
+					"type":        "string",
+					"description": "Filter to patterns with this severity, e.g. 'critical'",
+				},
+				"category": map[string]interface{}{
+					"type":        "string",
+					"description": "Filter to patterns in this category, e.g. 'injection'",
+				},
+				"language": map[string]interface{}{
+					"type":        "string",
+					"enum":        supportedLanguages(),
+					"description": "Filter to patterns tagged for this language",
+				},
+				"framework": map[string]interface{}{
+					"type":        "string",
+					"enum":        supportedFrameworks(),
+					"description": "Filter to patterns tagged for this framework",
+				},
+				"limit": map[string]interface{}{
+					"type":        "integer",
+					"default":     defaultListPatternsLimit,
+					"description": "Maximum number of patterns to return in this page",
+				},
+				"cursor": map[string]interface{}{
+					"type":        "string",
+					"description": "Pagination cursor from a prior response's next_cursor",
+				},
+			},
+			"additionalProperties": false,
+		},
+	}
+}
+
+// patternSummary is one tmkb_list_patterns result entry: enough to decide
+// whether to fetch the full pattern via tmkb_get_pattern, not the whole
+// verbose record.
+type patternSummary struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Severity  string `json:"severity"`
+	Language  string `json:"language"`
+	Framework string `json:"framework"`
+	Threat    string `json:"threat"`
+}
+
+// listPatternsResult is tmkb_list_patterns's response.
+type listPatternsResult struct {
+	Patterns   []patternSummary `json:"patterns"`
+	Total      int              `json:"total"`
+	NextCursor string           `json:"next_cursor,omitempty"`
+}
+
+// invokeListPatternsTool is tmkb_list_patterns's Tool.Invoke.
+func (s *Server) invokeListPatternsTool(ctx context.Context, args map[string]interface{}) (string, error) {
+	severity, _ := args["severity"].(string)
+	category, _ := args["category"].(string)
+	language, _ := args["language"].(string)
+	framework, _ := args["framework"].(string)
+	cursor, _ := args["cursor"].(string)
+
+	limit := defaultListPatternsLimit
+	if v, ok := args["limit"].(float64); ok {
+		limit = int(v)
+	}
+	if limit <= 0 {
+		return "", fmt.Errorf("limit must be a positive integer")
+	}
+
+	if language != "" && !languageRegistry.IsValidLanguage(language) {
+		return "", fmt.Errorf("Invalid language '%s'. Run `tmkb languages` for the supported list.", language)
+	}
+	if framework != "" && !languageRegistry.IsValidFrameworkAnyLanguage(framework) {
+		return "", fmt.Errorf("Invalid framework '%s'. Run `tmkb languages` for the supported list.", framework)
+	}
+
+	offset, err := decodePatternCursor(cursor)
+	if err != nil {
+		return "", err
+	}
+
+	all := s.Index().GetAll()
+	sort.Slice(all, func(i, j int) bool { return all[i].ID < all[j].ID })
+
+	matched := make([]knowledge.ThreatPattern, 0, len(all))
+	for _, p := range all {
+		if severity != "" && !strings.EqualFold(p.Severity, severity) {
+			continue
+		}
+		if category != "" && !strings.EqualFold(p.Category, category) {
+			continue
+		}
+		if language != "" && !strings.EqualFold(p.Language, language) {
+			continue
+		}
+		if framework != "" && framework != "any" && !strings.EqualFold(p.Framework, framework) {
+			continue
+		}
+		matched = append(matched, p)
+	}
+
+	if offset > len(matched) {
+		offset = len(matched)
+	}
+	end := offset + limit
+	if end > len(matched) {
+		end = len(matched)
+	}
+	page := matched[offset:end]
+
+	summaries := make([]patternSummary, 0, len(page))
+	for _, p := range page {
+		summaries = append(summaries, patternSummary{
+			ID:        p.ID,
+			Name:      p.Name,
+			Severity:  p.Severity,
+			Language:  p.Language,
+			Framework: p.Framework,
+			Threat:    p.AgentSummary.Threat,
+		})
+	}
+
+	result := listPatternsResult{Patterns: summaries, Total: len(matched)}
+	if end < len(matched) {
+		result.NextCursor = encodePatternCursor(end)
+	}
+
+	text, err := json.Marshal(result)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal pattern list: %w", err)
+	}
+	return string(text), nil
+}
+
+// encodePatternCursor turns a matched-slice offset into an opaque cursor
+// string; 0 encodes to "" so a fresh listing doesn't require a caller to
+// pass anything, mirroring knowledge's response_builder cursor encoding.
+func encodePatternCursor(offset int) string {
+	if offset <= 0 {
+		return ""
+	}
+	return strconv.Itoa(offset)
+}
+
+// decodePatternCursor parses a cursor produced by encodePatternCursor back
+// into an offset, rejecting anything else so a tampered or hand-written
+// cursor fails loudly rather than silently resetting to page 1.
+func decodePatternCursor(cursor string) (int, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+	offset, err := strconv.Atoi(cursor)
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("invalid cursor %q: expected a non-negative integer offset", cursor)
+	}
+	return offset, nil
+}
+
+// explainMitigationToolDefinition returns the MCP tool definition for
+// tmkb_explain_mitigation.
+func explainMitigationToolDefinition() map[string]interface{} {
+	return map[string]interface{}{
+		"name":        "tmkb_explain_mitigation",
+		"description": "Look up a single mitigation and its code examples by its MIT-xxx id, without the surrounding pattern's full detail.",
+		"inputSchema": map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"id": map[string]interface{}{
+					"type":        "string",
+					"minLength":   1,
+					"description": "Mitigation id, e.g. 'MIT-001'",
+				},
+			},
+			"required":             []string{"id"},
+			"additionalProperties": false,
+		},
+	}
+}
+
+// explainMitigationResult is tmkb_explain_mitigation's response: the
+// mitigation itself, plus the pattern it belongs to so a caller can follow
+// up with tmkb_get_pattern for full context.
+type explainMitigationResult struct {
+	PatternID  string               `json:"pattern_id"`
+	Mitigation knowledge.Mitigation `json:"mitigation"`
+}
+
+// invokeExplainMitigationTool is tmkb_explain_mitigation's Tool.Invoke.
+func (s *Server) invokeExplainMitigationTool(ctx context.Context, args map[string]interface{}) (string, error) {
+	id, _ := args["id"].(string)
+	if strings.TrimSpace(id) == "" {
+		return "", fmt.Errorf("id must be non-empty")
+	}
+
+	for _, p := range s.Index().GetAll() {
+		for _, m := range p.Mitigations {
+			if m.ID == id {
+				text, err := json.Marshal(explainMitigationResult{PatternID: p.ID, Mitigation: m})
+				if err != nil {
+					return "", fmt.Errorf("failed to marshal mitigation: %w", err)
+				}
+				return string(text), nil
+			}
+		}
+	}
+	return "", fmt.Errorf("unknown mitigation: %s", id)
+}
+
+// statsToolDefinition returns the MCP tool definition for tmkb_stats.
+func statsToolDefinition() map[string]interface{} {
+	return map[string]interface{}{
+		"name":        "tmkb_stats",
+		"description": "Summarize the loaded knowledge base: total pattern count, counts by severity and by category, how long the last index build took, and any non-fatal warnings from loading (e.g. a duplicate pattern id dropped across TMKB_PATTERNS_PATH sources). Useful for an agent to sanity-check the KB before querying it.",
+		"inputSchema": map[string]interface{}{
+			"type":                 "object",
+			"properties":           map[string]interface{}{},
+			"additionalProperties": false,
+		},
+	}
+}
+
+// statsResult is tmkb_stats's response.
+type statsResult struct {
+	PatternCount int            `json:"pattern_count"`
+	BySeverity   map[string]int `json:"by_severity"`
+	ByCategory   map[string]int `json:"by_category"`
+	BuildTimeMs  float64        `json:"build_time_ms"`
+	LoadWarnings []string       `json:"load_warnings,omitempty"`
+}
+
+// invokeStatsTool is tmkb_stats's Tool.Invoke.
+func (s *Server) invokeStatsTool(ctx context.Context, args map[string]interface{}) (string, error) {
+	all := s.Index().GetAll()
+
+	bySeverity := make(map[string]int)
+	byCategory := make(map[string]int)
+	for _, p := range all {
+		if p.Severity != "" {
+			bySeverity[strings.ToLower(p.Severity)]++
+		}
+		if p.Category != "" {
+			byCategory[strings.ToLower(p.Category)]++
+		}
+	}
+
+	result := statsResult{
+		PatternCount: len(all),
+		BySeverity:   bySeverity,
+		ByCategory:   byCategory,
+		BuildTimeMs:  float64(s.Index().BuildDuration().Microseconds()) / 1000,
+		LoadWarnings: s.Index().LoadWarnings(),
+	}
+
+	text, err := json.Marshal(result)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal stats: %w", err)
+	}
+	return string(text), nil
+}
@@ -0,0 +1,178 @@
+package mcp
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mark-chris/tmkb/internal/knowledge"
+)
+
+func testIndexWithMitigation() *knowledge.Index {
+	idx := knowledge.NewIndex()
+	idx.Build([]knowledge.ThreatPattern{
+		{
+			ID:       "TMKB-TEST-001",
+			Name:     "Test Pattern",
+			Severity: "medium",
+			Category: "injection",
+			Language: "python",
+			Mitigations: []knowledge.Mitigation{
+				{ID: "MIT-001", Name: "Use parameterized queries", Description: "Never interpolate user input into a query string."},
+			},
+		},
+		{
+			ID:       "TMKB-TEST-002",
+			Name:     "Another Pattern",
+			Severity: "high",
+			Category: "authz",
+			Language: "go",
+		},
+	})
+	return idx
+}
+
+func TestInvokeGetPatternTool_Success(t *testing.T) {
+	srv := NewServer(testIndexWithPattern())
+
+	text, err := srv.invokeGetPatternTool(context.Background(), map[string]interface{}{"id": "TMKB-TEST-001"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if text == "" {
+		t.Error("expected non-empty pattern detail")
+	}
+}
+
+func TestInvokeGetPatternTool_UnknownID(t *testing.T) {
+	srv := NewServer(testIndexWithPattern())
+
+	_, err := srv.invokeGetPatternTool(context.Background(), map[string]interface{}{"id": "TMKB-NOPE"})
+	if err == nil {
+		t.Fatal("expected error for unknown pattern id")
+	}
+}
+
+func TestInvokeGetPatternTool_MissingID(t *testing.T) {
+	srv := NewServer(testIndexWithPattern())
+
+	_, err := srv.invokeGetPatternTool(context.Background(), map[string]interface{}{})
+	if err == nil {
+		t.Fatal("expected error for missing id")
+	}
+}
+
+func TestInvokeListPatternsTool_FiltersAndPaginates(t *testing.T) {
+	srv := NewServer(testIndexWithMitigation())
+
+	text, err := srv.invokeListPatternsTool(context.Background(), map[string]interface{}{"severity": "high"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !contains(text, "TMKB-TEST-002") {
+		t.Errorf("expected the high-severity pattern in the result, got %s", text)
+	}
+	if contains(text, "TMKB-TEST-001") {
+		t.Errorf("expected the medium-severity pattern to be filtered out, got %s", text)
+	}
+
+	page1, err := srv.invokeListPatternsTool(context.Background(), map[string]interface{}{"limit": float64(1)})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !contains(page1, "TMKB-TEST-001") || !contains(page1, `"next_cursor":"1"`) {
+		t.Errorf("expected the first page to hold TMKB-TEST-001 and a next_cursor, got %s", page1)
+	}
+	page2, err := srv.invokeListPatternsTool(context.Background(), map[string]interface{}{"limit": float64(1), "cursor": "1"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !contains(page2, "TMKB-TEST-002") || contains(page2, "next_cursor") {
+		t.Errorf("expected the last page to hold only TMKB-TEST-002, got %s", page2)
+	}
+}
+
+func TestInvokeListPatternsTool_FiltersByCategory(t *testing.T) {
+	srv := NewServer(testIndexWithMitigation())
+
+	text, err := srv.invokeListPatternsTool(context.Background(), map[string]interface{}{"category": "authz"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !contains(text, "TMKB-TEST-002") {
+		t.Errorf("expected the authz pattern in the result, got %s", text)
+	}
+	if contains(text, "TMKB-TEST-001") {
+		t.Errorf("expected the injection pattern to be filtered out, got %s", text)
+	}
+}
+
+func TestInvokeListPatternsTool_InvalidLanguage(t *testing.T) {
+	srv := NewServer(testIndexWithMitigation())
+
+	_, err := srv.invokeListPatternsTool(context.Background(), map[string]interface{}{"language": "not-a-real-language"})
+	if err == nil {
+		t.Fatal("expected error for invalid language")
+	}
+}
+
+func TestInvokeListPatternsTool_InvalidCursor(t *testing.T) {
+	srv := NewServer(testIndexWithMitigation())
+
+	_, err := srv.invokeListPatternsTool(context.Background(), map[string]interface{}{"cursor": "not-a-number"})
+	if err == nil {
+		t.Fatal("expected error for malformed cursor")
+	}
+}
+
+func TestInvokeExplainMitigationTool_Success(t *testing.T) {
+	srv := NewServer(testIndexWithMitigation())
+
+	text, err := srv.invokeExplainMitigationTool(context.Background(), map[string]interface{}{"id": "MIT-001"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !contains(text, "TMKB-TEST-001") {
+		t.Errorf("expected the owning pattern id in the result, got %s", text)
+	}
+}
+
+func TestInvokeExplainMitigationTool_UnknownID(t *testing.T) {
+	srv := NewServer(testIndexWithMitigation())
+
+	_, err := srv.invokeExplainMitigationTool(context.Background(), map[string]interface{}{"id": "MIT-999"})
+	if err == nil {
+		t.Fatal("expected error for unknown mitigation id")
+	}
+}
+
+func TestInvokeStatsTool_CountsByField(t *testing.T) {
+	srv := NewServer(testIndexWithMitigation())
+
+	text, err := srv.invokeStatsTool(context.Background(), map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !contains(text, `"pattern_count":2`) {
+		t.Errorf("expected pattern_count of 2, got %s", text)
+	}
+	if !contains(text, `"high":1`) || !contains(text, `"medium":1`) {
+		t.Errorf("expected severity counts for high and medium, got %s", text)
+	}
+	if !contains(text, `"injection":1`) || !contains(text, `"authz":1`) {
+		t.Errorf("expected category counts for injection and authz, got %s", text)
+	}
+}
+
+func TestInvokeStatsTool_SurfacesLoadWarnings(t *testing.T) {
+	idx := testIndexWithMitigation()
+	idx.SetLoadWarnings([]string{"duplicate pattern id TMKB-001: keeping the copy from /a, ignoring the one from /b"})
+	srv := NewServer(idx)
+
+	text, err := srv.invokeStatsTool(context.Background(), map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !contains(text, "duplicate pattern id TMKB-001") {
+		t.Errorf("expected load_warnings to surface the duplicate-ID warning, got %s", text)
+	}
+}
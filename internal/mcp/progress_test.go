@@ -0,0 +1,154 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mark-chris/tmkb/internal/knowledge"
+)
+
+// TestHandleCancelledNotification_CancelsRegisteredContext verifies that a
+// notifications/cancelled naming a still-registered request id invokes
+// that request's cancel func.
+func TestHandleCancelledNotification_CancelsRegisteredContext(t *testing.T) {
+	srv := NewServer(knowledge.NewIndex())
+	sess := srv.sessionFor(defaultSessionID)
+
+	cancelled := false
+	sess.registerCancel(NewRequestID(7), func() { cancelled = true })
+
+	handleCancelledNotification(srv, defaultSessionID, []byte(`{"requestId":7}`))
+
+	if !cancelled {
+		t.Error("expected cancel func to have been invoked")
+	}
+	if _, ok := sess.inFlight[NewRequestID(7)]; ok {
+		t.Error("expected cancelled request to be removed from inFlight")
+	}
+}
+
+// TestHandleCancelledNotification_UnknownRequestIsNoop verifies that
+// cancelling a request id that was never registered (already finished, or
+// never existed) doesn't panic or affect other in-flight requests.
+func TestHandleCancelledNotification_UnknownRequestIsNoop(t *testing.T) {
+	srv := NewServer(knowledge.NewIndex())
+	handleCancelledNotification(srv, defaultSessionID, []byte(`{"requestId":"no-such-request"}`))
+}
+
+// TestHandleRequest_CancelledContextAbortsToolsCall verifies that a
+// tools/call whose context is already cancelled when its query runs
+// surfaces the cancellation as a tool execution error (isError: true)
+// rather than a protocol error.
+func TestHandleRequest_CancelledContextAbortsToolsCall(t *testing.T) {
+	idx := knowledge.NewIndex()
+	idx.Build([]knowledge.ThreatPattern{
+		{ID: "TMKB-TEST-001", Name: "Test", Severity: "medium", Triggers: knowledge.Triggers{Keywords: []string{"job"}}},
+	})
+	srv := NewServer(idx)
+	srv.setState(stateInitialized)
+
+	params, _ := json.Marshal(toolsCallParams{
+		Name:      "tmkb_query",
+		Arguments: map[string]interface{}{"context": "background job"},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	result, err := handleToolsCall(srv, defaultSessionID, ctx, params)
+	if err != nil {
+		t.Fatalf("expected no protocol error, got %v", err)
+	}
+	if result.(map[string]interface{})["isError"] != true {
+		t.Errorf("expected isError true for a cancelled query, got %v", result)
+	}
+}
+
+// TestSendProgress_StdioWritesFrame verifies sendProgress writes a
+// notifications/progress frame onto the stdio writer for the default
+// session.
+func TestSendProgress_StdioWritesFrame(t *testing.T) {
+	srv := NewServer(knowledge.NewIndex())
+	var buf bytes.Buffer
+	srv.stdioWriter = bufio.NewWriter(&buf)
+
+	srv.sendProgress(defaultSessionID, json.Number("1"), 0.5, 1)
+
+	if !strings.Contains(buf.String(), "notifications/progress") {
+		t.Errorf("expected a notifications/progress frame, got %s", buf.String())
+	}
+}
+
+// TestSendProgress_HTTPQueuesOnSessionChannel verifies sendProgress queues
+// the notification onto an HTTP session's notifyCh, the same channel
+// handleMCPStream drains over SSE.
+func TestSendProgress_HTTPQueuesOnSessionChannel(t *testing.T) {
+	srv := NewServer(knowledge.NewIndex())
+	sess := srv.sessionFor("session-a")
+
+	srv.sendProgress("session-a", "token-1", 1, 2)
+
+	select {
+	case data := <-sess.notifyCh:
+		if !strings.Contains(string(data), "token-1") {
+			t.Errorf("expected progress frame to carry the progress token, got %s", data)
+		}
+	default:
+		t.Fatal("expected a queued progress notification")
+	}
+}
+
+// TestExtractProgressToken reports whether a request's params._meta carries
+// a progressToken, per the MCP spec's optional progress-reporting field.
+func TestExtractProgressToken(t *testing.T) {
+	token, ok := extractProgressToken([]byte(`{"context":"x","_meta":{"progressToken":"abc"}}`))
+	if !ok || token != "abc" {
+		t.Errorf("expected progressToken abc, got %v, %v", token, ok)
+	}
+
+	if _, ok := extractProgressToken([]byte(`{"context":"x"}`)); ok {
+		t.Error("expected no progress token when _meta is absent")
+	}
+}
+
+// TestHandleMCPPost_ToolsCallWithProgressToken verifies a tools/call whose
+// params._meta.progressToken is set emits progress notifications over the
+// HTTP session's SSE queue in addition to its normal response.
+func TestHandleMCPPost_ToolsCallWithProgressToken(t *testing.T) {
+	idx := knowledge.NewIndex()
+	idx.Build([]knowledge.ThreatPattern{
+		{ID: "TMKB-TEST-001", Name: "Test", Severity: "medium", Triggers: knowledge.Triggers{Keywords: []string{"job"}}},
+	})
+	srv := NewServer(idx)
+
+	initBody := `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-11-25","capabilities":{}}}`
+	initReq := httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(initBody))
+	initReq.Header.Set(sessionHeader, "session-a")
+	srv.handleMCPPost(httptest.NewRecorder(), initReq)
+
+	initializedBody := `{"jsonrpc":"2.0","method":"notifications/initialized"}`
+	notifReq := httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(initializedBody))
+	notifReq.Header.Set(sessionHeader, "session-a")
+	srv.handleMCPPost(httptest.NewRecorder(), notifReq)
+
+	callBody := `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"tmkb_query","arguments":{"context":"background job"},"_meta":{"progressToken":"t1"}}}`
+	callReq := httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(callBody))
+	callReq.Header.Set(sessionHeader, "session-a")
+	callRec := httptest.NewRecorder()
+	srv.handleMCPPost(callRec, callReq)
+
+	if callRec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", callRec.Code, callRec.Body.String())
+	}
+
+	sess := srv.sessionFor("session-a")
+	if len(sess.notifyCh) != 2 {
+		t.Fatalf("expected 2 queued progress notifications, got %d", len(sess.notifyCh))
+	}
+}
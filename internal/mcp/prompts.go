@@ -0,0 +1,158 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mark-chris/tmkb/internal/knowledge"
+)
+
+// promptDefinition describes one ready-made prompt template: a canned
+// question shape that inlines the top relevant TMKB patterns as message
+// content, so an agent can pull in threat context without first learning
+// the tmkb_query tool's parameters.
+type promptDefinition struct {
+	Description string
+	ArgHint     string // description of the required "context" argument
+}
+
+// promptRegistry holds the prompts/list and prompts/get templates.
+var promptRegistry = map[string]promptDefinition{
+	"review-auth-endpoint": {
+		Description: "Review an authorization-sensitive endpoint against TMKB threat patterns",
+		ArgHint:     "what the endpoint does, e.g. 'DELETE /orgs/:id/members/:userId'",
+	},
+	"threat-model-background-job": {
+		Description: "Threat-model a background job or async task against TMKB threat patterns",
+		ArgHint:     "what the job does, e.g. 'nightly billing reconciliation job'",
+	},
+}
+
+// promptsListResult is the prompts/list response.
+type promptsListResult struct {
+	Prompts []promptInfo `json:"prompts"`
+}
+
+type promptInfo struct {
+	Name        string           `json:"name"`
+	Description string           `json:"description,omitempty"`
+	Arguments   []promptArgument `json:"arguments,omitempty"`
+}
+
+type promptArgument struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+// promptsGetParams represents the prompts/get request parameters.
+type promptsGetParams struct {
+	Name      string            `json:"name"`
+	Arguments map[string]string `json:"arguments"`
+}
+
+// promptsGetResult is the prompts/get response: the rendered message(s)
+// ready to hand to a model.
+type promptsGetResult struct {
+	Description string          `json:"description,omitempty"`
+	Messages    []promptMessage `json:"messages"`
+}
+
+type promptMessage struct {
+	Role    string            `json:"role"`
+	Content promptMessageText `json:"content"`
+}
+
+type promptMessageText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// handlePromptsList handles the prompts/list request.
+func handlePromptsList(s *Server, sessionID string, ctx context.Context, params json.RawMessage) (interface{}, error) {
+	if s.sessionFor(sessionID).getState() != stateInitialized {
+		return nil, fmt.Errorf("server not initialized")
+	}
+
+	prompts := make([]promptInfo, 0, len(promptRegistry))
+	for name, def := range promptRegistry {
+		prompts = append(prompts, promptInfo{
+			Name:        name,
+			Description: def.Description,
+			Arguments: []promptArgument{{
+				Name:        "context",
+				Description: def.ArgHint,
+				Required:    true,
+			}},
+		})
+	}
+	sort.Slice(prompts, func(i, j int) bool { return prompts[i].Name < prompts[j].Name })
+
+	return promptsListResult{Prompts: prompts}, nil
+}
+
+// handlePromptsGet handles the prompts/get request: it queries the index
+// for the caller-supplied context and inlines the top relevant patterns
+// into a single rendered user message.
+func handlePromptsGet(s *Server, sessionID string, ctx context.Context, params json.RawMessage) (interface{}, error) {
+	if s.sessionFor(sessionID).getState() != stateInitialized {
+		return nil, fmt.Errorf("server not initialized")
+	}
+
+	var p promptsGetParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, fmt.Errorf("invalid prompts/get params: %w", err)
+	}
+
+	def, ok := promptRegistry[p.Name]
+	if !ok {
+		return nil, fmt.Errorf("unknown prompt: %s", p.Name)
+	}
+
+	context := p.Arguments["context"]
+	if context == "" {
+		return nil, fmt.Errorf("prompt %q requires a non-empty \"context\" argument", p.Name)
+	}
+
+	result, err := knowledge.Query(s.Index(), knowledge.QueryOptions{
+		Context:   context,
+		Verbosity: "agent",
+		Limit:     5,
+		Ctx:       ctx,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+
+	return promptsGetResult{
+		Description: def.Description,
+		Messages: []promptMessage{{
+			Role:    "user",
+			Content: promptMessageText{Type: "text", Text: renderPromptText(def, context, result)},
+		}},
+	}, nil
+}
+
+// renderPromptText builds the message text for a prompts/get response:
+// the caller's context followed by the relevant patterns TMKB found for
+// it, in a plain format a model can read directly.
+func renderPromptText(def promptDefinition, context string, result knowledge.QueryResult) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n\n", def.Description)
+	fmt.Fprintf(&b, "Context: %s\n\n", context)
+
+	if len(result.Patterns) == 0 {
+		b.WriteString("No TMKB threat patterns matched this context.\n")
+		return b.String()
+	}
+
+	b.WriteString("Relevant threat patterns:\n\n")
+	for _, pat := range result.Patterns {
+		fmt.Fprintf(&b, "- [%s] %s (severity: %s)\n  Check: %s\n  Fix: %s\n", pat.ID, pat.Threat, pat.Severity, pat.Check, pat.Fix)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,73 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestHandlePromptsList_Success(t *testing.T) {
+	srv := NewServer(testIndexWithPattern())
+	srv.setState(stateInitialized)
+
+	result, err := handlePromptsList(srv, defaultSessionID, context.Background(), nil)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	list := result.(promptsListResult)
+	if len(list.Prompts) != len(promptRegistry) {
+		t.Errorf("expected %d prompts, got %d", len(promptRegistry), len(list.Prompts))
+	}
+	for _, p := range list.Prompts {
+		if len(p.Arguments) != 1 || p.Arguments[0].Name != "context" {
+			t.Errorf("expected prompt %s to require a single 'context' argument, got %+v", p.Name, p.Arguments)
+		}
+	}
+}
+
+func TestHandlePromptsGet_Success(t *testing.T) {
+	srv := NewServer(testIndexWithPattern())
+	srv.setState(stateInitialized)
+
+	params, _ := json.Marshal(promptsGetParams{
+		Name:      "threat-model-background-job",
+		Arguments: map[string]string{"context": "background job processing"},
+	})
+
+	result, err := handlePromptsGet(srv, defaultSessionID, context.Background(), params)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	get := result.(promptsGetResult)
+	if len(get.Messages) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(get.Messages))
+	}
+	if !strings.Contains(get.Messages[0].Content.Text, "TMKB-TEST-001") {
+		t.Errorf("expected rendered prompt to include the matching pattern, got %s", get.Messages[0].Content.Text)
+	}
+}
+
+func TestHandlePromptsGet_UnknownPrompt(t *testing.T) {
+	srv := NewServer(testIndexWithPattern())
+	srv.setState(stateInitialized)
+
+	params, _ := json.Marshal(promptsGetParams{Name: "no-such-prompt", Arguments: map[string]string{"context": "x"}})
+	_, err := handlePromptsGet(srv, defaultSessionID, context.Background(), params)
+	if err == nil {
+		t.Fatal("expected error for unknown prompt")
+	}
+}
+
+func TestHandlePromptsGet_MissingContext(t *testing.T) {
+	srv := NewServer(testIndexWithPattern())
+	srv.setState(stateInitialized)
+
+	params, _ := json.Marshal(promptsGetParams{Name: "review-auth-endpoint", Arguments: map[string]string{}})
+	_, err := handlePromptsGet(srv, defaultSessionID, context.Background(), params)
+	if err == nil {
+		t.Fatal("expected error for missing context argument")
+	}
+}
@@ -0,0 +1,103 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mark-chris/tmkb/internal/knowledge"
+)
+
+// patternURIPrefix is the URI scheme under which every ThreatPattern is
+// addressable as an MCP resource, e.g. "tmkb://pattern/TMKB-001".
+const patternURIPrefix = "tmkb://pattern/"
+
+// resourcesListResult is the resources/list response.
+type resourcesListResult struct {
+	Resources []resourceInfo `json:"resources"`
+}
+
+type resourceInfo struct {
+	URI         string `json:"uri"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType"`
+}
+
+// resourcesReadParams represents the resources/read request parameters.
+type resourcesReadParams struct {
+	URI string `json:"uri"`
+}
+
+// resourcesReadResult is the resources/read response.
+type resourcesReadResult struct {
+	Contents []resourceContent `json:"contents"`
+}
+
+type resourceContent struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+// handleResourcesList handles the resources/list request, listing one
+// resource per indexed ThreatPattern.
+func handleResourcesList(s *Server, sessionID string, ctx context.Context, params json.RawMessage) (interface{}, error) {
+	if s.sessionFor(sessionID).getState() != stateInitialized {
+		return nil, fmt.Errorf("server not initialized")
+	}
+
+	patterns := s.Index().GetAll()
+	resources := make([]resourceInfo, 0, len(patterns))
+	for _, p := range patterns {
+		resources = append(resources, resourceInfo{
+			URI:         patternURIPrefix + p.ID,
+			Name:        p.Name,
+			Description: p.AgentSummary.Threat,
+			MimeType:    "application/json",
+		})
+	}
+	sort.Slice(resources, func(i, j int) bool { return resources[i].URI < resources[j].URI })
+
+	return resourcesListResult{Resources: resources}, nil
+}
+
+// handleResourcesRead handles the resources/read request, returning the
+// verbose JSON for a single tmkb://pattern/<ID> resource so an agent can
+// pin one specific threat into context without re-running a query.
+func handleResourcesRead(s *Server, sessionID string, ctx context.Context, params json.RawMessage) (interface{}, error) {
+	if s.sessionFor(sessionID).getState() != stateInitialized {
+		return nil, fmt.Errorf("server not initialized")
+	}
+
+	var p resourcesReadParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, fmt.Errorf("invalid resources/read params: %w", err)
+	}
+
+	id := strings.TrimPrefix(p.URI, patternURIPrefix)
+	if id == p.URI {
+		return nil, fmt.Errorf("unsupported resource uri: %s", p.URI)
+	}
+
+	pattern := s.Index().GetByID(id)
+	if pattern == nil {
+		return nil, fmt.Errorf("unknown pattern: %s", id)
+	}
+
+	verbose := knowledge.BuildVerbosePattern(pattern, "", knowledge.EnforcementContext{})
+	text, err := json.Marshal(verbose)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal pattern: %w", err)
+	}
+
+	return resourcesReadResult{
+		Contents: []resourceContent{{
+			URI:      p.URI,
+			MimeType: "application/json",
+			Text:     string(text),
+		}},
+	}, nil
+}
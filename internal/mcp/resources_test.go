@@ -0,0 +1,97 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/mark-chris/tmkb/internal/knowledge"
+)
+
+func testIndexWithPattern() *knowledge.Index {
+	idx := knowledge.NewIndex()
+	idx.Build([]knowledge.ThreatPattern{
+		{
+			ID:       "TMKB-TEST-001",
+			Name:     "Test Pattern",
+			Severity: "medium",
+			Category: "authorization",
+			Triggers: knowledge.Triggers{Keywords: []string{"background job"}},
+			AgentSummary: knowledge.AgentSummary{
+				Threat: "Test threat description",
+				Check:  "Test check description",
+				Fix:    "Test fix description",
+			},
+		},
+	})
+	return idx
+}
+
+func TestHandleResourcesList_Success(t *testing.T) {
+	srv := NewServer(testIndexWithPattern())
+	srv.setState(stateInitialized)
+
+	result, err := handleResourcesList(srv, defaultSessionID, context.Background(), nil)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	list := result.(resourcesListResult)
+	if len(list.Resources) != 1 {
+		t.Fatalf("expected 1 resource, got %d", len(list.Resources))
+	}
+	if list.Resources[0].URI != "tmkb://pattern/TMKB-TEST-001" {
+		t.Errorf("expected resource uri tmkb://pattern/TMKB-TEST-001, got %s", list.Resources[0].URI)
+	}
+}
+
+func TestHandleResourcesList_BeforeInit(t *testing.T) {
+	srv := NewServer(testIndexWithPattern())
+
+	_, err := handleResourcesList(srv, defaultSessionID, context.Background(), nil)
+	if err == nil {
+		t.Fatal("expected error when not initialized")
+	}
+}
+
+func TestHandleResourcesRead_Success(t *testing.T) {
+	srv := NewServer(testIndexWithPattern())
+	srv.setState(stateInitialized)
+
+	params, _ := json.Marshal(resourcesReadParams{URI: "tmkb://pattern/TMKB-TEST-001"})
+	result, err := handleResourcesRead(srv, defaultSessionID, context.Background(), params)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	read := result.(resourcesReadResult)
+	if len(read.Contents) != 1 {
+		t.Fatalf("expected 1 content entry, got %d", len(read.Contents))
+	}
+	if !strings.Contains(read.Contents[0].Text, `"id":"TMKB-TEST-001"`) {
+		t.Errorf("expected verbose pattern JSON, got %s", read.Contents[0].Text)
+	}
+}
+
+func TestHandleResourcesRead_UnknownPattern(t *testing.T) {
+	srv := NewServer(testIndexWithPattern())
+	srv.setState(stateInitialized)
+
+	params, _ := json.Marshal(resourcesReadParams{URI: "tmkb://pattern/NO-SUCH-ID"})
+	_, err := handleResourcesRead(srv, defaultSessionID, context.Background(), params)
+	if err == nil {
+		t.Fatal("expected error for unknown pattern")
+	}
+}
+
+func TestHandleResourcesRead_UnsupportedURI(t *testing.T) {
+	srv := NewServer(testIndexWithPattern())
+	srv.setState(stateInitialized)
+
+	params, _ := json.Marshal(resourcesReadParams{URI: "https://example.com/TMKB-TEST-001"})
+	_, err := handleResourcesRead(srv, defaultSessionID, context.Background(), params)
+	if err == nil {
+		t.Fatal("expected error for an unsupported uri scheme")
+	}
+}
@@ -2,13 +2,19 @@ package mcp
 
 import (
 	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log"
+	"net/http"
 	"sync"
+	"sync/atomic"
 
 	"github.com/mark-chris/tmkb/internal/knowledge"
+	"github.com/mark-chris/tmkb/internal/knowledge/matcher"
 )
 
 // serverState represents the server lifecycle state
@@ -20,39 +26,243 @@ const (
 	stateInitialized
 )
 
-// Server implements the Model Context Protocol for TMKB
-type Server struct {
-	index              *knowledge.Index
+// sessionHeader is the header Streamable HTTP clients use to correlate
+// requests with server-held session state.
+const sessionHeader = "Mcp-Session-Id"
+
+// defaultSessionID is the session key used by the stdio transport, which
+// serves exactly one client per process and never sends a session
+// header.
+const defaultSessionID = "stdio"
+
+// session holds per-client MCP lifecycle state: handshake progress,
+// negotiated capabilities, and protocol version, plus a queue of
+// server-to-client notifications waiting to be delivered over the HTTP
+// transport's SSE stream. The stdio transport only ever has one session,
+// keyed by defaultSessionID.
+type session struct {
+	mu                 sync.RWMutex
 	state              serverState
 	protocolVersion    string
 	clientCapabilities map[string]interface{}
-	mu                 sync.RWMutex
+	notifyCh           chan []byte
+	inFlight           map[RequestID]context.CancelFunc
+}
+
+func newSession() *session {
+	return &session{
+		state:    stateNotInitialized,
+		notifyCh: make(chan []byte, 16),
+		inFlight: make(map[RequestID]context.CancelFunc),
+	}
+}
+
+func (sess *session) getState() serverState {
+	sess.mu.RLock()
+	defer sess.mu.RUnlock()
+	return sess.state
 }
 
+func (sess *session) setState(state serverState) {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	sess.state = state
+}
+
+// setHandshake records the negotiated protocol version and client
+// capabilities from a successful initialize call.
+func (sess *session) setHandshake(protocolVersion string, capabilities map[string]interface{}) {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	sess.protocolVersion = protocolVersion
+	sess.clientCapabilities = capabilities
+}
+
+// getProtocolVersion returns the version negotiated by initialize, or ""
+// before the handshake completes.
+func (sess *session) getProtocolVersion() string {
+	sess.mu.RLock()
+	defer sess.mu.RUnlock()
+	return sess.protocolVersion
+}
+
+// registerCancel records cancel as the way to abort the in-flight request
+// identified by id, so a later notifications/cancelled can reach it. The
+// null id (a request that was sent with "id": null) is never tracked,
+// since the spec's cancellation notification can't reference one anyway.
+func (sess *session) registerCancel(id RequestID, cancel context.CancelFunc) {
+	if id.IsNull() {
+		return
+	}
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	sess.inFlight[id] = cancel
+}
+
+// clearCancel stops tracking id's cancel func once its request has
+// finished, successfully or not.
+func (sess *session) clearCancel(id RequestID) {
+	if id.IsNull() {
+		return
+	}
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	delete(sess.inFlight, id)
+}
+
+// cancel invokes and forgets the cancel func registered for id, if the
+// request it names is still in flight. A miss (already finished, or never
+// existed) is silently ignored, matching notifications/cancelled's
+// best-effort semantics in the MCP spec.
+func (sess *session) cancel(id RequestID) {
+	sess.mu.Lock()
+	cancelFunc, ok := sess.inFlight[id]
+	if ok {
+		delete(sess.inFlight, id)
+	}
+	sess.mu.Unlock()
+	if ok {
+		cancelFunc()
+	}
+}
+
+// Server implements the Model Context Protocol for TMKB
+type Server struct {
+	// index is held behind an atomic.Pointer rather than a plain field so
+	// a pattern-directory watcher (see knowledge.Loader.Watch) can swap in
+	// a freshly rebuilt Index while requests are in flight, without a
+	// lock around every read. Always go through Index()/SetIndex, never
+	// this field directly.
+	index      atomic.Pointer[knowledge.Index]
+	sessions   map[string]*session
+	sessionsMu sync.RWMutex
+
+	// stdioWriter and stdioMu let sendProgress interleave
+	// notifications/progress frames with ServeStdio's own responses on the
+	// same underlying writer without corrupting either. Both are nil until
+	// ServeStdio runs.
+	stdioWriter *bufio.Writer
+	stdioMu     sync.Mutex
+
+	// Tools is the set of tools tools/list advertises and tools/call
+	// dispatches to. It's exported so an embedder can Register additional
+	// tools of its own without forking this package.
+	Tools *ToolRegistry
+
+	// Debug, when true, includes a recovered panic's stack trace in the
+	// JSON-RPC error response RecoveryMiddleware returns to the client.
+	// Off by default: the stack is always written to Logger regardless,
+	// but a production deployment shouldn't leak it to callers.
+	Debug bool
+
+	// StrictValidation, when true, makes handleInitializedNotification
+	// refuse to move a session into stateInitialized if the current
+	// Index's patterns fail knowledge.ValidateSet (duplicate ids,
+	// conflicting category/framework/language/keyword scopes, ...). The
+	// session is left stuck in stateInitializing, so every tools/list,
+	// tools/call, resources/* and prompts/* request keeps failing with
+	// the usual "not initialized" error instead of silently serving a
+	// corrupt index. Off by default, since most embedders would rather a
+	// warning than a hard refusal to serve.
+	StrictValidation bool
+
+	// Logger receives RecoveryMiddleware's recovered-panic stacks and
+	// LoggingMiddleware's per-request log lines. Defaults to log.Default()
+	// when left nil (see (*Server).logger).
+	Logger *log.Logger
+
+	// metrics accumulates TimingMiddleware's per-method latencies,
+	// readable via MethodLatencies.
+	metrics *requestMetrics
+
+	// SupportedVersions lists the MCP protocol versions this server
+	// accepts, newest first. handleInitialize echoes back the client's
+	// requested version if it's in this list, or offers
+	// SupportedVersions[0] otherwise (see negotiateProtocolVersion).
+	// Exported so an embedder pinning to an older or newer spec revision
+	// can override it without forking this package.
+	SupportedVersions []string
+}
+
+// defaultProtocolVersion is the MCP spec revision TMKB targets by
+// default; it's always SupportedVersions[0] on a server built via
+// NewServer.
+const defaultProtocolVersion = "2025-11-25"
+
 // NewServer creates a new MCP server
 func NewServer(index *knowledge.Index) *Server {
-	return &Server{
-		index: index,
-		state: stateNotInitialized,
+	s := &Server{
+		sessions:          make(map[string]*session),
+		Tools:             newToolRegistry(),
+		metrics:           newRequestMetrics(),
+		SupportedVersions: []string{defaultProtocolVersion},
+	}
+	s.index.Store(index)
+	s.registerBuiltinTools()
+	return s
+}
+
+// Index returns the Index currently serving requests. Every handler reads
+// the index through here rather than capturing one at construction time,
+// so a SetIndex swap from a watcher takes effect on the very next call.
+func (s *Server) Index() *knowledge.Index {
+	return s.index.Load()
+}
+
+// SetIndex atomically replaces the Index serving requests, e.g. with a
+// freshly rebuilt one from a knowledge.Loader.Watch callback. In-flight
+// requests keep using whatever Index they already loaded via Index();
+// only calls made after SetIndex returns observe idx.
+func (s *Server) SetIndex(idx *knowledge.Index) {
+	s.index.Store(idx)
+}
+
+// sessionFor returns the session keyed by id, creating a fresh
+// (stateNotInitialized) one on first use. The stdio transport always
+// passes defaultSessionID; the HTTP transport passes the client's
+// Mcp-Session-Id, so distinct clients (or a client and a malicious
+// guesser) never observe each other's handshake state.
+func (s *Server) sessionFor(id string) *session {
+	s.sessionsMu.RLock()
+	sess, ok := s.sessions[id]
+	s.sessionsMu.RUnlock()
+	if ok {
+		return sess
 	}
+
+	s.sessionsMu.Lock()
+	defer s.sessionsMu.Unlock()
+	if sess, ok := s.sessions[id]; ok {
+		return sess
+	}
+	sess = newSession()
+	s.sessions[id] = sess
+	return sess
 }
 
-// setState sets the server state (thread-safe)
+// setState sets the default (stdio) session's state (thread-safe).
 func (s *Server) setState(state serverState) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	s.state = state
+	s.sessionFor(defaultSessionID).setState(state)
 }
 
-// getState gets the server state (thread-safe)
+// getState gets the default (stdio) session's state (thread-safe).
 func (s *Server) getState() serverState {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	return s.state
+	return s.sessionFor(defaultSessionID).getState()
 }
 
-// ToolDefinition returns the MCP tool definition for tmkb_query
-func (s *Server) ToolDefinition() map[string]interface{} {
+// registerBuiltinTools populates s.Tools with the tools TMKB ships with.
+// An embedder can add more by calling s.Tools.Register directly; it never
+// needs to touch this method.
+func (s *Server) registerBuiltinTools() {
+	s.Tools.Register("tmkb_query", Tool{Definition: queryToolDefinition(), Invoke: s.invokeQueryTool})
+	s.Tools.Register("tmkb_get_pattern", Tool{Definition: getPatternToolDefinition(), Invoke: s.invokeGetPatternTool})
+	s.Tools.Register("tmkb_list_patterns", Tool{Definition: listPatternsToolDefinition(), Invoke: s.invokeListPatternsTool})
+	s.Tools.Register("tmkb_explain_mitigation", Tool{Definition: explainMitigationToolDefinition(), Invoke: s.invokeExplainMitigationTool})
+	s.Tools.Register("tmkb_stats", Tool{Definition: statsToolDefinition(), Invoke: s.invokeStatsTool})
+}
+
+// queryToolDefinition returns the MCP tool definition for tmkb_query
+func queryToolDefinition() map[string]interface{} {
 	return map[string]interface{}{
 		"name":        "tmkb_query",
 		"description": "Query the Threat Model Knowledge Base for authorization security threats relevant to your implementation. Returns concise, actionable security context optimized for code generation.",
@@ -61,53 +271,223 @@ func (s *Server) ToolDefinition() map[string]interface{} {
 			"properties": map[string]interface{}{
 				"context": map[string]interface{}{
 					"type":        "string",
+					"minLength":   1,
 					"description": "What you're implementing (e.g., 'multi-tenant API endpoint', 'background job processing', 'admin dashboard')",
 				},
 				"language": map[string]interface{}{
 					"type":        "string",
-					"enum":        []string{"python"},
-					"description": "Programming language (MVP: Python only)",
+					"enum":        supportedLanguages(),
+					"description": "Programming language. Run `tmkb languages` for the full list and their frameworks.",
 				},
 				"framework": map[string]interface{}{
 					"type":        "string",
-					"enum":        []string{"flask", "any"},
-					"description": "Framework context (MVP: Flask only)",
+					"enum":        supportedFrameworks(),
+					"description": "Framework context, or 'any' for language-agnostic examples. Run `tmkb languages` for the full list.",
 				},
 				"verbosity": map[string]interface{}{
 					"type":        "string",
-					"enum":        []string{"agent", "human"},
+					"enum":        []string{"agent", "human", "compressed"},
 					"default":     "agent",
-					"description": "Output format: 'agent' for concise, 'human' for detailed",
+					"description": "Output format: 'agent' for concise, 'human' for detailed, 'compressed' for threat+fix only (~40 tokens/pattern, use with max_tokens to fit more patterns)",
+				},
+				"filter": map[string]interface{}{
+					"type":        "string",
+					"description": `Boolean expression to filter patterns before scoring, e.g. 'severity in ["critical","high"] and "CWE-285" in cwe_references'`,
+				},
+				"matcher": map[string]interface{}{
+					"type":        "object",
+					"description": `Structured boolean query tree, a JSON-native alternative to filter for clients that would rather build a query as an object than compose expr syntax. A node is either {"any":[...]} (OR), {"all":[...]} (AND), or a leaf {"selector":"<pattern field, e.g. severity, triggers.keywords>","operator":"eq|neq|matches|contains|in|gte|lte","value":<any>}. Composes with filter and applies before scoring.`,
+				},
+				"enforcement_scope": map[string]interface{}{
+					"type":        "string",
+					"enum":        []string{"agent", "human", "ci", "ide"},
+					"description": "Caller type used to resolve each pattern's effective enforcement action, e.g. 'ci' to prefer deny-scoped actions",
+				},
+				"cwe": map[string]interface{}{
+					"type":        "string",
+					"description": "CWE identifier to filter patterns by, e.g. 'CWE-352'",
+				},
+				"owasp": map[string]interface{}{
+					"type":        "string",
+					"description": "OWASP Top 10 identifier to filter patterns by, e.g. 'A03:2021'",
+				},
+				"ranker": map[string]interface{}{
+					"type":        "string",
+					"enum":        []string{"hybrid", "bm25", "bm25f"},
+					"default":     "hybrid",
+					"description": "Relevance ranking formula: 'hybrid' (default), 'bm25' (Okapi BM25 over corpus keyword statistics), or 'bm25f' (field-boosted BM25 weighting keywords/agent summary/name above description)",
+				},
+				"match_mode": map[string]interface{}{
+					"type":        "string",
+					"enum":        []string{"exact", "stemmed", "fuzzy"},
+					"default":     "stemmed",
+					"description": "Context-matching mode: 'stemmed' (default, also matches morphological variants like 'uploading' -> 'upload'), 'exact' (literal terms only), or 'fuzzy' (stemmed plus edit-distance typo tolerance)",
+				},
+				"k1": map[string]interface{}{
+					"type":        "number",
+					"description": "BM25 term-frequency saturation parameter, only used by the 'bm25f' ranker. Defaults to 1.2.",
+				},
+				"b": map[string]interface{}{
+					"type":        "number",
+					"description": "BM25 length-normalization parameter, only used by the 'bm25f' ranker. Defaults to 0.75.",
+				},
+				"max_tokens": map[string]interface{}{
+					"type":        "integer",
+					"description": "Token budget for the response. When set, switches to knapsack-optimized pattern selection maximizing relevance within budget, and enables cursor-based pagination via next_cursor.",
+				},
+				"cursor": map[string]interface{}{
+					"type":        "string",
+					"description": "Pagination cursor from a prior response's next_cursor. Only meaningful together with max_tokens.",
+				},
+				"fields": map[string]interface{}{
+					"type":        "array",
+					"items":       map[string]interface{}{"type": "string"},
+					"description": `Dotted selector paths narrowing each result down to just these fields of its full pattern, e.g. ["id", "severity", "mitigations[*].code_examples[*].secure_code"]. Supports the same grammar as the CLI's --fields flag: dotted access, "[*]" wildcards, "[?(@.field)]" filters. Populates projected_patterns instead of patterns. Ignored when max_tokens is set.`,
+				},
+				"include": map[string]interface{}{
+					"type":        "array",
+					"items":       map[string]interface{}{"type": "string"},
+					"description": "Synonym for fields, used when fields is omitted.",
+				},
+				"exclude": map[string]interface{}{
+					"type":        "array",
+					"items":       map[string]interface{}{"type": "string"},
+					"description": `Dotted field paths (no "[*]" wildcards) to drop from each result after fields/include has been applied, e.g. ["description"].`,
+				},
+				"token_budget": map[string]interface{}{
+					"type":        "integer",
+					"description": "Token budget for the response. Unlike max_tokens, this greedily drops the lowest-relevance patterns from the tail of the already-ranked result until it fits, rather than knapsack-optimizing a page. Ignored when max_tokens is set.",
 				},
 			},
-			"required": []string{"context"},
+			"required":             []string{"context"},
+			"additionalProperties": false,
 		},
 	}
 }
 
-// HandleRequest processes an MCP tool call
-func (s *Server) HandleRequest(input map[string]interface{}) (string, error) {
+// supportedLanguages lists the language IDs registered in languageRegistry,
+// for the tmkb_query tool's "language" enum.
+func supportedLanguages() []string {
+	plugins := languageRegistry.Plugins()
+	ids := make([]string, 0, len(plugins))
+	for _, p := range plugins {
+		ids = append(ids, p.ID)
+	}
+	return ids
+}
+
+// supportedFrameworks lists every framework recognized by any registered
+// plugin, plus the universal "any" sentinel, for the tmkb_query tool's
+// "framework" enum.
+func supportedFrameworks() []string {
+	frameworks := []string{"any"}
+	for _, p := range languageRegistry.Plugins() {
+		frameworks = append(frameworks, p.Frameworks...)
+	}
+	return frameworks
+}
+
+// stringsFromInput converts the []interface{} shape encoding/json
+// produces for a JSON array arg into []string, for the fields/include/
+// exclude params (already type-checked by validateStringArrayArg).
+// raw being anything else, including nil, yields an empty slice.
+func stringsFromInput(raw interface{}) []string {
+	items, ok := raw.([]interface{})
+	if !ok {
+		return nil
+	}
+	out := make([]string, 0, len(items))
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// HandleRequest processes an MCP tool call. ctx is forwarded to
+// knowledge.Query so a long-running query can be aborted by the caller
+// (handleToolsCall passes through the request's cancellation context).
+func (s *Server) HandleRequest(ctx context.Context, input map[string]interface{}) (string, error) {
 	// Extract parameters
 	context, _ := input["context"].(string)
 	language, _ := input["language"].(string)
 	framework, _ := input["framework"].(string)
 	verbosity, _ := input["verbosity"].(string)
+	filterExpr, _ := input["filter"].(string)
+	enforcementScope, _ := input["enforcement_scope"].(string)
+	ranker, _ := input["ranker"].(string)
+	matchMode, _ := input["match_mode"].(string)
+	cwe, _ := input["cwe"].(string)
+	owasp, _ := input["owasp"].(string)
+	cursor, _ := input["cursor"].(string)
+	maxTokens := 0
+	if v, ok := input["max_tokens"].(float64); ok {
+		maxTokens = int(v)
+	}
+	var k1, b float64
+	if v, ok := input["k1"].(float64); ok {
+		k1 = v
+	}
+	if v, ok := input["b"].(float64); ok {
+		b = v
+	}
+	tokenBudget := 0
+	if v, ok := input["token_budget"].(float64); ok {
+		tokenBudget = int(v)
+	}
+	fields := stringsFromInput(input["fields"])
+	include := stringsFromInput(input["include"])
+	exclude := stringsFromInput(input["exclude"])
 
 	if verbosity == "" {
 		verbosity = "agent"
 	}
 
+	// The matcher argument arrives as the generic map/slice shape
+	// encoding/json produces for an untyped interface{}, so round-trip it
+	// through JSON to decode it into a matcher.Matcher.
+	var m matcher.Matcher
+	if raw, ok := input["matcher"]; ok {
+		data, err := json.Marshal(raw)
+		if err != nil {
+			return "", fmt.Errorf("invalid matcher: %w", err)
+		}
+		if err := json.Unmarshal(data, &m); err != nil {
+			return "", fmt.Errorf("invalid matcher: %w", err)
+		}
+	}
+
 	// Build query options
 	opts := knowledge.QueryOptions{
-		Context:   context,
-		Language:  language,
-		Framework: framework,
-		Verbosity: verbosity,
-		Limit:     3,
+		Context:          context,
+		Language:         language,
+		Framework:        framework,
+		Filter:           filterExpr,
+		Matcher:          m,
+		Verbosity:        verbosity,
+		EnforcementScope: enforcementScope,
+		Ranker:           ranker,
+		MatchMode:        matchMode,
+		K1:               k1,
+		B:                b,
+		CWE:              cwe,
+		OWASP:            owasp,
+		MaxTokens:        maxTokens,
+		Cursor:           cursor,
+		Fields:           fields,
+		Include:          include,
+		Exclude:          exclude,
+		TokenBudget:      tokenBudget,
+		Limit:            3,
+		Ctx:              ctx,
 	}
 
 	// Execute query
-	result := knowledge.Query(s.index, opts)
+	result, err := knowledge.Query(s.Index(), opts)
+	if err != nil {
+		return "", fmt.Errorf("invalid filter: %w", err)
+	}
 
 	// Return JSON
 	data, err := json.MarshalIndent(result, "", "  ")
@@ -121,7 +501,7 @@ func (s *Server) HandleRequest(input map[string]interface{}) (string, error) {
 // ServeStdio runs the MCP server over stdin/stdout
 func (s *Server) ServeStdio(r io.Reader, w io.Writer) error {
 	scanner := bufio.NewScanner(r)
-	writer := bufio.NewWriter(w)
+	s.stdioWriter = bufio.NewWriter(w)
 
 	// Set max buffer size to 10MB
 	const maxBufferSize = 10 * 1024 * 1024
@@ -132,7 +512,7 @@ func (s *Server) ServeStdio(r io.Reader, w io.Writer) error {
 		msg := scanner.Bytes()
 
 		// Handle message
-		resp, err := s.handleMessage(msg)
+		resp, err := s.handleMessage(defaultSessionID, msg)
 		if err != nil {
 			log.Printf("[ERROR] Failed to handle message: %v", err)
 			continue
@@ -140,17 +520,9 @@ func (s *Server) ServeStdio(r io.Reader, w io.Writer) error {
 
 		// Write response if non-empty (notifications have no response)
 		if len(resp) > 0 {
-			if _, err := writer.Write(resp); err != nil {
+			if err := s.writeStdio(resp); err != nil {
 				log.Printf("[ERROR] Failed to write response: %v", err)
-				return fmt.Errorf("failed to write response: %w", err)
-			}
-			if err := writer.WriteByte('\n'); err != nil {
-				log.Printf("[ERROR] Failed to write newline: %v", err)
-				return fmt.Errorf("failed to write newline: %w", err)
-			}
-			if err := writer.Flush(); err != nil {
-				log.Printf("[ERROR] Failed to flush writer: %v", err)
-				return fmt.Errorf("failed to flush writer: %w", err)
+				return err
 			}
 		}
 	}
@@ -165,3 +537,274 @@ func (s *Server) ServeStdio(r io.Reader, w io.Writer) error {
 
 	return nil
 }
+
+// writeStdio writes one frame (a JSON-RPC response or notification) to the
+// stdio transport's writer, serialized against concurrent callers -
+// ServeStdio's own response writes and sendProgress's notification writes
+// both go through here, since a batch's elements (and therefore their
+// progress notifications) can now be dispatched concurrently.
+func (s *Server) writeStdio(data []byte) error {
+	s.stdioMu.Lock()
+	defer s.stdioMu.Unlock()
+
+	if _, err := s.stdioWriter.Write(data); err != nil {
+		return fmt.Errorf("failed to write response: %w", err)
+	}
+	if err := s.stdioWriter.WriteByte('\n'); err != nil {
+		return fmt.Errorf("failed to write newline: %w", err)
+	}
+	if err := s.stdioWriter.Flush(); err != nil {
+		return fmt.Errorf("failed to flush writer: %w", err)
+	}
+	return nil
+}
+
+// ServeHTTP runs the MCP server over the Streamable HTTP transport:
+// POST /mcp dispatches a single JSON-RPC message or batch and returns the
+// response; GET /mcp upgrades to Server-Sent Events carrying
+// server-to-client notifications. Both endpoints key per-client state by
+// the Mcp-Session-Id header, so one tmkb process can serve several
+// agents concurrently instead of each needing its own subprocess.
+func (s *Server) ServeHTTP(addr string) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/mcp", s.handleMCP)
+	return http.ListenAndServe(addr, mux)
+}
+
+// handleMCP routes a Streamable HTTP request to the message-dispatch
+// path (POST) or the notification stream (GET).
+func (s *Server) handleMCP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		s.handleMCPPost(w, r)
+	case http.MethodGet:
+		s.handleMCPStream(w, r)
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleMCPPost reads one JSON-RPC message (or batch) from the request
+// body, dispatches it through the same handleMessage path ServeStdio
+// uses, and writes back the response. A request without a
+// Mcp-Session-Id header starts a fresh session, whose id is echoed back
+// in the response header so the client can send it on every subsequent
+// call (including the GET that opens its notification stream).
+func (s *Server) handleMCPPost(w http.ResponseWriter, r *http.Request) {
+	sessionID := r.Header.Get(sessionHeader)
+	if sessionID == "" {
+		sessionID = newSessionID()
+	}
+	s.sessionFor(sessionID)
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "failed to read request body", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := s.handleMessage(sessionID, body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set(sessionHeader, sessionID)
+	if len(resp) == 0 {
+		// Notification(s) only: JSON-RPC forbids a response body, but the
+		// session id still needs to reach the client on its first call.
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(resp)
+}
+
+// handleMCPStream upgrades a GET /mcp request to an SSE stream that
+// relays this session's queued server-to-client notifications (the
+// notifications/progress frames sendProgress queues and BroadcastMessage's
+// notifications/message) until the client disconnects.
+func (s *Server) handleMCPStream(w http.ResponseWriter, r *http.Request) {
+	sessionID := r.Header.Get(sessionHeader)
+	if sessionID == "" {
+		http.Error(w, "missing "+sessionHeader+" header", http.StatusBadRequest)
+		return
+	}
+	sess := s.sessionFor(sessionID)
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case msg := <-sess.notifyCh:
+			fmt.Fprintf(w, "data: %s\n\n", msg)
+			flusher.Flush()
+		}
+	}
+}
+
+// progressTokenKey is the context key under which handleRequest stashes a
+// request's progressToken (extracted from its params._meta), for handlers
+// to retrieve via progressTokenFromContext without threading it through
+// every call signature separately from ctx.
+type progressTokenKey struct{}
+
+// withProgressToken returns a copy of ctx carrying token for later
+// retrieval by progressTokenFromContext.
+func withProgressToken(ctx context.Context, token interface{}) context.Context {
+	return context.WithValue(ctx, progressTokenKey{}, token)
+}
+
+// progressTokenFromContext returns the progressToken stashed in ctx by
+// handleRequest, and whether one was present at all - a request without a
+// params._meta.progressToken has nothing for sendProgress to address.
+func progressTokenFromContext(ctx context.Context) (interface{}, bool) {
+	token := ctx.Value(progressTokenKey{})
+	return token, token != nil
+}
+
+// protocolVersionKey is the context key under which handleRequest stashes
+// the session's negotiated MCP protocol version, so a tool handler can
+// branch on capabilities via protocolVersionFromContext instead of
+// reaching into the session directly.
+type protocolVersionKey struct{}
+
+// withProtocolVersion returns a copy of ctx carrying version for later
+// retrieval by protocolVersionFromContext.
+func withProtocolVersion(ctx context.Context, version string) context.Context {
+	return context.WithValue(ctx, protocolVersionKey{}, version)
+}
+
+// protocolVersionFromContext returns the protocol version stashed in ctx
+// by handleRequest, and whether the session had completed its handshake
+// yet (a request dispatched before a successful initialize has no
+// negotiated version to report).
+func protocolVersionFromContext(ctx context.Context) (string, bool) {
+	version, _ := ctx.Value(protocolVersionKey{}).(string)
+	return version, version != ""
+}
+
+// requestMeta is the MCP "_meta" envelope a request's params may carry,
+// currently only used to carry progressToken.
+type requestMeta struct {
+	Meta struct {
+		ProgressToken interface{} `json:"progressToken"`
+	} `json:"_meta"`
+}
+
+// extractProgressToken reads params._meta.progressToken per the MCP spec,
+// reporting ok=false when params carries no (or an empty) token.
+func extractProgressToken(params json.RawMessage) (interface{}, bool) {
+	if len(params) == 0 {
+		return nil, false
+	}
+	var m requestMeta
+	if err := json.Unmarshal(params, &m); err != nil {
+		return nil, false
+	}
+	if m.Meta.ProgressToken == nil {
+		return nil, false
+	}
+	return m.Meta.ProgressToken, true
+}
+
+// sendProgress emits a notifications/progress JSON-RPC notification for
+// token, the progress token a client attached to its original request via
+// params._meta. For the stdio transport (sessionID == defaultSessionID) it
+// writes straight to the shared stdio writer; for an HTTP session it
+// queues onto that session's SSE notifyCh, dropping the notification
+// rather than blocking if the client isn't reading its stream fast enough
+// - progress updates are advisory, so a dropped one isn't fatal the way a
+// dropped response would be.
+func (s *Server) sendProgress(sessionID string, token interface{}, progress, total float64) {
+	params, err := json.Marshal(map[string]interface{}{
+		"progressToken": token,
+		"progress":      progress,
+		"total":         total,
+	})
+	if err != nil {
+		return
+	}
+	notif := JSONRPCNotification{JSONRPC: "2.0", Method: "notifications/progress", Params: params}
+	data, err := json.Marshal(notif)
+	if err != nil {
+		return
+	}
+
+	if sessionID == defaultSessionID {
+		if s.stdioWriter != nil {
+			_ = s.writeStdio(data)
+		}
+		return
+	}
+
+	sess := s.sessionFor(sessionID)
+	select {
+	case sess.notifyCh <- data:
+	default:
+	}
+}
+
+// BroadcastMessage emits a notifications/message JSON-RPC notification
+// (the MCP logging notification) to every session this server currently
+// knows about, plus stdio if it's active - unlike sendProgress, which
+// addresses a single in-flight request, this is for server-wide events
+// with no particular request to attach to, e.g. a knowledge.Loader.Watch
+// reload failure. level follows the MCP/syslog severity names ("error",
+// "warning", "info", ...); data carries a human-readable summary.
+func (s *Server) BroadcastMessage(level, data string) {
+	params, err := json.Marshal(map[string]interface{}{
+		"level": level,
+		"data":  data,
+	})
+	if err != nil {
+		return
+	}
+	notif := JSONRPCNotification{JSONRPC: "2.0", Method: "notifications/message", Params: params}
+	msg, err := json.Marshal(notif)
+	if err != nil {
+		return
+	}
+
+	if s.stdioWriter != nil {
+		_ = s.writeStdio(msg)
+	}
+
+	s.sessionsMu.RLock()
+	defer s.sessionsMu.RUnlock()
+	for id, sess := range s.sessions {
+		if id == defaultSessionID {
+			continue
+		}
+		select {
+		case sess.notifyCh <- msg:
+		default:
+		}
+	}
+}
+
+// newSessionID generates a random session id for a Streamable HTTP
+// client that connected without one.
+func newSessionID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		// The only way crypto/rand.Read fails is a broken OS entropy
+		// source; there is no safe fallback for a session identifier, so
+		// fail loudly rather than hand out a predictable one.
+		panic(fmt.Sprintf("mcp: failed to generate session id: %v", err))
+	}
+	return hex.EncodeToString(b)
+}
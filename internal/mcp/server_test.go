@@ -1,6 +1,7 @@
 package mcp
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/mark-chris/tmkb/internal/knowledge"
@@ -31,3 +32,52 @@ func TestServer_StateTransitions(t *testing.T) {
 		t.Errorf("expected state Initialized, got %v", srv.getState())
 	}
 }
+
+// TestServer_SetIndexSwapsLiveIndex verifies Index() observes a SetIndex
+// swap immediately, the mechanism a knowledge.Loader.Watch reload uses to
+// replace the serving index without restarting the server.
+func TestServer_SetIndexSwapsLiveIndex(t *testing.T) {
+	original := knowledge.NewIndex()
+	srv := NewServer(original)
+
+	if srv.Index() != original {
+		t.Fatalf("expected Index() to return the constructor's index")
+	}
+
+	replacement := knowledge.NewIndex()
+	replacement.Build([]knowledge.ThreatPattern{
+		{ID: "TMKB-TEST-001", Name: "Test", Severity: "medium", Triggers: knowledge.Triggers{Keywords: []string{"job"}}},
+	})
+	srv.SetIndex(replacement)
+
+	if srv.Index() != replacement {
+		t.Errorf("expected Index() to return the swapped-in index after SetIndex")
+	}
+	if srv.Index().Count() != 1 {
+		t.Errorf("Index().Count() = %d, want 1", srv.Index().Count())
+	}
+}
+
+// TestBroadcastMessage_QueuesOnEverySessionExceptStdio verifies
+// BroadcastMessage queues a notifications/message frame onto every known
+// HTTP session's SSE channel, skipping the stdio pseudo-session (which has
+// no notifyCh consumer and is instead written directly when active).
+func TestBroadcastMessage_QueuesOnEverySessionExceptStdio(t *testing.T) {
+	srv := NewServer(knowledge.NewIndex())
+	sessA := srv.sessionFor("session-a")
+	sessB := srv.sessionFor("session-b")
+	srv.sessionFor(defaultSessionID)
+
+	srv.BroadcastMessage("warning", "pattern reload failed: bad yaml")
+
+	for name, sess := range map[string]*session{"session-a": sessA, "session-b": sessB} {
+		select {
+		case data := <-sess.notifyCh:
+			if !strings.Contains(string(data), "notifications/message") {
+				t.Errorf("%s: expected a notifications/message frame, got %s", name, data)
+			}
+		default:
+			t.Errorf("%s: expected a queued broadcast notification", name)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package mcp
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// Tool is one entry in a Server's ToolRegistry: the JSON Schema tools/list
+// advertises for it, and the function tools/call invokes by name. Invoke
+// returning an error surfaces it to the client as a tool execution error
+// (isError: true in the tools/call result) rather than a JSON-RPC protocol
+// error - name validation and argument unmarshaling happen before Invoke
+// is called, so by the time it runs, returning an error always means the
+// tool itself rejected its arguments or failed to run.
+type Tool struct {
+	Definition map[string]interface{}
+	Invoke     func(ctx context.Context, args map[string]interface{}) (string, error)
+}
+
+// ToolRegistry holds the set of tools a Server exposes over tools/list and
+// tools/call, keyed by name. Registering tools here instead of hard-coding
+// a single tmkb_query dispatch lets an embedder add its own tools to a
+// Server without forking this package.
+type ToolRegistry struct {
+	mu    sync.RWMutex
+	tools map[string]Tool
+}
+
+// newToolRegistry creates an empty ToolRegistry.
+func newToolRegistry() *ToolRegistry {
+	return &ToolRegistry{tools: make(map[string]Tool)}
+}
+
+// Register adds or replaces the tool named name.
+func (r *ToolRegistry) Register(name string, tool Tool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tools[name] = tool
+}
+
+// Get returns the tool named name, if registered.
+func (r *ToolRegistry) Get(name string) (Tool, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	tool, ok := r.tools[name]
+	return tool, ok
+}
+
+// Definitions returns every registered tool's Definition, sorted by name
+// for a deterministic tools/list response.
+func (r *ToolRegistry) Definitions() []interface{} {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.tools))
+	for name := range r.tools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	defs := make([]interface{}, 0, len(names))
+	for _, name := range names {
+		defs = append(defs, r.tools[name].Definition)
+	}
+	return defs
+}
@@ -1,6 +1,7 @@
 package mcp
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 )
@@ -23,37 +24,25 @@ type toolCallResult struct {
 }
 
 // handleToolsList handles the tools/list request
-func handleToolsList(s *Server, params json.RawMessage) (interface{}, error) {
+func handleToolsList(s *Server, sessionID string, ctx context.Context, params json.RawMessage) (interface{}, error) {
 	// Check if initialized
-	if s.getState() != stateInitialized {
+	if s.sessionFor(sessionID).getState() != stateInitialized {
 		return nil, fmt.Errorf("server not initialized")
 	}
 
-	// Return tool definition
-	tool := s.ToolDefinition()
-
-	// Update tool schema with strict validation
-	inputSchema := tool["inputSchema"].(map[string]interface{})
-	properties := inputSchema["properties"].(map[string]interface{})
-
-	// Add minLength to context
-	context := properties["context"].(map[string]interface{})
-	context["minLength"] = 1
-
-	// Add additionalProperties: false
-	inputSchema["additionalProperties"] = false
-
 	result := map[string]interface{}{
-		"tools": []interface{}{tool},
+		"tools": s.Tools.Definitions(),
 	}
 
 	return result, nil
 }
 
-// handleToolsCall handles the tools/call request
-func handleToolsCall(s *Server, params json.RawMessage) (interface{}, error) {
+// handleToolsCall handles the tools/call request, dispatching by name to
+// s.Tools. Name lookup and argument unmarshaling are protocol-level
+// concerns handled here; everything past that is the tool's own Invoke.
+func handleToolsCall(s *Server, sessionID string, ctx context.Context, params json.RawMessage) (interface{}, error) {
 	// Check if initialized
-	if s.getState() != stateInitialized {
+	if s.sessionFor(sessionID).getState() != stateInitialized {
 		return nil, fmt.Errorf("server not initialized")
 	}
 
@@ -63,55 +52,28 @@ func handleToolsCall(s *Server, params json.RawMessage) (interface{}, error) {
 		return nil, fmt.Errorf("invalid tools/call params: %w", err)
 	}
 
-	// Validate tool name (protocol error)
-	if err := validateToolName(p.Name); err != nil {
-		return nil, err // Protocol error
-	}
-
-	// Extract and validate arguments
-	context, _ := p.Arguments["context"].(string)
-	language, _ := p.Arguments["language"].(string)
-	framework, _ := p.Arguments["framework"].(string)
-	verbosity, _ := p.Arguments["verbosity"].(string)
-
-	// Validate context (tool execution error)
-	if err := validateContext(context); err != nil {
-		return createToolExecutionErrorResult(err.Error()), nil
-	}
-
-	// Validate language (tool execution error)
-	if err := validateLanguage(language); err != nil {
-		return createToolExecutionErrorResult(err.Error()), nil
-	}
-
-	// Validate framework (tool execution error)
-	if err := validateFramework(framework); err != nil {
-		return createToolExecutionErrorResult(err.Error()), nil
+	tool, ok := s.Tools.Get(p.Name)
+	if !ok {
+		return nil, fmt.Errorf("unknown tool: %s", p.Name) // Protocol error
 	}
 
-	// Validate verbosity (tool execution error)
-	if err := validateVerbosity(verbosity); err != nil {
-		return createToolExecutionErrorResult(err.Error()), nil
+	// If the client attached a progressToken, report start/end so it can
+	// show a progress indicator around what may be a long-running tool.
+	if token, ok := progressTokenFromContext(ctx); ok {
+		s.sendProgress(sessionID, token, 0, 1)
+		defer s.sendProgress(sessionID, token, 1, 1)
 	}
 
-	// Check for unknown parameters (tool execution error)
-	allowed := []string{"context", "language", "framework", "verbosity"}
-	if err := validateNoUnknownParams(p.Arguments, allowed); err != nil {
-		return createToolExecutionErrorResult(err.Error()), nil
-	}
-
-	// Execute query using existing HandleRequest
-	queryResult, err := s.HandleRequest(p.Arguments)
+	text, err := tool.Invoke(ctx, p.Arguments)
 	if err != nil {
-		return createToolExecutionErrorResult(fmt.Sprintf("Query failed: %v", err)), nil
+		return createToolExecutionErrorResult(err.Error()), nil
 	}
 
-	// Wrap result in MCP tool call format
 	result := map[string]interface{}{
 		"content": []interface{}{
 			map[string]interface{}{
 				"type": "text",
-				"text": queryResult,
+				"text": text,
 			},
 		},
 		"isError": false,
@@ -120,6 +82,83 @@ func handleToolsCall(s *Server, params json.RawMessage) (interface{}, error) {
 	return result, nil
 }
 
+// invokeQueryTool is tmkb_query's Tool.Invoke: it validates every
+// argument, then delegates to HandleRequest, which runs knowledge.Query and
+// returns the result as indented JSON.
+func (s *Server) invokeQueryTool(ctx context.Context, args map[string]interface{}) (string, error) {
+	context, _ := args["context"].(string)
+	language, _ := args["language"].(string)
+	framework, _ := args["framework"].(string)
+	verbosity, _ := args["verbosity"].(string)
+	enforcementScope, _ := args["enforcement_scope"].(string)
+	ranker, _ := args["ranker"].(string)
+	matchMode, _ := args["match_mode"].(string)
+	cwe, _ := args["cwe"].(string)
+	owasp, _ := args["owasp"].(string)
+	maxTokens := 0
+	if v, ok := args["max_tokens"].(float64); ok {
+		maxTokens = int(v)
+	}
+	tokenBudget := 0
+	if v, ok := args["token_budget"].(float64); ok {
+		tokenBudget = int(v)
+	}
+
+	if err := validateContext(context); err != nil {
+		return "", err
+	}
+	if err := validateLanguage(language); err != nil {
+		return "", err
+	}
+	if err := validateFramework(framework); err != nil {
+		return "", err
+	}
+	if err := validateVerbosity(verbosity); err != nil {
+		return "", err
+	}
+	if err := validateEnforcementScope(enforcementScope); err != nil {
+		return "", err
+	}
+	if err := validateRanker(ranker); err != nil {
+		return "", err
+	}
+	if err := validateMatchMode(matchMode); err != nil {
+		return "", err
+	}
+	if err := validateCWE(cwe); err != nil {
+		return "", err
+	}
+	if err := validateOWASP(owasp); err != nil {
+		return "", err
+	}
+	if err := validateMaxTokens(maxTokens); err != nil {
+		return "", err
+	}
+	if err := validateTokenBudget(tokenBudget); err != nil {
+		return "", err
+	}
+	if err := validateStringArrayArg(args, "fields"); err != nil {
+		return "", err
+	}
+	if err := validateStringArrayArg(args, "include"); err != nil {
+		return "", err
+	}
+	if err := validateStringArrayArg(args, "exclude"); err != nil {
+		return "", err
+	}
+
+	allowed := []string{"context", "language", "framework", "verbosity", "filter", "matcher", "enforcement_scope", "ranker", "match_mode", "k1", "b", "cwe", "owasp", "max_tokens", "cursor", "fields", "include", "exclude", "token_budget"}
+	if err := validateNoUnknownParams(args, allowed); err != nil {
+		return "", err
+	}
+
+	queryResult, err := s.HandleRequest(ctx, args)
+	if err != nil {
+		return "", fmt.Errorf("Query failed: %v", err)
+	}
+	return queryResult, nil
+}
+
 // createToolExecutionErrorResult creates a tool execution error result
 func createToolExecutionErrorResult(message string) interface{} {
 	return map[string]interface{}{
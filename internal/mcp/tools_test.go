@@ -1,6 +1,7 @@
 package mcp
 
 import (
+	"context"
 	"encoding/json"
 	"testing"
 
@@ -12,20 +13,22 @@ func TestHandleToolsList_Success(t *testing.T) {
 	srv := NewServer(idx)
 	srv.setState(stateInitialized)
 
-	result, err := handleToolsList(srv, nil)
+	result, err := handleToolsList(srv, defaultSessionID, context.Background(), nil)
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
 
 	resultMap := result.(map[string]interface{})
 	tools := resultMap["tools"].([]interface{})
-	if len(tools) != 1 {
-		t.Errorf("expected 1 tool, got %d", len(tools))
+	if len(tools) != 5 {
+		t.Errorf("expected 5 tools, got %d", len(tools))
 	}
 
-	tool := tools[0].(map[string]interface{})
-	if tool["name"] != "tmkb_query" {
-		t.Errorf("expected tool name tmkb_query, got %v", tool["name"])
+	// Definitions() sorts by name, so tmkb_stats sorts last among the
+	// builtins.
+	tool := tools[len(tools)-1].(map[string]interface{})
+	if tool["name"] != "tmkb_stats" {
+		t.Errorf("expected tool name tmkb_stats, got %v", tool["name"])
 	}
 }
 
@@ -33,7 +36,7 @@ func TestHandleToolsList_BeforeInit(t *testing.T) {
 	idx := knowledge.NewIndex()
 	srv := NewServer(idx)
 
-	_, err := handleToolsList(srv, nil)
+	_, err := handleToolsList(srv, defaultSessionID, context.Background(), nil)
 	if err == nil {
 		t.Fatal("expected error when not initialized")
 	}
@@ -58,7 +61,7 @@ func TestHandleToolsCall_Success(t *testing.T) {
 	}
 	paramsJSON, _ := json.Marshal(params)
 
-	result, err := handleToolsCall(srv, paramsJSON)
+	result, err := handleToolsCall(srv, defaultSessionID, context.Background(), paramsJSON)
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
@@ -85,7 +88,7 @@ func TestHandleToolsCall_UnknownTool(t *testing.T) {
 	}
 	paramsJSON, _ := json.Marshal(params)
 
-	_, err := handleToolsCall(srv, paramsJSON)
+	_, err := handleToolsCall(srv, defaultSessionID, context.Background(), paramsJSON)
 	if err == nil {
 		t.Fatal("expected error for unknown tool")
 	}
@@ -102,7 +105,7 @@ func TestHandleToolsCall_MissingContext(t *testing.T) {
 	}
 	paramsJSON, _ := json.Marshal(params)
 
-	result, err := handleToolsCall(srv, paramsJSON)
+	result, err := handleToolsCall(srv, defaultSessionID, context.Background(), paramsJSON)
 	if err != nil {
 		t.Fatalf("expected no protocol error, got %v", err)
 	}
@@ -122,12 +125,12 @@ func TestHandleToolsCall_InvalidLanguage(t *testing.T) {
 		"name": "tmkb_query",
 		"arguments": map[string]interface{}{
 			"context":  "test",
-			"language": "java",
+			"language": "cobol",
 		},
 	}
 	paramsJSON, _ := json.Marshal(params)
 
-	result, err := handleToolsCall(srv, paramsJSON)
+	result, err := handleToolsCall(srv, defaultSessionID, context.Background(), paramsJSON)
 	if err != nil {
 		t.Fatalf("expected no protocol error, got %v", err)
 	}
@@ -145,6 +148,76 @@ func TestHandleToolsCall_InvalidLanguage(t *testing.T) {
 	}
 }
 
+// TestHandleToolsCall_FieldsProjectsPatterns verifies the tmkb_query tool's
+// "fields" argument reaches knowledge.Query and comes back as
+// projected_patterns in the tool's text response.
+func TestHandleToolsCall_FieldsProjectsPatterns(t *testing.T) {
+	idx := knowledge.NewIndex()
+	idx.Build([]knowledge.ThreatPattern{
+		{
+			ID:       "TMKB-TEST-001",
+			Severity: "medium",
+			Triggers: knowledge.Triggers{Keywords: []string{"background", "job"}},
+			AgentSummary: knowledge.AgentSummary{
+				Threat: "Test threat description",
+				Check:  "Test check description",
+				Fix:    "Test fix description",
+			},
+		},
+	})
+
+	srv := NewServer(idx)
+	srv.setState(stateInitialized)
+
+	params := map[string]interface{}{
+		"name": "tmkb_query",
+		"arguments": map[string]interface{}{
+			"context": "background job processing",
+			"fields":  []interface{}{"id", "severity"},
+		},
+	}
+	paramsJSON, _ := json.Marshal(params)
+
+	result, err := handleToolsCall(srv, defaultSessionID, context.Background(), paramsJSON)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	resultMap := result.(map[string]interface{})
+	content := resultMap["content"].([]interface{})
+	text := content[0].(map[string]interface{})["text"].(string)
+	if !contains(text, "projected_patterns") {
+		t.Errorf("expected projected_patterns in response, got %s", text)
+	}
+}
+
+// TestHandleToolsCall_InvalidFieldsType verifies a non-array "fields"
+// argument surfaces as a tool execution error rather than panicking.
+func TestHandleToolsCall_InvalidFieldsType(t *testing.T) {
+	idx := knowledge.NewIndex()
+	srv := NewServer(idx)
+	srv.setState(stateInitialized)
+
+	params := map[string]interface{}{
+		"name": "tmkb_query",
+		"arguments": map[string]interface{}{
+			"context": "test",
+			"fields":  "id",
+		},
+	}
+	paramsJSON, _ := json.Marshal(params)
+
+	result, err := handleToolsCall(srv, defaultSessionID, context.Background(), paramsJSON)
+	if err != nil {
+		t.Fatalf("expected no protocol error, got %v", err)
+	}
+
+	resultMap := result.(map[string]interface{})
+	if resultMap["isError"] != true {
+		t.Error("expected isError true for a non-array fields argument")
+	}
+}
+
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && (s == substr || len(s) > len(substr) && (s[:len(substr)] == substr || s[len(s)-len(substr):] == substr || containsInner(s, substr)))
 }
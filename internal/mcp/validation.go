@@ -3,54 +3,140 @@ package mcp
 import (
 	"fmt"
 	"strings"
+
+	"github.com/mark-chris/tmkb/internal/knowledge"
+	"github.com/mark-chris/tmkb/internal/langs"
 )
 
-// validateToolName validates the tool name is "tmkb_query"
-func validateToolName(name string) error {
-	if name != "tmkb_query" {
-		return fmt.Errorf("unknown tool: %s", name)
-	}
-	return nil
-}
+// languageRegistry is the set of languages/frameworks TMKB validates
+// query parameters against. It's the same registry `tmkb languages` lists.
+var languageRegistry = langs.Default()
+
+// maxContextLength bounds the context parameter so a misbehaving client
+// can't hand the ranker an unbounded string.
+const maxContextLength = 10000
 
 // validateContext validates the context parameter
 func validateContext(context string) error {
 	if strings.TrimSpace(context) == "" {
 		return fmt.Errorf("context must be non-empty")
 	}
+	if len(context) > maxContextLength {
+		return fmt.Errorf("context exceeds maximum length of %d characters", maxContextLength)
+	}
 	return nil
 }
 
-// validateLanguage validates the language parameter
+// validateToolName reports whether name carries the "tmkb_" prefix every
+// tool this package registers uses (see server.go's s.Tools.Register
+// calls), checking the prefix rather than a hardcoded list that would go
+// stale as tools are added. handleToolsCall does not call it: tools/call
+// resolves names through s.Tools.Get alone.
+func validateToolName(name string) error {
+	if !strings.HasPrefix(name, "tmkb_") {
+		return fmt.Errorf("Invalid tool name '%s'.", name)
+	}
+	return nil
+}
+
+// validateLanguage validates the language parameter against languageRegistry
 func validateLanguage(language string) error {
 	if language == "" {
 		return nil // Optional field
 	}
 
-	validLanguages := []string{"python"}
-	for _, valid := range validLanguages {
-		if language == valid {
+	if !languageRegistry.IsValidLanguage(language) {
+		return fmt.Errorf("Invalid language '%s'. Run `tmkb languages` for the supported list.", language)
+	}
+	return nil
+}
+
+// validateFramework validates the framework parameter. It has no language
+// argument to cross-check against, so it accepts any framework recognized
+// by at least one registered language plugin (plus the universal "any").
+func validateFramework(framework string) error {
+	if framework == "" {
+		return nil // Optional field
+	}
+
+	if !languageRegistry.IsValidFrameworkAnyLanguage(framework) {
+		return fmt.Errorf("Invalid framework '%s'. Run `tmkb languages` for the supported list.", framework)
+	}
+	return nil
+}
+
+// validateEnforcementScope validates the enforcement_scope parameter
+func validateEnforcementScope(scope string) error {
+	if scope == "" {
+		return nil // Optional field
+	}
+
+	validScopes := []string{"agent", "human", "ci", "ide"}
+	for _, valid := range validScopes {
+		if scope == valid {
 			return nil
 		}
 	}
 
-	return fmt.Errorf("Invalid language '%s'. Supported languages: python", language)
+	return fmt.Errorf("Invalid enforcement_scope '%s'. Supported values: agent, human, ci, ide", scope)
 }
 
-// validateFramework validates the framework parameter
-func validateFramework(framework string) error {
-	if framework == "" {
+// validateRanker validates the ranker parameter
+func validateRanker(ranker string) error {
+	if ranker == "" {
+		return nil // Optional field
+	}
+
+	validRankers := []string{"hybrid", "bm25", "bm25f"}
+	for _, valid := range validRankers {
+		if ranker == valid {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("Invalid ranker '%s'. Supported values: hybrid, bm25, bm25f", ranker)
+}
+
+// validateMatchMode validates the match_mode parameter
+func validateMatchMode(mode string) error {
+	if mode == "" {
 		return nil // Optional field
 	}
 
-	validFrameworks := []string{"flask", "any"}
-	for _, valid := range validFrameworks {
-		if framework == valid {
+	validModes := []string{"exact", "stemmed", "fuzzy"}
+	for _, valid := range validModes {
+		if mode == valid {
 			return nil
 		}
 	}
 
-	return fmt.Errorf("Invalid framework '%s'. Supported frameworks: flask, any", framework)
+	return fmt.Errorf("Invalid match_mode '%s'. Supported values: exact, stemmed, fuzzy", mode)
+}
+
+// validateCWE validates the cwe parameter against the canonical
+// "CWE-<number>" format (see knowledge.ParseCWE).
+func validateCWE(cwe string) error {
+	if cwe == "" {
+		return nil // Optional field
+	}
+
+	if _, err := knowledge.ParseCWE(cwe); err != nil {
+		return fmt.Errorf("Invalid cwe '%s'. Expected format CWE-<number>, e.g. CWE-79.", cwe)
+	}
+	return nil
+}
+
+// validateOWASP validates the owasp parameter against the canonical
+// "A<rank>:<year>" format (see knowledge.ParseOWASP).
+func validateOWASP(owasp string) error {
+	if owasp == "" {
+		return nil // Optional field
+	}
+
+	if _, err := knowledge.ParseOWASP(owasp); err != nil {
+		return fmt.Errorf("Invalid owasp '%s'. Expected format A<rank>:<year>, e.g. A03:2021.", owasp)
+	}
+	return nil
 }
 
 // validateVerbosity validates the verbosity parameter
@@ -59,14 +145,62 @@ func validateVerbosity(verbosity string) error {
 		return nil // Optional field
 	}
 
-	validVerbosity := []string{"agent", "human"}
+	validVerbosity := []string{"agent", "human", "compressed"}
 	for _, valid := range validVerbosity {
 		if verbosity == valid {
 			return nil
 		}
 	}
 
-	return fmt.Errorf("Invalid verbosity '%s'. Supported values: agent, human", verbosity)
+	return fmt.Errorf("Invalid verbosity '%s'. Supported values: agent, human, compressed", verbosity)
+}
+
+// validateMaxTokens validates the max_tokens parameter. 0 means "unset"
+// (knapsack/pagination mode disabled), same as omitting it entirely.
+func validateMaxTokens(maxTokens int) error {
+	if maxTokens == 0 {
+		return nil // Optional field
+	}
+
+	if maxTokens < 0 {
+		return fmt.Errorf("Invalid max_tokens '%d'. Must be a positive integer.", maxTokens)
+	}
+	return nil
+}
+
+// validateTokenBudget validates the token_budget parameter. 0 means
+// "unset" (no tail-dropping), same as omitting it entirely.
+func validateTokenBudget(tokenBudget int) error {
+	if tokenBudget == 0 {
+		return nil // Optional field
+	}
+
+	if tokenBudget < 0 {
+		return fmt.Errorf("Invalid token_budget '%d'. Must be a positive integer.", tokenBudget)
+	}
+	return nil
+}
+
+// validateStringArrayArg validates that args[key], if present, is a JSON
+// array of strings - the shape "fields"/"include"/"exclude" arrive in over
+// the wire, since encoding/json decodes a JSON array into []interface{}
+// rather than []string.
+func validateStringArrayArg(args map[string]interface{}, key string) error {
+	raw, ok := args[key]
+	if !ok {
+		return nil // Optional field
+	}
+
+	items, ok := raw.([]interface{})
+	if !ok {
+		return fmt.Errorf("Invalid %s: expected an array of strings.", key)
+	}
+	for _, item := range items {
+		if _, ok := item.(string); !ok {
+			return fmt.Errorf("Invalid %s: expected an array of strings.", key)
+		}
+	}
+	return nil
 }
 
 // validateNoUnknownParams checks for unknown parameters
@@ -60,9 +60,10 @@ func TestValidateLanguage(t *testing.T) {
 		wantErr bool
 	}{
 		{"Valid Python", "python", false},
+		{"Valid Java", "java", false},
+		{"Valid Go", "go", false},
 		{"Empty (optional)", "", false},
-		{"Invalid Java", "java", true},
-		{"Invalid Go", "go", true},
+		{"Invalid Ruby", "ruby", true},
 	}
 
 	for _, tt := range tests {
@@ -82,9 +83,11 @@ func TestValidateFramework(t *testing.T) {
 		wantErr bool
 	}{
 		{"Valid Flask", "flask", false},
+		{"Valid Django", "django", false},
+		{"Valid Spring", "spring", false},
 		{"Valid Any", "any", false},
 		{"Empty (optional)", "", false},
-		{"Invalid Django", "django", true},
+		{"Invalid Rails", "rails", true},
 	}
 
 	for _, tt := range tests {
@@ -97,6 +100,122 @@ func TestValidateFramework(t *testing.T) {
 	}
 }
 
+func TestValidateEnforcementScope(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{"Valid agent", "agent", false},
+		{"Valid human", "human", false},
+		{"Valid ci", "ci", false},
+		{"Valid ide", "ide", false},
+		{"Empty (optional)", "", false},
+		{"Invalid", "production", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateEnforcementScope(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateEnforcementScope(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateRanker(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{"Valid hybrid", "hybrid", false},
+		{"Valid bm25", "bm25", false},
+		{"Valid bm25f", "bm25f", false},
+		{"Empty (optional)", "", false},
+		{"Invalid", "tfidf", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateRanker(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateRanker(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateMatchMode(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{"Valid exact", "exact", false},
+		{"Valid stemmed", "stemmed", false},
+		{"Valid fuzzy", "fuzzy", false},
+		{"Empty (optional)", "", false},
+		{"Invalid", "soundex", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateMatchMode(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateMatchMode(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateCWE(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{"Valid CWE", "CWE-79", false},
+		{"Valid lowercase CWE", "cwe-352", false},
+		{"Empty (optional)", "", false},
+		{"Missing number", "CWE-", true},
+		{"Wrong prefix", "OWASP-79", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateCWE(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateCWE(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateOWASP(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{"Valid OWASP", "A03:2021", false},
+		{"Valid lowercase OWASP", "a01:2021", false},
+		{"Empty (optional)", "", false},
+		{"Missing year", "A03", true},
+		{"Wrong format", "CWE-79", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateOWASP(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateOWASP(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func TestValidateVerbosity(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -105,6 +224,7 @@ func TestValidateVerbosity(t *testing.T) {
 	}{
 		{"Valid Agent", "agent", false},
 		{"Valid Human", "human", false},
+		{"Valid Compressed", "compressed", false},
 		{"Empty (optional)", "", false},
 		{"Invalid Verbose", "verbose", true},
 	}
@@ -119,6 +239,27 @@ func TestValidateVerbosity(t *testing.T) {
 	}
 }
 
+func TestValidateMaxTokens(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   int
+		wantErr bool
+	}{
+		{"Unset", 0, false},
+		{"Valid", 1000, false},
+		{"Negative", -1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateMaxTokens(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateMaxTokens(%d) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func TestValidateNoUnknownParams(t *testing.T) {
 	allowed := []string{"context", "language", "framework", "verbosity"}
 
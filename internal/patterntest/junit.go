@@ -0,0 +1,70 @@
+package patterntest
+
+import (
+	"encoding/xml"
+	"io"
+)
+
+// junitSuite mirrors the handful of JUnit XML fields CI systems (GitHub
+// Actions, GitLab, Jenkins) actually read: pass/fail counts and one
+// testcase per fixture, with a failure element listing why.
+type junitSuite struct {
+	XMLName  xml.Name    `xml:"testsuite"`
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name    string        `xml:"name,attr"`
+	Failure *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit renders report as a JUnit XML testsuite, one testcase per
+// fixture, to w.
+func WriteJUnit(w io.Writer, report *Report) error {
+	suite := junitSuite{
+		Name:     "patterntest",
+		Tests:    len(report.Results),
+		Failures: report.Failed,
+		Cases:    make([]junitCase, 0, len(report.Results)),
+	}
+	for _, r := range report.Results {
+		tc := junitCase{Name: r.Fixture.Name}
+		if !r.Passed {
+			tc.Failure = &junitFailure{
+				Message: "fixture failed",
+				Text:    joinLines(r.Failures),
+			}
+		}
+		suite.Cases = append(suite.Cases, tc)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(suite); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func joinLines(lines []string) string {
+	out := ""
+	for i, l := range lines {
+		if i > 0 {
+			out += "\n"
+		}
+		out += l
+	}
+	return out
+}
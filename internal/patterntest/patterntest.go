@@ -0,0 +1,259 @@
+// Package patterntest discovers and runs per-pattern YAML fixtures from
+// sibling "<pattern>.tests/" directories - e.g. "patterns/authz/celery.yaml"
+// pairs with "patterns/authz/celery.tests/*.yaml". This is a different
+// granularity from internal/knowledge/bench and internal/knowledge/regression,
+// whose fixtures live in a single centralized glob and assert an exact
+// ranked ID list or precision/recall; a patterntest Fixture instead scopes
+// its assertions to the one pattern it sits next to, so adding a pattern
+// and its fixtures is a single self-contained change.
+package patterntest
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v3"
+
+	"github.com/mark-chris/tmkb/internal/knowledge"
+)
+
+// Expectation is a fixture's assertion block: what a query for its
+// Context/Language/Framework must and must not surface.
+type Expectation struct {
+	MustMatch    []string `yaml:"must_match,omitempty"`
+	MustNotMatch []string `yaml:"must_not_match,omitempty"`
+	Top          string   `yaml:"top,omitempty"`
+	MinScore     float64  `yaml:"min_score,omitempty"`
+}
+
+// Fixture is one scripted query and its expectations, loaded from a YAML
+// file under a pattern's sibling "<pattern>.tests/" directory.
+type Fixture struct {
+	// PatternID, Name, and Path identify the fixture for -run filtering
+	// and test output; none of the three are part of the YAML itself.
+	PatternID string `yaml:"-"`
+	Name      string `yaml:"-"`
+	Path      string `yaml:"-"`
+
+	Context   string      `yaml:"context"`
+	Language  string      `yaml:"language"`
+	Framework string      `yaml:"framework"`
+	Expect    Expectation `yaml:"expect"`
+}
+
+// testsDirFor returns the sibling fixtures directory for a pattern's
+// source file, e.g. "patterns/authz/celery.yaml" -> "patterns/authz/celery.tests".
+func testsDirFor(sourceFile string) string {
+	ext := filepath.Ext(sourceFile)
+	return strings.TrimSuffix(sourceFile, ext) + ".tests"
+}
+
+// Discover finds every fixture in a sibling "<pattern>.tests/" directory,
+// reading YAML files from fs. A pattern with no SourceFile (synthesized in
+// memory rather than loaded from disk) or no ".tests/" directory simply
+// contributes no fixtures - this is the common case.
+func Discover(fs afero.Fs, patterns []knowledge.ThreatPattern) ([]Fixture, error) {
+	var fixtures []Fixture
+	for _, p := range patterns {
+		if p.SourceFile == "" {
+			continue
+		}
+		dir := testsDirFor(p.SourceFile)
+		entries, err := afero.ReadDir(fs, dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+			ext := strings.ToLower(filepath.Ext(entry.Name()))
+			if ext != ".yaml" && ext != ".yml" {
+				continue
+			}
+			path := filepath.Join(dir, entry.Name())
+			data, err := afero.ReadFile(fs, path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read fixture %s: %w", path, err)
+			}
+			var f Fixture
+			if err := yaml.Unmarshal(data, &f); err != nil {
+				return nil, fmt.Errorf("failed to parse fixture %s: %w", path, err)
+			}
+			f.PatternID = p.ID
+			f.Name = p.ID + "/" + strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+			f.Path = path
+			fixtures = append(fixtures, f)
+		}
+	}
+	sort.Slice(fixtures, func(i, j int) bool { return fixtures[i].Name < fixtures[j].Name })
+	return fixtures, nil
+}
+
+// Filter narrows fixtures to those whose Name matches run, a Go-test-style
+// regexp; an empty run returns fixtures unchanged.
+func Filter(fixtures []Fixture, run string) ([]Fixture, error) {
+	if run == "" {
+		return fixtures, nil
+	}
+	re, err := regexp.Compile(run)
+	if err != nil {
+		return nil, fmt.Errorf("invalid -run pattern: %w", err)
+	}
+	var filtered []Fixture
+	for _, f := range fixtures {
+		if re.MatchString(f.Name) {
+			filtered = append(filtered, f)
+		}
+	}
+	return filtered, nil
+}
+
+// Result is the pass/fail outcome of running a single Fixture.
+type Result struct {
+	Fixture  Fixture
+	Passed   bool
+	Got      []string
+	Failures []string
+}
+
+// Report summarizes a patterntest run across all fixtures.
+type Report struct {
+	Results []Result
+	Passed  int
+	Failed  int
+}
+
+// resultWindow is the Limit passed to knowledge.Query for a fixture: large
+// enough that must_match/must_not_match/top see the full ranked list a
+// human would scroll through, not just the agent-mode top 3.
+const resultWindow = 50
+
+// Run executes every fixture's query against idx and checks it against the
+// fixture's Expectation.
+func Run(idx *knowledge.Index, fixtures []Fixture) (*Report, error) {
+	report := &Report{Results: make([]Result, 0, len(fixtures))}
+	for _, f := range fixtures {
+		result, err := knowledge.Query(idx, queryOptionsFor(f))
+		if err != nil {
+			return nil, fmt.Errorf("fixture %s: %w", f.Name, err)
+		}
+
+		got := make([]string, 0, len(result.VerbosePatterns))
+		gotSet := make(map[string]bool, len(result.VerbosePatterns))
+		for _, p := range result.VerbosePatterns {
+			got = append(got, p.ID)
+			gotSet[p.ID] = true
+		}
+
+		failures := checkExpectation(idx, f, got, gotSet)
+
+		report.Results = append(report.Results, Result{
+			Fixture:  f,
+			Passed:   len(failures) == 0,
+			Got:      got,
+			Failures: failures,
+		})
+		if len(failures) == 0 {
+			report.Passed++
+		} else {
+			report.Failed++
+		}
+	}
+	return report, nil
+}
+
+func queryOptionsFor(f Fixture) knowledge.QueryOptions {
+	return knowledge.QueryOptions{
+		Context:   f.Context,
+		Language:  f.Language,
+		Framework: f.Framework,
+		Verbosity: "human",
+		Limit:     resultWindow,
+	}
+}
+
+func checkExpectation(idx *knowledge.Index, f Fixture, got []string, gotSet map[string]bool) []string {
+	var failures []string
+	for _, id := range f.Expect.MustMatch {
+		if !gotSet[id] {
+			failures = append(failures, fmt.Sprintf("expected %s in results, not found", id))
+		}
+	}
+	for _, id := range f.Expect.MustNotMatch {
+		if gotSet[id] {
+			failures = append(failures, fmt.Sprintf("expected %s to be absent, but it matched", id))
+		}
+	}
+	if f.Expect.Top != "" {
+		top := "<no results>"
+		if len(got) > 0 {
+			top = got[0]
+		}
+		if top != f.Expect.Top {
+			failures = append(failures, fmt.Sprintf("expected %s ranked first, got %s", f.Expect.Top, top))
+		}
+	}
+	if f.Expect.MinScore > 0 {
+		if len(got) == 0 {
+			failures = append(failures, fmt.Sprintf("expected top result's relevance score >= %.3f, got no results", f.Expect.MinScore))
+		} else if score := topScore(idx, f, got[0]); score < f.Expect.MinScore {
+			failures = append(failures, fmt.Sprintf("expected top result's relevance score >= %.3f, got %.3f", f.Expect.MinScore, score))
+		}
+	}
+	return failures
+}
+
+// topScore approximates the relevance score of a fixture's top match using
+// CalculateRelevance, the standalone keyword-overlap formula. A fixture's
+// query runs with the default ranker, which scores with field-boosted
+// BM25F, and Query doesn't surface that score (see QueryOptions.Ranker and
+// PatternOutput), so min_score is checked against CalculateRelevance rather
+// than against the score the result was ranked by.
+func topScore(idx *knowledge.Index, f Fixture, topID string) float64 {
+	p := idx.GetByID(topID)
+	if p == nil {
+		return 0
+	}
+	return knowledge.CalculateRelevance(knowledge.ExtractKeywords(f.Context), p.Triggers.Keywords)
+}
+
+// Update rewrites each fixture's Expect.Top and Expect.MustMatch to match
+// the query's current actual results, the same "regenerate expected
+// output" workflow as a golden-file test's -update flag. MustNotMatch and
+// MinScore express author intent (what should never match, how confident a
+// match should be) rather than "whatever happened", so Update leaves both
+// alone.
+func Update(fs afero.Fs, idx *knowledge.Index, fixtures []Fixture) error {
+	for _, f := range fixtures {
+		result, err := knowledge.Query(idx, queryOptionsFor(f))
+		if err != nil {
+			return fmt.Errorf("fixture %s: %w", f.Name, err)
+		}
+
+		got := make([]string, 0, len(result.VerbosePatterns))
+		for _, p := range result.VerbosePatterns {
+			got = append(got, p.ID)
+		}
+
+		f.Expect.MustMatch = got
+		if len(got) > 0 {
+			f.Expect.Top = got[0]
+		} else {
+			f.Expect.Top = ""
+		}
+
+		data, err := yaml.Marshal(f)
+		if err != nil {
+			return fmt.Errorf("fixture %s: marshal: %w", f.Name, err)
+		}
+		if err := afero.WriteFile(fs, f.Path, data, 0644); err != nil {
+			return fmt.Errorf("fixture %s: write: %w", f.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+package patterntest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v3"
+
+	"github.com/mark-chris/tmkb/internal/cli/testutil"
+	"github.com/mark-chris/tmkb/internal/knowledge"
+)
+
+func TestDiscover_FindsSiblingFixtures(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	writeFixture(t, fs, "/patterns/authz/celery.tests/basic.yaml", `
+context: a celery beat background job
+expect:
+  must_match: [TMKB-TEST-001]
+`)
+
+	patterns := []knowledge.ThreatPattern{
+		{ID: "TMKB-TEST-001", SourceFile: "/patterns/authz/celery.yaml"},
+	}
+
+	fixtures, err := Discover(fs, patterns)
+	if err != nil {
+		t.Fatalf("Discover failed: %v", err)
+	}
+	if len(fixtures) != 1 {
+		t.Fatalf("expected 1 fixture, got %d", len(fixtures))
+	}
+	if fixtures[0].Name != "TMKB-TEST-001/basic" {
+		t.Errorf("fixture name = %q, want %q", fixtures[0].Name, "TMKB-TEST-001/basic")
+	}
+	if len(fixtures[0].Expect.MustMatch) != 1 || fixtures[0].Expect.MustMatch[0] != "TMKB-TEST-001" {
+		t.Errorf("fixture expect.must_match = %v", fixtures[0].Expect.MustMatch)
+	}
+}
+
+func TestDiscover_NoTestsDirectoryIsNotAnError(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	patterns := []knowledge.ThreatPattern{
+		{ID: "TMKB-TEST-001", SourceFile: "/patterns/authz/celery.yaml"},
+	}
+
+	fixtures, err := Discover(fs, patterns)
+	if err != nil {
+		t.Fatalf("Discover failed: %v", err)
+	}
+	if len(fixtures) != 0 {
+		t.Errorf("expected no fixtures, got %v", fixtures)
+	}
+}
+
+func TestFilter(t *testing.T) {
+	fixtures := []Fixture{
+		{Name: "TMKB-AUTHZ-001/basic"},
+		{Name: "TMKB-AUTHZ-002/basic"},
+	}
+
+	filtered, err := Filter(fixtures, "AUTHZ-001")
+	if err != nil {
+		t.Fatalf("Filter failed: %v", err)
+	}
+	if len(filtered) != 1 || filtered[0].Name != "TMKB-AUTHZ-001/basic" {
+		t.Errorf("Filter() = %v, want only TMKB-AUTHZ-001/basic", filtered)
+	}
+
+	if _, err := Filter(fixtures, "("); err == nil {
+		t.Error("expected an error for an invalid -run regexp")
+	}
+}
+
+func TestRun_MustMatchMustNotMatchAndTop(t *testing.T) {
+	idx := buildTestIndex(t)
+
+	fixtures := []Fixture{
+		{
+			Name:    "ok",
+			Context: "background job authorization",
+			Expect: Expectation{
+				MustMatch:    []string{"TMKB-TEST-001"},
+				MustNotMatch: []string{"TMKB-TEST-003"},
+				Top:          "TMKB-TEST-001",
+			},
+		},
+	}
+
+	report, err := Run(idx, fixtures)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if report.Passed != 1 || report.Failed != 0 {
+		t.Fatalf("expected 1 pass/0 fail, got %d pass/%d fail: %+v", report.Passed, report.Failed, report.Results)
+	}
+}
+
+func TestRun_ReportsFailures(t *testing.T) {
+	idx := buildTestIndex(t)
+
+	fixtures := []Fixture{
+		{
+			Name:    "wrong expectation",
+			Context: "background job authorization",
+			Expect: Expectation{
+				MustMatch: []string{"TMKB-NOT-REAL"},
+			},
+		},
+	}
+
+	report, err := Run(idx, fixtures)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if report.Passed != 0 || report.Failed != 1 {
+		t.Fatalf("expected 0 pass/1 fail, got %d pass/%d fail", report.Passed, report.Failed)
+	}
+	if !strings.Contains(report.Results[0].Failures[0], "TMKB-NOT-REAL") {
+		t.Errorf("expected failure message to mention TMKB-NOT-REAL, got %v", report.Results[0].Failures)
+	}
+}
+
+func TestUpdate_RewritesMustMatchAndTop(t *testing.T) {
+	idx := buildTestIndex(t)
+	fs := afero.NewMemMapFs()
+	writeFixture(t, fs, "/fixture.yaml", `
+context: background job authorization
+expect:
+  must_match: [TMKB-STALE-ID]
+  top: TMKB-STALE-ID
+  must_not_match: [TMKB-TEST-003]
+`)
+
+	fixtures, err := discoverSingle(fs, "/fixture.yaml", "TMKB-TEST-001")
+	if err != nil {
+		t.Fatalf("failed to load fixture: %v", err)
+	}
+
+	if err := Update(fs, idx, fixtures); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	updated, err := discoverSingle(fs, "/fixture.yaml", "TMKB-TEST-001")
+	if err != nil {
+		t.Fatalf("failed to reload fixture: %v", err)
+	}
+	if updated[0].Expect.Top != "TMKB-TEST-001" {
+		t.Errorf("Expect.Top = %q, want TMKB-TEST-001", updated[0].Expect.Top)
+	}
+	if len(updated[0].Expect.MustMatch) == 0 || updated[0].Expect.MustMatch[0] != "TMKB-TEST-001" {
+		t.Errorf("Expect.MustMatch = %v, want to start with TMKB-TEST-001", updated[0].Expect.MustMatch)
+	}
+	if len(updated[0].Expect.MustNotMatch) != 1 || updated[0].Expect.MustNotMatch[0] != "TMKB-TEST-003" {
+		t.Errorf("Update should leave MustNotMatch alone, got %v", updated[0].Expect.MustNotMatch)
+	}
+}
+
+// buildTestIndex reuses the repo's shared 3-pattern fixture.
+func buildTestIndex(t *testing.T) *knowledge.Index {
+	t.Helper()
+	fixture := testutil.SetupTestPatternsFS(t)
+	idx := knowledge.NewIndex()
+	idx.Build(fixture.Patterns)
+	return idx
+}
+
+func writeFixture(t *testing.T, fs afero.Fs, path, contents string) {
+	t.Helper()
+	if err := afero.WriteFile(fs, path, []byte(contents), 0644); err != nil {
+		t.Fatalf("failed to write fixture %s: %v", path, err)
+	}
+}
+
+// discoverSingle reads a single fixture file at path as if it belonged to
+// patternID, without requiring a full "<pattern>.tests/" directory layout.
+func discoverSingle(fs afero.Fs, path, patternID string) ([]Fixture, error) {
+	data, err := afero.ReadFile(fs, path)
+	if err != nil {
+		return nil, err
+	}
+	var f Fixture
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, err
+	}
+	f.PatternID = patternID
+	f.Name = patternID + "/fixture"
+	f.Path = path
+	return []Fixture{f}, nil
+}